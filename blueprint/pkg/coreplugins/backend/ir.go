@@ -22,8 +22,28 @@ type (
 		service.ServiceNode
 	}
 
+	PubSub interface {
+		ir.IRNode
+		service.ServiceNode
+	}
+
 	RelDB interface {
 		ir.IRNode
 		service.ServiceNode
 	}
+
+	BlobStore interface {
+		ir.IRNode
+		service.ServiceNode
+	}
+
+	SearchIndex interface {
+		ir.IRNode
+		service.ServiceNode
+	}
+
+	LockService interface {
+		ir.IRNode
+		service.ServiceNode
+	}
 )
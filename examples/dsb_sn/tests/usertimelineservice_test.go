@@ -33,7 +33,7 @@ func init() {
 
 	// Requires that the mongodb server is running.
 	userTimelineDBRegistry.Register("local", func(ctx context.Context) (backend.NoSQLDatabase, error) {
-		return mongodb.NewMongoDB(ctx, "localhost:27017")
+		return mongodb.NewMongoDB(ctx, "localhost:27017", "", "")
 	})
 
 	userTimelineServiceRegistry.Register("local", func(ctx context.Context) (socialnetwork.UserTimelineService, error) {
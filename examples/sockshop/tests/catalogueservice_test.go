@@ -19,7 +19,7 @@ var catalogueRegistry = registry.NewServiceRegistry[catalogue.CatalogueService](
 func init() {
 	// If the tests are run locally, we fall back to this CatalogueService implementation
 	catalogueRegistry.Register("local", func(ctx context.Context) (catalogue.CatalogueService, error) {
-		db, err := sqlitereldb.NewSqliteRelDB(ctx)
+		db, err := sqlitereldb.NewSqliteRelDB(ctx, "catalogue_db", "")
 		if err != nil {
 			return nil, err
 		}
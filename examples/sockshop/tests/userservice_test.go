@@ -21,7 +21,7 @@ var userServiceRegistry = registry.NewServiceRegistry[user.UserService]("user_se
 func init() {
 	// // For local testing, switching between mongo and local
 	// userServiceRegistry.Register("local-mongo", func(ctx context.Context) (user.UserService, error) {
-	// 	db, err := mongodb.NewMongoDB(ctx, "localhost:27017")
+	// 	db, err := mongodb.NewMongoDB(ctx, "localhost:27017", "", "")
 	// 	if err != nil {
 	// 		return nil, err
 	// 	}
@@ -49,14 +49,16 @@ type queueMasterImpl struct {
 
 // Starts a processing loop that continually pulls elements from the queue.
 // Does not exit when an error is encountered; only when ctx is cancelled
+//
+// A shipment is acknowledged once its status is updated.  If the update fails, the shipment is
+// returned to the queue to be retried.
 func (q *queueMasterImpl) Run(ctx context.Context) error {
 	for {
 		select {
 		case <-ctx.Done():
 			return nil
 		default:
-			var shipment shipping.Shipment
-			didPop, err := q.q.Pop(ctx, &shipment)
+			delivery, received, err := q.q.Receive(ctx)
 			if err != nil {
 				if q.exitOnError {
 					return err
@@ -65,25 +67,41 @@ func (q *queueMasterImpl) Run(ctx context.Context) error {
 					continue
 				}
 			}
-			if didPop {
-				msgNumber := atomic.AddInt32(&q.processed, 1)
-				slog.Info(fmt.Sprintf("Received shipment task %v %v: %v", msgNumber, shipment.ID, shipment.Name))
+			if !received {
+				continue
+			}
 
-				// Keep attempting to update shipping status
-				for {
-					err := q.shipping.UpdateStatus(ctx, shipment.ID, "shipped")
-					if err != nil {
-						if q.exitOnError {
-							return err
-						} else {
-							slog.Error(fmt.Sprintf("Unable to send shipment %v due to %v; waiting 1 second then retrying", shipment.ID, err))
-							time.Sleep(1 * time.Second)
-						}
-					} else {
-						break
-					}
+			var shipment shipping.Shipment
+			if err := delivery.Decode(&shipment); err != nil {
+				// The shipment can never be processed, so reject it rather than retrying
+				delivery.Nack(ctx, false)
+				if q.exitOnError {
+					return err
+				} else {
+					slog.Error(fmt.Sprintf("QueueMaster rejected malformed shipment due to %v", err))
+					continue
 				}
 			}
+			slog.Info(fmt.Sprintf("Received shipment task %v: %v (delivery %v)", shipment.ID, shipment.Name, delivery.DeliveryCount()))
+
+			err = q.shipping.UpdateStatus(ctx, shipment.ID, "shipped")
+			if err != nil {
+				if q.exitOnError {
+					delivery.Nack(ctx, true)
+					return err
+				} else {
+					slog.Error(fmt.Sprintf("Unable to send shipment %v due to %v; waiting 1 second then retrying", shipment.ID, err))
+					time.Sleep(1 * time.Second)
+					delivery.Nack(ctx, true)
+					continue
+				}
+			}
+			if err := delivery.Ack(ctx); err != nil {
+				slog.Error(fmt.Sprintf("Unable to acknowledge shipment %v due to %v", shipment.ID, err))
+				continue
+			}
+			msgNumber := atomic.AddInt32(&q.processed, 1)
+			slog.Info(fmt.Sprintf("Shipped shipment task %v %v", msgNumber, shipment.ID))
 		}
 	}
 }
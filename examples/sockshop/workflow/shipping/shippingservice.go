@@ -51,6 +51,11 @@ type shippingImpl struct {
 
 // PostShipping implements ShippingService.
 func (service *shippingImpl) PostShipping(ctx context.Context, shipment Shipment) (Shipment, error) {
+	// Insert into the shipment DB before queueing, so that the shipment exists when it is processed
+	if err := service.db.InsertOne(ctx, shipment); err != nil {
+		return shipment, err
+	}
+
 	// Push to the queue to be shipped
 	shipped, err := service.q.Push(ctx, shipment)
 	if err != nil {
@@ -58,9 +63,7 @@ func (service *shippingImpl) PostShipping(ctx context.Context, shipment Shipment
 	} else if !shipped {
 		return shipment, fmt.Errorf("Unable to submit shipment %v %v to the shipping queue", shipment.ID, shipment.Name)
 	}
-
-	// Insert into the shipment DB
-	return shipment, service.db.InsertOne(ctx, shipment)
+	return shipment, nil
 }
 
 // GetShipment implements ShippingService.
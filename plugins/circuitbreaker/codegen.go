@@ -10,23 +10,30 @@ import (
 	"golang.org/x/exp/slog"
 )
 
-func generateClient(builder golang.ModuleBuilder, wrapped *gocode.ServiceInterface, outputPackage string, MinReqs int64, FailureRate float64, Interval string) error {
+// Number of responses retained per client for the [FallbackCached] fallback
+const fallbackCacheCapacity = 1000
+
+func generateClient(builder golang.ModuleBuilder, wrapped *gocode.ServiceInterface, outputPackage string, node *CircuitBreakerClient) error {
 	pkg, err := builder.CreatePackage(outputPackage)
 	if err != nil {
 		return err
 	}
 
 	client := clientArgs{
-		Package:     pkg,
-		Service:     wrapped,
-		Name:        wrapped.BaseName + "_CircuitBreakerClient",
-		MinReqs:     MinReqs,
-		FailureRate: FailureRate,
-		Interval:    Interval,
-		Imports:     gogen.NewImports(pkg.Name),
+		Package:        pkg,
+		Service:        wrapped,
+		Name:           wrapped.BaseName + "_CircuitBreakerClient",
+		MinReqs:        node.Min_Reqs,
+		FailureRate:    node.FailureRate,
+		Interval:       node.Interval,
+		OpenTimeout:    node.OpenTimeout,
+		HalfOpenProbes: node.HalfOpenProbes,
+		Fallback:       string(node.Fallback),
+		CacheCapacity:  fallbackCacheCapacity,
+		Imports:        gogen.NewImports(pkg.Name),
 	}
 
-	client.Imports.AddPackages("context", "time", "github.com/mercari/go-circuitbreaker")
+	client.Imports.AddPackages("context", "time", "github.com/blueprint-uservices/blueprint/runtime/plugins/circuitbreaker")
 
 	slog.Info(fmt.Sprintf("Generating %v/%v", client.Package.PackageName, wrapped.BaseName+"CircuitBreakerClient"))
 	outputFile := filepath.Join(client.Package.Path, wrapped.BaseName+"_CircuitBreakerClient.go")
@@ -34,13 +41,17 @@ func generateClient(builder golang.ModuleBuilder, wrapped *gocode.ServiceInterfa
 }
 
 type clientArgs struct {
-	Package     golang.PackageInfo
-	Service     *gocode.ServiceInterface
-	Name        string
-	MinReqs     int64
-	FailureRate float64
-	Interval    string
-	Imports     *gogen.Imports
+	Package        golang.PackageInfo
+	Service        *gocode.ServiceInterface
+	Name           string
+	MinReqs        int64
+	FailureRate    float64
+	Interval       string
+	OpenTimeout    string
+	HalfOpenProbes int64
+	Fallback       string
+	CacheCapacity  int
+	Imports        *gogen.Imports
 }
 
 var clientTemplate = `// Blueprint: Auto-generated by CircuitBreaker Plugin
@@ -52,7 +63,10 @@ type {{.Name}} struct {
 	Client {{.Imports.NameOf .Service.UserType}}
 	MinReqs int64
 	FailureRate float64
-	cb *circuitbreaker.CircuitBreaker
+	cbs *circuitbreaker.CircuitBreakers
+	{{- if eq .Fallback "cached"}}
+	fallbacks *circuitbreaker.FallbackCache
+	{{- end}}
 }
 
 func New_{{.Name}} (ctx context.Context, client {{.Imports.NameOf .Service.UserType}}) (*{{.Name}}, error) {
@@ -61,32 +75,74 @@ func New_{{.Name}} (ctx context.Context, client {{.Imports.NameOf .Service.UserT
 	handler.MinReqs = {{.MinReqs}}
 	handler.FailureRate = {{.FailureRate}}
 
-	dur, err := time.ParseDuration("{{.Interval}}")
+	interval, err := time.ParseDuration("{{.Interval}}")
+	if err != nil {
+		return nil, err
+	}
+	openTimeout, err := time.ParseDuration("{{.OpenTimeout}}")
 	if err != nil {
 		return nil, err
 	}
 
-	cb := circuitbreaker.New(
-		circuitbreaker.WithFailOnContextCancel(true),
-		circuitbreaker.WithFailOnContextDeadline(true),
-		circuitbreaker.WithCounterResetInterval(dur),
-		circuitbreaker.WithTripFunc(circuitbreaker.NewTripFuncFailureRate(handler.MinReqs,handler.FailureRate)),
+	config := circuitbreaker.Config{
+		MinRequests:    handler.MinReqs,
+		FailureRate:    handler.FailureRate,
+		Interval:       interval,
+		OpenTimeout:    openTimeout,
+		HalfOpenProbes: {{.HalfOpenProbes}},
+	}
+	handler.cbs, err = circuitbreaker.NewCircuitBreakers(ctx, "{{.Service.BaseName}}", config,
+		{{- range $_, $f := .Service.Methods}}
+		"{{$f.Name}}",
+		{{- end}}
 	)
-	handler.cb = cb
+	if err != nil {
+		return nil, err
+	}
+	{{- if eq .Fallback "cached"}}
+	handler.fallbacks = circuitbreaker.NewFallbackCache({{.CacheCapacity}})
+	{{- end}}
 
 	return handler, nil
 }
 
 {{$service := .Service.Name -}}
 {{$receiver := .Name -}}
+{{$fallback := .Fallback -}}
 {{ range $_, $f := .Service.Methods }}
 func (client *{{$receiver}}) {{$f.Name -}} ({{ArgVarsAndTypes $f "ctx context.Context"}}) ({{RetVarsAndTypes $f "err error"}}) {
-	if !client.cb.Ready() {
-		err = circuitbreaker.ErrOpen
+	{{- if eq $fallback "cached"}}
+	type response struct {
+		{{- range $i, $ret := $f.Returns}}
+		Ret{{$i}} {{NameOf $ret.Type}}
+		{{- end}}
+	}
+	key := circuitbreaker.Key("{{$f.Name}}"{{range $_, $arg := $f.Arguments}}, {{$arg.Name}}{{end}})
+	{{- end}}
+	done, err := client.cbs.Allow(ctx, "{{$f.Name}}")
+	if err != nil {
+		{{- if eq $fallback "default"}}
+		err = nil
+		{{- else if eq $fallback "cached"}}
+		if {{if $f.Returns}}cached{{else}}_{{end}}, exists := client.fallbacks.Get(key); exists {
+			{{- if $f.Returns}}
+			r := cached.(response)
+			return {{range $i, $_ := $f.Returns}}r.Ret{{$i}}, {{end}}nil
+			{{- else}}
+			return nil
+			{{- end}}
+		}
+		{{- end}}
 		return
 	}
-	defer func() { err = client.cb.Done(ctx, err)}()
-	return client.Client.{{$f.Name}}({{ArgVars $f "ctx"}})
+	defer func() { done(err) }()
+	{{RetVars $f "err"}} = client.Client.{{$f.Name}}({{ArgVars $f "ctx"}})
+	{{- if eq $fallback "cached"}}
+	if err == nil {
+		client.fallbacks.Put(key, response{ {{- RetVars $f -}} })
+	}
+	{{- end}}
+	return
 }
 {{end}}
 `
@@ -20,10 +20,13 @@ type CircuitBreakerClient struct {
 	InstanceName string
 	Wrapped      golang.Service
 
-	outputPackage string
-	Min_Reqs      int64
-	FailureRate   float64
-	Interval      string
+	outputPackage  string
+	Min_Reqs       int64
+	FailureRate    float64
+	Interval       string
+	OpenTimeout    string
+	HalfOpenProbes int64
+	Fallback       Fallback
 }
 
 func (node *CircuitBreakerClient) ImplementsGolangNode() {}
@@ -36,7 +39,7 @@ func (node *CircuitBreakerClient) String() string {
 	return node.Name() + " = CircuitBreaker(" + node.Wrapped.Name() + ")"
 }
 
-func newCircuitBreakerClient(name string, server ir.IRNode, min_reqs int64, failure_rate float64, interval string) (*CircuitBreakerClient, error) {
+func newCircuitBreakerClient(name string, server ir.IRNode, config Config) (*CircuitBreakerClient, error) {
 	serverNode, is_callable := server.(golang.Service)
 	if !is_callable {
 		return nil, blueprint.Errorf("circuitbreaker client wrapper requires %s to be a golang service but got %s", server.Name(), reflect.TypeOf(server).String())
@@ -46,9 +49,25 @@ func newCircuitBreakerClient(name string, server ir.IRNode, min_reqs int64, fail
 	node.InstanceName = name
 	node.Wrapped = serverNode
 	node.outputPackage = "cb"
-	node.Min_Reqs = min_reqs
-	node.FailureRate = failure_rate
-	node.Interval = interval
+	node.Min_Reqs = config.MinReqs
+	node.FailureRate = config.FailureRate
+	node.Interval = config.Interval
+	node.OpenTimeout = config.OpenTimeout
+	if node.OpenTimeout == "" {
+		node.OpenTimeout = config.Interval
+	}
+	node.HalfOpenProbes = config.HalfOpenProbes
+	if node.HalfOpenProbes <= 0 {
+		node.HalfOpenProbes = 1
+	}
+	switch config.Fallback {
+	case "":
+		node.Fallback = FallbackError
+	case FallbackError, FallbackDefault, FallbackCached:
+		node.Fallback = config.Fallback
+	default:
+		return nil, blueprint.Errorf("circuitbreaker client %s has unknown fallback %s", name, config.Fallback)
+	}
 
 	return node, nil
 }
@@ -71,7 +90,7 @@ func (node *CircuitBreakerClient) GenerateFuncs(builder golang.ModuleBuilder) er
 		return err
 	}
 
-	return generateClient(builder, iface, node.outputPackage, node.Min_Reqs, node.FailureRate, node.Interval)
+	return generateClient(builder, iface, node.outputPackage, node)
 }
 
 func (node *CircuitBreakerClient) AddInstantiation(builder golang.NamespaceBuilder) error {
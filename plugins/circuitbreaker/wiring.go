@@ -1,6 +1,18 @@
 // Package circuitbreaker provides a Blueprint modifier for the client side of service calls.
 //
-// The plugin wraps clients with a circuitbreaker that blocks any new requests from being sent out over a connection if the failure rate exceeds a provided number in a fixed duration. The block is removed after the completion of the fixed duration interval.
+// The plugin wraps clients with per-method circuitbreakers that block any new requests to a method from being sent out over a connection if the failure rate of that method exceeds a provided number in a fixed duration.
+// After a timeout, a tripped breaker becomes half-open and lets a configurable number of probe requests through; if the probes succeed the breaker closes again, otherwise it re-opens.
+//
+// While a breaker is open, calls either fail with the runtime's typed ErrCircuitOpen error, or return a fallback value (a default value, or the most recent successful response for the same arguments).
+//
+// Breaker state changes and rejected calls are exported as OpenTelemetry metrics via backend.Meter.
+//
+// # Artifacts Generated
+//
+// During compilation, the circuitbreaker plugin will generate a client-side wrapper class.  The plugin
+// also utilizes some code in the [runtime/plugins/circuitbreaker] package.
+//
+// [runtime/plugins/circuitbreaker]: https://github.com/Blueprint-uServices/blueprint/tree/main/runtime/plugins/circuitbreaker
 package circuitbreaker
 
 import (
@@ -12,14 +24,68 @@ import (
 	"golang.org/x/exp/slog"
 )
 
+// Determines what a call returns when it is rejected by an open circuit breaker
+type Fallback string
+
+const (
+	// Rejected calls return the runtime's ErrCircuitOpen error
+	FallbackError Fallback = "error"
+
+	// Rejected calls return zero values and no error
+	FallbackDefault Fallback = "default"
+
+	// Rejected calls return the most recent successful response for the same arguments,
+	// or the runtime's ErrCircuitOpen error if there is no such response
+	FallbackCached Fallback = "cached"
+)
+
+// Configuration of the circuit breakers added by [AddCircuitBreakerWithConfig]
+type Config struct {
+	// Minimum number of requests within Interval for the circuit to break
+	MinReqs int64
+
+	// Fraction of failed requests, between 0 and 1, at which the circuit breaks
+	FailureRate float64
+
+	// Duration after which the breaker's counters are reset, e.g. "1s"
+	Interval string
+
+	// Duration that a breaker stays open before letting probe requests through.  Defaults to Interval.
+	OpenTimeout string
+
+	// Number of successful probe requests needed to close a half-open breaker.  Defaults to 1.
+	HalfOpenProbes int64
+
+	// What rejected calls return.  Defaults to [FallbackError].
+	Fallback Fallback
+}
+
 // Adds circuit breaker functionality to all clients of the specified service.
 // Uses a [blueprint.WiringSpec].
+// Each method of the service has its own circuit breaker.
 // Circuit breaker trips when `failure_rate` percentage of requests fail. Minimum number of requests for the circuit to break is specified using `min_reqs`.
 // The circuit breaker counters are reset after `interval` duration.
 // Usage:
 //
 //	AddCircuitBreaker(spec, "serviceA", 1000, 0.1, "1s")
 func AddCircuitBreaker(spec wiring.WiringSpec, serviceName string, min_reqs int64, failure_rate float64, interval string) {
+	AddCircuitBreakerWithConfig(spec, serviceName, Config{MinReqs: min_reqs, FailureRate: failure_rate, Interval: interval})
+}
+
+// Adds circuit breaker functionality to all clients of the specified service, using the provided [Config].
+// Uses a [blueprint.WiringSpec].
+// Each method of the service has its own circuit breaker.
+// Usage:
+//
+//	AddCircuitBreakerWithConfig(spec, "serviceA", circuitbreaker.Config{
+//		MinReqs:        1000,
+//		FailureRate:    0.1,
+//		Interval:       "1s",
+//		OpenTimeout:    "5s",
+//		HalfOpenProbes: 3,
+//		Fallback:       circuitbreaker.FallbackCached,
+//	})
+func AddCircuitBreakerWithConfig(spec wiring.WiringSpec, serviceName string, config Config) {
 	clientWrapper := serviceName + ".client.cb"
 
 	ptr := pointer.GetPointer(spec, serviceName)
@@ -30,13 +96,13 @@ func AddCircuitBreaker(spec wiring.WiringSpec, serviceName string, min_reqs int6
 
 	clientNext := ptr.AddSrcModifier(spec, clientWrapper)
 
-	spec.Define(clientWrapper, &CircuitBreakerClient{Min_Reqs: min_reqs, FailureRate: failure_rate}, func(ns wiring.Namespace) (ir.IRNode, error) {
+	spec.Define(clientWrapper, &CircuitBreakerClient{}, func(ns wiring.Namespace) (ir.IRNode, error) {
 		var wrapped golang.Service
 
 		if err := ns.Get(clientNext, &wrapped); err != nil {
 			return nil, blueprint.Errorf("CircuitBreaker %s expected %s to be a golang.Service, but encountered %s", clientWrapper, clientNext, err)
 		}
 
-		return newCircuitBreakerClient(clientWrapper, wrapped, min_reqs, failure_rate, interval)
+		return newCircuitBreakerClient(clientWrapper, wrapped, config)
 	})
 }
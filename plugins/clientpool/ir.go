@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/blueprint"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/blueprint/stringutil"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/service"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
@@ -15,17 +17,65 @@ import (
 )
 
 // Blueprint IR node representing a ClientPool that uses [N] instances of [Client]
+//
+// If the ClientPool has method [Groups], then each group uses its own separate pool of instances of [Client].
 type ClientPool struct {
 	golang.Service
 	golang.GeneratesFuncs
 
 	PoolName string
 	N        int
+	Config   Config
+	Groups   []MethodGroup
 	Client   golang.Service
 	Edges    []ir.IRNode
 	Nodes    []ir.IRNode
 }
 
+// The name of the pool used by methods that are not in any group
+const defaultGroup = "default"
+
+func newClientPool(name string, config Config, groups []MethodGroup) (*ClientPool, error) {
+	pool := &ClientPool{PoolName: name, N: config.NumClients, Config: config, Groups: groups}
+	if config.NumClients <= 0 {
+		return nil, blueprint.Errorf("invalid NumClients %v for clientpool %v; must be positive", config.NumClients, name)
+	}
+	if _, err := parseMaxWait(config); err != nil {
+		return nil, blueprint.Errorf("invalid MaxWait for clientpool %v: %v", name, err)
+	}
+	groupNames := make(map[string]struct{})
+	groupMethods := make(map[string]string)
+	for _, group := range groups {
+		if group.Name == "" || group.Name == defaultGroup {
+			return nil, blueprint.Errorf("invalid method group name %q for clientpool %v", group.Name, name)
+		}
+		if _, exists := groupNames[group.Name]; exists {
+			return nil, blueprint.Errorf("clientpool %v has more than one method group named %v", name, group.Name)
+		}
+		groupNames[group.Name] = struct{}{}
+		if group.NumClients <= 0 {
+			return nil, blueprint.Errorf("invalid NumClients %v for method group %v of clientpool %v; must be positive", group.NumClients, group.Name, name)
+		}
+		if _, err := parseMaxWait(group.Config); err != nil {
+			return nil, blueprint.Errorf("invalid MaxWait for method group %v of clientpool %v: %v", group.Name, name, err)
+		}
+		for _, method := range group.Methods {
+			if other, exists := groupMethods[method]; exists {
+				return nil, blueprint.Errorf("method %v is in method groups %v and %v of clientpool %v", method, other, group.Name, name)
+			}
+			groupMethods[method] = group.Name
+		}
+	}
+	return pool, nil
+}
+
+func parseMaxWait(config Config) (time.Duration, error) {
+	if config.MaxWait == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(config.MaxWait)
+}
+
 // Implements ir.IRNode
 func (pool *ClientPool) Name() string {
 	return pool.PoolName
@@ -34,7 +84,11 @@ func (pool *ClientPool) Name() string {
 // Implements ir.IRNode
 func (pool *ClientPool) String() string {
 	var b strings.Builder
-	b.WriteString(fmt.Sprintf("%v = ClientPool(%v, %v) {\n", pool.PoolName, pool.Client.Name(), pool.N))
+	b.WriteString(fmt.Sprintf("%v = ClientPool(%v, %v", pool.PoolName, pool.Client.Name(), pool.N))
+	for _, group := range pool.Groups {
+		b.WriteString(fmt.Sprintf(", %v=%v", group.Name, group.NumClients))
+	}
+	b.WriteString(") {\n")
 	var children []string
 	for _, child := range pool.Nodes {
 		children = append(children, child.String())
@@ -88,6 +142,13 @@ func (pool *ClientPool) GenerateFuncs(module golang.ModuleBuilder) error {
 	if err != nil {
 		return err
 	}
+	for _, group := range pool.Groups {
+		for _, method := range group.Methods {
+			if _, exists := args.Service.Methods[method]; !exists {
+				return blueprint.Errorf("method group %v of clientpool %v contains unknown method %v of service %v", group.Name, pool.PoolName, method, args.Service.Name)
+			}
+		}
+	}
 	namespaceBuilder, err := gogen.NewNamespaceBuilder(module, args.Service.BaseName+"_PoolClient", args.ClientFileName, args.PackageShortName, args.ClientConstructor)
 	if err != nil {
 		return err
@@ -124,6 +185,7 @@ func (pool *ClientPool) AddInstantiation(builder golang.NamespaceBuilder) error
 	}
 
 	builder.Import(args.PackageName)
+	args.RuntimePackage = builder.Import("github.com/blueprint-uservices/blueprint/runtime/plugins/clientpool")
 
 	slog.Info(fmt.Sprintf("Instantiating ClientPool %v in %v/%v", pool.PoolName, builder.Info().Package.PackageName, builder.Info().FileName))
 	code, err := gogen.ExecuteTemplate("clientpool", buildPoolTemplate, args)
@@ -143,6 +205,14 @@ func (pool *ClientPool) getTemplateArgs(module golang.ModuleBuilder) (*templateA
 	args.WrappedClient = pool.Client.Name()
 	args.InstanceName = pool.PoolName
 	args.MaxClients = pool.N
+	args.Groups = append(args.Groups, poolGroupArgs(defaultGroup, pool.Config))
+	args.MethodGroups = make(map[string]string)
+	for _, group := range pool.Groups {
+		args.Groups = append(args.Groups, poolGroupArgs(group.Name, group.Config))
+		for _, method := range group.Methods {
+			args.MethodGroups[method] = group.Name
+		}
+	}
 	args.PoolName = args.Service.Name + "_ClientPool"
 	args.PackageShortName = "pool"
 	args.PackageName = module.Info().Name + "/" + args.PackageShortName
@@ -150,10 +220,11 @@ func (pool *ClientPool) getTemplateArgs(module golang.ModuleBuilder) (*templateA
 	args.PoolFileName = args.Service.BaseName + "_pool.go"
 	args.ClientConstructor = fmt.Sprintf("New_%v_PoolClient", args.Service.BaseName)
 	args.PoolConstructor = fmt.Sprintf("New_%v_Pool", args.Service.BaseName)
+	args.DefaultGroup = defaultGroup
 	args.Imports = gogen.NewImports(args.PackageName)
 
 	args.Imports.AddPackages(
-		"context", "fmt",
+		"context", "fmt", "sync/atomic",
 		"github.com/blueprint-uservices/blueprint/runtime/plugins/clientpool",
 		"github.com/blueprint-uservices/blueprint/runtime/plugins/golang",
 	)
@@ -172,13 +243,46 @@ type (
 		PoolFileName      string
 		ClientConstructor string
 		PoolConstructor   string
+		RuntimePackage    string
+		DefaultGroup      string
+		Groups            []groupArgs
+		MethodGroups      map[string]string
 		Service           *gocode.ServiceInterface
 		Imports           *gogen.Imports
 	}
+
+	groupArgs struct {
+		Name       string
+		NumClients int
+		MaxWaiting int
+		MaxWait    string
+		MaxWaitNs  int64
+	}
 )
 
+func poolGroupArgs(name string, config Config) groupArgs {
+	maxWait, _ := parseMaxWait(config)
+	return groupArgs{
+		Name:       name,
+		NumClients: config.NumClients,
+		MaxWaiting: config.MaxWaiting,
+		MaxWait:    config.MaxWait,
+		MaxWaitNs:  maxWait.Nanoseconds(),
+	}
+}
+
 var buildPoolTemplate = `func(n *golang.Namespace) (any, error) {
-		return pool.{{.PoolConstructor}}(n), nil
+		configs := map[string]{{.RuntimePackage}}.Config{
+			{{- range .Groups}}
+			"{{.Name}}": {Capacity: {{.NumClients}}, MaxWaiting: {{.MaxWaiting}}, MaxWait: {{.MaxWaitNs}}}, {{- if .MaxWait}} // {{.MaxWait}}{{end}}
+			{{- end}}
+		}
+		methodGroups := map[string]string{
+			{{- range $method, $group := .MethodGroups}}
+			"{{$method}}": "{{$group}}",
+			{{- end}}
+		}
+		return pool.{{.PoolConstructor}}(n, "{{.InstanceName}}", configs, methodGroups)
 	}`
 
 var poolTemplate = `// This file is auto-generated by the Blueprint clientpool plugin
@@ -187,35 +291,61 @@ package {{.PackageShortName}}
 {{.Imports}}
 
 type {{.PoolName}} struct {
-	clients *clientpool.ClientPool[{{NameOf .Service.UserType}}]
+	pools        map[string]*clientpool.ClientPool[{{NameOf .Service.UserType}}]
+	methodGroups map[string]string
 }
 
-func {{.PoolConstructor}}(parent *golang.Namespace) *{{.PoolName}} {
-	i := 0
-	createClient := func() ({{NameOf .Service.UserType}}, error) {
-		clientName := fmt.Sprintf("{{.InstanceName}}.%v", i)
-		n, err := {{.ClientConstructor}}(clientName).BuildWithParent(parent)
-		if err != nil {
+// Instantiates a separate pool of clients for each group in configs.  Methods that are not
+// in methodGroups use the default group's pool.
+func {{.PoolConstructor}}(parent *golang.Namespace, name string, configs map[string]clientpool.Config, methodGroups map[string]string) (*{{.PoolName}}, error) {
+	pool := &{{.PoolName}}{
+		pools:        make(map[string]*clientpool.ClientPool[{{NameOf .Service.UserType}}]),
+		methodGroups: methodGroups,
+	}
+	for group, config := range configs {
+		poolName := name
+		if group != "{{.DefaultGroup}}" {
+			poolName = name + "." + group
+		}
+
+		var i int64
+		createClient := func() ({{NameOf .Service.UserType}}, error) {
+			clientName := fmt.Sprintf("%v.%v", poolName, atomic.AddInt64(&i, 1)-1)
+			n, err := {{.ClientConstructor}}(clientName).BuildWithParent(parent)
+			if err != nil {
+				return nil, err
+			}
+			var client {{NameOf .Service.UserType}}
+			err = n.Get("{{.WrappedClient}}", &client)
+			return client, err
+		}
+		clients := clientpool.NewClientPoolWithConfig(config, createClient)
+		if err := clientpool.ExportMetrics(context.Background(), poolName, clients); err != nil {
 			return nil, err
 		}
-		i++
-		var client {{NameOf .Service.UserType}}
-		err = n.Get("{{.WrappedClient}}", &client)
-		return client, err
+		pool.pools[group] = clients
+	}
+	return pool, nil
+}
+
+// Returns the pool of clients used for calls to method
+func (pool *{{.PoolName}}) clients(method string) *clientpool.ClientPool[{{NameOf .Service.UserType}}] {
+	if clients, exists := pool.pools[pool.methodGroups[method]]; exists {
+		return clients
 	}
-	clients := clientpool.NewClientPool({{.MaxClients}}, createClient)
-	return &{{.PoolName}}{clients: clients}
+	return pool.pools["{{.DefaultGroup}}"]
 }
 
 {{$service := .Service -}}
 {{$receiver := .PoolName -}}
 {{ range $_, $f := .Service.Methods }}
 func (pool *{{$receiver}}) {{SignatureWithRetVars $f}} {
-	client, err := pool.clients.Pop(ctx)
+	clients := pool.clients("{{$f.Name}}")
+	client, err := clients.Pop(ctx)
 	if err != nil {
 		return
 	}
-	defer pool.clients.Push(client)
+	defer clients.Push(client)
 	return client.{{$f.Name}}({{ArgVars $f "ctx"}})
 }
 {{end}}
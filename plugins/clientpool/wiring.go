@@ -18,6 +18,20 @@
 // After applying the clientpool plugin to a service, you can continue to apply application-level
 // modifiers to the service.
 //
+// # Bulkheads
+//
+// The clientpool plugin can also isolate slow and fast dependencies from each other, by using [CreateBulkhead]:
+//
+//	clientpool.CreateBulkhead(spec, "my_service", clientpool.Config{NumClients: 10, MaxWaiting: 20, MaxWait: "100ms"},
+//		clientpool.MethodGroup{Name: "search", Methods: []string{"Search"}, Config: clientpool.Config{NumClients: 2}},
+//	)
+//
+// Each method group has its own separate pool of clients, so that calls to slow methods cannot exhaust the clients
+// available to other methods.  Methods that are not in any group share the default pool.  Callers wait at most
+// MaxWait for a client; once MaxWaiting callers are already waiting for a client, further callers fail fast.
+//
+// The size, availability and queueing statistics of each pool are exported as OpenTelemetry metrics using backend.Meter.
+//
 // # Artifacts Generated
 //
 // During compilation, the clientpool plugin will generate a client-side wrapper class.  The plugin
@@ -46,6 +60,43 @@ import (
 //
 // After calling [Create] you can continue to apply application-level modifiers to serviceName.
 func Create(spec wiring.WiringSpec, serviceName string, numClients int) {
+	CreateBulkhead(spec, serviceName, Config{NumClients: numClients})
+}
+
+// Configuration of a pool of clients
+type Config struct {
+	// Number of clients in the pool
+	NumClients int
+
+	// Maximum number of callers that can wait for a client once all clients are in use;
+	// further callers fail immediately.  Zero means unlimited.
+	MaxWaiting int
+
+	// Maximum time that a caller waits for a client, e.g. "100ms".  Empty means callers wait until their context is done.
+	MaxWait string
+}
+
+// A group of methods that use their own separate pool of clients
+type MethodGroup struct {
+	Config
+
+	// Name of the group
+	Name string
+
+	// Names of the service methods in the group
+	Methods []string
+}
+
+// CreateBulkhead can be used by wiring specs to add a clientpool with bulkhead semantics to the client side of a service.
+//
+// Calls to methods of serviceName are made using a pool of clients configured by config, except for calls to the methods
+// in one of the groups, which are made using the group's own separate pool.
+//
+// serviceName must be an application-level service instance, e.g. clientpool must be applied to the service
+// before deploying the service over RPC or to a process.
+//
+// After calling [CreateBulkhead] you can continue to apply application-level modifiers to serviceName.
+func CreateBulkhead(spec wiring.WiringSpec, serviceName string, config Config, groups ...MethodGroup) {
 	poolName := serviceName + ".clientpool"
 
 	// Get the pointer metadata
@@ -60,7 +111,10 @@ func Create(spec wiring.WiringSpec, serviceName string, numClients int) {
 
 	// Define the client pool
 	spec.Define(poolName, &ClientPool{}, func(namespace wiring.Namespace) (ir.IRNode, error) {
-		pool := &ClientPool{PoolName: poolName, N: numClients}
+		pool, err := newClientPool(poolName, config, groups)
+		if err != nil {
+			return nil, err
+		}
 		poolNamespace, err := namespace.DeriveNamespace(poolName, &clientPoolNamespace{pool})
 		if err != nil {
 			return nil, err
@@ -134,6 +134,13 @@ type (
 		// Returns an error if an instance doesn't exist with the name `instanceName`.
 		SetEnvironmentVariable(instanceName string, key string, val string) error
 
+		// Adds an environment variable to a container instance whose value is that of a config node,
+		// such as a secret.  When instanceName is started, key will be set to the config's value if it
+		// has one; otherwise the value is passed through from the calling environment.
+		//
+		// Returns an error if an instance doesn't exist with the name `instanceName`.
+		SetEnvironmentVariableFromConfig(instanceName string, key string, config ir.IRConfig) error
+
 		ImplementsContainerWorkspace()
 	}
 
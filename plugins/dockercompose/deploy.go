@@ -125,6 +125,14 @@ func (d *dockerComposeWorkspace) SetEnvironmentVariable(instanceName string, key
 	return d.DockerComposeFile.AddEnvVar(instanceName, key, val)
 }
 
+// Implements docker.ContainerWorkspace
+func (d *dockerComposeWorkspace) SetEnvironmentVariableFromConfig(instanceName string, key string, config ir.IRConfig) error {
+	if config.HasValue() {
+		return d.DockerComposeFile.AddEnvVar(instanceName, key, config.Value())
+	}
+	return d.DockerComposeFile.PassthroughEnvVarFrom(instanceName, key, config.Name(), config.Optional())
+}
+
 // Generates the docker-compose file
 func (d *dockerComposeWorkspace) Finish() error {
 	// We didn't set any arguments or environment variables while accumulating instances. Do so now.
@@ -90,11 +90,17 @@ func (d *DockerComposeFile) AddEnvVar(instanceName string, key string, val strin
 
 // Pass through the specified environment variable key from the calling environment
 func (d *DockerComposeFile) PassthroughEnvVar(instanceName string, key string, optional bool) error {
+	return d.PassthroughEnvVarFrom(instanceName, key, key, optional)
+}
+
+// Sets the environment variable key for instanceName to the value of the environment variable from
+// in the calling environment
+func (d *DockerComposeFile) PassthroughEnvVarFrom(instanceName string, key string, from string, optional bool) error {
 	var passthroughValue string
 	if optional {
-		passthroughValue = fmt.Sprintf("${%v:-}", linux.EnvVar(key))
+		passthroughValue = fmt.Sprintf("${%v:-}", linux.EnvVar(from))
 	} else {
-		passthroughValue = fmt.Sprintf("${%v?%v must be set by the calling environment}", linux.EnvVar(key), key)
+		passthroughValue = fmt.Sprintf("${%v?%v must be set by the calling environment}", linux.EnvVar(from), from)
 	}
 	return d.AddEnvVar(instanceName, key, passthroughValue)
 }
@@ -0,0 +1,76 @@
+package elasticsearch
+
+import (
+	"fmt"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/address"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/backend"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/service"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
+	"github.com/blueprint-uservices/blueprint/plugins/golang"
+	"github.com/blueprint-uservices/blueprint/plugins/workflow/workflowspec"
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/elasticsearch"
+	"golang.org/x/exp/slog"
+)
+
+// Blueprint IR Node that represents the generated client for the elasticsearch container
+type ElasticsearchGoClient struct {
+	golang.Service
+	backend.SearchIndex
+
+	InstanceName string
+	Addr         *address.DialConfig
+	Index        *ir.IRValue
+
+	Spec *workflowspec.Service
+}
+
+func newElasticsearchGoClient(name string, addr *address.DialConfig, index *ir.IRValue) (*ElasticsearchGoClient, error) {
+	spec, err := workflowspec.GetService[elasticsearch.ElasticsearchIndex]()
+	client := &ElasticsearchGoClient{
+		InstanceName: name,
+		Addr:         addr,
+		Index:        index,
+		Spec:         spec,
+	}
+	return client, err
+}
+
+// Implements ir.IRNode
+func (e *ElasticsearchGoClient) Name() string {
+	return e.InstanceName
+}
+
+// Implements ir.IRNode
+func (e *ElasticsearchGoClient) String() string {
+	return e.InstanceName + " = ElasticsearchClient(" + e.Addr.Name() + ")"
+}
+
+// Implements service.ServiceNode
+func (e *ElasticsearchGoClient) GetInterface(ctx ir.BuildContext) (service.ServiceInterface, error) {
+	return e.Spec.Iface.ServiceInterface(ctx), nil
+}
+
+// Implements golang.ProvidesModule
+func (e *ElasticsearchGoClient) AddToWorkspace(builder golang.WorkspaceBuilder) error {
+	return e.Spec.AddToWorkspace(builder)
+}
+
+// Implements golang.ProvidesInterface
+func (e *ElasticsearchGoClient) AddInterfaces(builder golang.ModuleBuilder) error {
+	return e.Spec.AddToModule(builder)
+}
+
+// Implements golang.Instantiable
+func (e *ElasticsearchGoClient) AddInstantiation(builder golang.NamespaceBuilder) error {
+	if builder.Visited(e.InstanceName) {
+		return nil
+	}
+
+	slog.Info(fmt.Sprintf("Instantiating ElasticsearchClient %v in %v/%v", e.InstanceName, builder.Info().Package.PackageName, builder.Info().FileName))
+
+	return builder.DeclareConstructor(e.InstanceName, e.Spec.Constructor.AsConstructor(), []ir.IRNode{e.Addr, e.Index})
+}
+
+func (node *ElasticsearchGoClient) ImplementsGolangNode()    {}
+func (node *ElasticsearchGoClient) ImplementsGolangService() {}
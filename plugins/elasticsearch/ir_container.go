@@ -0,0 +1,93 @@
+package elasticsearch
+
+import (
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/address"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/backend"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/service"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
+	"github.com/blueprint-uservices/blueprint/plugins/docker"
+	"github.com/blueprint-uservices/blueprint/plugins/golang/goparser"
+	"github.com/blueprint-uservices/blueprint/plugins/workflow/workflowspec"
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/elasticsearch"
+)
+
+// The elasticsearch image used by the container
+const elasticsearchImage = "docker.elastic.co/elasticsearch/elasticsearch:8.13.4"
+
+// Blueprint IR Node that represents the server side docker container
+type ElasticsearchContainer struct {
+	backend.SearchIndex
+	docker.Container
+	docker.ProvidesContainerInstance
+
+	InstanceName string
+	BindAddr     *address.BindConfig
+	Iface        *goparser.ParsedInterface
+}
+
+// Elasticsearch interface exposed by the docker container.
+type ElasticsearchInterface struct {
+	service.ServiceInterface
+	Wrapped service.ServiceInterface
+}
+
+func (e *ElasticsearchInterface) GetName() string {
+	return "elasticsearch(" + e.Wrapped.GetName() + ")"
+}
+
+func (e *ElasticsearchInterface) GetMethods() []service.Method {
+	return e.Wrapped.GetMethods()
+}
+
+func newElasticsearchContainer(name string) (*ElasticsearchContainer, error) {
+	spec, err := workflowspec.GetService[elasticsearch.ElasticsearchIndex]()
+	if err != nil {
+		return nil, err
+	}
+
+	cntr := &ElasticsearchContainer{
+		InstanceName: name,
+		Iface:        spec.Iface,
+	}
+	return cntr, nil
+}
+
+// Implements ir.IRNode
+func (e *ElasticsearchContainer) String() string {
+	return e.InstanceName + " = ElasticsearchContainer(" + e.BindAddr.Name() + ")"
+}
+
+// Implements ir.IRNode
+func (e *ElasticsearchContainer) Name() string {
+	return e.InstanceName
+}
+
+// Implements service.ServiceNode
+func (e *ElasticsearchContainer) GetInterface(ctx ir.BuildContext) (service.ServiceInterface, error) {
+	iface := e.Iface.ServiceInterface(ctx)
+	return &ElasticsearchInterface{Wrapped: iface}, nil
+}
+
+// Implements docker.ProvidesContainerInstance
+//
+// The container runs a single elasticsearch node with security disabled, so clients connect over plain
+// HTTP without credentials.
+func (e *ElasticsearchContainer) AddContainerInstance(target docker.ContainerWorkspace) error {
+	e.BindAddr.Port = 9200
+	err := target.DeclarePrebuiltInstance(e.InstanceName, elasticsearchImage, e.BindAddr)
+	if err != nil {
+		return err
+	}
+	// Settings are passed as ES_SETTING_ variables, in which '.' in a setting's name is replaced by '_'
+	env := [][2]string{
+		{"ES_SETTING_DISCOVERY_TYPE", "single-node"},
+		{"ES_SETTING_XPACK_SECURITY_ENABLED", "false"},
+		{"ES_JAVA_OPTS", "-Xms512m -Xmx512m"},
+	}
+	for _, kv := range env {
+		if err := target.SetEnvironmentVariable(e.InstanceName, kv[0], kv[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+// Package elasticsearch provides a plugin to generate and include an elasticsearch instance in a Blueprint application.
+//
+// The package provides a built-in elasticsearch container that provides the server-side implementation
+// and a go-client for connecting to the server.  The client also works with OpenSearch, which has the
+// same REST API.
+//
+// The applications must use a backend.SearchIndex (runtime/core/backend) as the interface in the workflow.
+//
+// # Wiring Spec Usage
+//
+// To instantiate an elasticsearch container:
+//
+//	post_search := elasticsearch.Container(spec, "post_search")
+//
+// The client stores documents in an index with the same name as the instance, in lower case, e.g. "post_search",
+// which is created when the first document is indexed.
+package elasticsearch
+
+import (
+	"strings"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/blueprint"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/address"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/pointer"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/wiring"
+)
+
+// Container generates the IRNodes for an elasticsearch server docker container that uses the elasticsearch image
+// and the clients needed by the generated application to communicate with the server.
+func Container(spec wiring.WiringSpec, name string) string {
+	// The nodes that we are defining
+	ctrName := name + ".ctr"
+	clientName := name + ".client"
+	addrName := name + ".addr"
+
+	// Define the elasticsearch container
+	spec.Define(ctrName, &ElasticsearchContainer{}, func(ns wiring.Namespace) (ir.IRNode, error) {
+		ctr, err := newElasticsearchContainer(ctrName)
+		if err != nil {
+			return nil, err
+		}
+
+		err = address.Bind[*ElasticsearchContainer](ns, addrName, ctr, &ctr.BindAddr)
+		return ctr, err
+	})
+
+	// Create a pointer to the elasticsearch container
+	ptr := pointer.CreatePointer[*ElasticsearchGoClient](spec, name, ctrName)
+
+	// Define the address that points to the elasticsearch container
+	address.Define[*ElasticsearchContainer](spec, addrName, ctrName)
+
+	// Add the address to the pointer
+	ptr.AddAddrModifier(spec, addrName)
+
+	// Define the elasticsearch client and add it to the client side of the pointer
+	clientNext := ptr.AddSrcModifier(spec, clientName)
+	spec.Define(clientName, &ElasticsearchGoClient{}, func(ns wiring.Namespace) (ir.IRNode, error) {
+		addr, err := address.Dial[*ElasticsearchContainer](ns, clientNext)
+		if err != nil {
+			return nil, blueprint.Errorf("%s expected %s to be an address but encountered %s", clientName, clientNext, err)
+		}
+
+		index_val := &ir.IRValue{Value: strings.ToLower(ir.CleanName(name))}
+
+		return newElasticsearchGoClient(clientName, addr.Dial, index_val)
+	})
+
+	return name
+}
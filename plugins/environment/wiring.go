@@ -1,5 +1,6 @@
 // Package environment provides a plugin for generating a .env file in the root Blueprint output directory that automatically sets
-// address configuration variables (hostnames and ports for dial and bind addresses).
+// address configuration variables (hostnames and ports for dial and bind addresses).  Secrets declared with the [secrets] plugin
+// are not given values; the .env files contain commented-out placeholders for them instead.
 //
 // The plugin is intended for convenience so that Blueprint users do not have to manually allocate ports and pass them as
 // environment variables.  However, in more complex deployment, Blueprint users may wish to disable this plugin to afford themselves
@@ -21,6 +22,7 @@
 //
 //	USER_SERVICE_GRPC_DIAL_ADDR=user_service:12345
 //	USER_SERVICE_GRPC_BIND_ADDR=0.0.0.0:12345
+//	# USER_DB_PASSWORD=
 //
 // The plugin generates two different env files:
 //   - .local.env assumes all services will be deployed on a single machine; it uses localhost for dial hostnames and 0.0.0.0 for
@@ -31,7 +33,8 @@
 // # Running Artifacts
 //
 // Before running the application or a client, you can source one of the .env files to avoid having to manually set
-// environment variables or command line arguments.
+// environment variables or command line arguments.  Secrets must still be set in the calling environment, or by
+// uncommenting and filling in their placeholders.
 //
 // For example, if you are running a docker-compose deployment, you can run:
 //
@@ -53,6 +56,7 @@
 // The plugin does not guarantee that the ports (e.g. 12345) are actually available for use on any machine.  This is up to the user.
 //
 // [cmdbuilder]: https://github.com/Blueprint-uServices/blueprint/tree/main/plugins/cmdbuilder
+// [secrets]: https://github.com/Blueprint-uServices/blueprint/tree/main/plugins/secrets
 package environment
 
 import (
@@ -67,6 +71,7 @@ import (
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/address"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
 	"github.com/blueprint-uservices/blueprint/plugins/linux"
+	"github.com/blueprint-uservices/blueprint/plugins/secrets"
 )
 
 // [AssignPorts] can be called from a wiring spec to auto-generate .env files in the root of the build
@@ -121,15 +126,16 @@ func matchDialsToBinds(nodes []ir.IRNode) map[string]*addrconfig {
 // Generates a .env file to outputDir
 func generateEnvFiles(outputDir string, nodes []ir.IRNode, port uint16) error {
 	addrs := matchDialsToBinds(nodes)
+	secretNodes := ir.Filter[*secrets.Secret](nodes)
 
-	if err := generateEnv(filepath.Join(outputDir, ".local.env"), addrs, port, true); err != nil {
+	if err := generateEnv(filepath.Join(outputDir, ".local.env"), addrs, secretNodes, port, true); err != nil {
 		return err
 	}
 
-	return generateEnv(filepath.Join(outputDir, ".env"), addrs, port, false)
+	return generateEnv(filepath.Join(outputDir, ".env"), addrs, secretNodes, port, false)
 }
 
-func generateEnv(outputFile string, addrs map[string]*addrconfig, port uint16, localhost bool) error {
+func generateEnv(outputFile string, addrs map[string]*addrconfig, secretNodes []*secrets.Secret, port uint16, localhost bool) error {
 	b := strings.Builder{}
 	// Fix iteration order so that each address is allocated the same port across multiple invocations of this function
 	keys := make([]string, 0, len(addrs))
@@ -152,6 +158,19 @@ func generateEnv(outputFile string, addrs map[string]*addrconfig, port uint16, l
 		port += 1
 	}
 
+	// Secrets are never written to the .env files; the placeholders document the variables that must be set
+	if len(secretNodes) > 0 {
+		names := make([]string, 0, len(secretNodes))
+		for _, secret := range secretNodes {
+			names = append(names, linux.EnvVar(secret.Name()))
+		}
+		sort.Strings(names)
+		b.WriteString("# Secrets must be set by the calling environment\n")
+		for _, name := range names {
+			b.WriteString(fmt.Sprintf("# %s=\n", name))
+		}
+	}
+
 	if err := os.WriteFile(outputFile, []byte(b.String()), 0644); err != nil {
 		return err
 	}
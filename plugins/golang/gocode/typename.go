@@ -243,7 +243,11 @@ type (
 	}
 
 	/*
-		A struct with generics. For now blueprint doesn't support generics in service declarations
+		An instantiation of a generic type, e.g. backend.TypedCache[string].
+
+		For now blueprint doesn't support generics in service declarations, but
+		constructors can receive generic types.  Only the first type param is
+		recorded.
 	*/
 	GenericType struct {
 		TypeName
@@ -478,6 +482,9 @@ func (t *GenericType) Equals(other TypeName) bool {
 	if !isSameType {
 		return false
 	}
+	if t.TypeParam == nil || t2.TypeParam == nil {
+		return t.BaseType.Equals(t2.BaseType) && t.TypeParam == t2.TypeParam
+	}
 	return t.BaseType.Equals(t2.BaseType) && t.TypeParam.Equals(t2.TypeParam)
 }
 
@@ -107,6 +107,11 @@ func (imports *Imports) AddType(typeName gocode.TypeName) {
 		{
 			imports.AddType(t.SendType)
 		}
+	case *gocode.GenericType:
+		{
+			imports.AddType(t.BaseType)
+			imports.AddType(t.TypeParam)
+		}
 	}
 }
 
@@ -175,11 +180,13 @@ func (imports *Imports) NameOf(typeName gocode.TypeName) string {
 		{
 			return "chan<- " + imports.NameOf(t.SendType)
 		}
-	default:
+	case *gocode.GenericType:
 		{
-			slog.Warn(fmt.Sprintf("Importing unknown type %v %v", typeName, reflect.TypeOf(typeName)))
-			return typeName.String()
+			if t.TypeParam != nil {
+				return fmt.Sprintf("%s[%s]", imports.NameOf(t.BaseType), imports.NameOf(t.TypeParam))
+			}
 		}
 	}
-
+	slog.Warn(fmt.Sprintf("Importing unknown type %v %v", typeName, reflect.TypeOf(typeName)))
+	return typeName.String()
 }
@@ -192,7 +192,15 @@ func (namespace *NamespaceBuilderImpl) DeclareConstructor(name string, construct
 			NodeType:  &gocode.BasicType{Name: "string"},
 		}
 
-		if argIface, err := golang.GetGoInterface(namespace.Module(), args[i]); err == nil {
+		if generic, isGeneric := Var.Type.(*gocode.GenericType); isGeneric {
+			if base, isUserType := generic.BaseType.(*gocode.UserType); isUserType && base.Package == "github.com/blueprint-uservices/blueprint/runtime/core/backend" && base.Name == "TypedCollection" {
+				// Nodes are NoSQL databases rather than collections, so they can't be wrapped
+				return blueprint.Errorf("invalid constructor argument %v of %v; a backend.TypedCollection cannot be received from %v.  Receive a backend.NoSQLDatabase and call backend.GetTypedCollection instead", Var.Name, name, args[i].Name())
+			}
+			// Typed wrappers such as backend.TypedCache[V] are declared with the constructor's arg type;
+			// the node is wrapped when it is copied to the arg
+			arg.NodeType = Var.Type
+		} else if argIface, err := golang.GetGoInterface(namespace.Module(), args[i]); err == nil {
 			arg.NodeType = &argIface.UserType
 		}
 
@@ -91,8 +91,9 @@ type (
 
 	ParsedFunc struct {
 		gocode.Func
-		File *ParsedFile
-		Ast  *ast.FuncType
+		File       *ParsedFile
+		Ast        *ast.FuncType
+		TypeParams []string // Names of the generic type parameters of the receiver, if this is a method of a generic struct
 	}
 
 	// Currently we save var statements but don't do anything with them
@@ -319,10 +320,19 @@ func (f *ParsedField) Parse() error {
 }
 
 func (f *ParsedFunc) Parse() error {
+	// Generic funcs, and methods of generic structs, can refer to their type params in arguments and retvals
+	typeParams := slices.Clone(f.TypeParams)
+	if f.Ast.TypeParams != nil {
+		for _, field := range f.Ast.TypeParams.List {
+			for _, name := range field.Names {
+				typeParams = append(typeParams, name.Name)
+			}
+		}
+	}
 	if f.Ast.Params != nil {
 		for _, p := range f.Ast.Params.List {
 			// Determine the argument's type
-			argType := f.File.ResolveType(p.Type)
+			argType := f.File.ResolveType(p.Type, typeParams...)
 			if argType == nil {
 				return blueprint.Errorf("%v unable to resolve type of argument %v", f.Name, p.Type)
 			}
@@ -341,7 +351,7 @@ func (f *ParsedFunc) Parse() error {
 	if f.Ast.Results != nil {
 		for _, r := range f.Ast.Results.List {
 			// Determine the retval's type
-			retType := f.File.ResolveType(r.Type)
+			retType := f.File.ResolveType(r.Type, typeParams...)
 			if retType == nil {
 				return blueprint.Errorf("%v unable to resolve type of retval %v", f.Name, r.Type)
 			}
@@ -445,7 +455,7 @@ func (f *ParsedFile) ResolveType(expr ast.Expr, typeParams ...string) gocode.Typ
 	case *ast.StructType:
 		return &gocode.StructType{}
 	case *ast.IndexExpr:
-		return &gocode.GenericType{BaseType: f.ResolveType(e.X, typeParams...)}
+		return &gocode.GenericType{BaseType: f.ResolveType(e.X, typeParams...), TypeParam: f.ResolveType(e.Index, typeParams...)}
 	case *ast.IndexListExpr:
 		return &gocode.GenericType{BaseType: f.ResolveType(e.X, typeParams...)}
 	default:
@@ -643,30 +653,38 @@ func (f *ParsedFile) LoadFuncs() error {
 			continue
 		}
 
-		// This doesn't work with generic types
-
 		// Pull out the name of the receiver struct
-		var receiverName string
-		switch receiverType := d.Recv.List[0].Type.(type) {
-		case *ast.StarExpr:
+		receiverType := d.Recv.List[0].Type
+		if pointerReceiverType, isPointer := receiverType.(*ast.StarExpr); isPointer {
+			receiverType = pointerReceiverType.X
+		}
+
+		// Methods of generic structs name the struct's type params on the receiver,
+		// e.g. func (receiver *MyType[T]) funcName(...) {}
+		var receiverTypeParams []ast.Expr
+		switch genericReceiverType := receiverType.(type) {
+		case *ast.IndexExpr:
 			{
-				switch pointerReceiverType := receiverType.X.(type) {
-				case *ast.Ident:
-					{
-						receiverName = pointerReceiverType.Name
-					}
-				default:
-					{
-						return blueprint.Errorf("unable to parse receiver type of function %v", fun.Name)
-					}
-				}
+				receiverType = genericReceiverType.X
+				receiverTypeParams = []ast.Expr{genericReceiverType.Index}
 			}
-		case *ast.Ident:
+		case *ast.IndexListExpr:
 			{
-				// Declared as func(receiver MyType) funcName(...) {}
-				receiverName = receiverType.Name
+				receiverType = genericReceiverType.X
+				receiverTypeParams = genericReceiverType.Indices
 			}
 		}
+		for _, typeParam := range receiverTypeParams {
+			if ident, isIdent := typeParam.(*ast.Ident); isIdent {
+				fun.TypeParams = append(fun.TypeParams, ident.Name)
+			}
+		}
+
+		receiverIdent, isIdent := receiverType.(*ast.Ident)
+		if !isIdent {
+			return blueprint.Errorf("unable to parse receiver type of function %v", fun.Name)
+		}
+		receiverName := receiverIdent.Name
 
 		// Associate the func with the receiver struct
 		struc, exists := f.Package.Structs[receiverName]
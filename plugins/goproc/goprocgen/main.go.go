@@ -96,13 +96,20 @@ package main
 import (
 	"context"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"golang.org/x/exp/slog"
 )
 
 func main() {
 	slog.Info("Running {{.Name}}")
-	n, err := {{.NamespaceConstructor}}("{{.Name}}").Build(context.Background())
+
+	// Shut down the namespace on SIGINT or SIGTERM, so that its nodes can finish cleanly
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	n, err := {{.NamespaceConstructor}}("{{.Name}}").Build(ctx)
 	if err != nil {
 		slog.Error(err.Error())
 		os.Exit(1)
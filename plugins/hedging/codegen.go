@@ -0,0 +1,104 @@
+package hedging
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/blueprint-uservices/blueprint/plugins/golang"
+	"github.com/blueprint-uservices/blueprint/plugins/golang/gocode"
+	"github.com/blueprint-uservices/blueprint/plugins/golang/gogen"
+	"golang.org/x/exp/slog"
+)
+
+// code generation function called from the ir.go file
+func generateClient(builder golang.ModuleBuilder, wrapped *gocode.ServiceInterface, outputPackage string, delay string, percentile float64, idempotentMethods []string) error {
+	pkg, err := builder.CreatePackage(outputPackage)
+	if err != nil {
+		return err
+	}
+
+	client := clientArgs{
+		Package:    pkg,
+		Service:    wrapped,
+		Name:       wrapped.BaseName + "_HedgingClient",
+		Delay:      delay,
+		Percentile: percentile,
+		Idempotent: make(map[string]bool),
+		Imports:    gogen.NewImports(pkg.Name),
+	}
+	for _, method := range idempotentMethods {
+		client.Idempotent[method] = true
+	}
+
+	client.Imports.AddPackages("context", "github.com/blueprint-uservices/blueprint/runtime/plugins/hedging")
+	if delay != "" {
+		client.Imports.AddPackages("time")
+	}
+
+	slog.Info(fmt.Sprintf("Generating %v/%v", client.Package.PackageName, wrapped.BaseName+"_HedgingClient"))
+	outputFile := filepath.Join(client.Package.Path, wrapped.BaseName+"_HedgingClient.go")
+	return gogen.ExecuteTemplateToFile("Hedging", clientTemplate, client, outputFile)
+}
+
+type clientArgs struct {
+	Package    golang.PackageInfo
+	Service    *gocode.ServiceInterface
+	Name       string
+	Delay      string
+	Percentile float64
+	Idempotent map[string]bool
+	Imports    *gogen.Imports
+}
+
+var clientTemplate = `// Blueprint: Auto-generated by Hedging Plugin
+package {{.Package.ShortName}}
+
+{{.Imports}}
+
+type {{.Name}} struct {
+	Client {{.Imports.NameOf .Service.UserType}}
+	Hedgers map[string]*hedging.Hedger
+}
+
+func New_{{.Name}} (ctx context.Context, client {{.Imports.NameOf .Service.UserType}}) (*{{.Name}}, error) {
+	handler := &{{.Name}}{}
+	handler.Client = client
+	handler.Hedgers = make(map[string]*hedging.Hedger)
+	{{- if .Delay}}
+
+	delay, err := time.ParseDuration("{{.Delay}}")
+	if err != nil {
+		return nil, err
+	}
+	{{- end}}
+	{{- range $name, $_ := .Idempotent}}
+	{{- if $.Delay}}
+	handler.Hedgers["{{$name}}"] = hedging.NewFixedHedger(delay)
+	{{- else}}
+	handler.Hedgers["{{$name}}"] = hedging.NewPercentileHedger({{$.Percentile}})
+	{{- end}}
+	{{- end}}
+	return handler, nil
+}
+
+{{$service := .Service.Name -}}
+{{$receiver := .Name -}}
+{{ range $_, $f := .Service.Methods }}
+func (client *{{$receiver}}) {{$f.Name -}} ({{ArgVarsAndTypes $f "ctx context.Context"}}) ({{RetVarsAndTypes $f "err error"}}) {
+	{{- if index $.Idempotent $f.Name}}
+	type result struct {
+		{{- range $i, $ret := $f.Returns}}
+		ret{{$i}} {{NameOf $ret.Type}}
+		{{- end}}
+	}
+	{{if $f.Returns}}res, err := {{else}}_, err = {{end}}hedging.Do(ctx, client.Hedgers["{{$f.Name}}"], func(ctx context.Context) (res result, err error) {
+		{{range $i, $ret := $f.Returns}}res.ret{{$i}}, {{end}}err = client.Client.{{$f.Name}}({{ArgVars $f "ctx"}})
+		return
+	})
+	return {{range $i, $ret := $f.Returns}}res.ret{{$i}}, {{end}}err
+	{{- else}}
+	return client.Client.{{$f.Name}}({{ArgVars $f "ctx"}})
+	{{- end}}
+}
+{{end}}
+`
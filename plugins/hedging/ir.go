@@ -0,0 +1,113 @@
+package hedging
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/blueprint"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/service"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
+	"github.com/blueprint-uservices/blueprint/plugins/golang"
+	"github.com/blueprint-uservices/blueprint/plugins/golang/gocode"
+)
+
+// Blueprint IR node representing a client-side request hedger
+type HedgingClient struct {
+	golang.Service
+	golang.GeneratesFuncs
+	golang.Instantiable
+
+	InstanceName string
+	Wrapped      golang.Service
+
+	outputPackage     string
+	Delay             string
+	Percentile        float64
+	IdempotentMethods []string
+}
+
+// Implements ir.IRNode
+func (node *HedgingClient) ImplementsGolangNode() {}
+
+// Implements ir.IRNode
+func (node *HedgingClient) Name() string {
+	return node.InstanceName
+}
+
+// Implements ir.IRNode
+func (node *HedgingClient) String() string {
+	return node.Name() + " = Hedging(" + node.Wrapped.Name() + ", " + strings.Join(node.IdempotentMethods, ", ") + ")"
+}
+
+func newHedgingClient(name string, server ir.IRNode, delay string, percentile float64, idempotentMethods []string) (*HedgingClient, error) {
+	serverNode, is_callable := server.(golang.Service)
+	if !is_callable {
+		return nil, blueprint.Errorf("hedging client wrapper requires %s to be a golang service but got %s", server.Name(), reflect.TypeOf(server).String())
+	}
+
+	node := &HedgingClient{}
+	node.InstanceName = name
+	node.Wrapped = serverNode
+	node.outputPackage = "hedging"
+	node.Delay = delay
+	node.Percentile = percentile
+	node.IdempotentMethods = idempotentMethods
+
+	return node, nil
+}
+
+// Implements golang.Service
+func (node *HedgingClient) AddInterfaces(builder golang.ModuleBuilder) error {
+	return node.Wrapped.AddInterfaces(builder)
+}
+
+// Implements golang.Service
+func (node *HedgingClient) GetInterface(ctx ir.BuildContext) (service.ServiceInterface, error) {
+	return node.Wrapped.GetInterface(ctx)
+}
+
+// Implements golang.GeneratesFuncs
+func (node *HedgingClient) GenerateFuncs(builder golang.ModuleBuilder) error {
+	if builder.Visited(node.InstanceName + ".generateFuncs") {
+		return nil
+	}
+
+	iface, err := golang.GetGoInterface(builder, node)
+	if err != nil {
+		return err
+	}
+
+	for _, method := range node.IdempotentMethods {
+		if _, exists := iface.Methods[method]; !exists {
+			return blueprint.Errorf("hedging client %s cannot hedge calls to %s as %s has no such method", node.InstanceName, method, iface.Name)
+		}
+	}
+
+	return generateClient(builder, iface, node.outputPackage, node.Delay, node.Percentile, node.IdempotentMethods)
+}
+
+// Implements golang.Instantiable
+func (node *HedgingClient) AddInstantiation(builder golang.NamespaceBuilder) error {
+	if builder.Visited(node.InstanceName) {
+		return nil
+	}
+
+	iface, err := golang.GetGoInterface(builder, node.Wrapped)
+	if err != nil {
+		return err
+	}
+
+	constructor := &gocode.Constructor{
+		Package: builder.Module().Info().Name + "/" + node.outputPackage,
+		Func: gocode.Func{
+			Name: fmt.Sprintf("New_%v_HedgingClient", iface.BaseName),
+			Arguments: []gocode.Variable{
+				{Name: "ctx", Type: &gocode.UserType{Package: "context", Name: "Context"}},
+				{Name: "client", Type: iface},
+			},
+		},
+	}
+
+	return builder.DeclareConstructor(node.InstanceName, constructor, []ir.IRNode{node.Wrapped})
+}
@@ -0,0 +1,94 @@
+// Package hedging provides a Blueprint modifier for the client side of service calls.
+//
+// The plugin wraps clients with a hedger that, if a call has not returned after a configurable delay,
+// issues a second identical call to the service.  The first successful response is returned to the
+// caller, and the context of the other outstanding call is cancelled.
+//
+// The delay can either be fixed, or a percentile of the latencies that the client has recently observed.
+//
+// Hedging is only applied to the methods that are explicitly marked as idempotent when applying the
+// modifier; calls to all other methods are passed through to the wrapped client unmodified.
+//
+// Usage:
+//
+//	import "github.com/blueprint-uservices/blueprint/plugins/hedging"
+//	hedging.AddFixed(spec, "my_service", "50ms", "GetProfile", "GetRates")
+//	hedging.AddPercentile(spec, "my_service", 95, "GetProfile", "GetRates")
+//
+// # Artifacts Generated
+//
+// During compilation, the hedging plugin will generate a client-side wrapper class.  The plugin
+// also utilizes some code in the [runtime/plugins/hedging] package.
+//
+// [runtime/plugins/hedging]: https://github.com/Blueprint-uServices/blueprint/tree/main/runtime/plugins/hedging
+package hedging
+
+import (
+	"time"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/blueprint"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/pointer"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/wiring"
+	"github.com/blueprint-uservices/blueprint/plugins/golang"
+	"golang.org/x/exp/slog"
+)
+
+// Adds request hedging with a fixed delay to all clients of the specified service.
+// Uses a [blueprint.WiringSpec].
+// Modifies the given service such that, if a call to one of the idempotentMethods has not returned after
+// `delay`, the client issues a second identical call and returns whichever successful response arrives first.
+//
+// The `delay` string must be a sequence of decimal numbers, each with optional fraction and a unit suffix, such as "300ms", "1.5h" or "2h45m". Valid time units are "ns", "us" (or "µs"), "ms", "s", "m", "h".
+//
+// Usage:
+//
+//	AddFixed(spec, "my_service", "50ms", "GetProfile", "GetRates")
+func AddFixed(spec wiring.WiringSpec, serviceName string, delay string, idempotentMethods ...string) {
+	if _, err := time.ParseDuration(delay); err != nil {
+		slog.Error("Unable to add hedging to " + serviceName + " due to invalid delay " + delay + ": " + err.Error())
+		return
+	}
+	add(spec, serviceName, delay, 0, idempotentMethods)
+}
+
+// Adds request hedging with a latency-percentile delay to all clients of the specified service.
+// Uses a [blueprint.WiringSpec].
+// Modifies the given service such that, if a call to one of the idempotentMethods has been outstanding for
+// longer than the `percentile` (e.g. 95) of recently observed latencies for that method, the client issues
+// a second identical call and returns whichever successful response arrives first.
+//
+// Until enough latencies have been observed, calls are not hedged.
+//
+// Usage:
+//
+//	AddPercentile(spec, "my_service", 95, "GetProfile", "GetRates")
+func AddPercentile(spec wiring.WiringSpec, serviceName string, percentile float64, idempotentMethods ...string) {
+	if percentile <= 0 || percentile >= 100 {
+		slog.Error("Unable to add hedging to " + serviceName + " as the percentile must be between 0 and 100")
+		return
+	}
+	add(spec, serviceName, "", percentile, idempotentMethods)
+}
+
+func add(spec wiring.WiringSpec, serviceName string, delay string, percentile float64, idempotentMethods []string) {
+	clientWrapper := serviceName + ".client.hedging"
+
+	ptr := pointer.GetPointer(spec, serviceName)
+	if ptr == nil {
+		slog.Error("Unable to add hedging to " + serviceName + " as it is not a pointer")
+		return
+	}
+
+	clientNext := ptr.AddSrcModifier(spec, clientWrapper)
+
+	spec.Define(clientWrapper, &HedgingClient{}, func(ns wiring.Namespace) (ir.IRNode, error) {
+		var wrapped golang.Service
+
+		if err := ns.Get(clientNext, &wrapped); err != nil {
+			return nil, blueprint.Errorf("Hedging %s expected %s to be a golang.Service, but encountered %s", clientWrapper, clientNext, err)
+		}
+
+		return newHedgingClient(clientWrapper, wrapped, delay, percentile, idempotentMethods)
+	})
+}
@@ -79,7 +79,10 @@ func (handler *{{.Name}}) Run(ctx context.Context) error {
 		}
 	}()
 
-	return srv.ListenAndServe()
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
 }
 
 {{$service := .Service.Name -}}
@@ -0,0 +1,73 @@
+package kafka
+
+import (
+	"fmt"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/address"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/backend"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/service"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
+	"github.com/blueprint-uservices/blueprint/plugins/golang"
+	"github.com/blueprint-uservices/blueprint/plugins/workflow/workflowspec"
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/kafka"
+	"golang.org/x/exp/slog"
+)
+
+// Blueprint IR Node that represents the generated client for the kafka container
+type KafkaGoClient struct {
+	golang.Service
+	backend.Queue
+	InstanceName string
+	Topic        *ir.IRValue
+	Addr         *address.DialConfig
+	Spec         *workflowspec.Service
+}
+
+func newKafkaGoClient(name string, addr *address.DialConfig, topic *ir.IRValue) (*KafkaGoClient, error) {
+	spec, err := workflowspec.GetService[kafka.KafkaQueue]()
+	client := &KafkaGoClient{
+		InstanceName: name,
+		Addr:         addr,
+		Topic:        topic,
+		Spec:         spec,
+	}
+	return client, err
+}
+
+// Implements ir.IRNode
+func (n *KafkaGoClient) Name() string {
+	return n.InstanceName
+}
+
+// Implements ir.IRNode
+func (n *KafkaGoClient) String() string {
+	return n.InstanceName + " = KafkaClient(" + n.Addr.Name() + ")"
+}
+
+// Implements service.ServiceNode
+func (n *KafkaGoClient) GetInterface(ctx ir.BuildContext) (service.ServiceInterface, error) {
+	return n.Spec.Iface.ServiceInterface(ctx), nil
+}
+
+// Implements golang.ProvidesModule
+func (n *KafkaGoClient) AddToWorkspace(builder golang.WorkspaceBuilder) error {
+	return n.Spec.AddToWorkspace(builder)
+}
+
+// Implements golang.ProvidesInterface
+func (n *KafkaGoClient) AddInterfaces(builder golang.ModuleBuilder) error {
+	return n.Spec.AddToModule(builder)
+}
+
+// Implements golang.Instantiable
+func (n *KafkaGoClient) AddInstantiation(builder golang.NamespaceBuilder) error {
+	if builder.Visited(n.InstanceName) {
+		return nil
+	}
+	slog.Info(fmt.Sprintf("Instantiating KafkaClient %v in %v/%v", n.InstanceName, builder.Info().Package.PackageName, builder.Info().FileName))
+
+	return builder.DeclareConstructor(n.InstanceName, n.Spec.Constructor.AsConstructor(), []ir.IRNode{n.Addr, n.Topic})
+}
+
+func (n *KafkaGoClient) ImplementsGolangNode()    {}
+func (n *KafkaGoClient) ImplementsGolangService() {}
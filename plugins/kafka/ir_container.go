@@ -0,0 +1,96 @@
+package kafka
+
+import (
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/address"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/backend"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/service"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
+	"github.com/blueprint-uservices/blueprint/plugins/docker"
+	"github.com/blueprint-uservices/blueprint/plugins/golang/goparser"
+	"github.com/blueprint-uservices/blueprint/plugins/workflow/workflowspec"
+)
+
+// Blueprint IR Node that represents the server side docker container
+type KafkaContainer struct {
+	backend.Queue
+	docker.Container
+	docker.ProvidesContainerInstance
+
+	InstanceName string
+	BindAddr     *address.BindConfig
+	Iface        *goparser.ParsedInterface
+}
+
+// Kafka interface exposed by the docker container.
+type KafkaInterface struct {
+	service.ServiceInterface
+	Wrapped service.ServiceInterface
+}
+
+func (k *KafkaInterface) GetName() string {
+	return "kafka(" + k.Wrapped.GetName() + ")"
+}
+
+func (k *KafkaInterface) GetMethods() []service.Method {
+	return k.Wrapped.GetMethods()
+}
+
+// Creates a kafka container whose interface is that of the client implementation ClientImpl
+func newKafkaContainer[ClientImpl any](name string) (*KafkaContainer, error) {
+	spec, err := workflowspec.GetService[ClientImpl]()
+	if err != nil {
+		return nil, err
+	}
+	cntr := &KafkaContainer{
+		InstanceName: name,
+		Iface:        spec.Iface,
+	}
+	return cntr, nil
+}
+
+// Implements ir.IRNode
+func (n *KafkaContainer) String() string {
+	return n.InstanceName + " = KafkaContainer(" + n.BindAddr.Name() + ")"
+}
+
+// Implements ir.IRNode
+func (n *KafkaContainer) Name() string {
+	return n.InstanceName
+}
+
+// Implements service.ServiceNode
+func (n *KafkaContainer) GetInterface(ctx ir.BuildContext) (service.ServiceInterface, error) {
+	iface := n.Iface.ServiceInterface(ctx)
+	return &KafkaInterface{Wrapped: iface}, nil
+}
+
+// Implements docker.ProvidesContainerInstance
+//
+// The container runs a single kafka broker in KRaft mode, that advertises itself to clients by its
+// container hostname.  Clients outside of the container deployment must be able to resolve the hostname.
+func (n *KafkaContainer) AddContainerInstance(target docker.ContainerWorkspace) error {
+	n.BindAddr.Port = 9092
+	err := target.DeclarePrebuiltInstance(n.InstanceName, "apache/kafka:3.7.0", n.BindAddr)
+	if err != nil {
+		return err
+	}
+	env := [][2]string{
+		{"KAFKA_NODE_ID", "1"},
+		{"KAFKA_PROCESS_ROLES", "broker,controller"},
+		{"KAFKA_LISTENERS", "PLAINTEXT://:9092,CONTROLLER://:9093"},
+		{"KAFKA_ADVERTISED_LISTENERS", "PLAINTEXT://" + ir.CleanName(n.InstanceName) + ":9092"},
+		{"KAFKA_CONTROLLER_LISTENER_NAMES", "CONTROLLER"},
+		{"KAFKA_LISTENER_SECURITY_PROTOCOL_MAP", "CONTROLLER:PLAINTEXT,PLAINTEXT:PLAINTEXT"},
+		{"KAFKA_CONTROLLER_QUORUM_VOTERS", "1@localhost:9093"},
+		{"KAFKA_OFFSETS_TOPIC_REPLICATION_FACTOR", "1"},
+		{"KAFKA_TRANSACTION_STATE_LOG_REPLICATION_FACTOR", "1"},
+		{"KAFKA_TRANSACTION_STATE_LOG_MIN_ISR", "1"},
+		{"KAFKA_GROUP_INITIAL_REBALANCE_DELAY_MS", "0"},
+	}
+	for _, kv := range env {
+		if err := target.SetEnvironmentVariable(n.InstanceName, kv[0], kv[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package kafka
+
+import (
+	"fmt"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/address"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/backend"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/service"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
+	"github.com/blueprint-uservices/blueprint/plugins/golang"
+	"github.com/blueprint-uservices/blueprint/plugins/workflow/workflowspec"
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/kafka"
+	"golang.org/x/exp/slog"
+)
+
+// Blueprint IR Node that represents the generated pubsub client for the kafka container
+type KafkaPubSubClient struct {
+	golang.Service
+	backend.PubSub
+	InstanceName string
+	Topic        *ir.IRValue
+	Addr         *address.DialConfig
+	Spec         *workflowspec.Service
+}
+
+func newKafkaPubSubClient(name string, addr *address.DialConfig, topic *ir.IRValue) (*KafkaPubSubClient, error) {
+	spec, err := workflowspec.GetService[kafka.KafkaPubSub]()
+	client := &KafkaPubSubClient{
+		InstanceName: name,
+		Addr:         addr,
+		Topic:        topic,
+		Spec:         spec,
+	}
+	return client, err
+}
+
+// Implements ir.IRNode
+func (n *KafkaPubSubClient) Name() string {
+	return n.InstanceName
+}
+
+// Implements ir.IRNode
+func (n *KafkaPubSubClient) String() string {
+	return n.InstanceName + " = KafkaPubSubClient(" + n.Addr.Name() + ")"
+}
+
+// Implements service.ServiceNode
+func (n *KafkaPubSubClient) GetInterface(ctx ir.BuildContext) (service.ServiceInterface, error) {
+	return n.Spec.Iface.ServiceInterface(ctx), nil
+}
+
+// Implements golang.ProvidesModule
+func (n *KafkaPubSubClient) AddToWorkspace(builder golang.WorkspaceBuilder) error {
+	return n.Spec.AddToWorkspace(builder)
+}
+
+// Implements golang.ProvidesInterface
+func (n *KafkaPubSubClient) AddInterfaces(builder golang.ModuleBuilder) error {
+	return n.Spec.AddToModule(builder)
+}
+
+// Implements golang.Instantiable
+func (n *KafkaPubSubClient) AddInstantiation(builder golang.NamespaceBuilder) error {
+	if builder.Visited(n.InstanceName) {
+		return nil
+	}
+	slog.Info(fmt.Sprintf("Instantiating KafkaPubSubClient %v in %v/%v", n.InstanceName, builder.Info().Package.PackageName, builder.Info().FileName))
+
+	return builder.DeclareConstructor(n.InstanceName, n.Spec.Constructor.AsConstructor(), []ir.IRNode{n.Addr, n.Topic})
+}
+
+func (n *KafkaPubSubClient) ImplementsGolangNode()    {}
+func (n *KafkaPubSubClient) ImplementsGolangService() {}
@@ -0,0 +1,78 @@
+// Package kafka provides a plugin to generate and include a kafka instance in a Blueprint application.
+//
+// The package provides a built-in kafka container that provides the server-side implementation
+// and go-clients for connecting to the server.
+//
+// The applications must use a backend.Queue (runtime/core/backend) as the interface in the workflow,
+// or a backend.PubSub for containers created with [PubSubContainer].
+//
+// Items pushed with a key (backend.PushOptions.Key) are delivered in order with the other items of the
+// same key, and all clients of a queue share a kafka consumer group.  See [runtime/plugins/kafka] for details.
+//
+// [runtime/plugins/kafka]: https://github.com/Blueprint-uServices/blueprint/tree/main/runtime/plugins/kafka
+package kafka
+
+import (
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/blueprint"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/address"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/pointer"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/wiring"
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/kafka"
+)
+
+// Container generates the IRNodes for a kafka broker docker container that uses the apache/kafka image,
+// and the clients needed by the generated application to use the kafka topic topic as a queue.
+func Container(spec wiring.WiringSpec, name string, topic string) string {
+	return define[*KafkaGoClient, kafka.KafkaQueue](spec, name, func(clientName string, addr *address.DialConfig) (ir.IRNode, error) {
+		return newKafkaGoClient(clientName, addr, &ir.IRValue{Value: topic})
+	})
+}
+
+// PubSubContainer generates the IRNodes for a kafka broker docker container, and the pubsub clients needed
+// by the generated application to publish to and subscribe from the kafka topic topic.
+//
+// Workflow services receive the instance as a backend.PubSub.
+func PubSubContainer(spec wiring.WiringSpec, name string, topic string) string {
+	return define[*KafkaPubSubClient, kafka.KafkaPubSub](spec, name, func(clientName string, addr *address.DialConfig) (ir.IRNode, error) {
+		return newKafkaPubSubClient(clientName, addr, &ir.IRValue{Value: topic})
+	})
+}
+
+func define[ClientNode ir.IRNode, ClientImpl any](spec wiring.WiringSpec, name string, newClient func(string, *address.DialConfig) (ir.IRNode, error)) string {
+	// The nodes that we are defining
+	ctrName := name + ".ctr"
+	clientName := name + ".client"
+	addrName := name + ".addr"
+
+	// Define the kafka container
+	spec.Define(ctrName, &KafkaContainer{}, func(ns wiring.Namespace) (ir.IRNode, error) {
+		ctr, err := newKafkaContainer[ClientImpl](ctrName)
+		if err != nil {
+			return nil, err
+		}
+
+		err = address.Bind[*KafkaContainer](ns, addrName, ctr, &ctr.BindAddr)
+		return ctr, err
+	})
+
+	// Create a pointer to the kafka container
+	ptr := pointer.CreatePointer[ClientNode](spec, name, ctrName)
+
+	// Define the address that points to the kafka container
+	address.Define[*KafkaContainer](spec, addrName, ctrName)
+	ptr.AddAddrModifier(spec, addrName)
+
+	// Define the kafka client and add it to the client side of the pointer
+	clientNext := ptr.AddSrcModifier(spec, clientName)
+	var client ClientNode
+	spec.Define(clientName, client, func(ns wiring.Namespace) (ir.IRNode, error) {
+		addr, err := address.Dial[*KafkaContainer](ns, clientNext)
+		if err != nil {
+			return nil, blueprint.Errorf("%s expected %s to be an address but encountered %s", clientName, clientNext, err)
+		}
+		return newClient(clientName, addr.Dial)
+	})
+
+	return name
+}
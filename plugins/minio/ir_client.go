@@ -0,0 +1,81 @@
+package minio
+
+import (
+	"fmt"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/address"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/backend"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/service"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
+	"github.com/blueprint-uservices/blueprint/plugins/golang"
+	"github.com/blueprint-uservices/blueprint/plugins/secrets"
+	"github.com/blueprint-uservices/blueprint/plugins/workflow/workflowspec"
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/minio"
+	"golang.org/x/exp/slog"
+)
+
+// Blueprint IR Node that represents the generated client for the minio container
+type MinioGoClient struct {
+	golang.Service
+	backend.BlobStore
+
+	InstanceName string
+	Addr         *address.DialConfig
+	Bucket       *ir.IRValue
+	AccessKey    *ir.IRValue
+	SecretKey    *secrets.Secret
+
+	Spec *workflowspec.Service
+}
+
+func newMinioGoClient(name string, addr *address.DialConfig, bucket *ir.IRValue, accessKey *ir.IRValue, secretKey *secrets.Secret) (*MinioGoClient, error) {
+	spec, err := workflowspec.GetService[minio.MinioBlobStore]()
+	client := &MinioGoClient{
+		InstanceName: name,
+		Addr:         addr,
+		Bucket:       bucket,
+		AccessKey:    accessKey,
+		SecretKey:    secretKey,
+		Spec:         spec,
+	}
+	return client, err
+}
+
+// Implements ir.IRNode
+func (m *MinioGoClient) Name() string {
+	return m.InstanceName
+}
+
+// Implements ir.IRNode
+func (m *MinioGoClient) String() string {
+	return m.InstanceName + " = MinioClient(" + m.Addr.Name() + ")"
+}
+
+// Implements service.ServiceNode
+func (m *MinioGoClient) GetInterface(ctx ir.BuildContext) (service.ServiceInterface, error) {
+	return m.Spec.Iface.ServiceInterface(ctx), nil
+}
+
+// Implements golang.ProvidesModule
+func (m *MinioGoClient) AddToWorkspace(builder golang.WorkspaceBuilder) error {
+	return m.Spec.AddToWorkspace(builder)
+}
+
+// Implements golang.ProvidesInterface
+func (m *MinioGoClient) AddInterfaces(builder golang.ModuleBuilder) error {
+	return m.Spec.AddToModule(builder)
+}
+
+// Implements golang.Instantiable
+func (m *MinioGoClient) AddInstantiation(builder golang.NamespaceBuilder) error {
+	if builder.Visited(m.InstanceName) {
+		return nil
+	}
+
+	slog.Info(fmt.Sprintf("Instantiating MinioClient %v in %v/%v", m.InstanceName, builder.Info().Package.PackageName, builder.Info().FileName))
+
+	return builder.DeclareConstructor(m.InstanceName, m.Spec.Constructor.AsConstructor(), []ir.IRNode{m.Addr, m.Bucket, m.AccessKey, m.SecretKey})
+}
+
+func (node *MinioGoClient) ImplementsGolangNode()    {}
+func (node *MinioGoClient) ImplementsGolangService() {}
@@ -0,0 +1,120 @@
+package minio
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/address"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/backend"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/service"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
+	"github.com/blueprint-uservices/blueprint/plugins/docker"
+	"github.com/blueprint-uservices/blueprint/plugins/golang/goparser"
+	"github.com/blueprint-uservices/blueprint/plugins/secrets"
+	"github.com/blueprint-uservices/blueprint/plugins/workflow/workflowspec"
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/minio"
+	"golang.org/x/exp/slog"
+)
+
+// The minio image extended by the container's image
+const minioImage = "minio/minio"
+
+// Blueprint IR Node that represents the server side docker container
+type MinioContainer struct {
+	backend.BlobStore
+	docker.Container
+	docker.ProvidesContainerImage
+	docker.ProvidesContainerInstance
+
+	InstanceName string
+	BindAddr     *address.BindConfig
+	Iface        *goparser.ParsedInterface
+
+	Password *secrets.Secret // The password of the root user
+
+	user string
+}
+
+// Minio interface exposed by the docker container.
+type MinioInterface struct {
+	service.ServiceInterface
+	Wrapped service.ServiceInterface
+}
+
+func (m *MinioInterface) GetName() string {
+	return "minio(" + m.Wrapped.GetName() + ")"
+}
+
+func (m *MinioInterface) GetMethods() []service.Method {
+	return m.Wrapped.GetMethods()
+}
+
+func newMinioContainer(name, user string, password *secrets.Secret) (*MinioContainer, error) {
+	spec, err := workflowspec.GetService[minio.MinioBlobStore]()
+	if err != nil {
+		return nil, err
+	}
+
+	cntr := &MinioContainer{
+		InstanceName: name,
+		Iface:        spec.Iface,
+		Password:     password,
+		user:         user,
+	}
+	return cntr, nil
+}
+
+// Implements ir.IRNode
+func (m *MinioContainer) String() string {
+	return m.InstanceName + " = MinioContainer(" + m.BindAddr.Name() + ")"
+}
+
+// Implements ir.IRNode
+func (m *MinioContainer) Name() string {
+	return m.InstanceName
+}
+
+// Implements service.ServiceNode
+func (m *MinioContainer) GetInterface(ctx ir.BuildContext) (service.ServiceInterface, error) {
+	iface := m.Iface.ServiceInterface(ctx)
+	return &MinioInterface{Wrapped: iface}, nil
+}
+
+// Implements docker.ProvidesContainerImage
+//
+// Generates an image that extends the minio image with the command to run a server that stores objects in /data.
+func (m *MinioContainer) AddContainerArtifacts(target docker.ContainerWorkspace) error {
+	if target.Visited(m.InstanceName + ".artifacts") {
+		return nil
+	}
+
+	slog.Info(fmt.Sprintf("Creating container image %v", m.InstanceName))
+	dir, err := target.CreateImageDir(m.imageName())
+	if err != nil {
+		return err
+	}
+	dockerfile := fmt.Sprintf("FROM %v\nCMD [\"server\", \"/data\"]\n", minioImage)
+	return os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte(dockerfile), 0644)
+}
+
+func (m *MinioContainer) imageName() string {
+	return ir.CleanName(m.InstanceName) + "_image"
+}
+
+// Implements docker.ProvidesContainerInstance
+func (m *MinioContainer) AddContainerInstance(target docker.ContainerWorkspace) error {
+	m.BindAddr.Port = 9000
+	err := target.DeclareLocalImage(m.InstanceName, m.imageName(), m.BindAddr)
+	if err != nil {
+		return err
+	}
+
+	// Set the credentials of the root user, which are used by the clients
+	err = target.SetEnvironmentVariable(m.InstanceName, "MINIO_ROOT_USER", m.user)
+	if err != nil {
+		return err
+	}
+
+	return target.SetEnvironmentVariableFromConfig(m.InstanceName, "MINIO_ROOT_PASSWORD", m.Password)
+}
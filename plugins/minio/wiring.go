@@ -0,0 +1,118 @@
+// Package minio provides a plugin to generate and include a MinIO instance in a Blueprint application.
+//
+// The package provides a built-in minio container that provides the server-side implementation
+// and a go-client for connecting to the server.
+//
+// The applications must use a backend.BlobStore (runtime/core/backend) as the interface in the workflow.
+// The client talks to the server over the S3 API, so it can be exercised entirely against a local MinIO container.
+//
+// # Wiring Spec Usage
+//
+// To instantiate a minio container:
+//
+//	media_store := minio.Container(spec, "media_store")
+//
+// The client stores objects in a bucket named after the instance, e.g. "media-store", which is created
+// when the first object is stored.
+//
+// The password of the container's root user is a secret, which is provided when the application is run.
+// By default the secret is named after the instance, e.g. media_store.password; to use another secret,
+// attach it with the [secrets] plugin:
+//
+//	secrets.Use(spec, media_store, secrets.Define(spec, "media_password"))
+//
+// [secrets]: https://github.com/Blueprint-uServices/blueprint/tree/main/plugins/secrets
+package minio
+
+import (
+	"strings"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/blueprint"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/address"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/pointer"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/wiring"
+	"github.com/blueprint-uservices/blueprint/plugins/secrets"
+)
+
+var minio_user = "minioadmin"
+
+// Container generates the IRNodes for a minio server docker container that uses the minio image
+// and the clients needed by the generated application to communicate with the server.
+func Container(spec wiring.WiringSpec, name string) string {
+	// The nodes that we are defining
+	ctrName := name + ".ctr"
+	clientName := name + ".client"
+	addrName := name + ".addr"
+	passwordName := name + ".password"
+
+	// Define the default secret for the root password, which is used if no other secret is attached
+	secrets.Define(spec, passwordName)
+
+	// Define the minio container
+	spec.Define(ctrName, &MinioContainer{}, func(ns wiring.Namespace) (ir.IRNode, error) {
+		password, err := secrets.Get(spec, ns, name, passwordName)
+		if err != nil {
+			return nil, err
+		}
+
+		ctr, err := newMinioContainer(ctrName, minio_user, password)
+		if err != nil {
+			return nil, err
+		}
+
+		err = address.Bind[*MinioContainer](ns, addrName, ctr, &ctr.BindAddr)
+		return ctr, err
+	})
+
+	// Create a pointer to the minio container
+	ptr := pointer.CreatePointer[*MinioGoClient](spec, name, ctrName)
+
+	// Define the address that points to the minio container
+	address.Define[*MinioContainer](spec, addrName, ctrName)
+
+	// Add the address to the pointer
+	ptr.AddAddrModifier(spec, addrName)
+
+	// Define the minio client and add it to the client side of the pointer
+	clientNext := ptr.AddSrcModifier(spec, clientName)
+	spec.Define(clientName, &MinioGoClient{}, func(ns wiring.Namespace) (ir.IRNode, error) {
+		addr, err := address.Dial[*MinioContainer](ns, clientNext)
+		if err != nil {
+			return nil, blueprint.Errorf("%s expected %s to be an address but encountered %s", clientName, clientNext, err)
+		}
+
+		password, err := secrets.Get(spec, ns, name, passwordName)
+		if err != nil {
+			return nil, err
+		}
+
+		bucket_val := &ir.IRValue{Value: bucketName(name)}
+		user_val := &ir.IRValue{Value: minio_user}
+
+		return newMinioGoClient(clientName, addr.Dial, bucket_val, user_val, password)
+	})
+
+	return name
+}
+
+// Returns a valid S3 bucket name for the instance name: 3 to 63 lower case letters, digits, and hyphens,
+// that begins and ends with a letter or digit.
+func bucketName(name string) string {
+	bucket := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			return r
+		} else if r >= 'A' && r <= 'Z' {
+			return r - 'A' + 'a'
+		}
+		return '-'
+	}, name)
+	if len(bucket) > 63 {
+		bucket = bucket[:63]
+	}
+	bucket = strings.Trim(bucket, "-")
+	for len(bucket) < 3 {
+		bucket += "0"
+	}
+	return bucket
+}
@@ -19,15 +19,19 @@ type MongoDBGoClient struct {
 	backend.NoSQLDB
 	InstanceName string
 	Addr         *address.DialConfig
+	Username     ir.IRNode // Empty if the server does not require authentication
+	Password     ir.IRNode // A secret, or empty if the server does not require authentication
 
 	Spec *workflowspec.Service
 }
 
-func newMongoDBGoClient(name string, addr *address.DialConfig) (*MongoDBGoClient, error) {
+func newMongoDBGoClient(name string, addr *address.DialConfig, username ir.IRNode, password ir.IRNode) (*MongoDBGoClient, error) {
 	spec, err := workflowspec.GetService[mongodb.MongoDB]()
 	client := &MongoDBGoClient{
 		InstanceName: name,
 		Addr:         addr,
+		Username:     username,
+		Password:     password,
 		Spec:         spec,
 	}
 	return client, err
@@ -66,7 +70,7 @@ func (n *MongoDBGoClient) AddInstantiation(builder golang.NamespaceBuilder) erro
 
 	slog.Info(fmt.Sprintf("Instantiating MongoClient %v in %v/%v", n.InstanceName, builder.Info().Package.PackageName, builder.Info().FileName))
 
-	return builder.DeclareConstructor(n.InstanceName, n.Spec.Constructor.AsConstructor(), []ir.IRNode{n.Addr})
+	return builder.DeclareConstructor(n.InstanceName, n.Spec.Constructor.AsConstructor(), []ir.IRNode{n.Addr, n.Username, n.Password})
 }
 
 func (node *MongoDBGoClient) ImplementsGolangNode()    {}
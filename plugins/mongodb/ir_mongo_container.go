@@ -1,25 +1,41 @@
 package mongodb
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/address"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/backend"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/service"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
 	"github.com/blueprint-uservices/blueprint/plugins/docker"
 	"github.com/blueprint-uservices/blueprint/plugins/golang/goparser"
+	"github.com/blueprint-uservices/blueprint/plugins/secrets"
 	"github.com/blueprint-uservices/blueprint/plugins/workflow/workflowspec"
 	"github.com/blueprint-uservices/blueprint/runtime/plugins/mongodb"
+	"golang.org/x/exp/slog"
 )
 
+// The mongo image extended by the container's image
+const mongoImage = "mongo"
+
+// The name of the single-member replica set that the server runs.  Change streams and transactions
+// are only supported by replica sets.
+const replicaSetName = "rs0"
+
 // Blueprint IR Node that represents the server side docker container
 type MongoDBContainer struct {
 	docker.Container
+	docker.ProvidesContainerImage
 	docker.ProvidesContainerInstance
 	backend.NoSQLDB
 
 	InstanceName string
 	BindAddr     *address.BindConfig
 	Iface        *goparser.ParsedInterface
+	Username     string          // The root user, if Password is not nil
+	Password     *secrets.Secret // The root user's password, or nil if the server does not require authentication
 }
 
 // MongoDB interface exposed by the docker container.
@@ -36,7 +52,7 @@ func (m *MongoInterface) GetMethods() []service.Method {
 	return m.Wrapped.GetMethods()
 }
 
-func newMongoDBContainer(name string) (*MongoDBContainer, error) {
+func newMongoDBContainer(name string, username string, password *secrets.Secret) (*MongoDBContainer, error) {
 	spec, err := workflowspec.GetService[mongodb.MongoDB]()
 	if err != nil {
 		return nil, err
@@ -45,6 +61,8 @@ func newMongoDBContainer(name string) (*MongoDBContainer, error) {
 	proc := &MongoDBContainer{
 		InstanceName: name,
 		Iface:        spec.Iface,
+		Username:     username,
+		Password:     password,
 	}
 	return proc, nil
 }
@@ -65,8 +83,57 @@ func (m *MongoDBContainer) GetInterface(ctx ir.BuildContext) (service.ServiceInt
 	return &MongoInterface{Wrapped: iface}, nil
 }
 
+// Implements docker.ProvidesContainerImage
+//
+// Generates an image that extends the mongo image to run the server as a single-member replica set.
+// The replica set is initiated by the container's healthcheck, the first time that it runs.  Until then
+// the server rejects writes, so clients wait for it to become primary; see [mongodb.NewMongoDB].
+//
+// If the server requires authentication, the members of the replica set also authenticate to each other
+// using a key file that is generated when the image is built.
+func (node *MongoDBContainer) AddContainerArtifacts(target docker.ContainerWorkspace) error {
+	if target.Visited(node.InstanceName + ".artifacts") {
+		return nil
+	}
+
+	slog.Info(fmt.Sprintf("Creating container image %v", node.InstanceName))
+	dir, err := target.CreateImageDir(node.imageName())
+	if err != nil {
+		return err
+	}
+	initiate := fmt.Sprintf("try { rs.status() } catch (e) { rs.initiate({_id: '%v', members: [{_id: 0, host: 'localhost:27017'}]}) }", replicaSetName)
+	var dockerfile string
+	if node.Password == nil {
+		dockerfile = fmt.Sprintf(`FROM %v
+CMD ["--replSet", "%v", "--bind_ip_all"]
+HEALTHCHECK --interval=5s --start-period=10s CMD mongosh --quiet --eval "%v"
+`, mongoImage, replicaSetName, initiate)
+	} else {
+		dockerfile = fmt.Sprintf(`FROM %v
+RUN head -c 756 /dev/urandom | base64 -w 0 > /etc/mongo-keyfile && chmod 400 /etc/mongo-keyfile && chown mongodb:mongodb /etc/mongo-keyfile
+CMD ["--replSet", "%v", "--bind_ip_all", "--keyFile", "/etc/mongo-keyfile"]
+HEALTHCHECK --interval=5s --start-period=10s CMD mongosh --quiet -u "$MONGO_INITDB_ROOT_USERNAME" -p "$MONGO_INITDB_ROOT_PASSWORD" --eval "%v"
+`, mongoImage, replicaSetName, initiate)
+	}
+	return os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte(dockerfile), 0644)
+}
+
+func (node *MongoDBContainer) imageName() string {
+	return ir.CleanName(node.InstanceName) + "_image"
+}
+
 // Implements docker.ProvidesContainerInstance
 func (node *MongoDBContainer) AddContainerInstance(target docker.ContainerWorkspace) error {
 	node.BindAddr.Port = 27017
-	return target.DeclarePrebuiltInstance(node.InstanceName, "mongo", node.BindAddr)
+	err := target.DeclareLocalImage(node.InstanceName, node.imageName(), node.BindAddr)
+	if err != nil || node.Password == nil {
+		return err
+	}
+
+	// The root user is created when the database is first initialized
+	err = target.SetEnvironmentVariable(node.InstanceName, "MONGO_INITDB_ROOT_USERNAME", node.Username)
+	if err != nil {
+		return err
+	}
+	return target.SetEnvironmentVariableFromConfig(node.InstanceName, "MONGO_INITDB_ROOT_PASSWORD", node.Password)
 }
@@ -1,9 +1,18 @@
 // Package mongodb provides a plugin to generate and include a mongodb instance in a Blueprint application.
 //
 // The package provides a built-in mongodb container that provides the server-side implementation
-// and a go-client for connecting to the client.
+// and a go-client for connecting to the client.  The container runs the server as a single-member
+// replica set, so that collections support transactions and change streams (Watch).
 //
 // The applications must use a backend.NoSQLDatabase (runtime/core/backend) as the interface in the application workflow.
+//
+// By default the server does not require authentication.  To require clients to authenticate as the root user,
+// attach a secret for the root user's password with the [secrets] plugin:
+//
+//	post_db := mongodb.Container(spec, "post_db")
+//	secrets.Use(spec, post_db, secrets.Define(spec, "post_db_password"))
+//
+// [secrets]: https://github.com/Blueprint-uServices/blueprint/tree/main/plugins/secrets
 package mongodb
 
 import (
@@ -12,8 +21,11 @@ import (
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/pointer"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/wiring"
+	"github.com/blueprint-uservices/blueprint/plugins/secrets"
 )
 
+var mongo_root_username = "root"
+
 // Container generates the IRNodes for a mongodb server docker container that uses the latest mongodb image
 // and the clients needed by the generated application to communicate with the server.
 //
@@ -26,7 +38,12 @@ func Container(spec wiring.WiringSpec, dbName string) string {
 
 	// Define the MongoDB container
 	spec.Define(ctrName, &MongoDBContainer{}, func(ns wiring.Namespace) (ir.IRNode, error) {
-		ctr, err := newMongoDBContainer(ctrName)
+		password, err := secrets.Get(spec, ns, dbName, "")
+		if err != nil {
+			return nil, err
+		}
+
+		ctr, err := newMongoDBContainer(ctrName, mongo_root_username, password)
 		if err != nil {
 			return nil, err
 		}
@@ -51,7 +68,15 @@ func Container(spec wiring.WiringSpec, dbName string) string {
 			return nil, blueprint.Errorf("%s expected %s to be an address but encountered %s", clientName, clientNext, err)
 		}
 
-		return newMongoDBGoClient(clientName, addr.Dial)
+		// The client only authenticates if the server requires it
+		var user_val, pwd_val ir.IRNode = &ir.IRValue{}, &ir.IRValue{}
+		if password, err := secrets.Get(spec, ns, dbName, ""); err != nil {
+			return nil, err
+		} else if password != nil {
+			user_val, pwd_val = &ir.IRValue{Value: mongo_root_username}, password
+		}
+
+		return newMongoDBGoClient(clientName, addr.Dial, user_val, pwd_val)
 	})
 
 	// Return the pointer; anybody who wants to access the MongoDB instance should do so through the pointer
@@ -8,6 +8,8 @@ import (
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/service"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
 	"github.com/blueprint-uservices/blueprint/plugins/golang"
+	"github.com/blueprint-uservices/blueprint/plugins/secrets"
+	"github.com/blueprint-uservices/blueprint/plugins/sqlmigrate"
 	"github.com/blueprint-uservices/blueprint/plugins/workflow/workflowspec"
 	"github.com/blueprint-uservices/blueprint/runtime/plugins/mysql"
 	"golang.org/x/exp/slog"
@@ -20,14 +22,15 @@ type MySQLDBGoClient struct {
 
 	InstanceName string
 	Username     *ir.IRValue
-	Password     *ir.IRValue
+	Password     *secrets.Secret
 	DBVal        *ir.IRValue
 	Addr         *address.DialConfig
+	Migrations   *sqlmigrate.Migrations // Applied by the client at startup, if not nil
 
 	Spec *workflowspec.Service
 }
 
-func newMySQLDBGoClient(name string, addr *address.DialConfig, username *ir.IRValue, password *ir.IRValue, dbname *ir.IRValue) (*MySQLDBGoClient, error) {
+func newMySQLDBGoClient(name string, addr *address.DialConfig, username *ir.IRValue, password *secrets.Secret, dbname *ir.IRValue, migrations *sqlmigrate.Migrations) (*MySQLDBGoClient, error) {
 	spec, err := workflowspec.GetService[mysql.MySqlDB]()
 	client := &MySQLDBGoClient{
 		InstanceName: name,
@@ -35,6 +38,7 @@ func newMySQLDBGoClient(name string, addr *address.DialConfig, username *ir.IRVa
 		Password:     password,
 		DBVal:        dbname,
 		Addr:         addr,
+		Migrations:   migrations,
 		Spec:         spec,
 	}
 	return client, err
@@ -73,7 +77,16 @@ func (m *MySQLDBGoClient) AddInstantiation(builder golang.NamespaceBuilder) erro
 
 	slog.Info(fmt.Sprintf("Instantiating MySqlClient %v in %v/%v", m.InstanceName, builder.Info().Package.PackageName, builder.Info().FileName))
 
-	return builder.DeclareConstructor(m.InstanceName, m.Spec.Constructor.AsConstructor(), []ir.IRNode{m.Addr, m.DBVal, m.Username, m.Password})
+	constructor := m.Spec.Constructor.AsConstructor()
+	if m.Migrations != nil {
+		var err error
+		constructor, err = m.Migrations.GenerateConstructor(builder.Module(), m.InstanceName, constructor)
+		if err != nil {
+			return err
+		}
+	}
+
+	return builder.DeclareConstructor(m.InstanceName, constructor, []ir.IRNode{m.Addr, m.DBVal, m.Username, m.Password})
 }
 
 func (node *MySQLDBGoClient) ImplementsGolangNode()    {}
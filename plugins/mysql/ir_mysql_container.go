@@ -1,27 +1,39 @@
 package mysql
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/address"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/backend"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/service"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
 	"github.com/blueprint-uservices/blueprint/plugins/docker"
 	"github.com/blueprint-uservices/blueprint/plugins/golang/goparser"
+	"github.com/blueprint-uservices/blueprint/plugins/secrets"
+	"github.com/blueprint-uservices/blueprint/plugins/sqlmigrate"
 	"github.com/blueprint-uservices/blueprint/plugins/workflow/workflowspec"
 	"github.com/blueprint-uservices/blueprint/runtime/plugins/mysql"
+	"golang.org/x/exp/slog"
 )
 
+// The mysql image used by the container, and by the images built for containers with migrations
+const mysqlImage = "mysql/mysql-server"
+
 // Blueprint IR Node that represents the server side docker container
 type MySQLDBContainer struct {
 	backend.RelDB
 	docker.Container
+	docker.ProvidesContainerImage
 	docker.ProvidesContainerInstance
 
 	InstanceName string
 	BindAddr     *address.BindConfig
 	Iface        *goparser.ParsedInterface
-
-	password string
+	DBName       string                 // The database created when the container is first initialized
+	Migrations   *sqlmigrate.Migrations // Run when the database is first initialized, if not nil
+	Password     *secrets.Secret        // The root password
 }
 
 // MySQL interface exposed by the docker container.
@@ -38,7 +50,7 @@ func (m *MySQLInterface) GetMethods() []service.Method {
 	return m.Wrapped.GetMethods()
 }
 
-func newMySQLDBContainer(name, root_password string) (*MySQLDBContainer, error) {
+func newMySQLDBContainer(name, dbName string, root_password *secrets.Secret, migrations *sqlmigrate.Migrations) (*MySQLDBContainer, error) {
 	spec, err := workflowspec.GetService[mysql.MySqlDB]()
 	if err != nil {
 		return nil, err
@@ -47,7 +59,9 @@ func newMySQLDBContainer(name, root_password string) (*MySQLDBContainer, error)
 	cntr := &MySQLDBContainer{
 		InstanceName: name,
 		Iface:        spec.Iface,
-		password:     root_password,
+		DBName:       dbName,
+		Migrations:   migrations,
+		Password:     root_password,
 	}
 	return cntr, nil
 }
@@ -68,10 +82,44 @@ func (m *MySQLDBContainer) GetInterface(ctx ir.BuildContext) (service.ServiceInt
 	return &MySQLInterface{Wrapped: iface}, nil
 }
 
+// Implements docker.ProvidesContainerImage
+//
+// If the container has migrations, generates an image that extends the mysql image with an init script
+// in /docker-entrypoint-initdb.d that runs the migrations.
+func (m *MySQLDBContainer) AddContainerArtifacts(target docker.ContainerWorkspace) error {
+	if m.Migrations == nil || target.Visited(m.InstanceName+".artifacts") {
+		return nil
+	}
+
+	slog.Info(fmt.Sprintf("Creating container image %v", m.InstanceName))
+	dir, err := target.CreateImageDir(m.imageName())
+	if err != nil {
+		return err
+	}
+	initDir := filepath.Join(dir, "initdb")
+	if err := os.MkdirAll(initDir, 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(initDir, "migrations.sql"), []byte(m.Migrations.Script()), 0644); err != nil {
+		return err
+	}
+	dockerfile := fmt.Sprintf("FROM %v\nCOPY ./initdb/ /docker-entrypoint-initdb.d/\n", mysqlImage)
+	return os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte(dockerfile), 0644)
+}
+
+func (m *MySQLDBContainer) imageName() string {
+	return ir.CleanName(m.InstanceName) + "_image"
+}
+
 // Implements docker.ProvidesContainerInstance
 func (m *MySQLDBContainer) AddContainerInstance(target docker.ContainerWorkspace) error {
 	m.BindAddr.Port = 3306
-	err := target.DeclarePrebuiltInstance(m.InstanceName, "mysql/mysql-server", m.BindAddr)
+	var err error
+	if m.Migrations == nil {
+		err = target.DeclarePrebuiltInstance(m.InstanceName, mysqlImage, m.BindAddr)
+	} else {
+		err = target.DeclareLocalImage(m.InstanceName, m.imageName(), m.BindAddr)
+	}
 	if err != nil {
 		return err
 	}
@@ -81,5 +129,11 @@ func (m *MySQLDBContainer) AddContainerInstance(target docker.ContainerWorkspace
 		return err
 	}
 
-	return target.SetEnvironmentVariable(m.InstanceName, "MYSQL_ROOT_PASSWORD", m.password)
+	// Init scripts are run against the database named by MYSQL_DATABASE
+	err = target.SetEnvironmentVariable(m.InstanceName, "MYSQL_DATABASE", m.DBName)
+	if err != nil {
+		return err
+	}
+
+	return target.SetEnvironmentVariableFromConfig(m.InstanceName, "MYSQL_ROOT_PASSWORD", m.Password)
 }
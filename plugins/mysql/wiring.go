@@ -4,6 +4,29 @@
 // and a go-client for connecting to the server.
 //
 // The applications must use a backend.RelationalDB (runtime/core/backend) as the interface in the workflow.
+//
+// # Wiring Spec Usage
+//
+// To instantiate a mysql container:
+//
+//	post_db := mysql.Container(spec, "post_db")
+//
+// The container's database has the same name as the instance, e.g. "post_db".  To create the database's
+// tables and seed data, attach a directory of versioned SQL migrations with the [sqlmigrate] plugin:
+//
+//	sqlmigrate.Add(spec, post_db, "migrations/post_db")
+//
+// The migrations are run when the container's database is first initialized, and any that are still
+// pending are applied by the client when the application starts.
+//
+// The root password of the container is a secret, which is provided when the application is run.  By default
+// the secret is named after the instance, e.g. post_db.password; to use another secret, attach it with the
+// [secrets] plugin:
+//
+//	secrets.Use(spec, post_db, secrets.Define(spec, "db_password"))
+//
+// [sqlmigrate]: https://github.com/Blueprint-uServices/blueprint/tree/main/plugins/sqlmigrate
+// [secrets]: https://github.com/Blueprint-uServices/blueprint/tree/main/plugins/secrets
 package mysql
 
 import (
@@ -12,22 +35,40 @@ import (
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/pointer"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/wiring"
+	"github.com/blueprint-uservices/blueprint/plugins/secrets"
+	"github.com/blueprint-uservices/blueprint/plugins/sqlmigrate"
 )
 
 var mysql_root_username = "root"
-var mysql_root_password = "pass"
 
 // Container generate the IRNodes for a mysql server docker container that uses the latest mysql/mysql image
 // and the clients needed by the generated application to communicate with the server.
+//
+// If migrations are attached to dbName using [sqlmigrate.Add], the container is built from an image that
+// runs the migrations when the database is first initialized.
 func Container(spec wiring.WiringSpec, dbName string) string {
 	// The nodes that we are defining
 	ctrName := dbName + ".ctr"
 	clientName := dbName + ".client"
 	addrName := dbName + ".addr"
+	passwordName := dbName + ".password"
+
+	// Define the default secret for the root password, which is used if no other secret is attached
+	secrets.Define(spec, passwordName)
 
 	// Define the MySQL container
 	spec.Define(ctrName, &MySQLDBContainer{}, func(ns wiring.Namespace) (ir.IRNode, error) {
-		ctr, err := newMySQLDBContainer(ctrName, mysql_root_password)
+		migrations, err := sqlmigrate.Get(spec, dbName)
+		if err != nil {
+			return nil, err
+		}
+
+		password, err := secrets.Get(spec, ns, dbName, passwordName)
+		if err != nil {
+			return nil, err
+		}
+
+		ctr, err := newMySQLDBContainer(ctrName, dbName, password, migrations)
 		if err != nil {
 			return nil, err
 		}
@@ -53,11 +94,20 @@ func Container(spec wiring.WiringSpec, dbName string) string {
 			return nil, blueprint.Errorf("%s expected %s to be an address but encountered %s", clientName, clientNext, err)
 		}
 
+		migrations, err := sqlmigrate.Get(spec, dbName)
+		if err != nil {
+			return nil, err
+		}
+
+		password, err := secrets.Get(spec, ns, dbName, passwordName)
+		if err != nil {
+			return nil, err
+		}
+
 		user_val := &ir.IRValue{Value: mysql_root_username}
-		pwd_val := &ir.IRValue{Value: mysql_root_password}
 		db_val := &ir.IRValue{Value: dbName}
 
-		return newMySQLDBGoClient(clientName, addr.Dial, user_val, pwd_val, db_val)
+		return newMySQLDBGoClient(clientName, addr.Dial, user_val, password, db_val, migrations)
 	})
 
 	return dbName
@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"fmt"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/address"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/backend"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/service"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
+	"github.com/blueprint-uservices/blueprint/plugins/golang"
+	"github.com/blueprint-uservices/blueprint/plugins/secrets"
+	"github.com/blueprint-uservices/blueprint/plugins/sqlmigrate"
+	"github.com/blueprint-uservices/blueprint/plugins/workflow/workflowspec"
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/postgres"
+	"golang.org/x/exp/slog"
+)
+
+// Blueprint IR Node that represents the generated client for the postgres container
+type PostgresGoClient struct {
+	golang.Service
+	backend.RelDB
+
+	InstanceName string
+	Username     *ir.IRValue
+	Password     *secrets.Secret
+	DBVal        *ir.IRValue
+	Addr         *address.DialConfig
+	Migrations   *sqlmigrate.Migrations // Applied by the client at startup, if not nil
+
+	Spec *workflowspec.Service
+}
+
+func newPostgresGoClient(name string, addr *address.DialConfig, username *ir.IRValue, password *secrets.Secret, dbname *ir.IRValue, migrations *sqlmigrate.Migrations) (*PostgresGoClient, error) {
+	spec, err := workflowspec.GetService[postgres.PostgresDB]()
+	client := &PostgresGoClient{
+		InstanceName: name,
+		Username:     username,
+		Password:     password,
+		DBVal:        dbname,
+		Addr:         addr,
+		Migrations:   migrations,
+		Spec:         spec,
+	}
+	return client, err
+}
+
+// Implements ir.IRNode
+func (m *PostgresGoClient) Name() string {
+	return m.InstanceName
+}
+
+// Implements ir.IRNode
+func (m *PostgresGoClient) String() string {
+	return m.InstanceName + " = PostgresClient(" + m.Addr.Name() + ")"
+}
+
+// Implements service.ServiceNode
+func (m *PostgresGoClient) GetInterface(ctx ir.BuildContext) (service.ServiceInterface, error) {
+	return m.Spec.Iface.ServiceInterface(ctx), nil
+}
+
+// Implements golang.ProvidesModule
+func (m *PostgresGoClient) AddToWorkspace(builder golang.WorkspaceBuilder) error {
+	return m.Spec.AddToWorkspace(builder)
+}
+
+// Implements golang.ProvidesInterface
+func (n *PostgresGoClient) AddInterfaces(builder golang.ModuleBuilder) error {
+	return n.Spec.AddToModule(builder)
+}
+
+// Implements golang.Instantiable
+func (m *PostgresGoClient) AddInstantiation(builder golang.NamespaceBuilder) error {
+	if builder.Visited(m.InstanceName) {
+		return nil
+	}
+
+	slog.Info(fmt.Sprintf("Instantiating PostgresClient %v in %v/%v", m.InstanceName, builder.Info().Package.PackageName, builder.Info().FileName))
+
+	constructor := m.Spec.Constructor.AsConstructor()
+	if m.Migrations != nil {
+		var err error
+		constructor, err = m.Migrations.GenerateConstructor(builder.Module(), m.InstanceName, constructor)
+		if err != nil {
+			return err
+		}
+	}
+
+	return builder.DeclareConstructor(m.InstanceName, constructor, []ir.IRNode{m.Addr, m.DBVal, m.Username, m.Password})
+}
+
+func (node *PostgresGoClient) ImplementsGolangNode()    {}
+func (node *PostgresGoClient) ImplementsGolangService() {}
@@ -0,0 +1,167 @@
+package postgres
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/blueprint"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/address"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/backend"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/service"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
+	"github.com/blueprint-uservices/blueprint/plugins/docker"
+	"github.com/blueprint-uservices/blueprint/plugins/golang/goparser"
+	"github.com/blueprint-uservices/blueprint/plugins/secrets"
+	"github.com/blueprint-uservices/blueprint/plugins/sqlmigrate"
+	"github.com/blueprint-uservices/blueprint/plugins/workflow/workflowspec"
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/postgres"
+	"golang.org/x/exp/slog"
+)
+
+// The postgres image used by the container, and by the images built for containers with init scripts
+const postgresImage = "postgres:16"
+
+// Blueprint IR Node that represents the server side docker container
+type PostgresContainer struct {
+	backend.RelDB
+	docker.Container
+	docker.ProvidesContainerImage
+	docker.ProvidesContainerInstance
+
+	InstanceName string
+	BindAddr     *address.BindConfig
+	Iface        *goparser.ParsedInterface
+	DBName       string                 // The database created when the container is first initialized
+	InitScripts  []string               // Absolute paths of the scripts run when the database is first initialized
+	Migrations   *sqlmigrate.Migrations // Run after the init scripts when the database is first initialized, if not nil
+	Password     *secrets.Secret        // The password of the postgres user
+}
+
+// Postgres interface exposed by the docker container.
+type PostgresInterface struct {
+	service.ServiceInterface
+	Wrapped service.ServiceInterface
+}
+
+func (p *PostgresInterface) GetName() string {
+	return "postgres(" + p.Wrapped.GetName() + ")"
+}
+
+func (p *PostgresInterface) GetMethods() []service.Method {
+	return p.Wrapped.GetMethods()
+}
+
+func newPostgresContainer(name, dbName string, password *secrets.Secret, initScripts []string, migrations *sqlmigrate.Migrations) (*PostgresContainer, error) {
+	spec, err := workflowspec.GetService[postgres.PostgresDB]()
+	if err != nil {
+		return nil, err
+	}
+
+	cntr := &PostgresContainer{
+		InstanceName: name,
+		Iface:        spec.Iface,
+		DBName:       dbName,
+		Migrations:   migrations,
+		Password:     password,
+	}
+	for _, script := range initScripts {
+		path, err := filepath.Abs(script)
+		if err != nil {
+			return nil, err
+		}
+		if info, err := os.Stat(path); err != nil {
+			return nil, blueprint.Errorf("init script %v of %v does not exist: %v", script, name, err)
+		} else if info.IsDir() {
+			return nil, blueprint.Errorf("init script %v of %v is a directory", script, name)
+		}
+		cntr.InitScripts = append(cntr.InitScripts, path)
+	}
+	return cntr, nil
+}
+
+// Implements ir.IRNode
+func (p *PostgresContainer) String() string {
+	return p.InstanceName + " = PostgresContainer(" + p.BindAddr.Name() + ")"
+}
+
+// Implements ir.IRNode
+func (p *PostgresContainer) Name() string {
+	return p.InstanceName
+}
+
+// Implements service.ServiceNode
+func (p *PostgresContainer) GetInterface(ctx ir.BuildContext) (service.ServiceInterface, error) {
+	iface := p.Iface.ServiceInterface(ctx)
+	return &PostgresInterface{Wrapped: iface}, nil
+}
+
+// Implements docker.ProvidesContainerImage
+//
+// If the container has init scripts or migrations, generates an image that extends the postgres image with
+// the scripts in /docker-entrypoint-initdb.d.  The scripts are named so that they run in the order given,
+// followed by a script that runs the migrations.
+func (p *PostgresContainer) AddContainerArtifacts(target docker.ContainerWorkspace) error {
+	if !p.hasImage() || target.Visited(p.InstanceName+".artifacts") {
+		return nil
+	}
+
+	slog.Info(fmt.Sprintf("Creating container image %v", p.InstanceName))
+	dir, err := target.CreateImageDir(p.imageName())
+	if err != nil {
+		return err
+	}
+	initDir := filepath.Join(dir, "initdb")
+	if err := os.MkdirAll(initDir, 0755); err != nil {
+		return err
+	}
+	for i, script := range p.InitScripts {
+		contents, err := os.ReadFile(script)
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(initDir, fmt.Sprintf("%02d_%v", i, filepath.Base(script)))
+		if err := os.WriteFile(dst, contents, 0644); err != nil {
+			return err
+		}
+	}
+	if p.Migrations != nil {
+		dst := filepath.Join(initDir, fmt.Sprintf("%02d_migrations.sql", len(p.InitScripts)))
+		if err := os.WriteFile(dst, []byte(p.Migrations.Script()), 0644); err != nil {
+			return err
+		}
+	}
+	dockerfile := fmt.Sprintf("FROM %v\nCOPY ./initdb/ /docker-entrypoint-initdb.d/\n", postgresImage)
+	return os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte(dockerfile), 0644)
+}
+
+// Reports whether the container is built from a generated image, rather than the postgres image
+func (p *PostgresContainer) hasImage() bool {
+	return len(p.InitScripts) > 0 || p.Migrations != nil
+}
+
+func (p *PostgresContainer) imageName() string {
+	return ir.CleanName(p.InstanceName) + "_image"
+}
+
+// Implements docker.ProvidesContainerInstance
+func (p *PostgresContainer) AddContainerInstance(target docker.ContainerWorkspace) error {
+	p.BindAddr.Port = 5432
+	var err error
+	if !p.hasImage() {
+		err = target.DeclarePrebuiltInstance(p.InstanceName, postgresImage, p.BindAddr)
+	} else {
+		err = target.DeclareLocalImage(p.InstanceName, p.imageName(), p.BindAddr)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Set necessary environment variables
+	err = target.SetEnvironmentVariable(p.InstanceName, "POSTGRES_DB", p.DBName)
+	if err != nil {
+		return err
+	}
+
+	return target.SetEnvironmentVariableFromConfig(p.InstanceName, "POSTGRES_PASSWORD", p.Password)
+}
@@ -0,0 +1,117 @@
+// Package postgres provides a plugin to generate and include a postgres instance in a Blueprint application.
+//
+// The package provides a built-in postgres container that provides the server-side implementation
+// and a go-client for connecting to the server.
+//
+// The applications must use a backend.RelationalDB (runtime/core/backend) as the interface in the workflow.
+// Queries use "?" placeholders, the same as for the other backend.RelationalDB implementations.
+//
+// # Wiring Spec Usage
+//
+// To instantiate a postgres container, optionally with SQL scripts that initialize the database:
+//
+//	catalogue_db := postgres.Container(spec, "catalogue_db", "schema.sql", "data.sql")
+//
+// The scripts are read from the filesystem when the application is compiled, and are run in the order
+// given when the container's database is first initialized.  The container's database has the same
+// name as the instance, e.g. "catalogue_db", and the scripts are run against it.
+//
+// Alternatively, attach a directory of versioned SQL migrations with the [sqlmigrate] plugin:
+//
+//	sqlmigrate.Add(spec, catalogue_db, "migrations/catalogue_db")
+//
+// The migrations are run after any init scripts when the container's database is first initialized, and
+// any that are still pending are applied by the client when the application starts.
+//
+// The password of the container is a secret, which is provided when the application is run.  By default
+// the secret is named after the instance, e.g. catalogue_db.password; to use another secret, attach it with
+// the [secrets] plugin:
+//
+//	secrets.Use(spec, catalogue_db, secrets.Define(spec, "db_password"))
+//
+// [sqlmigrate]: https://github.com/Blueprint-uServices/blueprint/tree/main/plugins/sqlmigrate
+// [secrets]: https://github.com/Blueprint-uServices/blueprint/tree/main/plugins/secrets
+package postgres
+
+import (
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/blueprint"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/address"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/pointer"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/wiring"
+	"github.com/blueprint-uservices/blueprint/plugins/secrets"
+	"github.com/blueprint-uservices/blueprint/plugins/sqlmigrate"
+)
+
+var postgres_username = "postgres"
+
+// Container generates the IRNodes for a postgres server docker container that uses the postgres image
+// and the clients needed by the generated application to communicate with the server.
+//
+// initScripts are optional paths of SQL or shell scripts that are run when the database is first initialized.
+func Container(spec wiring.WiringSpec, dbName string, initScripts ...string) string {
+	// The nodes that we are defining
+	ctrName := dbName + ".ctr"
+	clientName := dbName + ".client"
+	addrName := dbName + ".addr"
+	passwordName := dbName + ".password"
+
+	// Define the default secret for the password, which is used if no other secret is attached
+	secrets.Define(spec, passwordName)
+
+	// Define the postgres container
+	spec.Define(ctrName, &PostgresContainer{}, func(ns wiring.Namespace) (ir.IRNode, error) {
+		migrations, err := sqlmigrate.Get(spec, dbName)
+		if err != nil {
+			return nil, err
+		}
+
+		password, err := secrets.Get(spec, ns, dbName, passwordName)
+		if err != nil {
+			return nil, err
+		}
+
+		ctr, err := newPostgresContainer(ctrName, dbName, password, initScripts, migrations)
+		if err != nil {
+			return nil, err
+		}
+
+		err = address.Bind[*PostgresContainer](ns, addrName, ctr, &ctr.BindAddr)
+		return ctr, err
+	})
+
+	// Create a pointer to the postgres container
+	ptr := pointer.CreatePointer[*PostgresGoClient](spec, dbName, ctrName)
+
+	// Define the address that points to the postgres container
+	address.Define[*PostgresContainer](spec, addrName, ctrName)
+
+	// Add the address to the pointer
+	ptr.AddAddrModifier(spec, addrName)
+
+	// Define the postgres client and add it to the client side of the pointer
+	clientNext := ptr.AddSrcModifier(spec, clientName)
+	spec.Define(clientName, &PostgresGoClient{}, func(ns wiring.Namespace) (ir.IRNode, error) {
+		addr, err := address.Dial[*PostgresContainer](ns, clientNext)
+		if err != nil {
+			return nil, blueprint.Errorf("%s expected %s to be an address but encountered %s", clientName, clientNext, err)
+		}
+
+		migrations, err := sqlmigrate.Get(spec, dbName)
+		if err != nil {
+			return nil, err
+		}
+
+		password, err := secrets.Get(spec, ns, dbName, passwordName)
+		if err != nil {
+			return nil, err
+		}
+
+		user_val := &ir.IRValue{Value: postgres_username}
+		db_val := &ir.IRValue{Value: dbName}
+
+		return newPostgresGoClient(clientName, addr.Dial, user_val, password, db_val, migrations)
+	})
+
+	return dbName
+}
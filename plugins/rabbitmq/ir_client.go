@@ -20,14 +20,18 @@ type RabbitmqGoClient struct {
 	InstanceName string
 	QueueName    *ir.IRValue
 	Addr         *address.DialConfig
+	Username     ir.IRNode // Empty to use the guest user
+	Password     ir.IRNode // A secret, or empty to use the guest user
 	Spec         *workflowspec.Service
 }
 
-func newRabbitmqGoClient(name string, addr *address.DialConfig, queue_name *ir.IRValue) (*RabbitmqGoClient, error) {
+func newRabbitmqGoClient(name string, addr *address.DialConfig, queue_name *ir.IRValue, username ir.IRNode, password ir.IRNode) (*RabbitmqGoClient, error) {
 	spec, err := workflowspec.GetService[rabbitmq.RabbitMQ]()
 	client := &RabbitmqGoClient{
 		InstanceName: name,
 		Addr:         addr,
+		Username:     username,
+		Password:     password,
 		QueueName:    queue_name,
 		Spec:         spec,
 	}
@@ -66,7 +70,7 @@ func (n *RabbitmqGoClient) AddInstantiation(builder golang.NamespaceBuilder) err
 	}
 	slog.Info(fmt.Sprintf("Instantiating RabbitmqClient %v in %v/%v", n.InstanceName, builder.Info().Package.PackageName, builder.Info().FileName))
 
-	return builder.DeclareConstructor(n.InstanceName, n.Spec.Constructor.AsConstructor(), []ir.IRNode{n.Addr, n.QueueName})
+	return builder.DeclareConstructor(n.InstanceName, n.Spec.Constructor.AsConstructor(), []ir.IRNode{n.Addr, n.QueueName, n.Username, n.Password})
 }
 
 func (n *RabbitmqGoClient) ImplementsGolangNode()    {}
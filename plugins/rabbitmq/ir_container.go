@@ -7,6 +7,7 @@ import (
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
 	"github.com/blueprint-uservices/blueprint/plugins/docker"
 	"github.com/blueprint-uservices/blueprint/plugins/golang/goparser"
+	"github.com/blueprint-uservices/blueprint/plugins/secrets"
 	"github.com/blueprint-uservices/blueprint/plugins/workflow/workflowspec"
 	"github.com/blueprint-uservices/blueprint/runtime/plugins/rabbitmq"
 )
@@ -20,6 +21,7 @@ type RabbitmqContainer struct {
 	InstanceName string
 	BindAddr     *address.BindConfig
 	Iface        *goparser.ParsedInterface
+	Password     *secrets.Secret // The password of the user created by the container, or nil to use the guest user
 }
 
 // RabbitMQ interface exposed by the docker container.
@@ -36,14 +38,20 @@ func (r *RabbitmqInterface) GetMethods() []service.Method {
 	return r.Wrapped.GetMethods()
 }
 
-func newRabbitmqContainer(name string) (*RabbitmqContainer, error) {
-	spec, err := workflowspec.GetService[rabbitmq.RabbitMQ]()
+func newRabbitmqContainer(name string, password *secrets.Secret) (*RabbitmqContainer, error) {
+	return newRabbitmqContainerFor[rabbitmq.RabbitMQ](name, password)
+}
+
+// Creates a rabbitmq container whose interface is that of the client implementation ClientImpl
+func newRabbitmqContainerFor[ClientImpl any](name string, password *secrets.Secret) (*RabbitmqContainer, error) {
+	spec, err := workflowspec.GetService[ClientImpl]()
 	if err != nil {
 		return nil, err
 	}
 	cntr := &RabbitmqContainer{
 		InstanceName: name,
 		Iface:        spec.Iface,
+		Password:     password,
 	}
 	return cntr, nil
 }
@@ -75,5 +83,15 @@ func (n *RabbitmqContainer) AddContainerInstance(target docker.ContainerWorkspac
 	if err != nil {
 		return err
 	}
-	return target.SetEnvironmentVariable(n.InstanceName, "RABBITMQ_ERLANG_COOKIE", n.InstanceName+"-RABBITMQ")
+	err = target.SetEnvironmentVariable(n.InstanceName, "RABBITMQ_ERLANG_COOKIE", n.InstanceName+"-RABBITMQ")
+	if err != nil || n.Password == nil {
+		return err
+	}
+
+	// The user is created when the server is first started, instead of the guest user
+	err = target.SetEnvironmentVariable(n.InstanceName, "RABBITMQ_DEFAULT_USER", rabbitmq_username)
+	if err != nil {
+		return err
+	}
+	return target.SetEnvironmentVariableFromConfig(n.InstanceName, "RABBITMQ_DEFAULT_PASS", n.Password)
 }
@@ -0,0 +1,77 @@
+package rabbitmq
+
+import (
+	"fmt"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/address"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/backend"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/service"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
+	"github.com/blueprint-uservices/blueprint/plugins/golang"
+	"github.com/blueprint-uservices/blueprint/plugins/workflow/workflowspec"
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/rabbitmq"
+	"golang.org/x/exp/slog"
+)
+
+// Blueprint IR Node that represents the generated pubsub client for the rabbitmq container
+type RabbitmqPubSubClient struct {
+	golang.Service
+	backend.PubSub
+	InstanceName string
+	Exchange     *ir.IRValue
+	Addr         *address.DialConfig
+	Username     ir.IRNode // Empty to use the guest user
+	Password     ir.IRNode // A secret, or empty to use the guest user
+	Spec         *workflowspec.Service
+}
+
+func newRabbitmqPubSubClient(name string, addr *address.DialConfig, exchange *ir.IRValue, username ir.IRNode, password ir.IRNode) (*RabbitmqPubSubClient, error) {
+	spec, err := workflowspec.GetService[rabbitmq.RabbitPubSub]()
+	client := &RabbitmqPubSubClient{
+		InstanceName: name,
+		Addr:         addr,
+		Username:     username,
+		Password:     password,
+		Exchange:     exchange,
+		Spec:         spec,
+	}
+	return client, err
+}
+
+// Implements ir.IRNode
+func (n *RabbitmqPubSubClient) Name() string {
+	return n.InstanceName
+}
+
+// Implements ir.IRNode
+func (n *RabbitmqPubSubClient) String() string {
+	return n.InstanceName + " = RabbitmqPubSubClient(" + n.Addr.Name() + ")"
+}
+
+// Implements service.ServiceNode
+func (n *RabbitmqPubSubClient) GetInterface(ctx ir.BuildContext) (service.ServiceInterface, error) {
+	return n.Spec.Iface.ServiceInterface(ctx), nil
+}
+
+// Implements golang.ProvidesModule
+func (n *RabbitmqPubSubClient) AddToWorkspace(builder golang.WorkspaceBuilder) error {
+	return n.Spec.AddToWorkspace(builder)
+}
+
+// Implements golang.ProvidesInterface
+func (n *RabbitmqPubSubClient) AddInterfaces(builder golang.ModuleBuilder) error {
+	return n.Spec.AddToModule(builder)
+}
+
+// Implements golang.Instantiable
+func (n *RabbitmqPubSubClient) AddInstantiation(builder golang.NamespaceBuilder) error {
+	if builder.Visited(n.InstanceName) {
+		return nil
+	}
+	slog.Info(fmt.Sprintf("Instantiating RabbitmqPubSubClient %v in %v/%v", n.InstanceName, builder.Info().Package.PackageName, builder.Info().FileName))
+
+	return builder.DeclareConstructor(n.InstanceName, n.Spec.Constructor.AsConstructor(), []ir.IRNode{n.Addr, n.Exchange, n.Username, n.Password})
+}
+
+func (n *RabbitmqPubSubClient) ImplementsGolangNode()    {}
+func (n *RabbitmqPubSubClient) ImplementsGolangService() {}
@@ -3,7 +3,16 @@
 // The package provides a built-in rabbitmq container that provides the server-side implementation
 // and a go-client for connecting to the client.
 //
-// The applications must use a backend.Queue (runtime/core/backend) as the interface in the workflow.
+// The applications must use a backend.Queue (runtime/core/backend) as the interface in the workflow,
+// or a backend.PubSub for containers created with [PubSubContainer].
+//
+// By default clients connect as rabbitmq's guest user.  To instead create a user whose password is a secret,
+// attach the secret with the [secrets] plugin:
+//
+//	orders := rabbitmq.Container(spec, "orders", "orders")
+//	secrets.Use(spec, orders, secrets.Define(spec, "orders_password"))
+//
+// [secrets]: https://github.com/Blueprint-uServices/blueprint/tree/main/plugins/secrets
 package rabbitmq
 
 import (
@@ -12,8 +21,13 @@ import (
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/pointer"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/wiring"
+	"github.com/blueprint-uservices/blueprint/plugins/secrets"
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/rabbitmq"
 )
 
+// The user created by containers that have a secret
+var rabbitmq_username = "blueprint"
+
 // Container generate the IRNodes for a mysql server docker container that uses the latest mysql/mysql image
 // and the clients needed by the generated application to communicate with the server.
 func Container(spec wiring.WiringSpec, name string, queue_name string) string {
@@ -24,7 +38,12 @@ func Container(spec wiring.WiringSpec, name string, queue_name string) string {
 
 	// Define the rabbitmq container
 	spec.Define(ctrName, &RabbitmqContainer{}, func(ns wiring.Namespace) (ir.IRNode, error) {
-		ctr, err := newRabbitmqContainer(ctrName)
+		password, err := secrets.Get(spec, ns, name, "")
+		if err != nil {
+			return nil, err
+		}
+
+		ctr, err := newRabbitmqContainer(ctrName, password)
 		if err != nil {
 			return nil, err
 		}
@@ -48,10 +67,78 @@ func Container(spec wiring.WiringSpec, name string, queue_name string) string {
 			return nil, blueprint.Errorf("%s expected %s to be an address but encountered %s", clientName, clientNext, err)
 		}
 
+		user_val, pwd_val, err := getCredentials(spec, ns, name)
+		if err != nil {
+			return nil, err
+		}
+
 		queue_val := &ir.IRValue{Value: queue_name}
 
-		return newRabbitmqGoClient(clientName, addr.Dial, queue_val)
+		return newRabbitmqGoClient(clientName, addr.Dial, queue_val, user_val, pwd_val)
+	})
+
+	return name
+}
+
+// PubSubContainer generates the IRNodes for a rabbitmq server docker container, and the pubsub clients needed
+// by the generated application to publish to and subscribe from the topic exchange named exchange.
+//
+// Workflow services receive the instance as a backend.PubSub.
+func PubSubContainer(spec wiring.WiringSpec, name string, exchange string) string {
+	// The nodes that we are defining
+	ctrName := name + ".ctr"
+	clientName := name + ".client"
+	addrName := name + ".addr"
+
+	// Define the rabbitmq container
+	spec.Define(ctrName, &RabbitmqContainer{}, func(ns wiring.Namespace) (ir.IRNode, error) {
+		password, err := secrets.Get(spec, ns, name, "")
+		if err != nil {
+			return nil, err
+		}
+
+		ctr, err := newRabbitmqContainerFor[rabbitmq.RabbitPubSub](ctrName, password)
+		if err != nil {
+			return nil, err
+		}
+
+		err = address.Bind[*RabbitmqContainer](ns, addrName, ctr, &ctr.BindAddr)
+		return ctr, err
+	})
+
+	// Create a pointer to the rabbitmq container
+	ptr := pointer.CreatePointer[*RabbitmqPubSubClient](spec, name, ctrName)
+
+	// Define the address that points to the Rabbitmq container
+	address.Define[*RabbitmqContainer](spec, addrName, ctrName)
+	ptr.AddAddrModifier(spec, addrName)
+
+	// Define the pubsub client and add it to the client side of the pointer
+	clientNext := ptr.AddSrcModifier(spec, clientName)
+	spec.Define(clientName, &RabbitmqPubSubClient{}, func(ns wiring.Namespace) (ir.IRNode, error) {
+		addr, err := address.Dial[*RabbitmqContainer](ns, clientNext)
+		if err != nil {
+			return nil, blueprint.Errorf("%s expected %s to be an address but encountered %s", clientName, clientNext, err)
+		}
+
+		user_val, pwd_val, err := getCredentials(spec, ns, name)
+		if err != nil {
+			return nil, err
+		}
+
+		exchange_val := &ir.IRValue{Value: exchange}
+
+		return newRabbitmqPubSubClient(clientName, addr.Dial, exchange_val, user_val, pwd_val)
 	})
 
 	return name
 }
+
+// Returns the username and password arguments of the clients of name, which are empty if name has no secret
+func getCredentials(spec wiring.WiringSpec, ns wiring.Namespace, name string) (ir.IRNode, ir.IRNode, error) {
+	password, err := secrets.Get(spec, ns, name, "")
+	if err != nil || password == nil {
+		return &ir.IRValue{}, &ir.IRValue{}, err
+	}
+	return &ir.IRValue{Value: rabbitmq_username}, password, nil
+}
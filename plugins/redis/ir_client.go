@@ -20,14 +20,16 @@ type RedisGoClient struct {
 
 	InstanceName string
 	Addr         *address.DialConfig
+	Password     ir.IRNode // A secret, or empty if the server does not require authentication
 	Spec         *workflowspec.Service
 }
 
-func newRedisGoClient(name string, addr *address.DialConfig) (*RedisGoClient, error) {
+func newRedisGoClient(name string, addr *address.DialConfig, password ir.IRNode) (*RedisGoClient, error) {
 	spec, err := workflowspec.GetService[redis.RedisCache]()
 	client := &RedisGoClient{
 		InstanceName: name,
 		Addr:         addr,
+		Password:     password,
 		Spec:         spec,
 	}
 	return client, err
@@ -66,7 +68,7 @@ func (n *RedisGoClient) AddInstantiation(builder golang.NamespaceBuilder) error
 
 	slog.Info(fmt.Sprintf("Instantiating RedisClient %v in %v/%v", n.InstanceName, builder.Info().Package.PackageName, builder.Info().FileName))
 
-	return builder.DeclareConstructor(n.InstanceName, n.Spec.Constructor.AsConstructor(), []ir.IRNode{n.Addr})
+	return builder.DeclareConstructor(n.InstanceName, n.Spec.Constructor.AsConstructor(), []ir.IRNode{n.Addr, n.Password})
 }
 
 func (node *RedisGoClient) ImplementsGolangNode()    {}
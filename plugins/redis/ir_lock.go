@@ -0,0 +1,75 @@
+package redis
+
+import (
+	"fmt"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/address"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/backend"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/service"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
+	"github.com/blueprint-uservices/blueprint/plugins/golang"
+	"github.com/blueprint-uservices/blueprint/plugins/workflow/workflowspec"
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/redis"
+	"golang.org/x/exp/slog"
+)
+
+// Blueprint IR Node that represents a lock service client to a redis container
+type RedisLockClient struct {
+	golang.Service
+	backend.LockService
+
+	InstanceName string
+	Addr         *address.DialConfig
+	Password     ir.IRNode // A secret, or empty if the server does not require authentication
+	Spec         *workflowspec.Service
+}
+
+func newRedisLockClient(name string, addr *address.DialConfig, password ir.IRNode) (*RedisLockClient, error) {
+	spec, err := workflowspec.GetService[redis.RedisLockService]()
+	client := &RedisLockClient{
+		InstanceName: name,
+		Addr:         addr,
+		Password:     password,
+		Spec:         spec,
+	}
+	return client, err
+}
+
+// Implements ir.IRNode
+func (n *RedisLockClient) String() string {
+	return n.InstanceName + " = RedisLockClient(" + n.Addr.Name() + ")"
+}
+
+// Implements ir.IRNode
+func (n *RedisLockClient) Name() string {
+	return n.InstanceName
+}
+
+// Implements service.ServiceNode
+func (n *RedisLockClient) GetInterface(ctx ir.BuildContext) (service.ServiceInterface, error) {
+	return n.Spec.Iface.ServiceInterface(ctx), nil
+}
+
+// Implements golang.ProvidesModule
+func (n *RedisLockClient) AddToWorkspace(builder golang.WorkspaceBuilder) error {
+	return n.Spec.AddToWorkspace(builder)
+}
+
+// Implements golang.ProvidesInterface
+func (n *RedisLockClient) AddInterfaces(builder golang.ModuleBuilder) error {
+	return n.Spec.AddToModule(builder)
+}
+
+// Implements golang.Instantiable
+func (n *RedisLockClient) AddInstantiation(builder golang.NamespaceBuilder) error {
+	if builder.Visited(n.InstanceName) {
+		return nil
+	}
+
+	slog.Info(fmt.Sprintf("Instantiating RedisLockClient %v in %v/%v", n.InstanceName, builder.Info().Package.PackageName, builder.Info().FileName))
+
+	return builder.DeclareConstructor(n.InstanceName, n.Spec.Constructor.AsConstructor(), []ir.IRNode{n.Addr, n.Password})
+}
+
+func (node *RedisLockClient) ImplementsGolangNode()    {}
+func (node *RedisLockClient) ImplementsGolangService() {}
@@ -1,25 +1,35 @@
 package redis
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/address"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/backend"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/service"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
 	"github.com/blueprint-uservices/blueprint/plugins/docker"
 	"github.com/blueprint-uservices/blueprint/plugins/golang/goparser"
+	"github.com/blueprint-uservices/blueprint/plugins/secrets"
 	"github.com/blueprint-uservices/blueprint/plugins/workflow/workflowspec"
-	"github.com/blueprint-uservices/blueprint/runtime/plugins/redis"
+	"golang.org/x/exp/slog"
 )
 
+// The redis image used by the container, and extended by the image built for containers that require a password
+const redisImage = "redis"
+
 // Blueprint IR Node that represents a redis container
 type RedisContainer struct {
 	backend.Cache
 	docker.Container
+	docker.ProvidesContainerImage
 	docker.ProvidesContainerInstance
 
 	InstanceName string
 	BindAddr     *address.BindConfig
 	Iface        *goparser.ParsedInterface
+	Password     *secrets.Secret // Required by the server, if not nil
 }
 
 // Redis interface exposed to other services.
@@ -37,8 +47,9 @@ func (r *RedisInterface) GetMethods() []service.Method {
 	return r.Wrapped.GetMethods()
 }
 
-func newRedisContainer(name string) (*RedisContainer, error) {
-	spec, err := workflowspec.GetService[redis.RedisCache]()
+// Creates a redis container whose interface is that of the client implementation ClientImpl
+func newRedisContainer[ClientImpl any](name string, password *secrets.Secret) (*RedisContainer, error) {
+	spec, err := workflowspec.GetService[ClientImpl]()
 	if err != nil {
 		return nil, err
 	}
@@ -46,6 +57,7 @@ func newRedisContainer(name string) (*RedisContainer, error) {
 	proc := &RedisContainer{
 		InstanceName: name,
 		Iface:        spec.Iface,
+		Password:     password,
 	}
 	return proc, nil
 }
@@ -66,8 +78,39 @@ func (node *RedisContainer) GetInterface(ctx ir.BuildContext) (service.ServiceIn
 	return &RedisInterface{Wrapped: iface}, nil
 }
 
+// Implements docker.ProvidesContainerImage
+//
+// If the server requires a password, generates an image that extends the redis image to run the server with
+// the password in the REDIS_PASSWORD environment variable.
+func (node *RedisContainer) AddContainerArtifacts(target docker.ContainerWorkspace) error {
+	if node.Password == nil || target.Visited(node.InstanceName+".artifacts") {
+		return nil
+	}
+
+	slog.Info(fmt.Sprintf("Creating container image %v", node.InstanceName))
+	dir, err := target.CreateImageDir(node.imageName())
+	if err != nil {
+		return err
+	}
+	dockerfile := fmt.Sprintf(`FROM %v
+CMD ["sh", "-c", "exec redis-server --requirepass \"$REDIS_PASSWORD\""]
+`, redisImage)
+	return os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte(dockerfile), 0644)
+}
+
+func (node *RedisContainer) imageName() string {
+	return ir.CleanName(node.InstanceName) + "_image"
+}
+
 // Implements docker.ProvidesContainerInstance
 func (node *RedisContainer) AddContainerInstance(target docker.ContainerWorkspace) error {
 	node.BindAddr.Port = 6379 // Just use default redis port
-	return target.DeclarePrebuiltInstance(node.InstanceName, "redis", node.BindAddr)
+	if node.Password == nil {
+		return target.DeclarePrebuiltInstance(node.InstanceName, redisImage, node.BindAddr)
+	}
+	err := target.DeclareLocalImage(node.InstanceName, node.imageName(), node.BindAddr)
+	if err != nil {
+		return err
+	}
+	return target.SetEnvironmentVariableFromConfig(node.InstanceName, "REDIS_PASSWORD", node.Password)
 }
@@ -1,9 +1,21 @@
 // Package redis provides the Blueprint wiring and IR implementations of a redis plugin that
-// provides a Cache interface implementation via a pre-built redis container image.
+// provides Cache and LockService interface implementations via a pre-built redis container image.
 //
 // Usage: To add a redis container named `fooCache`
 //
-//	PrebuiltContainer(spec, "fooCache")
+//	Container(spec, "fooCache")
+//
+// To add a redis container named `fooLocks` that is used as a LockService, e.g. to elect a single
+// replica of a service to run a background task with backend.RunAsLeader
+//
+//	LockService(spec, "fooLocks")
+//
+// By default the server does not require authentication.  To require a password, attach a secret with
+// the [secrets] plugin:
+//
+//	secrets.Use(spec, "fooCache", secrets.Define(spec, "foo_cache_password"))
+//
+// [secrets]: https://github.com/Blueprint-uServices/blueprint/tree/main/plugins/secrets
 package redis
 
 import (
@@ -12,19 +24,40 @@ import (
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/pointer"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/wiring"
+	"github.com/blueprint-uservices/blueprint/plugins/secrets"
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/redis"
 )
 
 // Adds a redis container to the application that defines a cache called `cacheName` which uses
 // the pre-built redis process container
 func Container(spec wiring.WiringSpec, cacheName string) string {
+	return define[*RedisGoClient, redis.RedisCache](spec, cacheName, func(clientName string, addr *address.DialConfig, password ir.IRNode) (ir.IRNode, error) {
+		return newRedisGoClient(clientName, addr, password)
+	})
+}
+
+// Adds a redis container to the application that defines a lock service called `name` which uses
+// the pre-built redis process container
+func LockService(spec wiring.WiringSpec, name string) string {
+	return define[*RedisLockClient, redis.RedisLockService](spec, name, func(clientName string, addr *address.DialConfig, password ir.IRNode) (ir.IRNode, error) {
+		return newRedisLockClient(clientName, addr, password)
+	})
+}
+
+func define[ClientNode ir.IRNode, ClientImpl any](spec wiring.WiringSpec, name string, newClient func(string, *address.DialConfig, ir.IRNode) (ir.IRNode, error)) string {
 	// The nodes that we are defining
-	ctrName := cacheName + ".ctr"
-	clientName := cacheName + ".client"
-	addrName := cacheName + ".addr"
+	ctrName := name + ".ctr"
+	clientName := name + ".client"
+	addrName := name + ".addr"
 
 	// Define the Redis container
 	spec.Define(ctrName, &RedisContainer{}, func(ns wiring.Namespace) (ir.IRNode, error) {
-		redis, err := newRedisContainer(ctrName)
+		password, err := secrets.Get(spec, ns, name, "")
+		if err != nil {
+			return nil, err
+		}
+
+		redis, err := newRedisContainer[ClientImpl](ctrName, password)
 		if err != nil {
 			return nil, err
 		}
@@ -34,7 +67,7 @@ func Container(spec wiring.WiringSpec, cacheName string) string {
 	})
 
 	// Create a pointer to the Redis container
-	ptr := pointer.CreatePointer[*RedisGoClient](spec, cacheName, ctrName)
+	ptr := pointer.CreatePointer[ClientNode](spec, name, ctrName)
 
 	// Define the address that points to the Redis container
 	address.Define[*RedisContainer](spec, addrName, ctrName)
@@ -44,14 +77,23 @@ func Container(spec wiring.WiringSpec, cacheName string) string {
 
 	// Define the Redis client and add it to the client side of the pointer
 	clientNext := ptr.AddSrcModifier(spec, clientName)
-	spec.Define(clientName, &RedisGoClient{}, func(ns wiring.Namespace) (ir.IRNode, error) {
+	var client ClientNode
+	spec.Define(clientName, client, func(ns wiring.Namespace) (ir.IRNode, error) {
 		addr, err := address.Dial[*RedisContainer](ns, clientNext)
 		if err != nil {
 			return nil, blueprint.Errorf("%s expected %s to be an address but encountered %s", clientName, clientNext, err)
 		}
-		return newRedisGoClient(clientName, addr.Dial)
+		// The client only authenticates if the server requires it
+		var pwd_val ir.IRNode = &ir.IRValue{}
+		if password, err := secrets.Get(spec, ns, name, ""); err != nil {
+			return nil, err
+		} else if password != nil {
+			pwd_val = password
+		}
+
+		return newClient(clientName, addr.Dial, pwd_val)
 	})
 
 	// Return the pointer; anybody who wants to access the Redis instance should do so through the pointer
-	return cacheName
+	return name
 }
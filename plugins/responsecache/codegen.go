@@ -0,0 +1,109 @@
+package responsecache
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/blueprint-uservices/blueprint/plugins/golang"
+	"github.com/blueprint-uservices/blueprint/plugins/golang/gocode"
+	"github.com/blueprint-uservices/blueprint/plugins/golang/gogen"
+	"golang.org/x/exp/slog"
+)
+
+// code generation function called from the ir.go file
+func generateClient(builder golang.ModuleBuilder, wrapped *gocode.ServiceInterface, outputPackage string, config Config) error {
+	pkg, err := builder.CreatePackage(outputPackage)
+	if err != nil {
+		return err
+	}
+
+	client := clientArgs{
+		Package:       pkg,
+		Service:       wrapped,
+		Name:          wrapped.BaseName + "_ResponseCacheClient",
+		TTL:           config.TTL,
+		Cacheable:     make(map[string]bool),
+		Invalidations: config.Invalidations,
+		Imports:       gogen.NewImports(pkg.Name),
+	}
+	for _, method := range config.Methods {
+		client.Cacheable[method] = true
+	}
+
+	client.Imports.AddPackages(
+		"context",
+		"github.com/blueprint-uservices/blueprint/runtime/core/backend",
+		"github.com/blueprint-uservices/blueprint/runtime/plugins/responsecache",
+	)
+	if config.TTL != "" {
+		client.Imports.AddPackages("time")
+	}
+
+	slog.Info(fmt.Sprintf("Generating %v/%v", client.Package.PackageName, client.Name))
+	outputFile := filepath.Join(client.Package.Path, client.Name+".go")
+	return gogen.ExecuteTemplateToFile("ResponseCache", clientTemplate, client, outputFile)
+}
+
+type clientArgs struct {
+	Package       golang.PackageInfo
+	Service       *gocode.ServiceInterface
+	Name          string
+	TTL           string
+	Cacheable     map[string]bool
+	Invalidations map[string][]string
+	Imports       *gogen.Imports
+}
+
+var clientTemplate = `// Blueprint: Auto-generated by ResponseCache Plugin
+package {{.Package.ShortName}}
+
+{{.Imports}}
+
+type {{.Name}} struct {
+	Client {{.Imports.NameOf .Service.UserType}}
+	Cache *responsecache.ResponseCache
+}
+
+func New_{{.Name}} (ctx context.Context, client {{.Imports.NameOf .Service.UserType}}, cache backend.Cache) (*{{.Name}}, error) {
+	handler := &{{.Name}}{}
+	handler.Client = client
+	{{- if .TTL}}
+
+	ttl, err := time.ParseDuration("{{.TTL}}")
+	if err != nil {
+		return nil, err
+	}
+	handler.Cache = responsecache.NewResponseCache("{{.Service.Name}}", cache, ttl)
+	{{- else}}
+	handler.Cache = responsecache.NewResponseCache("{{.Service.Name}}", cache, 0)
+	{{- end}}
+	return handler, nil
+}
+
+{{$service := .Service.Name -}}
+{{$receiver := .Name -}}
+{{ range $_, $f := .Service.Methods }}
+func (client *{{$receiver}}) {{$f.Name -}} ({{ArgVarsAndTypes $f "ctx context.Context"}}) ({{RetVarsAndTypes $f "err error"}}) {
+	{{- if index $.Cacheable $f.Name}}
+	type response struct {
+		{{- range $i, $ret := $f.Returns}}
+		Ret{{$i}} {{NameOf $ret.Type}}
+		{{- end}}
+	}
+	res, err := responsecache.Do(ctx, client.Cache, "{{$f.Name}}", []any{ {{- ArgVars $f -}} }, func(ctx context.Context) (res response, err error) {
+		{{range $i, $ret := $f.Returns}}res.Ret{{$i}}, {{end}}err = client.Client.{{$f.Name}}({{ArgVars $f "ctx"}})
+		return
+	})
+	return {{range $i, $ret := $f.Returns}}res.Ret{{$i}}, {{end}}err
+	{{- else if index $.Invalidations $f.Name}}
+	{{RetVars $f "err"}} = client.Client.{{$f.Name}}({{ArgVars $f "ctx"}})
+	if err == nil {
+		client.Cache.Invalidate(ctx{{range $_, $m := index $.Invalidations $f.Name}}, "{{$m}}"{{end}})
+	}
+	return
+	{{- else}}
+	return client.Client.{{$f.Name}}({{ArgVars $f "ctx"}})
+	{{- end}}
+}
+{{end}}
+`
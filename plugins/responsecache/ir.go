@@ -0,0 +1,129 @@
+package responsecache
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/blueprint"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/service"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
+	"github.com/blueprint-uservices/blueprint/plugins/golang"
+	"github.com/blueprint-uservices/blueprint/plugins/golang/gocode"
+)
+
+// Blueprint IR node representing a client-side response cache
+type ResponseCacheClient struct {
+	golang.Service
+	golang.GeneratesFuncs
+	golang.Instantiable
+
+	InstanceName string
+	Wrapped      golang.Service
+	Cache        ir.IRNode
+
+	outputPackage string
+	Config        Config
+}
+
+// Implements ir.IRNode
+func (node *ResponseCacheClient) ImplementsGolangNode() {}
+
+// Implements ir.IRNode
+func (node *ResponseCacheClient) Name() string {
+	return node.InstanceName
+}
+
+// Implements ir.IRNode
+func (node *ResponseCacheClient) String() string {
+	return node.Name() + " = ResponseCache(" + node.Wrapped.Name() + ", " + node.Cache.Name() + ", " + strings.Join(node.Config.Methods, ", ") + ")"
+}
+
+func newResponseCacheClient(name string, wrapped golang.Service, cache ir.IRNode, config Config) (*ResponseCacheClient, error) {
+	cacheable := make(map[string]bool)
+	for _, method := range config.Methods {
+		cacheable[method] = true
+	}
+	for method, invalidated := range config.Invalidations {
+		if cacheable[method] {
+			return nil, blueprint.Errorf("response cache %s cannot both cache and invalidate with method %s", name, method)
+		}
+		for _, target := range invalidated {
+			if !cacheable[target] {
+				return nil, blueprint.Errorf("response cache %s cannot invalidate %s on calls to %s as %s is not cached", name, target, method, target)
+			}
+		}
+	}
+
+	node := &ResponseCacheClient{}
+	node.InstanceName = name
+	node.Wrapped = wrapped
+	node.Cache = cache
+	node.outputPackage = "responsecache"
+	node.Config = config
+	return node, nil
+}
+
+// Implements golang.Service
+func (node *ResponseCacheClient) AddInterfaces(builder golang.ModuleBuilder) error {
+	return node.Wrapped.AddInterfaces(builder)
+}
+
+// Implements golang.Service
+func (node *ResponseCacheClient) GetInterface(ctx ir.BuildContext) (service.ServiceInterface, error) {
+	return node.Wrapped.GetInterface(ctx)
+}
+
+// Implements golang.GeneratesFuncs
+func (node *ResponseCacheClient) GenerateFuncs(builder golang.ModuleBuilder) error {
+	if builder.Visited(node.InstanceName + ".generateFuncs") {
+		return nil
+	}
+
+	iface, err := golang.GetGoInterface(builder, node)
+	if err != nil {
+		return err
+	}
+
+	for _, method := range node.Config.Methods {
+		f, exists := iface.Methods[method]
+		if !exists {
+			return blueprint.Errorf("response cache %s cannot cache responses of %s as %s has no such method", node.InstanceName, method, iface.Name)
+		}
+		if len(f.Returns) == 0 {
+			return blueprint.Errorf("response cache %s cannot cache responses of %s as it has no return values", node.InstanceName, method)
+		}
+	}
+	for method := range node.Config.Invalidations {
+		if _, exists := iface.Methods[method]; !exists {
+			return blueprint.Errorf("response cache %s cannot invalidate on calls to %s as %s has no such method", node.InstanceName, method, iface.Name)
+		}
+	}
+
+	return generateClient(builder, iface, node.outputPackage, node.Config)
+}
+
+// Implements golang.Instantiable
+func (node *ResponseCacheClient) AddInstantiation(builder golang.NamespaceBuilder) error {
+	if builder.Visited(node.InstanceName) {
+		return nil
+	}
+
+	iface, err := golang.GetGoInterface(builder, node.Wrapped)
+	if err != nil {
+		return err
+	}
+
+	constructor := &gocode.Constructor{
+		Package: builder.Module().Info().Name + "/" + node.outputPackage,
+		Func: gocode.Func{
+			Name: fmt.Sprintf("New_%v_ResponseCacheClient", iface.BaseName),
+			Arguments: []gocode.Variable{
+				{Name: "ctx", Type: &gocode.UserType{Package: "context", Name: "Context"}},
+				{Name: "client", Type: iface},
+				{Name: "cache", Type: &gocode.UserType{Package: "github.com/blueprint-uservices/blueprint/runtime/core/backend", Name: "Cache"}},
+			},
+		},
+	}
+
+	return builder.DeclareConstructor(node.InstanceName, constructor, []ir.IRNode{node.Wrapped, node.Cache})
+}
@@ -0,0 +1,102 @@
+// Package responsecache provides a Blueprint modifier for the client side of service calls.
+//
+// The plugin wraps clients with a cache-aside layer that caches the responses of designated
+// methods in a [backend.Cache] such as a [simple] cache, a [redis] container, or a [memcached] container.
+// Responses are keyed on the method name and the serialized arguments of the call.  Cached responses
+// expire after a configurable TTL, and can be explicitly invalidated by calls to other methods; for
+// example, a successful call to UpdateProfile can invalidate all cached responses of GetProfile.
+//
+// Only successful responses are cached.  Calls to methods that are neither cacheable nor invalidating
+// are passed through to the wrapped client unmodified.
+//
+// Usage:
+//
+//	import "github.com/blueprint-uservices/blueprint/plugins/responsecache"
+//	profile_cache := simple.Cache(spec, "profile_cache")
+//	responsecache.Add(spec, "profile_service", profile_cache, responsecache.Config{
+//		TTL:           "30s",
+//		Methods:       []string{"GetProfiles"},
+//		Invalidations: map[string][]string{"UpdateProfile": {"GetProfiles"}},
+//	})
+//
+// # Artifacts Generated
+//
+// During compilation, the responsecache plugin will generate a client-side wrapper class.  The plugin
+// also utilizes some code in the [runtime/plugins/responsecache] package.
+//
+// [runtime/plugins/responsecache]: https://github.com/Blueprint-uServices/blueprint/tree/main/runtime/plugins/responsecache
+// [backend.Cache]: https://github.com/Blueprint-uServices/blueprint/tree/main/runtime/core/backend
+// [simple]: https://github.com/Blueprint-uServices/blueprint/tree/main/plugins/simple
+// [redis]: https://github.com/Blueprint-uServices/blueprint/tree/main/plugins/redis
+// [memcached]: https://github.com/Blueprint-uServices/blueprint/tree/main/plugins/memcached
+package responsecache
+
+import (
+	"time"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/blueprint"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/pointer"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/wiring"
+	"github.com/blueprint-uservices/blueprint/plugins/golang"
+	"golang.org/x/exp/slog"
+)
+
+// Configuration of the response cache added by [Add]
+type Config struct {
+	// Duration after which cached responses expire, e.g. "30s".  Empty means cached responses
+	// only become stale when they are invalidated.
+	TTL string
+
+	// Names of the methods whose responses are cached
+	Methods []string
+
+	// Maps the name of a method to the cacheable methods whose cached responses are invalidated
+	// when a call to the method succeeds
+	Invalidations map[string][]string
+}
+
+// Adds response caching to all clients of the specified service.
+// Uses a [blueprint.WiringSpec].
+// Modifies the given service such that the responses of calls to the cacheable methods in config are
+// stored in cacheName, which must be a [backend.Cache] node already declared in the wiring spec.
+//
+// The config.TTL string, if set, must be a sequence of decimal numbers, each with optional fraction and a unit suffix, such as "300ms", "1.5h" or "2h45m". Valid time units are "ns", "us" (or "µs"), "ms", "s", "m", "h".
+//
+// Usage:
+//
+//	Add(spec, "my_service", "my_cache", responsecache.Config{TTL: "30s", Methods: []string{"GetProfiles"}})
+//
+// [backend.Cache]: https://github.com/Blueprint-uServices/blueprint/tree/main/runtime/core/backend
+func Add(spec wiring.WiringSpec, serviceName string, cacheName string, config Config) {
+	if config.TTL != "" {
+		if _, err := time.ParseDuration(config.TTL); err != nil {
+			slog.Error("Unable to add response caching to " + serviceName + " due to invalid TTL " + config.TTL + ": " + err.Error())
+			return
+		}
+	}
+
+	clientWrapper := serviceName + ".client.responsecache"
+
+	ptr := pointer.GetPointer(spec, serviceName)
+	if ptr == nil {
+		slog.Error("Unable to add response caching to " + serviceName + " as it is not a pointer")
+		return
+	}
+
+	clientNext := ptr.AddSrcModifier(spec, clientWrapper)
+
+	spec.Define(clientWrapper, &ResponseCacheClient{}, func(ns wiring.Namespace) (ir.IRNode, error) {
+		var wrapped golang.Service
+		if err := ns.Get(clientNext, &wrapped); err != nil {
+			return nil, blueprint.Errorf("ResponseCache %s expected %s to be a golang.Service, but encountered %s", clientWrapper, clientNext, err)
+		}
+
+		var cache ir.IRNode
+		if err := ns.Get(cacheName, &cache); err != nil {
+			return nil, blueprint.Errorf("ResponseCache %s unable to get cache %s: %s", clientWrapper, cacheName, err)
+		}
+
+		return newResponseCacheClient(clientWrapper, wrapped, cache, config)
+	})
+}
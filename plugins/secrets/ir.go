@@ -0,0 +1,35 @@
+package secrets
+
+// The Secret IR node is a configuration variable for a credential, such as the password of a backend.
+// A secret never has a value during compilation; it is provided to the compiled application by the
+// calling environment when the application is run.
+type Secret struct {
+	Key string
+}
+
+// Implements ir.IRNode
+func (s *Secret) Name() string {
+	return s.Key
+}
+
+// Implements ir.IRNode
+func (s *Secret) String() string {
+	return s.Key + " = Secret()"
+}
+
+// Implements ir.IRConfig
+func (s *Secret) Optional() bool {
+	return false
+}
+
+// Implements ir.IRConfig
+func (s *Secret) HasValue() bool {
+	return false
+}
+
+// Implements ir.IRConfig
+func (s *Secret) Value() string {
+	return ""
+}
+
+func (s *Secret) ImplementsIRConfig() {}
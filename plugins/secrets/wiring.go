@@ -0,0 +1,93 @@
+// Package secrets provides a Blueprint plugin for declaring secrets, such as the passwords of backends,
+// that are provided to the compiled application when it is run instead of being hard-coded into it.
+//
+// # Wiring Spec Usage
+//
+// To declare a secret and use it as the credential of a backend, call [Define] and [Use]:
+//
+//	post_db := mongodb.Container(spec, "post_db")
+//	post_db_password := secrets.Define(spec, "post_db_password")
+//	secrets.Use(spec, post_db, post_db_password)
+//
+// Secrets are supported by the [mysql], [postgres], [minio], [mongodb], [redis], and [rabbitmq] plugins.
+// The mysql, postgres, and minio plugins always require a password; if a secret is not attached with
+// [Use], the password is a secret named after the backend, e.g. post_db.password.  The mongodb, redis,
+// and rabbitmq plugins only enable authentication if a secret is attached.
+//
+// # Running Artifacts
+//
+// A secret is a configuration variable of the compiled application, like an address.  Its value is passed
+// through from the calling environment to both the backend's container and the processes that use the
+// backend, e.g. for a docker-compose deployment:
+//
+//	POST_DB_PASSWORD=... docker compose up
+//
+// The .env files generated by the [environment] plugin contain commented-out placeholders for secrets,
+// rather than values.
+//
+// # Plugin developers
+//
+// Backend plugins support secrets by calling [Get] from their wiring spec.  Container nodes pass the
+// secret to the container using docker.ContainerWorkspace.SetEnvironmentVariableFromConfig, and client
+// nodes pass the secret to their constructor like any other argument.
+//
+// [mysql]: https://github.com/Blueprint-uServices/blueprint/tree/main/plugins/mysql
+// [postgres]: https://github.com/Blueprint-uServices/blueprint/tree/main/plugins/postgres
+// [minio]: https://github.com/Blueprint-uServices/blueprint/tree/main/plugins/minio
+// [mongodb]: https://github.com/Blueprint-uServices/blueprint/tree/main/plugins/mongodb
+// [redis]: https://github.com/Blueprint-uServices/blueprint/tree/main/plugins/redis
+// [rabbitmq]: https://github.com/Blueprint-uServices/blueprint/tree/main/plugins/rabbitmq
+// [environment]: https://github.com/Blueprint-uServices/blueprint/tree/main/plugins/environment
+package secrets
+
+import (
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/blueprint"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/wiring"
+)
+
+// The property of a backend's wiring definition that holds the name of its secret
+const prop_SECRET = "secret"
+
+// Define declares a secret called name, and returns its name.
+//
+// In the compiled application, the secret is a configuration variable named name, e.g. the
+// environment variable POST_DB_PASSWORD for a secret named post_db_password.
+func Define(spec wiring.WiringSpec, name string) string {
+	spec.Define(name, &Secret{}, func(namespace wiring.Namespace) (ir.IRNode, error) {
+		return &Secret{Key: name}, nil
+	})
+	return name
+}
+
+// Use attaches the secret secretName, declared with [Define], as the credential of the backend backendName.
+//
+// backendName must be defined by a plugin that supports secrets, such as [mongodb.Container].  Calling Use
+// again for the same backend replaces its secret.
+//
+// [mongodb.Container]: https://github.com/Blueprint-uServices/blueprint/tree/main/plugins/mongodb
+func Use(spec wiring.WiringSpec, backendName string, secretName string) {
+	spec.SetProperty(backendName, prop_SECRET, secretName)
+}
+
+// Get is used by backend plugins to get the secret attached to backendName by [Use].
+//
+// If backendName has no secret, returns the secret defaultName if it is not empty, or nil otherwise.
+func Get(spec wiring.WiringSpec, namespace wiring.Namespace, backendName string, defaultName string) (*Secret, error) {
+	var secretName string
+	if err := spec.GetProperty(backendName, prop_SECRET, &secretName); err != nil {
+		return nil, err
+	}
+	if secretName == "" {
+		secretName = defaultName
+	}
+	if secretName == "" {
+		return nil, nil
+	}
+
+	var secret *Secret
+	if err := namespace.Get(secretName, &secret); err != nil {
+		return nil, blueprint.Errorf("%v expected %v to be a secret, but encountered %v", backendName, secretName, err)
+	}
+	return secret, nil
+}
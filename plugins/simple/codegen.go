@@ -0,0 +1,142 @@
+package simple
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
+	"github.com/blueprint-uservices/blueprint/plugins/golang"
+	"github.com/blueprint-uservices/blueprint/plugins/golang/gocode"
+	"github.com/blueprint-uservices/blueprint/plugins/golang/gogen"
+	"golang.org/x/exp/slog"
+)
+
+// Generates a package for the backend instance name, that embeds the fixture file and wraps constructor.
+// The generated constructor calls constructor, then restores the backend from the fixture and snapshot.
+// If the backend is persisted, the generated constructor has an additional argument for the path of the
+// snapshot file, and returns the backend embedded in a struct that also embeds its [snapshot.Persister],
+// so that the namespace runs the persister and awaits its final snapshot on shutdown.
+//
+// [snapshot.Persister]: https://github.com/Blueprint-uServices/blueprint/tree/main/runtime/plugins/snapshot
+func (p *Persistence) generateConstructor(builder golang.ModuleBuilder, name string, constructor *gocode.Constructor) (*gocode.Constructor, error) {
+	pkgName := "simple/" + ir.CleanName(name)
+	generated := &gocode.Constructor{
+		Package: builder.Info().Name + "/" + pkgName,
+		Func: gocode.Func{
+			Name:      "New_" + ir.CleanName(name),
+			Arguments: append([]gocode.Variable(nil), constructor.Arguments...),
+			Returns:   constructor.Returns,
+		},
+	}
+	if p.Snapshot != nil {
+		generated.Arguments = append(generated.Arguments, gocode.Variable{Name: "snapshotPath", Type: &gocode.BasicType{Name: "string"}})
+		generated.Returns = []gocode.Variable{
+			{Type: &gocode.Pointer{PointerTo: &gocode.UserType{Package: generated.Package, Name: "Persisted"}}},
+			constructor.Returns[len(constructor.Returns)-1],
+		}
+	}
+	if builder.Visited(name + ".persistence") {
+		return generated, nil
+	}
+
+	pkg, err := builder.CreatePackage(pkgName)
+	if err != nil {
+		return nil, err
+	}
+
+	args := persistenceArgs{
+		Package:     pkg,
+		Name:        generated.Name,
+		Func:        generated.Func,
+		Constructor: constructor,
+		Backend:     constructor.Returns[0].Type,
+		Persistence: p,
+		Imports:     gogen.NewImports(pkg.PackageName),
+	}
+	args.Imports.AddPackages(constructor.Package)
+	for _, arg := range generated.Arguments {
+		args.Imports.AddType(arg.Type)
+	}
+	for _, ret := range generated.Returns {
+		args.Imports.AddType(ret.Type)
+	}
+
+	if p.Fixture != "" {
+		contents, err := os.ReadFile(p.Fixture)
+		if err != nil {
+			return nil, err
+		}
+		ext := strings.ToLower(filepath.Ext(p.Fixture))
+		args.FixtureFile = "fixture" + ext
+		args.RestoreFunc = "Restore"
+		if ext == ".bson" {
+			args.RestoreFunc = "RestoreBSON"
+		}
+		if err := os.WriteFile(filepath.Join(pkg.Path, args.FixtureFile), contents, 0644); err != nil {
+			return nil, err
+		}
+		args.Imports.AddPackages("bytes", "embed")
+	}
+	if p.Snapshot != nil {
+		args.Imports.AddPackages("time", "github.com/blueprint-uservices/blueprint/runtime/plugins/snapshot")
+	}
+
+	slog.Info(fmt.Sprintf("Generating %v/%v", pkg.PackageName, args.Name))
+	outputFile := filepath.Join(pkg.Path, "persistence.go")
+	return generated, gogen.ExecuteTemplateToFile("SimplePersistence", persistenceTemplate, args, outputFile)
+}
+
+type persistenceArgs struct {
+	Package     golang.PackageInfo
+	Name        string
+	Func        gocode.Func
+	Constructor *gocode.Constructor
+	Backend     gocode.TypeName
+	Persistence *Persistence
+	FixtureFile string
+	RestoreFunc string
+	Imports     *gogen.Imports
+}
+
+var persistenceTemplate = `// Blueprint: Auto-generated by Simple Plugin
+package {{.Package.ShortName}}
+
+{{.Imports}}
+{{if .FixtureFile}}
+//go:embed {{.FixtureFile}}
+var fixtures embed.FS
+{{end}}
+{{- if .Persistence.Snapshot}}
+// The backend, and the persister that saves snapshots of it while the namespace runs
+type Persisted struct {
+	{{.Imports.NameOf .Backend}}
+	*snapshot.Persister
+}
+{{end}}
+func {{.Name}}({{ArgVarsAndTypes .Func}}) ({{RetTypes .Func}}) {
+	backend, err := {{.Imports.Qualify .Constructor.Package .Constructor.Name}}({{ArgVars .Constructor.Func}})
+	if err != nil {
+		return nil, err
+	}
+	{{- if .FixtureFile}}
+	fixture, err := fixtures.ReadFile("{{.FixtureFile}}")
+	if err != nil {
+		return nil, err
+	}
+	if err := backend.{{.RestoreFunc}}(bytes.NewReader(fixture)); err != nil {
+		return nil, err
+	}
+	{{- end}}
+	{{- if .Persistence.Snapshot}}
+	persister, err := snapshot.Persist(backend, snapshotPath, time.Duration({{.Persistence.SnapshotInterval.Nanoseconds}}))
+	if err != nil {
+		return nil, err
+	}
+	return &Persisted{backend, persister}, nil
+	{{- else}}
+	return backend, nil
+	{{- end}}
+}
+`
@@ -2,11 +2,14 @@ package simple
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/service"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
 	"github.com/blueprint-uservices/blueprint/plugins/golang"
 	"github.com/blueprint-uservices/blueprint/plugins/golang/gocode"
+	"github.com/blueprint-uservices/blueprint/plugins/sqlmigrate"
 	"github.com/blueprint-uservices/blueprint/plugins/workflow/workflowspec"
 	"golang.org/x/exp/slog"
 )
@@ -29,7 +32,17 @@ type SimpleBackend struct {
 	BackendType  string // e.g. "NoSQLDatabase"
 	BackendImpl  string // e.g. "SimpleNoSQLDB"
 
-	Spec *workflowspec.Service // The backend's interface and implementation
+	Spec        *workflowspec.Service  // The backend's interface and implementation
+	Args        []ir.IRNode            // Arguments to the backend's constructor, after the context
+	Migrations  *sqlmigrate.Migrations // For a RelationalDB, migrations applied when the backend is instantiated
+	Persistence *Persistence           // For a NoSQLDB, Queue, or Cache, the fixture and snapshots of the backend
+}
+
+// The fixture and snapshot configuration of a simple backend; see [LoadFixture] and [Persist]
+type Persistence struct {
+	Fixture          string        // Absolute path of the fixture file, if any
+	Snapshot         *FileConfig   // The snapshot file, if the backend is persisted
+	SnapshotInterval time.Duration // How often a snapshot is saved, if the backend is persisted
 }
 
 // Creates a [SimpleBackend] IR node.
@@ -79,13 +92,69 @@ func (node *SimpleBackend) AddInstantiation(builder golang.NamespaceBuilder) err
 	}
 
 	slog.Info(fmt.Sprintf("Instantiating %v %v in %v/%v", node.BackendImpl, node.InstanceName, builder.Info().Package.PackageName, builder.Info().FileName))
-	return builder.DeclareConstructor(node.InstanceName, node.Spec.Constructor.AsConstructor(), nil)
+	constructor := node.Spec.Constructor.AsConstructor()
+	if node.Migrations != nil {
+		var err error
+		constructor, err = node.Migrations.GenerateConstructor(builder.Module(), node.InstanceName, constructor)
+		if err != nil {
+			return err
+		}
+	}
+	if node.Persistence != nil {
+		var err error
+		constructor, err = node.Persistence.generateConstructor(builder.Module(), node.InstanceName, constructor)
+		if err != nil {
+			return err
+		}
+	}
+	return builder.DeclareConstructor(node.InstanceName, constructor, node.Args)
 }
 
 // Implements ir.IRNode
 func (node *SimpleBackend) String() string {
-	return fmt.Sprintf("%v = %v()", node.InstanceName, node.BackendImpl)
+	var args []string
+	for _, arg := range node.Args {
+		if _, isValue := arg.(*ir.IRValue); !isValue {
+			args = append(args, arg.Name())
+		}
+	}
+	return fmt.Sprintf("%v = %v(%v)", node.InstanceName, node.BackendImpl, strings.Join(args, ", "))
 }
 
 func (node *SimpleBackend) ImplementsGolangNode()    {}
 func (node *SimpleBackend) ImplementsGolangService() {}
+
+// The FileConfig IR node is a configuration variable for the path of a file used by a simple backend,
+// such as the database file of a [PersistentRelationalDB] or the snapshot file of a backend configured
+// with [Persist].  The path is provided to the compiled application when it is run.
+type FileConfig struct {
+	Key  string
+	Path string // The path of the file, if it has been set
+}
+
+// Implements ir.IRNode
+func (conf *FileConfig) Name() string {
+	return conf.Key
+}
+
+// Implements ir.IRNode
+func (conf *FileConfig) String() string {
+	return conf.Key + " = FileConfig()"
+}
+
+// Implements ir.IRConfig
+func (conf *FileConfig) Optional() bool {
+	return false
+}
+
+// Implements ir.IRConfig
+func (conf *FileConfig) HasValue() bool {
+	return conf.Path != ""
+}
+
+// Implements ir.IRConfig
+func (conf *FileConfig) Value() string {
+	return conf.Path
+}
+
+func (conf *FileConfig) ImplementsIRConfig() {}
@@ -1,8 +1,8 @@
-// Package simple provides basic in-memory implementations of the Cache, Queue, NoSQLDB, and RelationalDB [backends]
+// Package simple provides basic in-memory implementations of the Cache, Queue, PubSub, NoSQLDB, RelationalDB, BlobStore, SearchIndex, and LockService [backends]
 // that are used by workflow services.
 //
 // The simple backend implementations are alternatives to the heavyweight "full system" implementations such as
-// [memcached], [rabbitmq], [mongodb], [mysql], etc.
+// [memcached], [rabbitmq], [mongodb], [mysql], [minio], [elasticsearch], etc.
 //
 // The simple backend implementations are in-memory data structures; they must reside within the same process as the
 // services that use them.
@@ -14,11 +14,27 @@
 //
 //	simple.NoSQLDB(spec, "my_nosql_db")
 //	simple.RelationalDB(spec, "my_relational_db")
+//	simple.PersistentRelationalDB(spec, "my_persistent_db")
 //	simple.Queue(spec, "my_queue")
+//	simple.PubSub(spec, "my_pubsub")
 //	simple.Cache(spec, "my_cache")
+//	simple.BlobStore(spec, "my_blob_store")
+//	simple.PersistentBlobStore(spec, "my_persistent_blob_store")
+//	simple.SearchIndex(spec, "my_search_index")
+//	simple.LockService(spec, "my_locks")
 //
 // After instantiating a backend, it can be provided as argument to a workflow service.
 //
+// Versioned SQL migrations can be attached to a RelationalDB with the [sqlmigrate] plugin:
+//
+//	sqlmigrate.Add(spec, "my_relational_db", "migrations/my_relational_db")
+//
+// A NoSQLDB, Queue, or Cache can be pre-populated by attaching a fixture file with [LoadFixture], and its contents
+// can be saved to disk and restored on startup with [Persist]:
+//
+//	simple.LoadFixture(spec, "my_nosql_db", "fixtures/my_nosql_db.json")
+//	simple.Persist(spec, "my_nosql_db", 30*time.Second)
+//
 // # Wiring Spec Example
 //
 // Consider the [SockShop User Service] which makes use of a `backend.NoSQLDatabase`.  The service has the
@@ -36,6 +52,10 @@
 // The simple implementations are just in-memory data structures, so they can't be shared by services running in
 // different processes.  You will encounter a compilation error if you attempt to do so.
 //
+// A NoSQLDB, Queue, or Cache with a fixture or snapshots is instantiated by a constructor generated in the
+// compiled application, which embeds the fixture file and uses the [runtime/plugins/snapshot] package to
+// save and restore snapshots.
+//
 // The simple implementations are primarily handy when developing and testing workflows, as they avoiding having
 // to deploy full-fledged applications.  However, they do not necessarily implement all features (e.g. all operators
 // of a query language), so in some cases they may be insufficient and you might need to resort to testing using
@@ -45,7 +65,11 @@
 //   - NoSQLDB: [runtime/plugins/simplenosqldb]
 //   - RelationalDB: [runtime/plugins/sqlitereldb]
 //   - Queue: [runtime/plugins/simplequeue]
+//   - PubSub: [runtime/plugins/simplepubsub]
 //   - Cache: [runtime/plugins/simplecache]
+//   - BlobStore: [runtime/plugins/simpleblobstore]
+//   - SearchIndex: [runtime/plugins/simplesearch]
+//   - LockService: [runtime/plugins/simplelock]
 //
 // [mongodb]: https://github.com/Blueprint-uServices/blueprint/tree/main/plugins/mongodb
 // [backends]: https://github.com/Blueprint-uServices/blueprint/tree/main/runtime/core/backend
@@ -53,20 +77,40 @@
 // [memcached]: https://github.com/Blueprint-uServices/blueprint/tree/main/plugins/memcached
 // [rabbitmq]: https://github.com/Blueprint-uServices/blueprint/tree/main/plugins/rabbitmq
 // [mysql]: https://github.com/Blueprint-uServices/blueprint/tree/main/plugins/mysql
+// [minio]: https://github.com/Blueprint-uServices/blueprint/tree/main/plugins/minio
+// [elasticsearch]: https://github.com/Blueprint-uServices/blueprint/tree/main/plugins/elasticsearch
+// [sqlmigrate]: https://github.com/Blueprint-uServices/blueprint/tree/main/plugins/sqlmigrate
 // [runtime/plugins/simplenosqldb]: https://github.com/Blueprint-uServices/blueprint/tree/main/runtime/plugins/simplenosqldb
 // [runtime/plugins/sqlitereldb]: https://github.com/Blueprint-uServices/blueprint/tree/main/runtime/plugins/sqlitereldb
 // [runtime/plugins/simplequeue]: https://github.com/Blueprint-uServices/blueprint/tree/main/runtime/plugins/simplequeue
+// [runtime/plugins/simplepubsub]: https://github.com/Blueprint-uServices/blueprint/tree/main/runtime/plugins/simplepubsub
 // [runtime/plugins/simplecache]: https://github.com/Blueprint-uServices/blueprint/tree/main/runtime/plugins/simplecache
+// [runtime/plugins/simpleblobstore]: https://github.com/Blueprint-uServices/blueprint/tree/main/runtime/plugins/simpleblobstore
+// [runtime/plugins/simplelock]: https://github.com/Blueprint-uServices/blueprint/tree/main/runtime/plugins/simplelock
+// [runtime/plugins/simplesearch]: https://github.com/Blueprint-uServices/blueprint/tree/main/runtime/plugins/simplesearch
+// [runtime/plugins/snapshot]: https://github.com/Blueprint-uServices/blueprint/tree/main/runtime/plugins/snapshot
 package simple
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/blueprint"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/pointer"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
 	"github.com/blueprint-uservices/blueprint/blueprint/pkg/wiring"
+	"github.com/blueprint-uservices/blueprint/plugins/golang/gocode"
+	"github.com/blueprint-uservices/blueprint/plugins/sqlmigrate"
 	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/simpleblobstore"
 	"github.com/blueprint-uservices/blueprint/runtime/plugins/simplecache"
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/simplelock"
 	"github.com/blueprint-uservices/blueprint/runtime/plugins/simplenosqldb"
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/simplepubsub"
 	"github.com/blueprint-uservices/blueprint/runtime/plugins/simplequeue"
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/simplesearch"
 	"github.com/blueprint-uservices/blueprint/runtime/plugins/sqlitereldb"
 )
 
@@ -74,35 +118,191 @@ import (
 // In the compiled application, uses the [simplenosqldb.SimpleNoSQLDB] implementation from the Blueprint runtime package
 // The SimpleNoSQLDB has limited support for query and update operations.
 func NoSQLDB(spec wiring.WiringSpec, name string) string {
-	return define[backend.NoSQLDatabase, simplenosqldb.SimpleNoSQLDB](spec, name)
+	return define[backend.NoSQLDatabase, simplenosqldb.SimpleNoSQLDB](spec, name, true)
 }
 
 // [RelationalDB] can be used by wiring specs to create an in-memory [backend.RelationalDB] instance with the specified name.
 // In the compiled application, uses the [sqlitereldb.SqliteRelDB] implementation from the Blueprint runtime package
 // The compiled application might fail to run if gcc is not installed and CGO_ENABLED is not set.
+//
+// Each RelationalDB instance has its own database, identified by the instance's name.
+//
+// The database's tables and seed data can be created by attaching versioned SQL migrations with [sqlmigrate.Add];
+// the migrations are applied when the database is instantiated.
 func RelationalDB(spec wiring.WiringSpec, name string) string {
-	return define[backend.RelationalDB, sqlitereldb.SqliteRelDB](spec, name)
+	return defineRelationalDB(spec, name, false)
+}
+
+// [PersistentRelationalDB] is like [RelationalDB], but the database is stored in a file on disk, so that its contents
+// persist when the application restarts.
+//
+// The path of the file is a configuration variable of the compiled application, named name.file.  For example,
+// if the database is in a goproc, the path is passed as a command-line argument:
+//
+//	go run . --my_relational_db.file=/var/lib/blueprint/my_relational_db.db
+func PersistentRelationalDB(spec wiring.WiringSpec, name string) string {
+	return defineRelationalDB(spec, name, true)
+}
+
+func defineRelationalDB(spec wiring.WiringSpec, name string, persistent bool) string {
+	// The nodes that we are defining
+	backendName := name + ".backend"
+	fileName := name + ".file"
+
+	// Define the config variable for the path of the database file
+	if persistent {
+		spec.Define(fileName, &FileConfig{}, func(namespace wiring.Namespace) (ir.IRNode, error) {
+			return &FileConfig{Key: fileName}, nil
+		})
+	}
+
+	// Define the backend instance, with any migrations attached to the pointer
+	spec.Define(backendName, &SimpleBackend{}, func(namespace wiring.Namespace) (ir.IRNode, error) {
+		node, err := newSimpleBackend[sqlitereldb.SqliteRelDB](name)
+		if err != nil {
+			return nil, err
+		}
+
+		var path ir.IRNode = &ir.IRValue{Value: ""}
+		if persistent {
+			var file *FileConfig
+			if err := namespace.Get(fileName, &file); err != nil {
+				return nil, err
+			}
+			path = file
+		}
+		node.Args = []ir.IRNode{&ir.IRValue{Value: name}, path}
+
+		if persistence, err := getPersistence(spec, namespace, name); err != nil {
+			return nil, err
+		} else if persistence != nil {
+			return nil, blueprint.Errorf("%v does not support fixtures or snapshots; use sqlmigrate or PersistentRelationalDB instead", name)
+		}
+
+		node.Migrations, err = sqlmigrate.Get(spec, name)
+		return node, err
+	})
+
+	// Create a pointer to the backend instance
+	pointer.CreatePointer[*SimpleBackend](spec, name, backendName)
+	return name
 }
 
 // [Queue] can be used by wiring specs to create an in-memory [backend.Queue] instance with the specified name.
 // In the compiled application, uses the [simplequeue.SimpleQueue] implementation from the Blueprint runtime package
 func Queue(spec wiring.WiringSpec, name string) string {
-	return define[backend.Queue, simplequeue.SimpleQueue](spec, name)
+	return define[backend.Queue, simplequeue.SimpleQueue](spec, name, true)
+}
+
+// [PubSub] can be used by wiring specs to create an in-memory [backend.PubSub] instance with the specified name.
+// In the compiled application, uses the [simplepubsub.SimplePubSub] implementation from the Blueprint runtime package
+func PubSub(spec wiring.WiringSpec, name string) string {
+	return define[backend.PubSub, simplepubsub.SimplePubSub](spec, name, false)
 }
 
 // [Cache] can be used by wiring specs to create an in-memory [backend.Cache] instance with the specified name.
 // In the compiled application, uses the [simplecache.SimpleCache] implementation from the Blueprint runtime package
 func Cache(spec wiring.WiringSpec, name string) string {
-	return define[backend.Cache, simplecache.SimpleCache](spec, name)
+	return define[backend.Cache, simplecache.SimpleCache](spec, name, true)
+}
+
+// [SearchIndex] can be used by wiring specs to create an in-memory [backend.SearchIndex] instance with the specified name.
+// In the compiled application, uses the [simplesearch.SimpleSearchIndex] implementation from the Blueprint runtime package
+func SearchIndex(spec wiring.WiringSpec, name string) string {
+	return define[backend.SearchIndex, simplesearch.SimpleSearchIndex](spec, name, false)
 }
 
-func define[BackendInterface any, BackendImpl any](spec wiring.WiringSpec, name string) string {
+// [LockService] can be used by wiring specs to create an in-memory [backend.LockService] instance with the specified name.
+// In the compiled application, uses the [simplelock.SimpleLockService] implementation from the Blueprint runtime package
+//
+// The locks are only shared by services in the same process, so they cannot coordinate replicas in different processes;
+// use a redis LockService instead.
+func LockService(spec wiring.WiringSpec, name string) string {
+	return define[backend.LockService, simplelock.SimpleLockService](spec, name, false)
+}
+
+// [BlobStore] can be used by wiring specs to create an in-memory [backend.BlobStore] instance with the specified name.
+// In the compiled application, uses the [simpleblobstore.SimpleBlobStore] implementation from the Blueprint runtime package
+func BlobStore(spec wiring.WiringSpec, name string) string {
+	return defineBlobStore(spec, name, false)
+}
+
+// [PersistentBlobStore] is like [BlobStore], but the objects are stored in a directory on disk, so that they persist
+// when the application restarts.
+//
+// The path of the directory is a configuration variable of the compiled application, named name.dir.  For example,
+// if the blob store is in a goproc, the path is passed as a command-line argument:
+//
+//	go run . --my_blob_store.dir=/var/lib/blueprint/my_blob_store
+func PersistentBlobStore(spec wiring.WiringSpec, name string) string {
+	return defineBlobStore(spec, name, true)
+}
+
+func defineBlobStore(spec wiring.WiringSpec, name string, persistent bool) string {
 	// The nodes that we are defining
 	backendName := name + ".backend"
+	dirName := name + ".dir"
+
+	// Define the config variable for the path of the directory
+	if persistent {
+		spec.Define(dirName, &FileConfig{}, func(namespace wiring.Namespace) (ir.IRNode, error) {
+			return &FileConfig{Key: dirName}, nil
+		})
+	}
 
 	// Define the backend instance
 	spec.Define(backendName, &SimpleBackend{}, func(namespace wiring.Namespace) (ir.IRNode, error) {
-		return newSimpleBackend[BackendImpl](name)
+		node, err := newSimpleBackend[simpleblobstore.SimpleBlobStore](name)
+		if err != nil {
+			return nil, err
+		}
+
+		var dir ir.IRNode = &ir.IRValue{Value: ""}
+		if persistent {
+			var file *FileConfig
+			if err := namespace.Get(dirName, &file); err != nil {
+				return nil, err
+			}
+			dir = file
+		}
+		node.Args = []ir.IRNode{dir}
+
+		if persistence, err := getPersistence(spec, namespace, name); err != nil {
+			return nil, err
+		} else if persistence != nil {
+			return nil, blueprint.Errorf("%v does not support fixtures or snapshots; use PersistentBlobStore instead", name)
+		}
+		return node, nil
+	})
+
+	// Create a pointer to the backend instance
+	pointer.CreatePointer[*SimpleBackend](spec, name, backendName)
+	return name
+}
+
+func define[BackendInterface any, BackendImpl any](spec wiring.WiringSpec, name string, snapshots bool) string {
+	// The nodes that we are defining
+	backendName := name + ".backend"
+
+	// Define the backend instance, with any fixture and snapshots attached to the pointer
+	spec.Define(backendName, &SimpleBackend{}, func(namespace wiring.Namespace) (ir.IRNode, error) {
+		node, err := newSimpleBackend[BackendImpl](name)
+		if err != nil {
+			return nil, err
+		}
+		node.Persistence, err = getPersistence(spec, namespace, name)
+		if err != nil {
+			return nil, err
+		} else if node.Persistence != nil && !snapshots {
+			return nil, blueprint.Errorf("%v does not support fixtures or snapshots", name)
+		}
+		if node.Persistence != nil && strings.EqualFold(filepath.Ext(node.Persistence.Fixture), ".bson") && node.BackendImpl != gocode.NameOf[simplenosqldb.SimpleNoSQLDB]() {
+			return nil, blueprint.Errorf("BSON fixture %v of %v is only supported by a NoSQLDB", node.Persistence.Fixture, name)
+		}
+		if node.Persistence != nil && node.Persistence.Snapshot != nil {
+			node.Args = []ir.IRNode{node.Persistence.Snapshot}
+		}
+		return node, nil
 	})
 
 	// Create a pointer to the backend instance
@@ -111,3 +311,82 @@ func define[BackendInterface any, BackendImpl any](spec wiring.WiringSpec, name
 	// Return the pointer; anybody who wants to access the backend instance should do so through the pointer
 	return name
 }
+
+// The properties of a backend's wiring definition that hold its fixture and snapshot interval
+const (
+	prop_FIXTURE           = "fixture"
+	prop_SNAPSHOT_INTERVAL = "snapshot_interval"
+)
+
+// [LoadFixture] can be used by wiring specs to pre-populate the simple NoSQLDB, Queue, or Cache with the specified
+// name, using the contents of a fixture file.
+//
+// The fixture file is copied into the compiled application, and loaded when the backend is instantiated.  The path
+// is relative to the working directory of the wiring spec.  The format of the fixture file depends on the backend:
+//   - NoSQLDB: MongoDB Extended JSON (a .json file) or BSON (a .bson file); see [simplenosqldb.SimpleNoSQLDB.Restore]
+//   - Queue: a JSON array of items; see [simplequeue.SimpleQueue.Restore]
+//   - Cache: a JSON array of entries; see [simplecache.SimpleCache.Restore]
+//
+// The snapshots saved by [Persist] are in the same format, so a snapshot can be used as a fixture.
+func LoadFixture(spec wiring.WiringSpec, name string, path string) {
+	spec.SetProperty(name, prop_FIXTURE, path)
+}
+
+// [Persist] can be used by wiring specs to save the contents of the simple NoSQLDB, Queue, or Cache with the
+// specified name to a snapshot file on disk, so that its contents persist when the application restarts.
+//
+// When the backend is instantiated, it is restored from the snapshot file if the file exists, replacing the
+// contents of any fixture attached with [LoadFixture].  A snapshot is then saved every interval, and once more
+// when the backend's namespace is shut down, e.g. when a goproc receives SIGINT or SIGTERM.  If interval is zero,
+// a snapshot is only saved on shutdown.
+//
+// The path of the snapshot file is a configuration variable of the compiled application, named name.snapshot.
+// For example, if the backend is in a goproc, the path is passed as a command-line argument:
+//
+//	go run . --my_nosql_db.snapshot=/var/lib/blueprint/my_nosql_db.json
+func Persist(spec wiring.WiringSpec, name string, interval time.Duration) {
+	snapshotName := name + ".snapshot"
+	spec.SetProperty(name, prop_SNAPSHOT_INTERVAL, interval)
+	spec.Define(snapshotName, &FileConfig{}, func(namespace wiring.Namespace) (ir.IRNode, error) {
+		return &FileConfig{Key: snapshotName}, nil
+	})
+}
+
+// Returns the fixture and snapshot configuration of the backend name, or nil if it has neither.
+func getPersistence(spec wiring.WiringSpec, namespace wiring.Namespace, name string) (*Persistence, error) {
+	var fixture string
+	if err := spec.GetProperty(name, prop_FIXTURE, &fixture); err != nil {
+		return nil, err
+	}
+	var interval time.Duration
+	if err := spec.GetProperty(name, prop_SNAPSHOT_INTERVAL, &interval); err != nil {
+		return nil, err
+	}
+	hasSnapshot := spec.GetDef(name+".snapshot") != nil
+	if fixture == "" && !hasSnapshot {
+		return nil, nil
+	}
+
+	persistence := &Persistence{SnapshotInterval: interval}
+	if fixture != "" {
+		path, err := filepath.Abs(fixture)
+		if err != nil {
+			return nil, err
+		}
+		if info, err := os.Stat(path); err != nil {
+			return nil, blueprint.Errorf("fixture %v of %v does not exist: %v", fixture, name, err)
+		} else if info.IsDir() {
+			return nil, blueprint.Errorf("fixture %v of %v is a directory", fixture, name)
+		}
+		persistence.Fixture = path
+	}
+	if hasSnapshot {
+		if interval < 0 {
+			return nil, blueprint.Errorf("invalid snapshot interval %v for %v", interval, name)
+		}
+		if err := namespace.Get(name+".snapshot", &persistence.Snapshot); err != nil {
+			return nil, err
+		}
+	}
+	return persistence, nil
+}
@@ -0,0 +1,84 @@
+package singleflight
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/blueprint-uservices/blueprint/plugins/golang"
+	"github.com/blueprint-uservices/blueprint/plugins/golang/gocode"
+	"github.com/blueprint-uservices/blueprint/plugins/golang/gogen"
+	"golang.org/x/exp/slog"
+)
+
+// code generation function called from the ir.go file.
+func generateServerWrapper(builder golang.ModuleBuilder, wrapped *gocode.ServiceInterface, outputPackage string, coalesced map[string]bool) error {
+	pkg, err := builder.CreatePackage(outputPackage)
+	if err != nil {
+		return err
+	}
+
+	server := serverArgs{
+		Package:   pkg,
+		Service:   wrapped,
+		Name:      wrapped.BaseName + "_SingleflightServer",
+		Coalesced: coalesced,
+		Imports:   gogen.NewImports(pkg.Name),
+	}
+
+	server.Imports.AddPackages("context", "github.com/blueprint-uservices/blueprint/runtime/plugins/singleflight")
+	slog.Info(fmt.Sprintf("Generating %v/%v", server.Package.PackageName, server.Name))
+	outputFile := filepath.Join(server.Package.Path, server.Name+".go")
+
+	return gogen.ExecuteTemplateToFile("SingleflightServer", serverTemplate, server, outputFile)
+}
+
+type serverArgs struct {
+	Package   golang.PackageInfo
+	Service   *gocode.ServiceInterface
+	Name      string
+	Coalesced map[string]bool
+	Imports   *gogen.Imports
+}
+
+var serverTemplate = `// Blueprint: Auto-generated by Singleflight Plugin
+package {{.Package.ShortName}}
+
+{{.Imports}}
+
+type {{.Name}} struct {
+	Server {{.Imports.NameOf .Service.UserType}}
+	Group *singleflight.Group
+}
+
+func New_{{.Name}} (ctx context.Context, server {{.Imports.NameOf .Service.UserType}}) (*{{.Name}}, error) {
+	handler := &{{.Name}}{}
+	handler.Server = server
+	group, err := singleflight.NewGroup(ctx, "{{.Service.Name}}")
+	if err != nil {
+		return nil, err
+	}
+	handler.Group = group
+	return handler, nil
+}
+
+{{$service := .Service.Name -}}
+{{$receiver := .Name -}}
+{{ range $_, $f := .Service.Methods }}
+func (server *{{$receiver}}) {{$f.Name -}} ({{ArgVarsAndTypes $f "ctx context.Context"}}) ({{RetVarsAndTypes $f "err error"}}) {
+	{{- if index $.Coalesced $f.Name}}
+	type response struct {
+		{{- range $i, $ret := $f.Returns}}
+		ret{{$i}} {{NameOf $ret.Type}}
+		{{- end}}
+	}
+	{{if $f.Returns}}res, err := {{else}}_, err = {{end}}singleflight.Do(ctx, server.Group, "{{$f.Name}}", []any{ {{- ArgVars $f -}} }, func(ctx context.Context) (res response, err error) {
+		{{range $i, $ret := $f.Returns}}res.ret{{$i}}, {{end}}err = server.Server.{{$f.Name}}({{ArgVars $f "ctx"}})
+		return
+	})
+	return {{range $i, $ret := $f.Returns}}res.ret{{$i}}, {{end}}err
+	{{- else}}
+	return server.Server.{{$f.Name}}({{ArgVars $f "ctx"}})
+	{{- end}}
+}
+{{end}}
+`
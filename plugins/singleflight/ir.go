@@ -0,0 +1,111 @@
+package singleflight
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/blueprint"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/service"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
+	"github.com/blueprint-uservices/blueprint/plugins/golang"
+	"github.com/blueprint-uservices/blueprint/plugins/golang/gocode"
+)
+
+// Blueprint IR Node representing a server side wrapper that coalesces concurrent identical calls
+type SingleflightServerWrapper struct {
+	golang.Service
+	golang.GeneratesFuncs
+	golang.Instantiable
+
+	InstanceName  string
+	Wrapped       golang.Service
+	outputPackage string
+	Methods       []string
+}
+
+func newSingleflightServerWrapper(name string, wrapped golang.Service, methods []string) (*SingleflightServerWrapper, error) {
+	node := &SingleflightServerWrapper{}
+	node.InstanceName = name
+	node.Wrapped = wrapped
+	node.outputPackage = "singleflight"
+	node.Methods = methods
+	return node, nil
+}
+
+// Implements [ir.IRNode]
+func (node *SingleflightServerWrapper) ImplementsGolangNode() {}
+
+// Implements [golang.Service]
+func (node *SingleflightServerWrapper) ImplementsGolangService() {}
+
+// Implements [ir.IRNode]
+func (node *SingleflightServerWrapper) Name() string {
+	return node.InstanceName
+}
+
+// Implements [ir.IRNode]
+func (node *SingleflightServerWrapper) String() string {
+	return node.Name() + " = Singleflight(" + node.Wrapped.Name() + ", " + strings.Join(node.Methods, ", ") + ")"
+}
+
+// Implements [golang.Service]
+func (node *SingleflightServerWrapper) AddInterfaces(builder golang.ModuleBuilder) error {
+	return node.Wrapped.AddInterfaces(builder)
+}
+
+// Implements [golang.Service]
+func (node *SingleflightServerWrapper) GetInterface(ctx ir.BuildContext) (service.ServiceInterface, error) {
+	return node.Wrapped.GetInterface(ctx)
+}
+
+// Implements [golang.GeneratesFuncs]
+func (node *SingleflightServerWrapper) GenerateFuncs(builder golang.ModuleBuilder) error {
+	if builder.Visited(node.InstanceName + ".generateFuncs") {
+		return nil
+	}
+
+	iface, err := golang.GetGoInterface(builder, node)
+	if err != nil {
+		return err
+	}
+
+	coalesced := make(map[string]bool)
+	for _, method := range node.Methods {
+		if _, exists := iface.Methods[method]; !exists {
+			return blueprint.Errorf("singleflight wrapper %s cannot coalesce calls to %s as %s has no such method", node.InstanceName, method, iface.Name)
+		}
+		coalesced[method] = true
+	}
+	if len(node.Methods) == 0 {
+		for method := range iface.Methods {
+			coalesced[method] = true
+		}
+	}
+
+	return generateServerWrapper(builder, iface, node.outputPackage, coalesced)
+}
+
+// Implements golang.Instantiable
+func (node *SingleflightServerWrapper) AddInstantiation(builder golang.NamespaceBuilder) error {
+	if builder.Visited(node.InstanceName) {
+		return nil
+	}
+
+	iface, err := golang.GetGoInterface(builder, node.Wrapped)
+	if err != nil {
+		return err
+	}
+
+	constructor := &gocode.Constructor{
+		Package: builder.Module().Info().Name + "/" + node.outputPackage,
+		Func: gocode.Func{
+			Name: fmt.Sprintf("New_%v_SingleflightServer", iface.BaseName),
+			Arguments: []gocode.Variable{
+				{Name: "ctx", Type: &gocode.UserType{Package: "context", Name: "Context"}},
+				{Name: "server", Type: iface},
+			},
+		},
+	}
+
+	return builder.DeclareConstructor(node.InstanceName, constructor, []ir.IRNode{node.Wrapped})
+}
@@ -0,0 +1,65 @@
+// Package singleflight provides a Blueprint modifier for the server side of service calls.
+//
+// The plugin wraps the server side of a service so that concurrent identical calls, i.e. calls to the
+// same method with the same arguments, share a single execution and its result.  This mitigates
+// thundering-herd behaviour, e.g. when many clients miss in a cache at the same time and request
+// the same item.
+//
+// Coalescing can be restricted to specific methods; calls to all other methods are passed through to
+// the wrapped service unmodified.  The number of coalesced calls is exported as an OpenTelemetry metric.
+//
+// Coalesced callers share the response of a single execution, so this plugin should only be applied
+// to methods that do not modify their arguments or responses and whose concurrent identical calls may
+// safely be combined, e.g. read-only methods.
+//
+// Usage:
+//
+//	import "github.com/blueprint-uservices/blueprint/plugins/singleflight"
+//	singleflight.Add(spec, "my_service", "GetProfile", "GetRates")
+//
+// # Artifacts Generated
+//
+// During compilation, the singleflight plugin will generate a server-side wrapper class.  The plugin
+// also utilizes some code in the [runtime/plugins/singleflight] package.
+//
+// [runtime/plugins/singleflight]: https://github.com/Blueprint-uServices/blueprint/tree/main/runtime/plugins/singleflight
+package singleflight
+
+import (
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/blueprint"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/coreplugins/pointer"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/wiring"
+	"github.com/blueprint-uservices/blueprint/plugins/golang"
+	"golang.org/x/exp/slog"
+)
+
+// Adds request coalescing to the server side of the specified service.
+// Uses a [blueprint.WiringSpec].
+// Modifies the given service such that concurrent identical calls to any of the specified methods
+// share a single execution.  If no methods are specified, calls to all methods are coalesced.
+//
+// Usage:
+//
+//	Add(spec, "my_service", "GetProfile", "GetRates")
+func Add(spec wiring.WiringSpec, serviceName string, methods ...string) {
+	serverWrapper := serviceName + ".server.singleflight"
+
+	ptr := pointer.GetPointer(spec, serviceName)
+	if ptr == nil {
+		slog.Error("Unable to add singleflight to " + serviceName + " as it is not a pointer")
+		return
+	}
+
+	serverNext := ptr.AddDstModifier(spec, serverWrapper)
+
+	spec.Define(serverWrapper, &SingleflightServerWrapper{}, func(ns wiring.Namespace) (ir.IRNode, error) {
+		var wrapped golang.Service
+
+		if err := ns.Get(serverNext, &wrapped); err != nil {
+			return nil, blueprint.Errorf("Singleflight %s expected %s to be a golang.Service, but encountered %s", serverWrapper, serverNext, err)
+		}
+
+		return newSingleflightServerWrapper(serverWrapper, wrapped, methods)
+	})
+}
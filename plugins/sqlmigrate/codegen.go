@@ -0,0 +1,88 @@
+package sqlmigrate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/ir"
+	"github.com/blueprint-uservices/blueprint/plugins/golang"
+	"github.com/blueprint-uservices/blueprint/plugins/golang/gocode"
+	"github.com/blueprint-uservices/blueprint/plugins/golang/gogen"
+	"golang.org/x/exp/slog"
+)
+
+// GenerateConstructor is used by relational database plugins to instantiate database clients that
+// apply the migrations at startup.
+//
+// Generates a package for the database instance name, that embeds the migration files and wraps
+// constructor, which must return the database client and an error.  The generated constructor calls
+// constructor, then applies any pending migrations to the client.  The generated constructor has the
+// same arguments as constructor, so it can be used in place of constructor by
+// [golang.NamespaceBuilder.DeclareConstructor].
+func (m *Migrations) GenerateConstructor(builder golang.ModuleBuilder, name string, constructor *gocode.Constructor) (*gocode.Constructor, error) {
+	pkgName := "sqlmigrate/" + ir.CleanName(name)
+	generated := &gocode.Constructor{
+		Package: builder.Info().Name + "/" + pkgName,
+		Func: gocode.Func{
+			Name:      "New_" + ir.CleanName(name),
+			Arguments: constructor.Arguments,
+			Returns:   constructor.Returns,
+		},
+	}
+	if builder.Visited(name + ".sqlmigrate") {
+		return generated, nil
+	}
+
+	pkg, err := builder.CreatePackage(pkgName)
+	if err != nil {
+		return nil, err
+	}
+	for _, migration := range m.Migrations {
+		if err := os.WriteFile(filepath.Join(pkg.Path, migration.Filename), []byte(migration.SQL), 0644); err != nil {
+			return nil, err
+		}
+	}
+
+	args := constructorArgs{
+		Package:     pkg,
+		Name:        generated.Name,
+		Constructor: constructor,
+		Imports:     gogen.NewImports(pkg.Name),
+	}
+	args.Imports.AddPackages("embed", "github.com/blueprint-uservices/blueprint/runtime/plugins/sqlmigrate", constructor.Package)
+	for _, arg := range constructor.Arguments {
+		args.Imports.AddType(arg.Type)
+	}
+	for _, ret := range constructor.Returns {
+		args.Imports.AddType(ret.Type)
+	}
+
+	slog.Info(fmt.Sprintf("Generating %v/%v", pkg.PackageName, args.Name))
+	outputFile := filepath.Join(pkg.Path, "migrations.go")
+	return generated, gogen.ExecuteTemplateToFile("SQLMigrate", constructorTemplate, args, outputFile)
+}
+
+type constructorArgs struct {
+	Package     golang.PackageInfo
+	Name        string
+	Constructor *gocode.Constructor
+	Imports     *gogen.Imports
+}
+
+var constructorTemplate = `// Blueprint: Auto-generated by SQLMigrate Plugin
+package {{.Package.ShortName}}
+
+{{.Imports}}
+
+//go:embed *.sql
+var migrations embed.FS
+
+func {{.Name}}({{ArgVarsAndTypes .Constructor.Func}}) ({{RetTypes .Constructor.Func}}) {
+	db, err := {{.Imports.Qualify .Constructor.Package .Constructor.Name}}({{ArgVars .Constructor.Func}})
+	if err != nil {
+		return nil, err
+	}
+	return db, sqlmigrate.Apply(ctx, db, migrations)
+}
+`
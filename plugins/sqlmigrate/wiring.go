@@ -0,0 +1,109 @@
+// Package sqlmigrate provides a Blueprint modifier for attaching versioned SQL migrations and seed data to
+// a [backend.RelationalDB] instance.
+//
+// # Wiring Spec Usage
+//
+// Migrations are SQL files in a directory, named <version>_<name>.sql and applied in version order.  Seed
+// data is added by migrations like any other change, e.g.
+//
+//	migrations/post_db/0001_create_posts.sql
+//	migrations/post_db/0002_seed_posts.sql
+//
+// To attach the migrations to a relational database, call [Add] with the name of the database:
+//
+//	post_db := mysql.Container(spec, "post_db")
+//	sqlmigrate.Add(spec, post_db, "migrations/post_db")
+//
+// The directory is relative to the working directory of the wiring spec.
+//
+// Migrations are supported by the [mysql] and [postgres] plugins, and by the sqlite implementation of the
+// [simple] plugin.
+//
+// # Description
+//
+// During compilation, the migration files are copied into the generated golang code, where they are
+// embedded into the compiled application.  When the application starts, the database's client applies
+// any pending migrations, recording the versions that have been applied in a blueprint_migrations table.
+// See the [runtime/plugins/sqlmigrate] package for details.
+//
+// Database containers are also initialized with the migrations, by generating a container image whose
+// init script applies the migrations and records their versions.
+//
+// # Plugin developers
+//
+// Relational database plugins support migrations by calling [Get] from their wiring spec, and using
+// [Migrations.GenerateConstructor] and [Migrations.Script] to generate code and container artifacts.
+//
+// [backend.RelationalDB]: https://github.com/Blueprint-uServices/blueprint/tree/main/runtime/core/backend
+// [mysql]: https://github.com/Blueprint-uServices/blueprint/tree/main/plugins/mysql
+// [postgres]: https://github.com/Blueprint-uServices/blueprint/tree/main/plugins/postgres
+// [simple]: https://github.com/Blueprint-uServices/blueprint/tree/main/plugins/simple
+// [runtime/plugins/sqlmigrate]: https://github.com/Blueprint-uServices/blueprint/tree/main/runtime/plugins/sqlmigrate
+package sqlmigrate
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/blueprint"
+	"github.com/blueprint-uservices/blueprint/blueprint/pkg/wiring"
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/sqlmigrate"
+)
+
+// The property of a database's wiring definition that holds its migrations directory
+const prop_MIGRATIONS = "migrations"
+
+// Add attaches the SQL migrations in dir to the relational database dbName.
+//
+// dbName must be a relational database defined by a plugin that supports migrations, such as
+// [mysql.Container] or [simple.RelationalDB].  Calling Add again for the same database replaces its
+// migrations directory.
+//
+// [mysql.Container]: https://github.com/Blueprint-uServices/blueprint/tree/main/plugins/mysql
+// [simple.RelationalDB]: https://github.com/Blueprint-uServices/blueprint/tree/main/plugins/simple
+func Add(spec wiring.WiringSpec, dbName string, dir string) {
+	spec.SetProperty(dbName, prop_MIGRATIONS, dir)
+}
+
+// The migrations attached to a relational database
+type Migrations struct {
+	Dir        string // Absolute path of the migrations directory
+	Migrations []sqlmigrate.Migration
+}
+
+// Get is used by relational database plugins to load the migrations attached to dbName by [Add].
+//
+// Returns nil if dbName has no migrations.  Returns an error if the migrations directory does not
+// exist, or does not contain any valid migrations.
+func Get(spec wiring.WiringSpec, dbName string) (*Migrations, error) {
+	var dir string
+	if err := spec.GetProperty(dbName, prop_MIGRATIONS, &dir); err != nil {
+		return nil, err
+	}
+	if dir == "" {
+		return nil, nil
+	}
+	path, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(path); err != nil {
+		return nil, blueprint.Errorf("migrations directory %v of %v does not exist: %v", dir, dbName, err)
+	} else if !info.IsDir() {
+		return nil, blueprint.Errorf("migrations directory %v of %v is not a directory", dir, dbName)
+	}
+	migrations, err := sqlmigrate.Load(os.DirFS(path))
+	if err != nil {
+		return nil, blueprint.Errorf("invalid migrations for %v: %v", dbName, err)
+	}
+	if len(migrations) == 0 {
+		return nil, blueprint.Errorf("migrations directory %v of %v contains no migrations", dir, dbName)
+	}
+	return &Migrations{Dir: path, Migrations: migrations}, nil
+}
+
+// Returns a SQL script that applies the migrations and records their versions, for use as the init
+// script of a database container.
+func (m *Migrations) Script() string {
+	return sqlmigrate.Script(m.Migrations)
+}
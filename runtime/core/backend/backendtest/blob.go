@@ -0,0 +1,142 @@
+package backendtest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"github.com/stretchr/testify/require"
+)
+
+// Runs a suite of tests against the [backend.BlobStore] instances returned by newStore.
+//
+// The store returned by newStore may be shared between tests, e.g. a client to a single server.
+// Tests use keys prefixed with the test name, and delete them before use.
+func RunBlobStoreSuite(t *testing.T, newStore func(t *testing.T) backend.BlobStore) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, ctx context.Context, store backend.BlobStore, prefix string)
+	}{
+		{"PutGet", testBlobPutGet},
+		{"Replace", testBlobReplace},
+		{"Delete", testBlobDelete},
+		{"List", testBlobList},
+		{"Metadata", testBlobMetadata},
+		{"InvalidPut", testBlobInvalidPut},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := newStore(t)
+			prefix := t.Name() + "/"
+			existing, err := store.List(ctx, prefix)
+			require.NoError(t, err)
+			for _, info := range existing {
+				require.NoError(t, store.Delete(ctx, info.Key))
+			}
+			test.test(t, ctx, store, prefix)
+		})
+	}
+}
+
+// Returns the contents and info of key
+func getBlob(t *testing.T, ctx context.Context, store backend.BlobStore, key string) ([]byte, backend.BlobInfo) {
+	r, info, err := store.Get(ctx, key)
+	require.NoError(t, err)
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return data, info
+}
+
+func testBlobPutGet(t *testing.T, ctx context.Context, store backend.BlobStore, prefix string) {
+	key := prefix + "images/avatar.png"
+	data := []byte{0x89, 'P', 'N', 'G', 0, 1, 2, 3}
+	require.NoError(t, store.Put(ctx, key, bytes.NewReader(data), backend.BlobOptions{ContentType: "image/png"}))
+
+	got, info := getBlob(t, ctx, store, key)
+	require.Equal(t, data, got)
+	require.Equal(t, key, info.Key)
+	require.Equal(t, int64(len(data)), info.Size)
+	require.Equal(t, "image/png", info.ContentType)
+	require.False(t, info.LastModified.IsZero())
+
+	empty := prefix + "empty"
+	require.NoError(t, store.Put(ctx, empty, bytes.NewReader(nil), backend.BlobOptions{}))
+	got, info = getBlob(t, ctx, store, empty)
+	require.Empty(t, got)
+	require.Equal(t, int64(0), info.Size)
+	require.Equal(t, backend.DefaultBlobContentType, info.ContentType)
+
+	_, _, err := store.Get(ctx, prefix+"missing")
+	require.True(t, errors.Is(err, backend.ErrBlobNotFound), "expected ErrBlobNotFound, got %v", err)
+}
+
+func testBlobReplace(t *testing.T, ctx context.Context, store backend.BlobStore, prefix string) {
+	key := prefix + "doc"
+	require.NoError(t, store.Put(ctx, key, strings.NewReader("first version"), backend.BlobOptions{Metadata: map[string]string{"version": "1"}}))
+	require.NoError(t, store.Put(ctx, key, strings.NewReader("second"), backend.BlobOptions{ContentType: "text/plain"}))
+
+	got, info := getBlob(t, ctx, store, key)
+	require.Equal(t, "second", string(got))
+	require.Equal(t, int64(6), info.Size)
+	require.Equal(t, "text/plain", info.ContentType)
+	require.Empty(t, info.Metadata)
+}
+
+func testBlobDelete(t *testing.T, ctx context.Context, store backend.BlobStore, prefix string) {
+	key := prefix + "doc"
+	require.NoError(t, store.Put(ctx, key, strings.NewReader("hello"), backend.BlobOptions{}))
+	require.NoError(t, store.Delete(ctx, key))
+
+	_, _, err := store.Get(ctx, key)
+	require.True(t, errors.Is(err, backend.ErrBlobNotFound), "expected ErrBlobNotFound, got %v", err)
+
+	// Deleting a missing object is not an error
+	require.NoError(t, store.Delete(ctx, key))
+}
+
+func testBlobList(t *testing.T, ctx context.Context, store backend.BlobStore, prefix string) {
+	keys := []string{"b/2", "a/1", "b/1", "b", "c"}
+	for _, key := range keys {
+		require.NoError(t, store.Put(ctx, prefix+key, strings.NewReader(key), backend.BlobOptions{}))
+	}
+
+	listKeys := func(p string) []string {
+		infos, err := store.List(ctx, prefix+p)
+		require.NoError(t, err)
+		var got []string
+		for _, info := range infos {
+			require.Equal(t, int64(len(info.Key)-len(prefix)), info.Size)
+			got = append(got, strings.TrimPrefix(info.Key, prefix))
+		}
+		return got
+	}
+	require.Equal(t, []string{"a/1", "b", "b/1", "b/2", "c"}, listKeys(""))
+	require.Equal(t, []string{"b", "b/1", "b/2"}, listKeys("b"))
+	require.Equal(t, []string{"b/1", "b/2"}, listKeys("b/"))
+	require.Empty(t, listKeys("d"))
+}
+
+func testBlobMetadata(t *testing.T, ctx context.Context, store backend.BlobStore, prefix string) {
+	key := prefix + "doc"
+	metadata := map[string]string{"Owner": "user-1", "x-version": "2"}
+	require.NoError(t, store.Put(ctx, key, strings.NewReader("hello"), backend.BlobOptions{Metadata: metadata}))
+
+	_, info := getBlob(t, ctx, store, key)
+	require.Equal(t, map[string]string{"owner": "user-1", "x-version": "2"}, info.Metadata)
+}
+
+func testBlobInvalidPut(t *testing.T, ctx context.Context, store backend.BlobStore, prefix string) {
+	require.Error(t, store.Put(ctx, "", strings.NewReader("hello"), backend.BlobOptions{}))
+	require.Error(t, store.Put(ctx, prefix+strings.Repeat("a", 1025), strings.NewReader("hello"), backend.BlobOptions{}))
+	require.Error(t, store.Put(ctx, prefix+"doc", strings.NewReader("hello"), backend.BlobOptions{Metadata: map[string]string{"bad key": "v"}}))
+	require.Error(t, store.Put(ctx, prefix+"doc", strings.NewReader("hello"), backend.BlobOptions{Metadata: map[string]string{"key": "bad\nvalue"}}))
+
+	_, _, err := store.Get(ctx, prefix+"doc")
+	require.True(t, errors.Is(err, backend.ErrBlobNotFound), "expected ErrBlobNotFound, got %v", err)
+}
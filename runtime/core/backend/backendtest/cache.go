@@ -0,0 +1,246 @@
+// Package backendtest provides test suites that check implementations of the interfaces in
+// [github.com/blueprint-uservices/blueprint/runtime/core/backend] for conformance with their
+// documented semantics.
+//
+// Each suite takes a function that instantiates the implementation under test, e.g.
+//
+//	func TestConformance(t *testing.T) {
+//		backendtest.RunCacheSuite(t, func(t *testing.T) backend.Cache {
+//			cache, err := NewSimpleCache(context.Background())
+//			require.NoError(t, err)
+//			return cache
+//		})
+//	}
+//
+// Implementations that are clients to a server, e.g. redis, can use the same suites, but the tests
+// require the server to be running.  Some suites include concurrent tests, so they should also be
+// run with the race detector.
+package backendtest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"github.com/stretchr/testify/require"
+)
+
+type cacheValue struct {
+	ID   int64
+	Name string
+}
+
+// Runs a suite of tests against the [backend.Cache] instances returned by newCache.
+//
+// The cache returned by newCache may be shared between tests, e.g. a client to a single server.
+// Tests use keys prefixed with the test name, and delete them before use.
+func RunCacheSuite(t *testing.T, newCache func(t *testing.T) backend.Cache) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, ctx context.Context, cache backend.Cache, key func(string) string)
+	}{
+		{"PutGet", testCachePutGet},
+		{"Delete", testCacheDelete},
+		{"Incr", testCacheIncr},
+		{"MsetMget", testCacheMsetMget},
+		{"TTL", testCacheTTL},
+		{"SetNX", testCacheSetNX},
+		{"CompareAndSwap", testCacheCompareAndSwap},
+		{"ConcurrentCompareAndSwap", testCacheConcurrentCompareAndSwap},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			cache := newCache(t)
+			key := func(name string) string {
+				k := fmt.Sprintf("%v/%v", t.Name(), name)
+				require.NoError(t, cache.Delete(ctx, k))
+				return k
+			}
+			test.test(t, ctx, cache, key)
+		})
+	}
+}
+
+func testCachePutGet(t *testing.T, ctx context.Context, cache backend.Cache, key func(string) string) {
+	k := key("value")
+	value := cacheValue{ID: 5, Name: "Vaastav"}
+	require.NoError(t, cache.Put(ctx, k, value))
+
+	var got cacheValue
+	exists, err := cache.Get(ctx, k, &got)
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.Equal(t, value, got)
+
+	exists, err = cache.Get(ctx, key("missing"), &got)
+	require.NoError(t, err)
+	require.False(t, exists)
+}
+
+func testCacheDelete(t *testing.T, ctx context.Context, cache backend.Cache, key func(string) string) {
+	k := key("value")
+	require.NoError(t, cache.Put(ctx, k, "hello"))
+	require.NoError(t, cache.Delete(ctx, k))
+
+	var got string
+	exists, err := cache.Get(ctx, k, &got)
+	require.NoError(t, err)
+	require.False(t, exists)
+}
+
+func testCacheIncr(t *testing.T, ctx context.Context, cache backend.Cache, key func(string) string) {
+	k := key("counter")
+	require.NoError(t, cache.Put(ctx, k, int64(5)))
+	for i := int64(6); i < 10; i++ {
+		v, err := cache.Incr(ctx, k)
+		require.NoError(t, err)
+		require.Equal(t, i, v)
+	}
+}
+
+func testCacheMsetMget(t *testing.T, ctx context.Context, cache backend.Cache, key func(string) string) {
+	keys := []string{key("a"), key("b")}
+	require.NoError(t, cache.Mset(ctx, keys, []interface{}{int64(5), "hello"}))
+
+	var a int64
+	var b string
+	require.NoError(t, cache.Mget(ctx, keys, []interface{}{&a, &b}))
+	require.Equal(t, int64(5), a)
+	require.Equal(t, "hello", b)
+}
+
+// Uses whole seconds, since some caches only support that granularity
+func testCacheTTL(t *testing.T, ctx context.Context, cache backend.Cache, key func(string) string) {
+	expiring, persistent, expired, persisted, setnx := key("expiring"), key("persistent"), key("expired"), key("persisted"), key("setnx")
+
+	require.NoError(t, cache.PutWithTTL(ctx, expiring, "a", time.Second))
+	require.NoError(t, cache.PutWithTTL(ctx, persistent, "b", 0))
+	require.NoError(t, cache.Put(ctx, expired, "c"))
+	require.NoError(t, cache.PutWithTTL(ctx, persisted, "d", time.Second))
+
+	exists, err := cache.Expire(ctx, expired, time.Second)
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	exists, err = cache.Expire(ctx, persisted, 0)
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	exists, err = cache.Expire(ctx, key("missing"), time.Second)
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	stored, err := cache.SetNX(ctx, setnx, "e", time.Second)
+	require.NoError(t, err)
+	require.True(t, stored)
+
+	require.Error(t, cache.PutWithTTL(ctx, key("negative"), "f", -time.Second))
+
+	var v string
+	exists, err = cache.Get(ctx, expiring, &v)
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.Equal(t, "a", v)
+
+	time.Sleep(2100 * time.Millisecond)
+
+	for _, k := range []string{expiring, expired, setnx} {
+		exists, err := cache.Get(ctx, k, &v)
+		require.NoError(t, err)
+		require.False(t, exists, "%v should have expired", k)
+	}
+	for _, k := range []string{persistent, persisted} {
+		exists, err := cache.Get(ctx, k, &v)
+		require.NoError(t, err)
+		require.True(t, exists, "%v should not have expired", k)
+	}
+
+	// An expired key can be set again
+	stored, err = cache.SetNX(ctx, setnx, "g", 0)
+	require.NoError(t, err)
+	require.True(t, stored)
+}
+
+func testCacheSetNX(t *testing.T, ctx context.Context, cache backend.Cache, key func(string) string) {
+	k := key("value")
+	stored, err := cache.SetNX(ctx, k, "first", 0)
+	require.NoError(t, err)
+	require.True(t, stored)
+
+	stored, err = cache.SetNX(ctx, k, "second", 0)
+	require.NoError(t, err)
+	require.False(t, stored)
+
+	var v string
+	_, err = cache.Get(ctx, k, &v)
+	require.NoError(t, err)
+	require.Equal(t, "first", v)
+}
+
+func testCacheCompareAndSwap(t *testing.T, ctx context.Context, cache backend.Cache, key func(string) string) {
+	k := key("value")
+	swapped, err := cache.CompareAndSwap(ctx, k, "a", "b")
+	require.NoError(t, err)
+	require.False(t, swapped, "the key does not exist")
+
+	old := cacheValue{ID: 1, Name: "a"}
+	require.NoError(t, cache.Put(ctx, k, old))
+
+	swapped, err = cache.CompareAndSwap(ctx, k, cacheValue{ID: 2, Name: "a"}, cacheValue{ID: 3, Name: "c"})
+	require.NoError(t, err)
+	require.False(t, swapped, "the value differs from old")
+
+	swapped, err = cache.CompareAndSwap(ctx, k, old, cacheValue{ID: 3, Name: "c"})
+	require.NoError(t, err)
+	require.True(t, swapped)
+
+	var got cacheValue
+	_, err = cache.Get(ctx, k, &got)
+	require.NoError(t, err)
+	require.Equal(t, cacheValue{ID: 3, Name: "c"}, got)
+}
+
+// Concurrent read-modify-write loops using CompareAndSwap should not lose updates
+func testCacheConcurrentCompareAndSwap(t *testing.T, ctx context.Context, cache backend.Cache, key func(string) string) {
+	k := key("counter")
+	require.NoError(t, cache.Put(ctx, k, int64(0)))
+
+	workers, increments := 8, 20
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < increments; {
+				var v int64
+				if _, err := cache.Get(ctx, k, &v); err != nil {
+					errs <- err
+					return
+				}
+				swapped, err := cache.CompareAndSwap(ctx, k, v, v+1)
+				if err != nil {
+					errs <- err
+					return
+				}
+				if swapped {
+					j++
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	var v int64
+	_, err := cache.Get(ctx, k, &v)
+	require.NoError(t, err)
+	require.Equal(t, int64(workers*increments), v)
+}
@@ -0,0 +1,170 @@
+package backendtest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"github.com/stretchr/testify/require"
+)
+
+// Runs a suite of tests against the [backend.LockService] instances returned by newLocks.
+//
+// The service returned by newLocks may be shared between tests, e.g. a client to a single server.
+// Tests use lock names prefixed with the test name.  Some tests wait for leases to expire, so the
+// suite takes a few seconds to run.
+func RunLockServiceSuite(t *testing.T, newLocks func(t *testing.T) backend.LockService) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, ctx context.Context, locks backend.LockService, prefix string)
+	}{
+		{"AcquireRelease", testLockAcquireRelease},
+		{"Expiry", testLockExpiry},
+		{"Renew", testLockRenew},
+		{"Invalid", testLockInvalid},
+		{"RunAsLeader", testLockRunAsLeader},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.test(t, context.Background(), newLocks(t), t.Name()+"/")
+		})
+	}
+}
+
+func requireLockHeld(t *testing.T, err error) {
+	require.True(t, errors.Is(err, backend.ErrLockHeld), "expected ErrLockHeld, got %v", err)
+}
+
+func testLockAcquireRelease(t *testing.T, ctx context.Context, locks backend.LockService, prefix string) {
+	name := prefix + "lock"
+	lease, err := locks.TryAcquire(ctx, name, 10*time.Second)
+	require.NoError(t, err)
+	defer locks.Release(ctx, lease)
+	require.Equal(t, name, lease.Name)
+	require.NotEmpty(t, lease.Token)
+	require.WithinDuration(t, time.Now().Add(10*time.Second), lease.Expires, 2*time.Second)
+
+	_, err = locks.TryAcquire(ctx, name, 10*time.Second)
+	requireLockHeld(t, err)
+
+	// Locks with other names are independent
+	other, err := locks.TryAcquire(ctx, prefix+"other", 10*time.Second)
+	require.NoError(t, err)
+	require.NoError(t, locks.Release(ctx, other))
+
+	require.NoError(t, locks.Release(ctx, lease))
+	require.NoError(t, locks.Release(ctx, lease))
+	next, err := locks.TryAcquire(ctx, name, 10*time.Second)
+	require.NoError(t, err)
+	require.NotEqual(t, lease.Token, next.Token)
+	require.NoError(t, locks.Release(ctx, next))
+}
+
+func testLockExpiry(t *testing.T, ctx context.Context, locks backend.LockService, prefix string) {
+	name := prefix + "lock"
+	lease, err := locks.TryAcquire(ctx, name, 200*time.Millisecond)
+	require.NoError(t, err)
+
+	time.Sleep(400 * time.Millisecond)
+	next, err := locks.TryAcquire(ctx, name, 10*time.Second)
+	require.NoError(t, err)
+	defer locks.Release(ctx, next)
+
+	// The expired lease cannot be renewed, and releasing it does not affect the current lease
+	_, err = locks.Renew(ctx, lease, 10*time.Second)
+	require.True(t, errors.Is(err, backend.ErrLeaseLost), "expected ErrLeaseLost, got %v", err)
+	require.NoError(t, locks.Release(ctx, lease))
+	_, err = locks.TryAcquire(ctx, name, 10*time.Second)
+	requireLockHeld(t, err)
+}
+
+func testLockRenew(t *testing.T, ctx context.Context, locks backend.LockService, prefix string) {
+	name := prefix + "lock"
+	lease, err := locks.TryAcquire(ctx, name, 300*time.Millisecond)
+	require.NoError(t, err)
+	defer func() { locks.Release(ctx, lease) }()
+
+	for i := 0; i < 3; i++ {
+		time.Sleep(200 * time.Millisecond)
+		renewed, err := locks.Renew(ctx, lease, 300*time.Millisecond)
+		require.NoError(t, err)
+		require.Equal(t, lease.Token, renewed.Token)
+		require.True(t, renewed.Expires.After(lease.Expires))
+		lease = renewed
+	}
+	_, err = locks.TryAcquire(ctx, name, 10*time.Second)
+	requireLockHeld(t, err)
+
+	// A released lease cannot be renewed
+	require.NoError(t, locks.Release(ctx, lease))
+	_, err = locks.Renew(ctx, lease, 300*time.Millisecond)
+	require.True(t, errors.Is(err, backend.ErrLeaseLost), "expected ErrLeaseLost, got %v", err)
+}
+
+func testLockInvalid(t *testing.T, ctx context.Context, locks backend.LockService, prefix string) {
+	_, err := locks.TryAcquire(ctx, "", time.Second)
+	require.Error(t, err)
+	_, err = locks.TryAcquire(ctx, prefix+"lock", 0)
+	require.Error(t, err)
+
+	lease, err := locks.TryAcquire(ctx, prefix+"lock", time.Second)
+	require.NoError(t, err)
+	_, err = locks.Renew(ctx, lease, -time.Second)
+	require.Error(t, err)
+	require.NoError(t, locks.Release(ctx, lease))
+}
+
+func testLockRunAsLeader(t *testing.T, ctx context.Context, locks backend.LockService, prefix string) {
+	name := prefix + "leader"
+	var active, maxActive, runs int32
+	run := func(ctx context.Context) error {
+		n := atomic.AddInt32(&active, 1)
+		for {
+			max := atomic.LoadInt32(&maxActive)
+			if n <= max || atomic.CompareAndSwapInt32(&maxActive, max, n) {
+				break
+			}
+		}
+		time.Sleep(300 * time.Millisecond)
+		atomic.AddInt32(&active, -1)
+		atomic.AddInt32(&runs, 1)
+		return nil
+	}
+
+	// Each candidate is elected in turn, and returns once its run completes.  RunAsLeader retries errors
+	// indefinitely, so the candidates give up after a timeout, e.g. if the service is unavailable.
+	electionCtx, cancelElection := context.WithTimeout(ctx, 10*time.Second)
+	defer cancelElection()
+	candidates := 3
+	var wg sync.WaitGroup
+	errs := make(chan error, candidates)
+	for i := 0; i < candidates; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := backend.RunAsLeader(electionCtx, locks, name, 200*time.Millisecond, run); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+	require.Equal(t, int32(candidates), runs)
+	require.Equal(t, int32(1), maxActive)
+
+	// Candidates return nil once their context is done
+	ctx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer cancel()
+	err := backend.RunAsLeader(ctx, locks, name, time.Second, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	require.NoError(t, err)
+}
@@ -0,0 +1,457 @@
+package backendtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type nosqlItem struct {
+	ID    int64    `bson:"_id"`
+	Name  string   `bson:"name"`
+	Count int64    `bson:"count"`
+	Tags  []string `bson:"tags"`
+}
+
+// Runs a suite of tests against the [backend.NoSQLDatabase] instances returned by newDB.
+//
+// The database returned by newDB may be shared between tests, e.g. a client to a single server.
+// Each test uses a collection named after the test, in the database "backendtest", and deletes
+// the collection's documents before use.  The Transaction test requires a database that supports
+// transactions, e.g. a MongoDB replica set.
+func RunNoSQLSuite(t *testing.T, newDB func(t *testing.T) backend.NoSQLDatabase) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, ctx context.Context, db backend.NoSQLDatabase, coll backend.NoSQLCollection)
+	}{
+		{"InsertFind", testNoSQLInsertFind},
+		{"Projection", testNoSQLProjection},
+		{"Update", testNoSQLUpdate},
+		{"Upsert", testNoSQLUpsert},
+		{"ReplaceDelete", testNoSQLReplaceDelete},
+		{"FindWithOptions", testNoSQLFindWithOptions},
+		{"Aggregate", testNoSQLAggregate},
+		{"UniqueIndex", testNoSQLUniqueIndex},
+		{"Transaction", testNoSQLTransaction},
+		{"ConcurrentUpdates", testNoSQLConcurrentUpdates},
+		{"Watch", testNoSQLWatch},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			db := newDB(t)
+			coll, err := db.GetCollection(ctx, "backendtest", strings.ReplaceAll(t.Name(), "/", "_"))
+			require.NoError(t, err)
+			require.NoError(t, coll.DeleteMany(ctx, bson.D{}))
+			test.test(t, ctx, db, coll)
+		})
+	}
+}
+
+// Inserts items 1 to n, with counts 1 to n and names a, b, c, ...
+func insertNoSQLItems(t *testing.T, ctx context.Context, coll backend.NoSQLCollection, n int) []nosqlItem {
+	var items []nosqlItem
+	var docs []interface{}
+	for i := 1; i <= n; i++ {
+		item := nosqlItem{ID: int64(i), Name: string(rune('a' + i - 1)), Count: int64(i), Tags: []string{}}
+		items = append(items, item)
+		docs = append(docs, item)
+	}
+	require.NoError(t, coll.InsertMany(ctx, docs))
+	return items
+}
+
+// Returns the documents matching filter, ordered by _id
+func findNoSQLItems(t *testing.T, ctx context.Context, coll backend.NoSQLCollection, filter bson.D) []nosqlItem {
+	cursor, err := coll.FindMany(ctx, filter)
+	require.NoError(t, err)
+	var items []nosqlItem
+	require.NoError(t, cursor.All(ctx, &items))
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+	return items
+}
+
+func findNoSQLItem(t *testing.T, ctx context.Context, coll backend.NoSQLCollection, id int64) (nosqlItem, bool) {
+	cursor, err := coll.FindOne(ctx, bson.D{{Key: "_id", Value: id}})
+	require.NoError(t, err)
+	var item nosqlItem
+	found, err := cursor.One(ctx, &item)
+	require.NoError(t, err)
+	return item, found
+}
+
+func testNoSQLInsertFind(t *testing.T, ctx context.Context, db backend.NoSQLDatabase, coll backend.NoSQLCollection) {
+	first := nosqlItem{ID: 1, Name: "a", Count: 1, Tags: []string{"x", "y"}}
+	require.NoError(t, coll.InsertOne(ctx, first))
+	require.NoError(t, coll.InsertMany(ctx, []interface{}{
+		nosqlItem{ID: 2, Name: "b", Count: 2, Tags: []string{"y"}},
+		nosqlItem{ID: 3, Name: "c", Count: 3, Tags: []string{}},
+	}))
+
+	item, found := findNoSQLItem(t, ctx, coll, 1)
+	require.True(t, found)
+	require.Equal(t, first, item)
+
+	_, found = findNoSQLItem(t, ctx, coll, 4)
+	require.False(t, found)
+
+	items := findNoSQLItems(t, ctx, coll, bson.D{{Key: "count", Value: bson.D{{Key: "$gte", Value: 2}}}})
+	require.Len(t, items, 2)
+	require.Equal(t, []int64{2, 3}, []int64{items[0].ID, items[1].ID})
+
+	// Matching a value in an array field
+	items = findNoSQLItems(t, ctx, coll, bson.D{{Key: "tags", Value: "y"}})
+	require.Len(t, items, 2)
+
+	require.Len(t, findNoSQLItems(t, ctx, coll, bson.D{}), 3)
+	require.Empty(t, findNoSQLItems(t, ctx, coll, bson.D{{Key: "name", Value: "z"}}))
+}
+
+func testNoSQLProjection(t *testing.T, ctx context.Context, db backend.NoSQLDatabase, coll backend.NoSQLCollection) {
+	insertNoSQLItems(t, ctx, coll, 2)
+
+	cursor, err := coll.FindOne(ctx, bson.D{{Key: "_id", Value: 2}}, bson.D{{Key: "name", Value: 1}})
+	require.NoError(t, err)
+	var item nosqlItem
+	found, err := cursor.One(ctx, &item)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, nosqlItem{ID: 2, Name: "b"}, item)
+
+	cursor, err = coll.FindMany(ctx, bson.D{}, bson.D{{Key: "count", Value: 0}, {Key: "tags", Value: 0}})
+	require.NoError(t, err)
+	var items []nosqlItem
+	require.NoError(t, cursor.All(ctx, &items))
+	require.Len(t, items, 2)
+	for _, item := range items {
+		require.NotEmpty(t, item.Name)
+		require.Zero(t, item.Count)
+	}
+}
+
+func testNoSQLUpdate(t *testing.T, ctx context.Context, db backend.NoSQLDatabase, coll backend.NoSQLCollection) {
+	insertNoSQLItems(t, ctx, coll, 3)
+
+	updated, err := coll.UpdateOne(ctx, bson.D{{Key: "name", Value: "b"}}, bson.D{{Key: "$set", Value: bson.D{{Key: "count", Value: 10}}}})
+	require.NoError(t, err)
+	require.Equal(t, 1, updated)
+
+	updated, err = coll.UpdateOne(ctx, bson.D{{Key: "name", Value: "z"}}, bson.D{{Key: "$set", Value: bson.D{{Key: "count", Value: 10}}}})
+	require.NoError(t, err)
+	require.Equal(t, 0, updated)
+
+	updated, err = coll.UpdateMany(ctx, bson.D{}, bson.D{
+		{Key: "$inc", Value: bson.D{{Key: "count", Value: 1}}},
+		{Key: "$push", Value: bson.D{{Key: "tags", Value: "updated"}}},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, updated)
+
+	items := findNoSQLItems(t, ctx, coll, bson.D{})
+	require.Equal(t, []int64{2, 11, 4}, []int64{items[0].Count, items[1].Count, items[2].Count})
+	for _, item := range items {
+		require.Equal(t, []string{"updated"}, item.Tags)
+	}
+}
+
+type nosqlObjectIDItem struct {
+	ID   primitive.ObjectID `bson:"_id"`
+	Name string             `bson:"name"`
+}
+
+func testNoSQLUpsert(t *testing.T, ctx context.Context, db backend.NoSQLDatabase, coll backend.NoSQLCollection) {
+	filter := bson.D{{Key: "_id", Value: 1}}
+	updated, err := coll.Upsert(ctx, filter, nosqlItem{ID: 1, Name: "a", Tags: []string{}})
+	require.NoError(t, err)
+	require.False(t, updated, "the document should have been inserted")
+
+	updated, err = coll.Upsert(ctx, filter, nosqlItem{ID: 1, Name: "b", Tags: []string{}})
+	require.NoError(t, err)
+	require.True(t, updated)
+
+	item, found := findNoSQLItem(t, ctx, coll, 1)
+	require.True(t, found)
+	require.Equal(t, "b", item.Name)
+
+	id := primitive.NewObjectID()
+	updated, err = coll.UpsertID(ctx, id, nosqlObjectIDItem{ID: id, Name: "c"})
+	require.NoError(t, err)
+	require.False(t, updated)
+	updated, err = coll.UpsertID(ctx, id, nosqlObjectIDItem{ID: id, Name: "d"})
+	require.NoError(t, err)
+	require.True(t, updated)
+
+	cursor, err := coll.FindOne(ctx, bson.D{{Key: "_id", Value: id}})
+	require.NoError(t, err)
+	var got nosqlObjectIDItem
+	found, err = cursor.One(ctx, &got)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, nosqlObjectIDItem{ID: id, Name: "d"}, got)
+}
+
+func testNoSQLReplaceDelete(t *testing.T, ctx context.Context, db backend.NoSQLDatabase, coll backend.NoSQLCollection) {
+	insertNoSQLItems(t, ctx, coll, 4)
+
+	replaced, err := coll.ReplaceOne(ctx, bson.D{{Key: "_id", Value: 1}}, nosqlItem{ID: 1, Name: "replaced", Tags: []string{}})
+	require.NoError(t, err)
+	require.Equal(t, 1, replaced)
+	replaced, err = coll.ReplaceOne(ctx, bson.D{{Key: "_id", Value: 5}}, nosqlItem{ID: 5, Name: "missing", Tags: []string{}})
+	require.NoError(t, err)
+	require.Equal(t, 0, replaced)
+
+	item, _ := findNoSQLItem(t, ctx, coll, 1)
+	require.Equal(t, nosqlItem{ID: 1, Name: "replaced", Tags: []string{}}, item)
+	_, found := findNoSQLItem(t, ctx, coll, 5)
+	require.False(t, found, "ReplaceOne should not insert")
+
+	require.NoError(t, coll.DeleteOne(ctx, bson.D{{Key: "_id", Value: 2}}))
+	require.NoError(t, coll.DeleteMany(ctx, bson.D{{Key: "count", Value: bson.D{{Key: "$gte", Value: 3}}}}))
+	require.NoError(t, coll.DeleteOne(ctx, bson.D{{Key: "_id", Value: 5}}), "deleting a missing document is not an error")
+
+	items := findNoSQLItems(t, ctx, coll, bson.D{})
+	require.Len(t, items, 1)
+	require.Equal(t, int64(1), items[0].ID)
+}
+
+func testNoSQLFindWithOptions(t *testing.T, ctx context.Context, db backend.NoSQLDatabase, coll backend.NoSQLCollection) {
+	insertNoSQLItems(t, ctx, coll, 5)
+
+	ids := func(opts backend.FindOptions) []int64 {
+		cursor, err := coll.FindManyWithOptions(ctx, bson.D{}, opts)
+		require.NoError(t, err)
+		var items []nosqlItem
+		require.NoError(t, cursor.All(ctx, &items))
+		var ids []int64
+		for _, item := range items {
+			ids = append(ids, item.ID)
+		}
+		return ids
+	}
+
+	sortByCount := bson.D{{Key: "count", Value: -1}}
+	require.Equal(t, []int64{5, 4, 3, 2, 1}, ids(backend.FindOptions{Sort: sortByCount}))
+	require.Equal(t, []int64{4, 3}, ids(backend.FindOptions{Sort: sortByCount, Skip: 1, Limit: 2}))
+
+	// Pages resume after the last document of the previous page
+	opts := backend.FindOptions{Sort: sortByCount, Limit: 2}
+	var pages [][]int64
+	for {
+		cursor, err := coll.FindManyWithOptions(ctx, bson.D{}, opts)
+		require.NoError(t, err)
+		var page []nosqlItem
+		require.NoError(t, cursor.All(ctx, &page))
+		if len(page) == 0 {
+			break
+		}
+		var pageIDs []int64
+		for _, item := range page {
+			pageIDs = append(pageIDs, item.ID)
+		}
+		pages = append(pages, pageIDs)
+		opts.PageToken, err = backend.NextPageToken(opts, page[len(page)-1])
+		require.NoError(t, err)
+	}
+	require.Equal(t, [][]int64{{5, 4}, {3, 2}, {1}}, pages)
+}
+
+func testNoSQLAggregate(t *testing.T, ctx context.Context, db backend.NoSQLDatabase, coll backend.NoSQLCollection) {
+	require.NoError(t, coll.InsertMany(ctx, []interface{}{
+		nosqlItem{ID: 1, Name: "a", Count: 1, Tags: []string{}},
+		nosqlItem{ID: 2, Name: "a", Count: 2, Tags: []string{}},
+		nosqlItem{ID: 3, Name: "b", Count: 4, Tags: []string{}},
+		nosqlItem{ID: 4, Name: "c", Count: 8, Tags: []string{}},
+	}))
+
+	cursor, err := coll.Aggregate(ctx, bson.A{
+		bson.D{{Key: "$match", Value: bson.D{{Key: "count", Value: bson.D{{Key: "$lt", Value: 8}}}}}},
+		bson.D{{Key: "$group", Value: bson.D{{Key: "_id", Value: "$name"}, {Key: "total", Value: bson.D{{Key: "$sum", Value: "$count"}}}}}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}},
+	})
+	require.NoError(t, err)
+	var groups []struct {
+		Name  string `bson:"_id"`
+		Total int64  `bson:"total"`
+	}
+	require.NoError(t, cursor.All(ctx, &groups))
+	require.Len(t, groups, 2)
+	require.Equal(t, "a", groups[0].Name)
+	require.Equal(t, int64(3), groups[0].Total)
+	require.Equal(t, "b", groups[1].Name)
+	require.Equal(t, int64(4), groups[1].Total)
+}
+
+func testNoSQLUniqueIndex(t *testing.T, ctx context.Context, db backend.NoSQLDatabase, coll backend.NoSQLCollection) {
+	require.NoError(t, coll.CreateIndex(ctx, bson.D{{Key: "name", Value: 1}}, true))
+	require.NoError(t, coll.CreateIndex(ctx, bson.D{{Key: "name", Value: 1}}, true), "creating an existing index is not an error")
+	insertNoSQLItems(t, ctx, coll, 2)
+
+	err := coll.InsertOne(ctx, nosqlItem{ID: 3, Name: "a", Tags: []string{}})
+	require.True(t, errors.Is(err, backend.ErrDuplicateKey), "expected ErrDuplicateKey, got %v", err)
+
+	_, err = coll.UpdateOne(ctx, bson.D{{Key: "_id", Value: 2}}, bson.D{{Key: "$set", Value: bson.D{{Key: "name", Value: "a"}}}})
+	require.True(t, errors.Is(err, backend.ErrDuplicateKey), "expected ErrDuplicateKey, got %v", err)
+
+	// Duplicate ids are always rejected
+	err = coll.InsertOne(ctx, nosqlItem{ID: 1, Name: "z", Tags: []string{}})
+	require.True(t, errors.Is(err, backend.ErrDuplicateKey), "expected ErrDuplicateKey, got %v", err)
+
+	require.Len(t, findNoSQLItems(t, ctx, coll, bson.D{}), 2)
+}
+
+func testNoSQLTransaction(t *testing.T, ctx context.Context, db backend.NoSQLDatabase, coll backend.NoSQLCollection) {
+	insertNoSQLItems(t, ctx, coll, 1)
+
+	require.NoError(t, db.WithTransaction(ctx, func(ctx context.Context) error {
+		if _, err := coll.UpdateOne(ctx, bson.D{{Key: "_id", Value: 1}}, bson.D{{Key: "$inc", Value: bson.D{{Key: "count", Value: 1}}}}); err != nil {
+			return err
+		}
+		return coll.InsertOne(ctx, nosqlItem{ID: 2, Name: "b", Tags: []string{}})
+	}))
+	item, _ := findNoSQLItem(t, ctx, coll, 1)
+	require.Equal(t, int64(2), item.Count)
+	_, found := findNoSQLItem(t, ctx, coll, 2)
+	require.True(t, found)
+
+	// Writes are discarded if the transaction fails, including those of a nested transaction
+	abort := errors.New("abort")
+	err := db.WithTransaction(ctx, func(ctx context.Context) error {
+		if _, err := coll.UpdateOne(ctx, bson.D{{Key: "_id", Value: 1}}, bson.D{{Key: "$inc", Value: bson.D{{Key: "count", Value: 1}}}}); err != nil {
+			return err
+		}
+		if err := db.WithTransaction(ctx, func(ctx context.Context) error {
+			return coll.InsertOne(ctx, nosqlItem{ID: 3, Name: "c", Tags: []string{}})
+		}); err != nil {
+			return err
+		}
+		return abort
+	})
+	require.ErrorIs(t, err, abort)
+	item, _ = findNoSQLItem(t, ctx, coll, 1)
+	require.Equal(t, int64(2), item.Count)
+	_, found = findNoSQLItem(t, ctx, coll, 3)
+	require.False(t, found)
+}
+
+// Concurrent updates and inserts should not be lost
+func testNoSQLConcurrentUpdates(t *testing.T, ctx context.Context, db backend.NoSQLDatabase, coll backend.NoSQLCollection) {
+	insertNoSQLItems(t, ctx, coll, 1)
+
+	workers, increments := 8, 20
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for j := 0; j < increments; j++ {
+				if _, err := coll.UpdateOne(ctx, bson.D{{Key: "_id", Value: 1}}, bson.D{{Key: "$inc", Value: bson.D{{Key: "count", Value: 1}}}}); err != nil {
+					errs <- err
+					return
+				}
+			}
+			id := int64(100 + worker)
+			if err := coll.InsertOne(ctx, nosqlItem{ID: id, Name: fmt.Sprintf("worker%d", worker), Tags: []string{}}); err != nil {
+				errs <- err
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	item, _ := findNoSQLItem(t, ctx, coll, 1)
+	require.Equal(t, int64(1+workers*increments), item.Count)
+	require.Len(t, findNoSQLItems(t, ctx, coll, bson.D{}), 1+workers)
+}
+
+// Receives the next change from stream, and checks its type and document id
+func requireNoSQLChange(t *testing.T, ctx context.Context, stream backend.NoSQLChangeStream, changeType backend.NoSQLChangeType, id int64) backend.NoSQLChangeEvent {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	event, received, err := stream.Next(ctx)
+	require.NoError(t, err)
+	require.True(t, received, "expected a %v event for %v", changeType, id)
+	require.Equal(t, changeType, event.Type)
+	require.Equal(t, id, event.DocumentID)
+	return event
+}
+
+func requireNoNoSQLChange(t *testing.T, ctx context.Context, stream backend.NoSQLChangeStream) {
+	ctx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer cancel()
+	event, received, err := stream.Next(ctx)
+	require.NoError(t, err)
+	require.False(t, received, "unexpected %v event for %v", event.Type, event.DocumentID)
+}
+
+func testNoSQLWatch(t *testing.T, ctx context.Context, db backend.NoSQLDatabase, coll backend.NoSQLCollection) {
+	all, err := coll.Watch(ctx, bson.D{})
+	require.NoError(t, err)
+	defer all.Close(ctx)
+	large, err := coll.Watch(ctx, bson.D{{Key: "count", Value: bson.D{{Key: "$gte", Value: 10}}}})
+	require.NoError(t, err)
+	defer large.Close(ctx)
+
+	// The filter is matched against the document after the change
+	require.NoError(t, coll.InsertOne(ctx, nosqlItem{ID: 1, Name: "a", Count: 1, Tags: []string{}}))
+	requireNoSQLChange(t, ctx, all, backend.NoSQLInsert, 1)
+	require.NoError(t, coll.InsertOne(ctx, nosqlItem{ID: 2, Name: "b", Count: 10, Tags: []string{}}))
+	requireNoSQLChange(t, ctx, all, backend.NoSQLInsert, 2)
+	event := requireNoSQLChange(t, ctx, large, backend.NoSQLInsert, 2)
+	var item nosqlItem
+	require.NoError(t, event.Decode(&item))
+	require.Equal(t, nosqlItem{ID: 2, Name: "b", Count: 10, Tags: []string{}}, item)
+
+	_, err = coll.UpdateOne(ctx, bson.D{{Key: "_id", Value: 1}}, bson.D{{Key: "$set", Value: bson.D{{Key: "count", Value: 20}}}})
+	require.NoError(t, err)
+	requireNoSQLChange(t, ctx, all, backend.NoSQLUpdate, 1)
+	event = requireNoSQLChange(t, ctx, large, backend.NoSQLUpdate, 1)
+	require.NoError(t, event.Decode(&item))
+	require.Equal(t, int64(20), item.Count)
+
+	_, err = coll.ReplaceOne(ctx, bson.D{{Key: "_id", Value: 2}}, nosqlItem{ID: 2, Name: "b", Count: 5, Tags: []string{}})
+	require.NoError(t, err)
+	requireNoSQLChange(t, ctx, all, backend.NoSQLReplace, 2)
+
+	// Delete events are received regardless of the filter
+	require.NoError(t, coll.DeleteOne(ctx, bson.D{{Key: "_id", Value: 2}}))
+	event = requireNoSQLChange(t, ctx, all, backend.NoSQLDelete, 2)
+	require.Nil(t, event.Document)
+	require.Error(t, event.Decode(&item))
+	requireNoSQLChange(t, ctx, large, backend.NoSQLDelete, 2)
+
+	// Changes made in a transaction are received once it commits
+	abort := errors.New("abort")
+	err = db.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := coll.InsertOne(ctx, nosqlItem{ID: 3, Name: "c", Count: 30, Tags: []string{}}); err != nil {
+			return err
+		}
+		return abort
+	})
+	require.ErrorIs(t, err, abort)
+	require.NoError(t, db.WithTransaction(ctx, func(ctx context.Context) error {
+		return coll.InsertOne(ctx, nosqlItem{ID: 4, Name: "d", Count: 40, Tags: []string{}})
+	}))
+	requireNoSQLChange(t, ctx, all, backend.NoSQLInsert, 4)
+	requireNoSQLChange(t, ctx, large, backend.NoSQLInsert, 4)
+	requireNoNoSQLChange(t, ctx, all)
+	requireNoNoSQLChange(t, ctx, large)
+
+	// Closed streams receive no further changes
+	require.NoError(t, large.Close(ctx))
+	_, _, err = large.Next(ctx)
+	require.Error(t, err)
+}
@@ -0,0 +1,227 @@
+package backendtest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"github.com/stretchr/testify/require"
+)
+
+type queueItem struct {
+	ID   int64
+	Name string
+}
+
+// Runs a suite of tests against the [backend.Queue] instances returned by newQueue.
+//
+// newQueue is called once per test, and must return an empty queue that is not used by other tests,
+// e.g. a client to a queue named after the test.  The queue must be able to hold at least 10 items.
+// Tests wait up to 10 seconds for items to be delivered.
+func RunQueueSuite(t *testing.T, newQueue func(t *testing.T) backend.Queue) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, ctx context.Context, q backend.Queue)
+	}{
+		{"PushPop", testQueuePushPop},
+		{"Order", testQueueOrder},
+		{"PopTimeout", testQueuePopTimeout},
+		{"AckNack", testQueueAckNack},
+		{"Delay", testQueueDelay},
+		{"ConcurrentPushPop", testQueueConcurrentPushPop},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			test.test(t, ctx, newQueue(t))
+		})
+	}
+}
+
+func pushQueueItem(t *testing.T, ctx context.Context, q backend.Queue, item any, opts backend.PushOptions) {
+	pushed, err := q.PushWithOptions(ctx, item, opts)
+	require.NoError(t, err)
+	require.True(t, pushed)
+}
+
+func receiveQueueItem(t *testing.T, ctx context.Context, q backend.Queue) backend.Delivery {
+	d, received, err := q.Receive(ctx)
+	require.NoError(t, err)
+	require.True(t, received)
+	return d
+}
+
+// Checks that the queue is empty.  Uses a short timeout, so a delayed item may not yet be delivered.
+func requireQueueEmpty(t *testing.T, ctx context.Context, q backend.Queue) {
+	ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	var item any
+	popped, err := q.Pop(ctx, &item)
+	require.NoError(t, err)
+	require.False(t, popped, "expected an empty queue, got %v", item)
+}
+
+func testQueuePushPop(t *testing.T, ctx context.Context, q backend.Queue) {
+	item := queueItem{ID: 5, Name: "Vaastav"}
+	pushed, err := q.Push(ctx, item)
+	require.NoError(t, err)
+	require.True(t, pushed)
+
+	var got queueItem
+	popped, err := q.Pop(ctx, &got)
+	require.NoError(t, err)
+	require.True(t, popped)
+	require.Equal(t, item, got)
+	requireQueueEmpty(t, ctx, q)
+}
+
+// Queues that partition their items only order items with the same key
+func testQueueOrder(t *testing.T, ctx context.Context, q backend.Queue) {
+	for i := int64(0); i < 5; i++ {
+		pushQueueItem(t, ctx, q, queueItem{ID: i}, backend.PushOptions{Key: "order"})
+	}
+	for i := int64(0); i < 5; i++ {
+		var got queueItem
+		popped, err := q.Pop(ctx, &got)
+		require.NoError(t, err)
+		require.True(t, popped)
+		require.Equal(t, i, got.ID)
+	}
+}
+
+func testQueuePopTimeout(t *testing.T, ctx context.Context, q backend.Queue) {
+	// A context timeout is not an error
+	timeoutCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	var got queueItem
+	popped, err := q.Pop(timeoutCtx, &got)
+	require.NoError(t, err)
+	require.False(t, popped)
+
+	_, received, err := q.Receive(timeoutCtx)
+	require.NoError(t, err)
+	require.False(t, received)
+
+	// Items pushed later are delivered to waiting consumers
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		q.Push(ctx, queueItem{ID: 1})
+	}()
+	popped, err = q.Pop(ctx, &got)
+	require.NoError(t, err)
+	require.True(t, popped)
+	require.Equal(t, int64(1), got.ID)
+}
+
+func testQueueAckNack(t *testing.T, ctx context.Context, q backend.Queue) {
+	headers := map[string]string{"trace": "abc"}
+	pushQueueItem(t, ctx, q, queueItem{ID: 1, Name: "first"}, backend.PushOptions{Headers: headers, Key: "acknack"})
+
+	d := receiveQueueItem(t, ctx, q)
+	var got queueItem
+	require.NoError(t, d.Decode(&got))
+	require.Equal(t, queueItem{ID: 1, Name: "first"}, got)
+	require.Equal(t, headers, d.Headers())
+	require.Equal(t, 1, d.DeliveryCount())
+
+	// A nacked item is redelivered
+	require.NoError(t, d.Nack(ctx, true))
+	require.True(t, errors.Is(d.Ack(ctx), backend.ErrDeliveryClosed), "a nacked delivery cannot be acknowledged")
+
+	d = receiveQueueItem(t, ctx, q)
+	require.NoError(t, d.Decode(&got))
+	require.Equal(t, int64(1), got.ID)
+	require.Equal(t, headers, d.Headers())
+	require.Equal(t, 2, d.DeliveryCount())
+
+	// An acknowledged item is removed from the queue
+	require.NoError(t, d.Ack(ctx))
+	require.True(t, errors.Is(d.Ack(ctx), backend.ErrDeliveryClosed), "a delivery can only be acknowledged once")
+	require.True(t, errors.Is(d.Nack(ctx, true), backend.ErrDeliveryClosed), "an acknowledged delivery cannot be nacked")
+	requireQueueEmpty(t, ctx, q)
+}
+
+func testQueueDelay(t *testing.T, ctx context.Context, q backend.Queue) {
+	start := time.Now()
+	pushQueueItem(t, ctx, q, queueItem{ID: 1}, backend.PushOptions{Delay: 500 * time.Millisecond})
+	pushQueueItem(t, ctx, q, queueItem{ID: 2}, backend.PushOptions{})
+
+	// The delayed item does not hold up items behind it
+	var got queueItem
+	popped, err := q.Pop(ctx, &got)
+	require.NoError(t, err)
+	require.True(t, popped)
+	require.Equal(t, int64(2), got.ID)
+
+	popped, err = q.Pop(ctx, &got)
+	require.NoError(t, err)
+	require.True(t, popped)
+	require.Equal(t, int64(1), got.ID)
+	require.GreaterOrEqual(t, time.Since(start), 500*time.Millisecond)
+}
+
+// Each item pushed by concurrent producers should be popped exactly once by concurrent consumers
+func testQueueConcurrentPushPop(t *testing.T, ctx context.Context, q backend.Queue) {
+	producers, consumers, items := 4, 4, 25
+	total := producers * items
+
+	var wg sync.WaitGroup
+	errs := make(chan error, producers+consumers)
+	popped := make(chan int64, 2*total)
+	for i := 0; i < producers; i++ {
+		wg.Add(1)
+		go func(producer int) {
+			defer wg.Done()
+			for j := 0; j < items; j++ {
+				if _, err := q.Push(ctx, queueItem{ID: int64(producer*items + j)}); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}(i)
+	}
+
+	// Consumers stop once all items are popped, or the test times out
+	var count atomic.Int64
+	consumeCtx, stopConsumers := context.WithCancel(ctx)
+	defer stopConsumers()
+	for i := 0; i < consumers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				var got queueItem
+				success, err := q.Pop(consumeCtx, &got)
+				if err != nil {
+					errs <- err
+					return
+				} else if !success {
+					return
+				}
+				popped <- got.ID
+				if count.Add(1) == int64(total) {
+					stopConsumers()
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+	close(popped)
+
+	seen := make(map[int64]bool)
+	for id := range popped {
+		require.False(t, seen[id], "item %v was popped twice", id)
+		seen[id] = true
+	}
+	require.Len(t, seen, total)
+}
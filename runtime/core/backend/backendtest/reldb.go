@@ -0,0 +1,221 @@
+package backendtest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"github.com/stretchr/testify/require"
+)
+
+type relItem struct {
+	ID    int64  `db:"id"`
+	Name  string `db:"name"`
+	Count int64  `db:"count"`
+}
+
+// Runs a suite of tests against the [backend.RelationalDB] instances returned by newDB.
+//
+// The database returned by newDB may be shared between tests, e.g. a client to a single server.
+// Each test drops and recreates a table named backendtest_item.  The queries use SQL that is
+// supported by MySQL, Postgres, and SQLite, with "?" placeholders.
+func RunRelDBSuite(t *testing.T, newDB func(t *testing.T) backend.RelationalDB) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, ctx context.Context, db backend.RelationalDB)
+	}{
+		{"ExecQuery", testRelDBExecQuery},
+		{"SelectGet", testRelDBSelectGet},
+		{"Prepare", testRelDBPrepare},
+		{"Transaction", testRelDBTransaction},
+		{"ConcurrentUpdates", testRelDBConcurrentUpdates},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			db := newDB(t)
+			_, err := db.Exec(ctx, `DROP TABLE IF EXISTS backendtest_item;`)
+			require.NoError(t, err)
+			_, err = db.Exec(ctx, `CREATE TABLE backendtest_item (id INT PRIMARY KEY, name VARCHAR(64) NOT NULL, count INT NOT NULL);`)
+			require.NoError(t, err)
+			test.test(t, ctx, db)
+		})
+	}
+}
+
+// Inserts items 1 to n, with counts 1 to n and names a, b, c, ...
+func insertRelItems(t *testing.T, ctx context.Context, db backend.RelationalDB, n int) {
+	for i := 1; i <= n; i++ {
+		_, err := db.Exec(ctx, `INSERT INTO backendtest_item (id, name, count) VALUES (?, ?, ?);`, i, string(rune('a'+i-1)), i)
+		require.NoError(t, err)
+	}
+}
+
+func relItemCounts(t *testing.T, ctx context.Context, db backend.RelationalDB) []int64 {
+	var counts []int64
+	require.NoError(t, db.Select(ctx, &counts, `SELECT count FROM backendtest_item ORDER BY id;`))
+	return counts
+}
+
+func testRelDBExecQuery(t *testing.T, ctx context.Context, db backend.RelationalDB) {
+	result, err := db.Exec(ctx, `INSERT INTO backendtest_item (id, name, count) VALUES (?, ?, ?), (?, ?, ?);`, 1, "a", 1, 2, "b", 2)
+	require.NoError(t, err)
+	affected, err := result.RowsAffected()
+	require.NoError(t, err)
+	require.Equal(t, int64(2), affected)
+
+	_, err = db.Exec(ctx, `INSERT INTO backendtest_item (id, name, count) VALUES (?, ?, ?);`, 1, "duplicate", 0)
+	require.Error(t, err, "the primary key should be unique")
+
+	result, err = db.Exec(ctx, `UPDATE backendtest_item SET count = count + ? WHERE name <> ?;`, 10, "z")
+	require.NoError(t, err)
+	affected, err = result.RowsAffected()
+	require.NoError(t, err)
+	require.Equal(t, int64(2), affected)
+
+	rows, err := db.Query(ctx, `SELECT name, count FROM backendtest_item WHERE count > ? ORDER BY id DESC;`, 11)
+	require.NoError(t, err)
+	defer rows.Close()
+	var name string
+	var count int64
+	require.True(t, rows.Next())
+	require.NoError(t, rows.Scan(&name, &count))
+	require.Equal(t, "b", name)
+	require.Equal(t, int64(12), count)
+	require.False(t, rows.Next())
+	require.NoError(t, rows.Err())
+}
+
+func testRelDBSelectGet(t *testing.T, ctx context.Context, db backend.RelationalDB) {
+	insertRelItems(t, ctx, db, 3)
+
+	var items []relItem
+	require.NoError(t, db.Select(ctx, &items, `SELECT * FROM backendtest_item WHERE count >= ? ORDER BY id;`, 2))
+	require.Equal(t, []relItem{{ID: 2, Name: "b", Count: 2}, {ID: 3, Name: "c", Count: 3}}, items)
+
+	items = nil
+	require.NoError(t, db.Select(ctx, &items, `SELECT * FROM backendtest_item WHERE count > ?;`, 3))
+	require.Empty(t, items)
+
+	var item relItem
+	require.NoError(t, db.Get(ctx, &item, `SELECT * FROM backendtest_item WHERE name = ?;`, "a"))
+	require.Equal(t, relItem{ID: 1, Name: "a", Count: 1}, item)
+
+	var total int64
+	require.NoError(t, db.Get(ctx, &total, `SELECT SUM(count) FROM backendtest_item;`))
+	require.Equal(t, int64(6), total)
+
+	err := db.Get(ctx, &item, `SELECT * FROM backendtest_item WHERE name = ?;`, "z")
+	require.True(t, errors.Is(err, sql.ErrNoRows), "expected sql.ErrNoRows, got %v", err)
+}
+
+func testRelDBPrepare(t *testing.T, ctx context.Context, db backend.RelationalDB) {
+	insert, err := db.Prepare(ctx, `INSERT INTO backendtest_item (id, name, count) VALUES (?, ?, ?);`)
+	require.NoError(t, err)
+	defer insert.Close()
+	for i := 1; i <= 3; i++ {
+		_, err := insert.ExecContext(ctx, i, "item", i*i)
+		require.NoError(t, err)
+	}
+
+	query, err := db.Prepare(ctx, `SELECT count FROM backendtest_item WHERE id = ?;`)
+	require.NoError(t, err)
+	defer query.Close()
+	var count int64
+	require.NoError(t, query.QueryRowContext(ctx, 3).Scan(&count))
+	require.Equal(t, int64(9), count)
+}
+
+func testRelDBTransaction(t *testing.T, ctx context.Context, db backend.RelationalDB) {
+	insertRelItems(t, ctx, db, 2)
+
+	increment := func(ctx context.Context, id int64) error {
+		_, err := db.Exec(ctx, `UPDATE backendtest_item SET count = count + 1 WHERE id = ?;`, id)
+		return err
+	}
+
+	require.NoError(t, db.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := increment(ctx, 1); err != nil {
+			return err
+		}
+		// Writes within the transaction are visible to its queries
+		var count int64
+		if err := db.Get(ctx, &count, `SELECT count FROM backendtest_item WHERE id = ?;`, 1); err != nil {
+			return err
+		}
+		require.Equal(t, int64(2), count)
+		return increment(ctx, 2)
+	}))
+	require.Equal(t, []int64{2, 3}, relItemCounts(t, ctx, db))
+
+	// Writes are rolled back if the transaction fails, including those of a nested transaction
+	abort := errors.New("abort")
+	err := db.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := increment(ctx, 1); err != nil {
+			return err
+		}
+		if err := db.WithTransaction(ctx, func(ctx context.Context) error {
+			return increment(ctx, 2)
+		}); err != nil {
+			return err
+		}
+		return abort
+	})
+	require.ErrorIs(t, err, abort)
+	require.Equal(t, []int64{2, 3}, relItemCounts(t, ctx, db))
+
+	// Writes are rolled back if the transaction panics, and the panic is propagated
+	require.Panics(t, func() {
+		db.WithTransaction(ctx, func(ctx context.Context) error {
+			if err := increment(ctx, 1); err != nil {
+				return err
+			}
+			panic("abort")
+		})
+	})
+	require.Equal(t, []int64{2, 3}, relItemCounts(t, ctx, db))
+}
+
+// Concurrent updates, within and outside of transactions, should not be lost
+func testRelDBConcurrentUpdates(t *testing.T, ctx context.Context, db backend.RelationalDB) {
+	insertRelItems(t, ctx, db, 1)
+
+	workers, increments := 4, 10
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for j := 0; j < increments; j++ {
+				err := db.WithTransaction(ctx, func(ctx context.Context) error {
+					_, err := db.Exec(ctx, `UPDATE backendtest_item SET count = count + 1 WHERE id = ?;`, 1)
+					return err
+				})
+				if err == nil {
+					_, err = db.Exec(ctx, `UPDATE backendtest_item SET count = count + 1 WHERE id = ?;`, 1)
+				}
+				if err != nil {
+					errs <- err
+					return
+				}
+			}
+			_, err := db.Exec(ctx, `INSERT INTO backendtest_item (id, name, count) VALUES (?, ?, ?);`, 100+worker, "worker", 0)
+			if err != nil {
+				errs <- err
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	counts := relItemCounts(t, ctx, db)
+	require.Len(t, counts, 1+workers)
+	require.Equal(t, int64(1+2*workers*increments), counts[0])
+}
@@ -0,0 +1,163 @@
+package backendtest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"github.com/stretchr/testify/require"
+)
+
+// Runs a suite of tests against the [backend.SearchIndex] instances returned by newIndex.
+//
+// The index returned by newIndex may be shared between tests, e.g. a client to a single server.
+// Every document indexed by a test has a "suite" keyword field with the test name, which the test's
+// queries filter on.  Tests delete their documents before use.
+func RunSearchIndexSuite(t *testing.T, newIndex func(t *testing.T) backend.SearchIndex) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, ctx context.Context, index *suiteIndex)
+	}{
+		{"IndexSearch", testSearchIndexSearch},
+		{"Replace", testSearchReplace},
+		{"Delete", testSearchDelete},
+		{"Filters", testSearchFilters},
+		{"Fields", testSearchFields},
+		{"Limit", testSearchLimit},
+		{"InvalidIndex", testSearchInvalidIndex},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			index := &suiteIndex{t: t, index: newIndex(t), suite: t.Name()}
+			existing, err := index.index.Search(ctx, backend.SearchQuery{Filters: map[string]string{"suite": index.suite}, Limit: 1000})
+			require.NoError(t, err)
+			for _, hit := range existing {
+				require.NoError(t, index.index.Delete(ctx, hit.ID))
+			}
+			test.test(t, ctx, index)
+		})
+	}
+}
+
+// Wraps the index under test, to scope documents and queries to a single test
+type suiteIndex struct {
+	t     *testing.T
+	index backend.SearchIndex
+	suite string
+}
+
+// Indexes a document with the given text fields, and keyword fields given as name, value pairs
+func (s *suiteIndex) add(ctx context.Context, id string, text map[string]string, keywords ...string) {
+	doc := backend.SearchDocument{Text: text, Keywords: map[string]string{"suite": s.suite}}
+	for i := 0; i+1 < len(keywords); i += 2 {
+		doc.Keywords[keywords[i]] = keywords[i+1]
+	}
+	require.NoError(s.t, s.index.Index(ctx, s.id(id), doc))
+}
+
+func (s *suiteIndex) id(id string) string {
+	return s.suite + "/" + id
+}
+
+// Runs query, restricted to the test's documents, and returns the hits
+func (s *suiteIndex) search(ctx context.Context, query backend.SearchQuery) []backend.SearchHit {
+	filters := map[string]string{"suite": s.suite}
+	for k, v := range query.Filters {
+		filters[k] = v
+	}
+	query.Filters = filters
+	hits, err := s.index.Search(ctx, query)
+	require.NoError(s.t, err)
+	return hits
+}
+
+// Runs query and returns the IDs of the hits, without the test's prefix
+func (s *suiteIndex) searchIDs(ctx context.Context, query backend.SearchQuery) []string {
+	var ids []string
+	for _, hit := range s.search(ctx, query) {
+		ids = append(ids, hit.ID[len(s.suite)+1:])
+	}
+	return ids
+}
+
+func testSearchIndexSearch(t *testing.T, ctx context.Context, index *suiteIndex) {
+	index.add(ctx, "socks", map[string]string{"title": "Blue Socks", "description": "Warm wool socks"}, "category", "clothing")
+	index.add(ctx, "hat", map[string]string{"title": "Blue Hat", "description": "A hat for the summer"}, "category", "clothing")
+	index.add(ctx, "green", map[string]string{"title": "Green Hat", "description": "A wool hat"}, "category", "clothing")
+
+	hits := index.search(ctx, backend.SearchQuery{Text: "blue socks"})
+	require.Len(t, hits, 2)
+	require.Equal(t, index.id("socks"), hits[0].ID)
+	require.Equal(t, index.id("hat"), hits[1].ID)
+	require.Greater(t, hits[0].Score, hits[1].Score)
+	require.Equal(t, map[string]string{"title": "Blue Socks", "description": "Warm wool socks"}, hits[0].Document.Text)
+	require.Equal(t, "clothing", hits[0].Document.Keywords["category"])
+
+	// Terms are case-insensitive, and punctuation separates terms
+	require.Equal(t, []string{"socks"}, index.searchIDs(ctx, backend.SearchQuery{Text: "SOCKS!"}))
+	require.ElementsMatch(t, []string{"socks", "green"}, index.searchIDs(ctx, backend.SearchQuery{Text: "wool,"}))
+	require.Empty(t, index.searchIDs(ctx, backend.SearchQuery{Text: "scarf"}))
+}
+
+func testSearchReplace(t *testing.T, ctx context.Context, index *suiteIndex) {
+	index.add(ctx, "doc", map[string]string{"title": "first version"})
+	index.add(ctx, "doc", map[string]string{"title": "second edition"})
+
+	require.Empty(t, index.searchIDs(ctx, backend.SearchQuery{Text: "first"}))
+	require.Equal(t, []string{"doc"}, index.searchIDs(ctx, backend.SearchQuery{Text: "second"}))
+}
+
+func testSearchDelete(t *testing.T, ctx context.Context, index *suiteIndex) {
+	index.add(ctx, "a", map[string]string{"title": "hello world"})
+	index.add(ctx, "b", map[string]string{"title": "hello there"})
+	require.NoError(t, index.index.Delete(ctx, index.id("a")))
+
+	require.Equal(t, []string{"b"}, index.searchIDs(ctx, backend.SearchQuery{Text: "hello"}))
+	require.Empty(t, index.searchIDs(ctx, backend.SearchQuery{Text: "world"}))
+
+	// Deleting a missing document is not an error
+	require.NoError(t, index.index.Delete(ctx, index.id("a")))
+}
+
+func testSearchFilters(t *testing.T, ctx context.Context, index *suiteIndex) {
+	index.add(ctx, "c", map[string]string{"title": "red shirt"}, "category", "clothing", "brand", "acme")
+	index.add(ctx, "a", map[string]string{"title": "red car"}, "category", "toys", "brand", "acme")
+	index.add(ctx, "b", map[string]string{"title": "blue shirt"}, "category", "clothing", "brand", "other")
+
+	require.Equal(t, []string{"c"}, index.searchIDs(ctx, backend.SearchQuery{Text: "red", Filters: map[string]string{"category": "clothing"}}))
+	require.Equal(t, []string{"c"}, index.searchIDs(ctx, backend.SearchQuery{Text: "shirt", Filters: map[string]string{"category": "clothing", "brand": "acme"}}))
+	require.Empty(t, index.searchIDs(ctx, backend.SearchQuery{Text: "red", Filters: map[string]string{"category": "Clothing"}}))
+	require.Empty(t, index.searchIDs(ctx, backend.SearchQuery{Filters: map[string]string{"missing": "value"}}))
+
+	// Without text, every document that matches the filters matches with the same score, ordered by ID
+	require.Equal(t, []string{"a", "b", "c"}, index.searchIDs(ctx, backend.SearchQuery{}))
+	require.Equal(t, []string{"a", "c"}, index.searchIDs(ctx, backend.SearchQuery{Filters: map[string]string{"brand": "acme"}}))
+}
+
+func testSearchFields(t *testing.T, ctx context.Context, index *suiteIndex) {
+	index.add(ctx, "a", map[string]string{"title": "blue socks", "description": "made of cotton"})
+	index.add(ctx, "b", map[string]string{"title": "cotton shirt", "description": "light blue"})
+
+	require.Equal(t, []string{"a"}, index.searchIDs(ctx, backend.SearchQuery{Text: "blue", Fields: []string{"title"}}))
+	require.Equal(t, []string{"b"}, index.searchIDs(ctx, backend.SearchQuery{Text: "blue", Fields: []string{"description"}}))
+	require.ElementsMatch(t, []string{"a", "b"}, index.searchIDs(ctx, backend.SearchQuery{Text: "blue"}))
+	require.Empty(t, index.searchIDs(ctx, backend.SearchQuery{Text: "blue", Fields: []string{"missing"}}))
+}
+
+func testSearchLimit(t *testing.T, ctx context.Context, index *suiteIndex) {
+	for i := 0; i < backend.DefaultSearchLimit+2; i++ {
+		index.add(ctx, fmt.Sprintf("%02d", i), map[string]string{"title": "item"})
+	}
+	require.Len(t, index.searchIDs(ctx, backend.SearchQuery{Text: "item"}), backend.DefaultSearchLimit)
+	require.Equal(t, []string{"00", "01", "02"}, index.searchIDs(ctx, backend.SearchQuery{Limit: 3}))
+}
+
+func testSearchInvalidIndex(t *testing.T, ctx context.Context, index *suiteIndex) {
+	require.Error(t, index.index.Index(ctx, "", backend.SearchDocument{Text: map[string]string{"title": "hello"}}))
+	require.Error(t, index.index.Index(ctx, index.id("doc"), backend.SearchDocument{Text: map[string]string{"a.b": "hello"}}))
+	require.Error(t, index.index.Index(ctx, index.id("doc"), backend.SearchDocument{Keywords: map[string]string{"": "value"}}))
+
+	require.Empty(t, index.searchIDs(ctx, backend.SearchQuery{}))
+}
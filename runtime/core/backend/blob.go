@@ -0,0 +1,89 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// A BlobStore backend is used for storing binary objects, such as images and media files.
+//
+// Objects are identified by keys, which are typically paths separated by "/", e.g. "media/user1/avatar.png".
+// Each object also has a content type and a set of metadata key-value pairs.
+type BlobStore interface {
+	// Stores the contents of data as the object key, replacing any existing object with that key.
+	Put(ctx context.Context, key string, data io.Reader, opts BlobOptions) error
+
+	// Returns the contents of the object key and its info.  The caller must close the returned reader.
+	//
+	// Returns [ErrBlobNotFound] if the object does not exist.
+	Get(ctx context.Context, key string) (io.ReadCloser, BlobInfo, error)
+
+	// Deletes the object key.  Deleting an object that does not exist is not an error.
+	Delete(ctx context.Context, key string) error
+
+	// Returns the info of the objects whose keys start with prefix, sorted by key.  If prefix
+	// is empty, returns all objects.
+	List(ctx context.Context, prefix string) ([]BlobInfo, error)
+}
+
+// Options for storing an object with [BlobStore.Put]
+type BlobOptions struct {
+	// The MIME type of the object, e.g. "image/png".  Defaults to "application/octet-stream".
+	ContentType string
+
+	// User-defined metadata of the object.  Keys are case-insensitive, and are returned in
+	// lower case.  See [ValidateBlobMetadata] for the keys and values that are allowed.
+	Metadata map[string]string
+}
+
+// Information about an object in a [BlobStore]
+type BlobInfo struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	Metadata     map[string]string // Nil if the info was returned by List
+	LastModified time.Time
+}
+
+// Returned by [BlobStore.Get] if the object does not exist
+var ErrBlobNotFound = errors.New("blob not found")
+
+// The content type of objects stored without one
+const DefaultBlobContentType = "application/octet-stream"
+
+// Checks that key is a valid [BlobStore] key.  Keys must be non-empty and at most 1024 bytes.
+// Implementations of [BlobStore] should call ValidateBlobKey in Put.
+func ValidateBlobKey(key string) error {
+	if key == "" || len(key) > 1024 {
+		return fmt.Errorf("invalid blob key %q; keys must be 1 to 1024 bytes", key)
+	}
+	return nil
+}
+
+// Checks that metadata is valid [BlobStore] metadata, and returns a copy with lower case keys.
+// Implementations of [BlobStore] should call ValidateBlobMetadata in Put.
+//
+// Keys may contain letters, digits, and '-'.  Values may contain printable ASCII characters.
+// These restrictions ensure that metadata can be stored as HTTP headers by S3-compatible stores.
+func ValidateBlobMetadata(metadata map[string]string) (map[string]string, error) {
+	if len(metadata) == 0 {
+		return nil, nil
+	}
+	normalized := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		if k == "" || strings.IndexFunc(k, func(c rune) bool {
+			return !(c == '-' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'))
+		}) >= 0 {
+			return nil, fmt.Errorf("invalid blob metadata key %q", k)
+		}
+		if strings.IndexFunc(v, func(c rune) bool { return c < ' ' || c > '~' }) >= 0 {
+			return nil, fmt.Errorf("invalid value for blob metadata key %q", k)
+		}
+		normalized[strings.ToLower(k)] = v
+	}
+	return normalized, nil
+}
@@ -1,6 +1,9 @@
 package backend
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // Represents a key-value cache.
 type Cache interface {
@@ -34,4 +37,29 @@ type Cache interface {
 
 	// Treats the value mapped to key as an integer, and increments it
 	Incr(ctx context.Context, key string) (int64, error)
+
+	// Store a key-value pair in the cache that expires after ttl.
+	// If ttl is zero, the value does not expire.  ttl must not be negative.
+	//
+	// Put and Mset store values that do not expire, replacing any existing ttl.  Implementations
+	// might round ttl up to their supported granularity, e.g. memcached uses whole seconds.
+	PutWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) error
+
+	// Sets the ttl of an existing key, after which it expires.
+	// If ttl is zero, the key no longer expires.
+	//
+	// Reports whether the key existed in the cache
+	Expire(ctx context.Context, key string, ttl time.Duration) (bool, error)
+
+	// Stores a key-value pair in the cache only if the key does not already exist.
+	// If ttl is nonzero, the value expires after ttl.
+	//
+	// Reports whether the value was stored
+	SetNX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error)
+
+	// Atomically replaces the value mapped to key with new, only if the current value is equal to old.
+	// Values are equal if they have the same JSON encoding.
+	//
+	// Reports whether the value was replaced; false if the key does not exist or its value differs from old.
+	CompareAndSwap(ctx context.Context, key string, old interface{}, new interface{}) (bool, error)
 }
@@ -0,0 +1,172 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/exp/slog"
+)
+
+// A LockService backend provides leases on named locks, that are used to coordinate the replicas of a service,
+// e.g. to elect a single replica to run a background task.
+//
+// A lock is held by at most one lease at a time.  A lease expires after its ttl unless it is renewed, so that
+// the lock is freed if the process holding it crashes.  Leases are identified by their token, so a process that
+// renews or releases a lease that has expired does not affect the lease of a process that since acquired the lock.
+//
+// Most services will not use a LockService directly, and instead use [RunAsLeader] to run a task on one replica.
+type LockService interface {
+	// Acquires the lock name, with a lease that expires after ttl.
+	//
+	// Returns [ErrLockHeld] if the lock is held by another lease.
+	TryAcquire(ctx context.Context, name string, ttl time.Duration) (Lease, error)
+
+	// Extends lease so that it expires ttl from now, and returns the renewed lease.
+	//
+	// Returns [ErrLeaseLost] if the lease has expired or been released.
+	Renew(ctx context.Context, lease Lease, ttl time.Duration) (Lease, error)
+
+	// Releases lease, freeing the lock.  Releasing a lease that has expired or been released is not an error.
+	Release(ctx context.Context, lease Lease) error
+}
+
+// A lease on a lock from a [LockService]
+type Lease struct {
+	Name    string    // The name of the lock
+	Token   string    // Identifies the lease; unique for every acquisition of a lock
+	Expires time.Time // When the lease expires, according to the local clock, unless it is renewed
+}
+
+// Returned by [LockService.TryAcquire] if the lock is held by another lease
+var ErrLockHeld = errors.New("lock is held by another lease")
+
+// Returned by [LockService.Renew] if the lease has expired or been released
+var ErrLeaseLost = errors.New("lease has expired or been released")
+
+// Checks that name and ttl are a valid [LockService] lock name and lease ttl.  Names must be non-empty, and
+// ttls must be at least a millisecond.  Implementations of [LockService] should call ValidateLock in
+// TryAcquire and Renew.
+func ValidateLock(name string, ttl time.Duration) error {
+	if name == "" {
+		return fmt.Errorf("invalid lock name %q", name)
+	}
+	if ttl < time.Millisecond {
+		return fmt.Errorf("invalid lease ttl %v for lock %v; ttls must be at least 1ms", ttl, name)
+	}
+	return nil
+}
+
+// Returns how long to wait before retrying to acquire a lock with the given ttl
+func lockRetryInterval(ttl time.Duration) time.Duration {
+	interval := ttl / 4
+	if interval < 10*time.Millisecond {
+		return 10 * time.Millisecond
+	} else if interval > time.Second {
+		return time.Second
+	}
+	return interval
+}
+
+// Acquires the lock name with a lease that expires after ttl, waiting until the lock is free.
+//
+// Returns an error if ctx is done before the lock is acquired, or if locks returns an error other than
+// [ErrLockHeld].
+func AcquireLock(ctx context.Context, locks LockService, name string, ttl time.Duration) (Lease, error) {
+	for {
+		lease, err := locks.TryAcquire(ctx, name, ttl)
+		if !errors.Is(err, ErrLockHeld) {
+			return lease, err
+		}
+		select {
+		case <-ctx.Done():
+			return Lease{}, ctx.Err()
+		case <-time.After(lockRetryInterval(ttl)):
+		}
+	}
+}
+
+// Elects a leader among the callers of RunAsLeader for the lock name, e.g. the replicas of a service, and
+// calls run on the leader.  RunAsLeader is typically called from the Run method of a service:
+//
+//	func (s *myServiceImpl) Run(ctx context.Context) error {
+//		return backend.RunAsLeader(ctx, s.locks, "my_service.worker", 10*time.Second, s.runWorker)
+//	}
+//
+// The leader holds a lease on the lock, which it renews every ttl/3.  If the lease cannot be renewed before
+// it expires, the context passed to run is cancelled, and once run returns, RunAsLeader waits to be elected
+// again.  Other callers wait until the lock is free.  Errors from locks are logged and retried.
+//
+// RunAsLeader returns nil once ctx is done.  If run returns while the caller is still the leader, RunAsLeader
+// releases the lock and returns the result of run.
+func RunAsLeader(ctx context.Context, locks LockService, name string, ttl time.Duration, run func(ctx context.Context) error) error {
+	if err := ValidateLock(name, ttl); err != nil {
+		return err
+	}
+	for {
+		lease, err := AcquireLock(ctx, locks, name, ttl)
+		if ctx.Err() != nil {
+			return nil
+		} else if err != nil {
+			slog.Error(fmt.Sprintf("Unable to acquire lock %v due to %v; retrying", name, err))
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(lockRetryInterval(ttl)):
+			}
+			continue
+		}
+
+		runCtx, cancel := context.WithCancel(ctx)
+		renewed := make(chan Lease)
+		go func() {
+			renewed <- renewLease(runCtx, cancel, locks, lease, ttl)
+		}()
+		err = run(runCtx)
+		lost := runCtx.Err() != nil && ctx.Err() == nil
+		cancel()
+		lease = <-renewed
+
+		// Release the lock even if ctx is done, so that another caller can be elected without waiting for it to expire
+		if err := locks.Release(context.WithoutCancel(ctx), lease); err != nil {
+			slog.Error(fmt.Sprintf("Unable to release lock %v due to %v", name, err))
+		}
+		if ctx.Err() != nil {
+			return nil
+		} else if !lost {
+			return err
+		}
+		slog.Warn(fmt.Sprintf("Lost lock %v; waiting to be elected again", name))
+	}
+}
+
+// Renews lease every ttl/3 until ctx is done, and returns the last renewed lease.  Calls cancel if the lease
+// is lost, or once it expires without having been renewed; each renewal must complete before the lease expires.
+func renewLease(ctx context.Context, cancel context.CancelFunc, locks LockService, lease Lease, ttl time.Duration) Lease {
+	expiry := time.AfterFunc(time.Until(lease.Expires), cancel)
+	defer expiry.Stop()
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return lease
+		case <-ticker.C:
+		}
+		renewCtx, cancelRenew := context.WithDeadline(ctx, lease.Expires)
+		renewed, err := locks.Renew(renewCtx, lease, ttl)
+		cancelRenew()
+		if err == nil {
+			lease = renewed
+			expiry.Reset(time.Until(lease.Expires))
+		} else if ctx.Err() != nil {
+			return lease
+		} else if errors.Is(err, ErrLeaseLost) || !time.Now().Before(lease.Expires) {
+			cancel()
+			return lease
+		} else {
+			slog.Error(fmt.Sprintf("Unable to renew lock %v due to %v; retrying", lease.Name, err))
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package backend_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/simplelock"
+	"github.com/stretchr/testify/require"
+)
+
+// A lock service whose renewals hang until their context is done
+type hangingLockService struct {
+	*simplelock.SimpleLockService
+}
+
+func (s hangingLockService) Renew(ctx context.Context, lease backend.Lease, ttl time.Duration) (backend.Lease, error) {
+	<-ctx.Done()
+	return backend.Lease{}, ctx.Err()
+}
+
+func TestRunAsLeaderCancelsOnExpiry(t *testing.T) {
+	simple, err := simplelock.NewSimpleLockService(context.Background())
+	require.NoError(t, err)
+	locks := hangingLockService{simple}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ttl := 300 * time.Millisecond
+	elections := 0
+	err = backend.RunAsLeader(ctx, locks, "leader", ttl, func(runCtx context.Context) error {
+		elections++
+		if elections > 1 {
+			cancel()
+			return nil
+		}
+		select {
+		case <-runCtx.Done():
+		case <-time.After(2 * ttl):
+			cancel()
+			return context.DeadlineExceeded
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, elections, "the leader's context should be cancelled once its lease expires")
+}
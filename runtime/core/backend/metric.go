@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 
+	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/metric"
 	"golang.org/x/exp/slog"
 )
@@ -55,3 +56,13 @@ func Meter(ctx context.Context, name string, opts ...metric.MeterOption) (metric
 	}
 	return mp.Meter(name, opts...), nil
 }
+
+// MeterOrGlobal is like [Meter], but if no metric collector has been registered (yet), it returns a
+// meter of the global OpenTelemetry meter provider, which forwards to the collector once it is.
+func MeterOrGlobal(ctx context.Context, name string, opts ...metric.MeterOption) metric.Meter {
+	meter, err := Meter(ctx, name, opts...)
+	if err != nil {
+		return otel.GetMeterProvider().Meter(name, opts...)
+	}
+	return meter
+}
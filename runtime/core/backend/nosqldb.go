@@ -2,6 +2,8 @@ package backend
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -13,6 +15,17 @@ type NoSQLDatabase interface {
 		or might not have those concepts.
 	*/
 	GetCollection(ctx context.Context, db_name string, collection_name string) (NoSQLCollection, error)
+
+	// Runs fn within a multi-document transaction.
+	//
+	// Collection operations that use the context passed to fn are part of the transaction.  If fn
+	// returns nil, the transaction is committed.  If fn returns an error, the transaction is aborted,
+	// none of its writes are applied, and the error is returned.  A nested call to WithTransaction,
+	// using the context passed to fn, joins the enclosing transaction.
+	//
+	// Implementations may retry fn if the transaction fails with a transient error, so fn should
+	// not have side effects other than its database operations.
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
 }
 
 type NoSQLCursor interface {
@@ -63,6 +76,31 @@ type NoSQLCollection interface {
 	// Projections are optional and behave with mongodb semantics.
 	FindMany(ctx context.Context, filter bson.D, projection ...bson.D) (NoSQLCursor, error) // Result is not a slice -> it is an object we can use to retrieve documents using res.All().
 
+	// Finds all documents that match the filter, sorted, skipped, limited, and paginated according to opts.
+	//
+	// We use the same filter semantics as mongodb
+	// https://www.mongodb.com/docs/manual/tutorial/query-documents/
+	//
+	// See [FindOptions] for the supported options.
+	FindManyWithOptions(ctx context.Context, filter bson.D, opts FindOptions) (NoSQLCursor, error)
+
+	// Runs an aggregation pipeline over the documents in the collection.
+	//
+	// We use the same pipeline semantics as mongodb
+	// https://www.mongodb.com/docs/manual/core/aggregation-pipeline/
+	//
+	// Each pipeline stage is a bson.D, e.g.
+	//
+	//   bson.A{
+	//     bson.D{{"$match", bson.D{{"status", "paid"}}}},
+	//     bson.D{{"$group", bson.D{{"_id", "$account"}, {"total", bson.D{{"$sum", "$amount"}}}}}},
+	//   }
+	//
+	// Implementations support at least the $match, $group (with $sum, $avg, $min, $max, and $push),
+	// $sort, $skip, $limit, $project, $unwind, and $lookup stages.  $lookup can join with other
+	// collections in the same database.
+	Aggregate(ctx context.Context, pipeline bson.A) (NoSQLCursor, error)
+
 	// Applies the provided update to the first document that matches filter
 	//
 	// We use the same filter semantics as mongodb
@@ -116,4 +154,103 @@ type NoSQLCollection interface {
 	//
 	// Returns the number of replaced documents.
 	ReplaceMany(ctx context.Context, filter bson.D, replacements ...interface{}) (int, error)
+
+	// Creates an index on the specified keys, if it does not already exist.
+	//
+	// keys is a sequence of field names with direction 1 (ascending) or -1 (descending), e.g.
+	//
+	//   bson.D{{"username", 1}}
+	//
+	// Nested fields can be selected using dot notation.  If unique is true, then subsequent operations
+	// that would result in two documents with the same values for keys fail with a [DuplicateKeyError],
+	// as does creating the index if the collection already contains such documents.
+	CreateIndex(ctx context.Context, keys bson.D, unique bool) error
+
+	// Watches the collection for changes to documents that match filter, e.g. to invalidate a cache or to
+	// maintain a read model.  The returned stream receives the changes made after Watch returns, in the order
+	// they were made, until it is closed.
+	//
+	// We use the same filter semantics as mongodb
+	// https://www.mongodb.com/docs/manual/tutorial/query-documents/
+	//
+	// The filter is matched against the document after the change.  Deleted documents cannot be matched,
+	// so the stream receives every delete event, regardless of filter.  Changes made in a transaction are
+	// received once the transaction commits, and not at all if it is aborted.
+	Watch(ctx context.Context, filter bson.D) (NoSQLChangeStream, error)
+}
+
+// The kind of change in a [NoSQLChangeEvent]
+type NoSQLChangeType string
+
+const (
+	NoSQLInsert  NoSQLChangeType = "insert"
+	NoSQLUpdate  NoSQLChangeType = "update"
+	NoSQLReplace NoSQLChangeType = "replace"
+	NoSQLDelete  NoSQLChangeType = "delete"
+)
+
+// A change to a document, received from a [NoSQLChangeStream]
+type NoSQLChangeEvent struct {
+	Type NoSQLChangeType
+
+	// The "_id" of the changed document
+	DocumentID any
+
+	// The document after the change; nil for delete events.
+	//
+	// An implementation may look up the document some time after an update, in which case Document
+	// can include subsequent changes, or be nil if the document has since been deleted.
+	Document bson.Raw
+}
+
+// Copies the document after the change into obj, which must be a pointer type.
+// Returns an error if the event has no document, e.g. if it is a delete event.
+func (e NoSQLChangeEvent) Decode(obj interface{}) error {
+	if e.Document == nil {
+		return fmt.Errorf("no document for %v event of %v", e.Type, e.DocumentID)
+	}
+	return bson.Unmarshal(e.Document, obj)
+}
+
+// A stream of changes to a [NoSQLCollection], returned by [NoSQLCollection.Watch].
+//
+// A stream must not be used concurrently.
+type NoSQLChangeStream interface {
+	// Receives the next change.
+	//
+	// This call will block until there is a change, or until the context is cancelled.
+	//
+	// Reports whether a change was received, or if an error was encountered.
+	// A context cancellation/timeout is not considered an error.
+	Next(ctx context.Context) (NoSQLChangeEvent, bool, error)
+
+	// Stops watching the collection.  Subsequent calls to Next return an error.
+	Close(ctx context.Context) error
+}
+
+// ErrDuplicateKey is matched by [errors.Is] for any [DuplicateKeyError].
+var ErrDuplicateKey = errors.New("duplicate key")
+
+// Returned by [NoSQLCollection] operations that would violate a unique index.
+type DuplicateKeyError struct {
+	// Name of the violated index, if known
+	Index string
+
+	// The underlying error reported by the database, if any
+	Err error
+}
+
+func (e *DuplicateKeyError) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("duplicate key error for index %v", e.Index)
+}
+
+func (e *DuplicateKeyError) Unwrap() error {
+	return e.Err
+}
+
+func (e *DuplicateKeyError) Is(target error) bool {
+	return target == ErrDuplicateKey
 }
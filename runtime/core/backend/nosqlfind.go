@@ -0,0 +1,167 @@
+package backend
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Options for [NoSQLCollection.FindManyWithOptions]
+type FindOptions struct {
+	// Optional projection; behaves with mongodb semantics.
+	Projection bson.D
+
+	// Optional sort order, as a sequence of keys with direction 1 (ascending) or -1 (descending), e.g.
+	//
+	//   bson.D{{"price", 1}, {"name", -1}}
+	//
+	// Nested fields can be selected using dot notation.  Documents with equal sort keys are ordered by "_id".
+	Sort bson.D
+
+	// Number of matching documents to skip before returning results.
+	Skip int64
+
+	// Maximum number of documents to return; zero means no limit.
+	Limit int64
+
+	// Optional token returned by [NextPageToken] for the last document of the previous page.
+	// If set, only documents that come after that document in the sort order are returned.
+	//
+	// Cursor-based pagination using PageToken is stable when documents are inserted or deleted
+	// between pages, unlike pagination using Skip.
+	PageToken string
+}
+
+// Returns the sort order used for a query with opts, with directions normalized to 1 or -1.
+//
+// The sort order is opts.Sort followed by "_id".  If opts.Sort is empty and the query is not paginated,
+// i.e. neither opts.Limit nor opts.PageToken is set, then the sort order is empty and documents are
+// returned in their natural order.
+func (opts FindOptions) SortKeys() (bson.D, error) {
+	if len(opts.Sort) == 0 && opts.Limit == 0 && opts.PageToken == "" {
+		return nil, nil
+	}
+	keys, err := NormalizeKeys(opts.Sort)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		if key.Key == "_id" {
+			return keys, nil
+		}
+	}
+	return append(keys, bson.E{Key: "_id", Value: int32(1)}), nil
+}
+
+// Returns a copy of keys, a sequence of sort or index keys, with directions normalized to 1 or -1.
+// Returns an error if any key has a direction other than 1 or -1.
+func NormalizeKeys(keys bson.D) (bson.D, error) {
+	normalized := make(bson.D, 0, len(keys)+1)
+	for _, e := range keys {
+		var direction int32
+		switch v := e.Value.(type) {
+		case int:
+			direction = int32(v)
+		case int32:
+			direction = v
+		case int64:
+			direction = int32(v)
+		case float64:
+			direction = int32(v)
+		}
+		if direction != 1 && direction != -1 {
+			return nil, fmt.Errorf("invalid direction %v for key %v; expected 1 or -1", e.Value, e.Key)
+		}
+		normalized = append(normalized, bson.E{Key: e.Key, Value: direction})
+	}
+	return normalized, nil
+}
+
+// Computes the page token that resumes a query with opts after document.
+// document is typically the last result of the previous page, and must contain the sort keys of opts.
+func NextPageToken(opts FindOptions, document any) (string, error) {
+	keys, err := opts.SortKeys()
+	if err != nil {
+		return "", err
+	}
+	if len(keys) == 0 {
+		keys = bson.D{{Key: "_id", Value: int32(1)}}
+	}
+	raw, err := bson.Marshal(document)
+	if err != nil {
+		return "", err
+	}
+	var values bson.D
+	for _, key := range keys {
+		var value any
+		if rv, err := bson.Raw(raw).LookupErr(strings.Split(key.Key, ".")...); err == nil {
+			if err := rv.Unmarshal(&value); err != nil {
+				return "", err
+			}
+		}
+		values = append(values, bson.E{Key: key.Key, Value: value})
+	}
+	encoded, err := bson.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(encoded), nil
+}
+
+// Decodes a page token returned by [NextPageToken] into the sort key values of the document
+// after which the page resumes.
+func DecodePageToken(token string) (bson.D, error) {
+	encoded, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid page token %v: %w", token, err)
+	}
+	var values bson.D
+	if err := bson.Unmarshal(encoded, &values); err != nil {
+		return nil, fmt.Errorf("invalid page token %v: %w", token, err)
+	}
+	return values, nil
+}
+
+// Returns a filter that matches the documents matched by filter that come after opts.PageToken in the
+// sort order of opts.  If opts.PageToken is not set, filter is returned unmodified.
+//
+// The returned filter uses mongodb comparison operators on the sort keys.
+func (opts FindOptions) PageFilter(filter bson.D) (bson.D, error) {
+	if opts.PageToken == "" {
+		return filter, nil
+	}
+	keys, err := opts.SortKeys()
+	if err != nil {
+		return nil, err
+	}
+	after, err := DecodePageToken(opts.PageToken)
+	if err != nil {
+		return nil, err
+	}
+	if len(after) != len(keys) {
+		return nil, fmt.Errorf("page token does not match sort order %v", keys)
+	}
+
+	// Documents that are equal on the first i sort keys and come after the token on the i+1th key
+	var clauses bson.A
+	for i, key := range keys {
+		if after[i].Key != key.Key {
+			return nil, fmt.Errorf("page token does not match sort order %v", keys)
+		}
+		clause := make(bson.D, 0, i+1)
+		clause = append(clause, after[:i]...)
+		op := "$gt"
+		if key.Value == int32(-1) {
+			op = "$lt"
+		}
+		clause = append(clause, bson.E{Key: key.Key, Value: bson.D{{Key: op, Value: after[i].Value}}})
+		clauses = append(clauses, clause)
+	}
+	page := bson.D{{Key: "$or", Value: clauses}}
+	if len(filter) == 0 {
+		return page, nil
+	}
+	return bson.D{{Key: "$and", Value: bson.A{filter, page}}}, nil
+}
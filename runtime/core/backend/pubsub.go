@@ -0,0 +1,67 @@
+package backend
+
+import (
+	"context"
+	"strings"
+)
+
+// A PubSub backend is used for publishing items to topics, and subscribing to topics.
+//
+// Unlike a [Queue], each item is delivered to every subscriber group whose subscriptions match
+// the item's topic.  Within a subscriber group, each item is delivered to only one subscription,
+// so the members of a group share the group's items.
+//
+// Topics are sequences of words separated by dots, e.g. "post.created".  Subscriptions use topic
+// patterns, in which "*" matches exactly one word and "#" matches zero or more words, e.g. "post.*".
+type PubSub interface {
+	// Publishes an item to topic.
+	//
+	// The item is delivered to each subscriber group with a subscription that matches topic.
+	// Items published to a topic without any matching subscriber groups are discarded.
+	Publish(ctx context.Context, topic string, item interface{}) error
+
+	// Subscribes to the topics matching pattern as a member of group.
+	//
+	// Subscriber groups are created by their first subscription.  Once created, a group
+	// accumulates items published to the topics matched by any of its members' patterns, even
+	// while none of its members are receiving items.  Each item is delivered to a group once,
+	// even if it matches more than one of the group's patterns.
+	//
+	// If group is empty, the subscription is an anonymous group of its own, that only receives
+	// items published while the subscription is open.
+	Subscribe(ctx context.Context, pattern string, group string) (Subscription, error)
+}
+
+// A Subscription to a [PubSub] topic pattern, created by [PubSub.Subscribe].
+type Subscription interface {
+	// Receives an item for at-least-once processing, with the same semantics as [Queue.Receive].
+	Receive(ctx context.Context) (Delivery, bool, error)
+
+	// Closes the subscription.  The subscriber group continues to accumulate items, unless the
+	// subscription was anonymous.
+	Close(ctx context.Context) error
+}
+
+// Reports whether topic matches pattern, in which "*" matches exactly one word and "#" matches zero
+// or more words.  Implementations of [PubSub] that match topics client-side can use MatchTopic.
+func MatchTopic(pattern string, topic string) bool {
+	return matchWords(strings.Split(pattern, "."), strings.Split(topic, "."))
+}
+
+func matchWords(pattern []string, words []string) bool {
+	if len(pattern) == 0 {
+		return len(words) == 0
+	}
+	if pattern[0] == "#" {
+		for i := 0; i <= len(words); i++ {
+			if matchWords(pattern[1:], words[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(words) == 0 || (pattern[0] != "*" && pattern[0] != words[0]) {
+		return false
+	}
+	return matchWords(pattern[1:], words[1:])
+}
@@ -2,6 +2,8 @@ package backend
 
 import (
 	"context"
+	"errors"
+	"time"
 )
 
 // A Queue backend is used for pushing and popping elements.
@@ -23,7 +25,68 @@ type Queue interface {
 	//
 	// dst must be a pointer type that can receive the item popped from the queue.
 	//
+	// The item is removed from the queue as soon as it is popped, so it is lost if the caller
+	// fails before processing it.  Use Receive for at-least-once processing.
+	//
 	// Reports whether the item was pushed to the queue, or if an error was encountered.
 	// A context cancellation/timeout is not considered an error.
 	Pop(ctx context.Context, dst interface{}) (bool, error)
+
+	// Pushes an item to the tail of the queue, with headers and an optional delay.
+	// See [PushOptions].
+	//
+	// Blocks and reports the same as Push.
+	PushWithOptions(ctx context.Context, item interface{}, opts PushOptions) (bool, error)
+
+	// Receives an item from the front of the queue for at-least-once processing.
+	//
+	// This call will block until an item is received, or until the context is cancelled.
+	//
+	// The item remains in the queue until the returned [Delivery] is acknowledged.  If the delivery
+	// is negatively acknowledged, or the consumer fails before acknowledging it, the item is
+	// redelivered.  Queues may limit the number of deliveries of an item, after which it is moved to
+	// a dead-letter queue.
+	//
+	// Reports whether an item was received, or if an error was encountered.
+	// A context cancellation/timeout is not considered an error.
+	Receive(ctx context.Context) (Delivery, bool, error)
+}
+
+// Options for pushing an item with [Queue.PushWithOptions]
+type PushOptions struct {
+	// Headers carried alongside the item, e.g. for propagating tracing context to consumers.
+	Headers map[string]string
+
+	// If nonzero, the item is not delivered to consumers until Delay has elapsed.
+	Delay time.Duration
+
+	// Items pushed with the same non-empty Key are delivered in the order they were pushed, by queues
+	// that partition their items (e.g. Kafka).  Queues that deliver all of their items in order ignore Key.
+	Key string
+}
+
+// An item received from a [Queue] with [Queue.Receive], that must be acknowledged once processed.
+type Delivery interface {
+	// Copies the item into dst, which must be a pointer type that can receive the item.
+	Decode(dst interface{}) error
+
+	// The headers that the item was pushed with
+	Headers() map[string]string
+
+	// The number of times the item has been delivered, including this delivery
+	DeliveryCount() int
+
+	// Acknowledges that the item has been processed, removing it from the queue.
+	Ack(ctx context.Context) error
+
+	// Negatively acknowledges the item.
+	//
+	// If requeue is true, the item is redelivered, unless it has reached the queue's delivery
+	// limit.  Otherwise, the item is moved to the queue's dead-letter queue, or discarded if the
+	// queue doesn't have one.
+	Nack(ctx context.Context, requeue bool) error
 }
+
+// Returned by [Delivery.Ack] and [Delivery.Nack] if the delivery was already acknowledged, or if
+// the queue has since redelivered the item to another consumer.
+var ErrDeliveryClosed = errors.New("delivery already acknowledged or expired")
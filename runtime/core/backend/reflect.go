@@ -16,13 +16,19 @@ func GetPointerValue(val any) (any, error) {
 /*
 Lots of APIs want to copy results into interfaces.  This is a helper method to do so.
 
-src can be anything; dst must be a pointer to the same type as src
+src can be anything; dst must be a pointer to the same type as src.
+
+If dst is a pointer to a typed wrapper such as [TypedCache], then src is wrapped, e.g. a [Cache]
+can be copied to a *TypedCache[V].
 */
 func CopyResult(src any, dst any) error {
 	dst_ptr := reflect.ValueOf(dst)
 	if dst_ptr.Kind() != reflect.Pointer || dst_ptr.IsNil() {
 		return fmt.Errorf("unable to copy result to type %v", reflect.TypeOf(dst))
 	}
+	if wrapper, isWrapper := dst.(typedWrapper); isWrapper {
+		return wrapper.wrap(src)
+	}
 	dst_val := reflect.Indirect(dst_ptr)
 	src_val := reflect.ValueOf(src)
 
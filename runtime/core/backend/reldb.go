@@ -10,6 +10,10 @@ import (
 // SQL is relatively standardized in golang under the database/sql interfaces.  Blueprint's [RelationalDB]
 // interface exposes the github.com/jmoiron/sqlx interfaces, which are more convenient for casual usage
 // and help in marshalling structs into rows and back.
+//
+// Queries should use "?" placeholders for their parameters.  Implementations whose databases use a
+// different placeholder syntax (e.g. Postgres) rewrite the placeholders, so that the same queries work
+// across implementations.
 type RelationalDB interface {
 	// Exec executes a query without returning any rows. The args are for any placeholder parameters in the query.
 	//
@@ -39,4 +43,15 @@ type RelationalDB interface {
 	//
 	// Uses [github.com/jmoiron/sqlx] to marshal query results into dst.
 	Get(ctx context.Context, dst interface{}, query string, args ...any) error
+
+	// Runs fn within a transaction.
+	//
+	// Queries that use the context passed to fn are part of the transaction.  If fn returns nil,
+	// the transaction is committed.  If fn returns an error or panics, the transaction is rolled
+	// back and the error is returned.  A nested call to WithTransaction, using the context passed
+	// to fn, joins the enclosing transaction.
+	//
+	// Statements returned by Prepare within the transaction are bound to it, and cannot be used
+	// after it completes.
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
 }
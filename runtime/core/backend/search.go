@@ -0,0 +1,88 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// A SearchIndex backend is used for full-text search over documents, such as posts or catalogue items.
+//
+// Documents are identified by IDs, and typically mirror records that are stored in a database.  Each document
+// has text fields, which are matched by full-text queries, and keyword fields, which are matched exactly by
+// filters.  For example, a catalogue item might have a "name" and "description" as text fields, and a
+// "category" as a keyword field.
+type SearchIndex interface {
+	// Indexes doc with the given id, replacing any existing document with that id.
+	Index(ctx context.Context, id string, doc SearchDocument) error
+
+	// Removes the document id from the index.  Deleting a document that does not exist is not an error.
+	Delete(ctx context.Context, id string) error
+
+	// Returns the documents that match query, ordered by decreasing score, then by ID.
+	Search(ctx context.Context, query SearchQuery) ([]SearchHit, error)
+}
+
+// A document in a [SearchIndex]
+type SearchDocument struct {
+	// Fields that are split into terms for full-text search, e.g. {"title": "Blue Socks"}
+	Text map[string]string
+
+	// Fields that are matched exactly by [SearchQuery] filters, e.g. {"category": "clothing"}
+	Keywords map[string]string
+}
+
+// A query of a [SearchIndex]
+type SearchQuery struct {
+	// The full-text query.  A document matches if any of its text fields contains any of the terms of
+	// the query, and documents that contain more of the terms score higher.  If Text is empty, every
+	// document matches with the same score.
+	Text string
+
+	// The text fields that Text is matched against.  If empty, Text is matched against all text fields.
+	Fields []string
+
+	// Keyword fields and the values that matching documents must have.  Every filter must match.
+	Filters map[string]string
+
+	// The maximum number of hits to return.  Defaults to [DefaultSearchLimit] if zero.
+	Limit int
+}
+
+// A document that matches a [SearchQuery]
+type SearchHit struct {
+	ID       string
+	Score    float64 // Scores are relative, and are only comparable between hits of the same query
+	Document SearchDocument
+}
+
+// The maximum number of hits returned by [SearchIndex.Search] if a query does not specify a limit
+const DefaultSearchLimit = 10
+
+// Checks that id and doc are a valid [SearchIndex] document.  IDs must be non-empty and at most 512
+// bytes, and field names must be non-empty and may not contain '.' or '*'.  Implementations of
+// [SearchIndex] should call ValidateSearchDocument in Index.
+func ValidateSearchDocument(id string, doc SearchDocument) error {
+	if id == "" || len(id) > 512 {
+		return fmt.Errorf("invalid search document id %q; ids must be 1 to 512 bytes", id)
+	}
+	for _, fields := range []map[string]string{doc.Text, doc.Keywords} {
+		for name := range fields {
+			if name == "" || strings.ContainsAny(name, ".*") {
+				return fmt.Errorf("invalid field name %q in search document %v", name, id)
+			}
+		}
+	}
+	return nil
+}
+
+// Splits text into lower case terms, separated by any characters other than letters and digits.
+//
+// Implementations of [SearchIndex] that analyze text themselves should use equivalent rules, so that
+// queries match the same documents on every implementation.
+func SearchTerms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsDigit(c)
+	})
+}
@@ -0,0 +1,335 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+/*
+The typed wrappers in this file are generic facades over the backend interfaces, e.g. [TypedCache] over [Cache].
+Instead of copying results into interface{} destinations, they return values of the wrapper's type parameter,
+so that misusing a backend is a compile-time error rather than a runtime error, e.g.
+
+	posts := backend.NewTypedCache[Post](cache)
+	post, exists, err := posts.Get(ctx, "post1")
+
+A typed wrapper makes the same calls to the backend as the untyped code it replaces, so it works with any
+implementation of the backend and does not change its behavior.
+
+Service constructors can receive a typed wrapper instead of the backend interface, e.g.
+
+	func NewPostService(ctx context.Context, cache backend.TypedCache[Post]) (PostService, error) {...}
+
+in which case the injected backend is wrapped when the service is instantiated.
+*/
+
+// Implemented by the typed wrappers, so that [CopyResult] can wrap a backend in a typed wrapper
+type typedWrapper interface {
+	wrap(backend any) error
+}
+
+// A [Cache] whose values are of type V.
+//
+// Use [NewTypedCache] to wrap a Cache.
+type TypedCache[V any] struct {
+	cache Cache
+}
+
+// Wraps cache in a [TypedCache] whose values are of type V.
+func NewTypedCache[V any](cache Cache) TypedCache[V] {
+	return TypedCache[V]{cache: cache}
+}
+
+// Returns the wrapped [Cache], e.g. to store values of a different type.
+func (c TypedCache[V]) Untyped() Cache {
+	return c.cache
+}
+
+// Store a key-value pair in the cache
+func (c TypedCache[V]) Put(ctx context.Context, key string, value V) error {
+	return c.cache.Put(ctx, key, value)
+}
+
+// Retrieves a value from the cache.
+//
+// Reports whether the key existed in the cache; if not, returns the zero value of V.
+func (c TypedCache[V]) Get(ctx context.Context, key string) (V, bool, error) {
+	var value V
+	exists, err := c.cache.Get(ctx, key, &value)
+	return value, exists, err
+}
+
+// Store multiple key-value pairs in the cache.
+// keys and values must have the same length or an error will be returned
+func (c TypedCache[V]) Mset(ctx context.Context, keys []string, values []V) error {
+	untyped := make([]interface{}, len(values))
+	for i := range values {
+		untyped[i] = values[i]
+	}
+	return c.cache.Mset(ctx, keys, untyped)
+}
+
+// Retrieve the values for multiple keys from the cache, in the same order as keys.
+func (c TypedCache[V]) Mget(ctx context.Context, keys []string) ([]V, error) {
+	values := make([]V, len(keys))
+	untyped := make([]interface{}, len(keys))
+	for i := range values {
+		untyped[i] = &values[i]
+	}
+	return values, c.cache.Mget(ctx, keys, untyped)
+}
+
+// Delete from the cache
+func (c TypedCache[V]) Delete(ctx context.Context, key string) error {
+	return c.cache.Delete(ctx, key)
+}
+
+// Treats the value mapped to key as an integer, and increments it
+func (c TypedCache[V]) Incr(ctx context.Context, key string) (int64, error) {
+	return c.cache.Incr(ctx, key)
+}
+
+// Store a key-value pair in the cache that expires after ttl.  See [Cache.PutWithTTL].
+func (c TypedCache[V]) PutWithTTL(ctx context.Context, key string, value V, ttl time.Duration) error {
+	return c.cache.PutWithTTL(ctx, key, value, ttl)
+}
+
+// Sets the ttl of an existing key, after which it expires.  See [Cache.Expire].
+func (c TypedCache[V]) Expire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return c.cache.Expire(ctx, key, ttl)
+}
+
+// Stores a key-value pair in the cache only if the key does not already exist.  See [Cache.SetNX].
+func (c TypedCache[V]) SetNX(ctx context.Context, key string, value V, ttl time.Duration) (bool, error) {
+	return c.cache.SetNX(ctx, key, value, ttl)
+}
+
+// Atomically replaces the value mapped to key with new, only if the current value is equal to old.
+// See [Cache.CompareAndSwap].
+func (c TypedCache[V]) CompareAndSwap(ctx context.Context, key string, old V, new V) (bool, error) {
+	return c.cache.CompareAndSwap(ctx, key, old, new)
+}
+
+func (c *TypedCache[V]) wrap(backend any) error {
+	switch b := backend.(type) {
+	case TypedCache[V]:
+		*c = b
+	case Cache:
+		*c = NewTypedCache[V](b)
+	default:
+		return fmt.Errorf("unable to wrap %T in %T; it is not a backend.Cache", backend, *c)
+	}
+	return nil
+}
+
+// A [NoSQLCollection] whose documents are of type T.
+//
+// Use [NewTypedCollection] to wrap a NoSQLCollection, or [GetTypedCollection] to get a collection
+// from a [NoSQLDatabase].
+//
+// Operations whose results are not documents of the collection, such as Aggregate, are not wrapped;
+// use [TypedCollection.Untyped] for those.
+type TypedCollection[T any] struct {
+	collection NoSQLCollection
+}
+
+// Wraps collection in a [TypedCollection] whose documents are of type T.
+func NewTypedCollection[T any](collection NoSQLCollection) TypedCollection[T] {
+	return TypedCollection[T]{collection: collection}
+}
+
+// Gets the collection collection_name of db_name from db, and wraps it in a [TypedCollection]
+// whose documents are of type T.
+func GetTypedCollection[T any](ctx context.Context, db NoSQLDatabase, db_name string, collection_name string) (TypedCollection[T], error) {
+	collection, err := db.GetCollection(ctx, db_name, collection_name)
+	if err != nil {
+		return TypedCollection[T]{}, err
+	}
+	return NewTypedCollection[T](collection), nil
+}
+
+// Returns the wrapped [NoSQLCollection]
+func (c TypedCollection[T]) Untyped() NoSQLCollection {
+	return c.collection
+}
+
+// Deletes the first document that matches filter
+func (c TypedCollection[T]) DeleteOne(ctx context.Context, filter bson.D) error {
+	return c.collection.DeleteOne(ctx, filter)
+}
+
+// Deletes all documents that match filter
+func (c TypedCollection[T]) DeleteMany(ctx context.Context, filter bson.D) error {
+	return c.collection.DeleteMany(ctx, filter)
+}
+
+// Inserts the document into the collection.
+func (c TypedCollection[T]) InsertOne(ctx context.Context, document T) error {
+	return c.collection.InsertOne(ctx, document)
+}
+
+// Inserts all provided documents into the collection
+func (c TypedCollection[T]) InsertMany(ctx context.Context, documents []T) error {
+	untyped := make([]interface{}, len(documents))
+	for i := range documents {
+		untyped[i] = documents[i]
+	}
+	return c.collection.InsertMany(ctx, untyped)
+}
+
+// Finds a document that matches filter.  See [NoSQLCollection.FindOne].
+//
+// Reports whether a document was found; if not, returns the zero value of T.
+func (c TypedCollection[T]) FindOne(ctx context.Context, filter bson.D, projection ...bson.D) (T, bool, error) {
+	var document T
+	cursor, err := c.collection.FindOne(ctx, filter, projection...)
+	if err != nil {
+		return document, false, err
+	}
+	found, err := cursor.One(ctx, &document)
+	return document, found, err
+}
+
+// Finds all documents that match the filter.  See [NoSQLCollection.FindMany].
+func (c TypedCollection[T]) FindMany(ctx context.Context, filter bson.D, projection ...bson.D) ([]T, error) {
+	cursor, err := c.collection.FindMany(ctx, filter, projection...)
+	if err != nil {
+		return nil, err
+	}
+	return allOf[T](ctx, cursor)
+}
+
+// Finds all documents that match the filter, according to opts.  See [NoSQLCollection.FindManyWithOptions].
+func (c TypedCollection[T]) FindManyWithOptions(ctx context.Context, filter bson.D, opts FindOptions) ([]T, error) {
+	cursor, err := c.collection.FindManyWithOptions(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	return allOf[T](ctx, cursor)
+}
+
+func allOf[T any](ctx context.Context, cursor NoSQLCursor) ([]T, error) {
+	var documents []T
+	err := cursor.All(ctx, &documents)
+	return documents, err
+}
+
+// Applies the provided update to the first document that matches filter.  See [NoSQLCollection.UpdateOne].
+func (c TypedCollection[T]) UpdateOne(ctx context.Context, filter bson.D, update bson.D) (int, error) {
+	return c.collection.UpdateOne(ctx, filter, update)
+}
+
+// Applies the provided update to all documents that match the filter.  See [NoSQLCollection.UpdateMany].
+func (c TypedCollection[T]) UpdateMany(ctx context.Context, filter bson.D, update bson.D) (int, error) {
+	return c.collection.UpdateMany(ctx, filter, update)
+}
+
+// Replaces the first document that matches filter, or inserts document.  See [NoSQLCollection.Upsert].
+func (c TypedCollection[T]) Upsert(ctx context.Context, filter bson.D, document T) (bool, error) {
+	return c.collection.Upsert(ctx, filter, document)
+}
+
+// Replaces the document with "_id" = id, or inserts document.  See [NoSQLCollection.UpsertID].
+func (c TypedCollection[T]) UpsertID(ctx context.Context, id primitive.ObjectID, document T) (bool, error) {
+	return c.collection.UpsertID(ctx, id, document)
+}
+
+// Replaces the first document that matches filter with the replacement document.  See [NoSQLCollection.ReplaceOne].
+func (c TypedCollection[T]) ReplaceOne(ctx context.Context, filter bson.D, replacement T) (int, error) {
+	return c.collection.ReplaceOne(ctx, filter, replacement)
+}
+
+// Replaces all documents that match filter with the replacement documents.  See [NoSQLCollection.ReplaceMany].
+func (c TypedCollection[T]) ReplaceMany(ctx context.Context, filter bson.D, replacements ...T) (int, error) {
+	untyped := make([]interface{}, len(replacements))
+	for i := range replacements {
+		untyped[i] = replacements[i]
+	}
+	return c.collection.ReplaceMany(ctx, filter, untyped...)
+}
+
+// Creates an index on the specified keys, if it does not already exist.  See [NoSQLCollection.CreateIndex].
+func (c TypedCollection[T]) CreateIndex(ctx context.Context, keys bson.D, unique bool) error {
+	return c.collection.CreateIndex(ctx, keys, unique)
+}
+
+func (c *TypedCollection[T]) wrap(backend any) error {
+	switch b := backend.(type) {
+	case TypedCollection[T]:
+		*c = b
+	default:
+		return fmt.Errorf("unable to wrap %T in %T; receive a backend.NoSQLDatabase and call backend.GetTypedCollection instead", backend, *c)
+	}
+	return nil
+}
+
+// A [Queue] whose items are of type T.
+//
+// Use [NewTypedQueue] to wrap a Queue.
+type TypedQueue[T any] struct {
+	queue Queue
+}
+
+// An item of type T received from a [TypedQueue], that must be acknowledged once processed.
+// See [Delivery].
+type TypedDelivery[T any] struct {
+	Delivery
+}
+
+// Wraps queue in a [TypedQueue] whose items are of type T.
+func NewTypedQueue[T any](queue Queue) TypedQueue[T] {
+	return TypedQueue[T]{queue: queue}
+}
+
+// Returns the wrapped [Queue]
+func (q TypedQueue[T]) Untyped() Queue {
+	return q.queue
+}
+
+// Pushes an item to the tail of the queue.  See [Queue.Push].
+func (q TypedQueue[T]) Push(ctx context.Context, item T) (bool, error) {
+	return q.queue.Push(ctx, item)
+}
+
+// Pops an item from the front of the queue.  See [Queue.Pop].
+//
+// Reports whether an item was popped; if not, returns the zero value of T.
+func (q TypedQueue[T]) Pop(ctx context.Context) (T, bool, error) {
+	var item T
+	popped, err := q.queue.Pop(ctx, &item)
+	return item, popped, err
+}
+
+// Pushes an item to the tail of the queue, with headers and an optional delay.  See [Queue.PushWithOptions].
+func (q TypedQueue[T]) PushWithOptions(ctx context.Context, item T, opts PushOptions) (bool, error) {
+	return q.queue.PushWithOptions(ctx, item, opts)
+}
+
+// Receives an item from the front of the queue for at-least-once processing.  See [Queue.Receive].
+func (q TypedQueue[T]) Receive(ctx context.Context) (TypedDelivery[T], bool, error) {
+	delivery, received, err := q.queue.Receive(ctx)
+	return TypedDelivery[T]{Delivery: delivery}, received, err
+}
+
+// Returns the item.
+func (d TypedDelivery[T]) Decode() (T, error) {
+	var item T
+	err := d.Delivery.Decode(&item)
+	return item, err
+}
+
+func (q *TypedQueue[T]) wrap(backend any) error {
+	switch b := backend.(type) {
+	case TypedQueue[T]:
+		*q = b
+	case Queue:
+		*q = NewTypedQueue[T](b)
+	default:
+		return fmt.Errorf("unable to wrap %T in %T; it is not a backend.Queue", backend, *q)
+	}
+	return nil
+}
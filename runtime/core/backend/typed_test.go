@@ -0,0 +1,136 @@
+package backend_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/simplecache"
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/simplenosqldb"
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/simplequeue"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type post struct {
+	ID    string
+	Title string
+	Likes int
+}
+
+func TestTypedCache(t *testing.T) {
+	ctx := context.Background()
+	cache, err := simplecache.NewSimpleCache(ctx)
+	require.NoError(t, err)
+	posts := backend.NewTypedCache[post](cache)
+
+	_, exists, err := posts.Get(ctx, "post1")
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	require.NoError(t, posts.Put(ctx, "post1", post{ID: "post1", Title: "hello"}))
+	p, exists, err := posts.Get(ctx, "post1")
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.Equal(t, post{ID: "post1", Title: "hello"}, p)
+
+	require.NoError(t, posts.Mset(ctx, []string{"post2", "post3"}, []post{{ID: "post2"}, {ID: "post3"}}))
+	ps, err := posts.Mget(ctx, []string{"post3", "post1"})
+	require.NoError(t, err)
+	require.Equal(t, []post{{ID: "post3"}, {ID: "post1", Title: "hello"}}, ps)
+
+	swapped, err := posts.CompareAndSwap(ctx, "post2", post{ID: "post2"}, post{ID: "post2", Likes: 1})
+	require.NoError(t, err)
+	require.True(t, swapped)
+
+	// The typed cache shares the values of the untyped cache
+	var untyped post
+	exists, err = cache.Get(ctx, "post2", &untyped)
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.Equal(t, post{ID: "post2", Likes: 1}, untyped)
+}
+
+func TestTypedCollection(t *testing.T) {
+	ctx := context.Background()
+	db, err := simplenosqldb.NewSimpleNoSQLDB(ctx)
+	require.NoError(t, err)
+	posts, err := backend.GetTypedCollection[post](ctx, db, "db", "posts")
+	require.NoError(t, err)
+
+	_, found, err := posts.FindOne(ctx, bson.D{{"id", "post1"}})
+	require.NoError(t, err)
+	require.False(t, found)
+
+	require.NoError(t, posts.InsertOne(ctx, post{ID: "post1", Title: "hello"}))
+	require.NoError(t, posts.InsertMany(ctx, []post{{ID: "post2", Likes: 3}, {ID: "post3", Likes: 3}}))
+
+	p, found, err := posts.FindOne(ctx, bson.D{{"id", "post1"}})
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, post{ID: "post1", Title: "hello"}, p)
+
+	ps, err := posts.FindManyWithOptions(ctx, bson.D{{"likes", 3}}, backend.FindOptions{Sort: bson.D{{"id", -1}}})
+	require.NoError(t, err)
+	require.Equal(t, []post{{ID: "post3", Likes: 3}, {ID: "post2", Likes: 3}}, ps)
+
+	ps, err = posts.FindMany(ctx, bson.D{{"likes", 4}})
+	require.NoError(t, err)
+	require.Empty(t, ps)
+}
+
+func TestTypedQueue(t *testing.T) {
+	ctx := context.Background()
+	queue, err := simplequeue.NewSimpleQueue(ctx)
+	require.NoError(t, err)
+	posts := backend.NewTypedQueue[post](queue)
+
+	pushed, err := posts.Push(ctx, post{ID: "post1"})
+	require.NoError(t, err)
+	require.True(t, pushed)
+	p, popped, err := posts.Pop(ctx)
+	require.NoError(t, err)
+	require.True(t, popped)
+	require.Equal(t, post{ID: "post1"}, p)
+
+	pushed, err = posts.Push(ctx, post{ID: "post2"})
+	require.NoError(t, err)
+	require.True(t, pushed)
+	delivery, received, err := posts.Receive(ctx)
+	require.NoError(t, err)
+	require.True(t, received)
+	p, err = delivery.Decode()
+	require.NoError(t, err)
+	require.Equal(t, post{ID: "post2"}, p)
+	require.NoError(t, delivery.Ack(ctx))
+}
+
+func TestCopyResultWrapsBackend(t *testing.T) {
+	ctx := context.Background()
+	cache, err := simplecache.NewSimpleCache(ctx)
+	require.NoError(t, err)
+	queue, err := simplequeue.NewSimpleQueue(ctx)
+	require.NoError(t, err)
+
+	// This is how the generated code passes a backend to a constructor that receives a typed wrapper
+	var built any = cache
+	var posts backend.TypedCache[post]
+	require.NoError(t, backend.CopyResult(built, &posts))
+	require.NoError(t, posts.Put(ctx, "post1", post{ID: "post1"}))
+	p, exists, err := posts.Get(ctx, "post1")
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.Equal(t, post{ID: "post1"}, p)
+
+	var copied backend.TypedCache[post]
+	require.NoError(t, backend.CopyResult(posts, &copied))
+	require.Equal(t, posts, copied)
+
+	var q backend.TypedQueue[post]
+	require.NoError(t, backend.CopyResult(queue, &q))
+
+	// A backend can't be wrapped in the wrong typed wrapper
+	require.Error(t, backend.CopyResult(queue, &posts))
+	var collection backend.TypedCollection[post]
+	require.Error(t, backend.CopyResult(cache, &collection))
+}
@@ -0,0 +1,189 @@
+// Package circuitbreaker implements the runtime components of Blueprint's circuitbreaker plugin.
+//
+// CircuitBreakers do not need to be used directly by application workflow specs.  Instead, this
+// code is included in a compiled application by applying the circuitbreaker modifier to the wiring spec.
+//
+// A [CircuitBreaker] is in one of three states:
+//   - [Closed]: calls are allowed.  If, within an interval, at least MinRequests calls were made and the
+//     fraction of failed calls reaches FailureRate, the breaker trips and becomes [Open].
+//   - [Open]: calls are rejected with [ErrCircuitOpen].  After OpenTimeout the breaker becomes [HalfOpen].
+//   - [HalfOpen]: up to HalfOpenProbes concurrent probe calls are allowed and all other calls are rejected.
+//     If HalfOpenProbes probes succeed the breaker becomes [Closed]; if any probe fails it becomes [Open] again.
+package circuitbreaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned for calls that are rejected because the circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// The state of a [CircuitBreaker]
+type State int
+
+const (
+	Closed State = iota
+	Open
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// Configuration of a [CircuitBreaker]
+type Config struct {
+	// Minimum number of calls within an interval before the breaker can trip
+	MinRequests int64
+
+	// Fraction of failed calls, between 0 and 1, at which the breaker trips
+	FailureRate float64
+
+	// Duration after which the call counters of a closed breaker are reset
+	Interval time.Duration
+
+	// Duration that a breaker stays open before allowing probe calls.  Defaults to Interval.
+	OpenTimeout time.Duration
+
+	// Number of successful probe calls needed to close a half-open breaker.  Defaults to 1.
+	HalfOpenProbes int64
+
+	// Optional callback invoked whenever a breaker changes state.  The callback must not call the breaker.
+	OnStateChange func(name string, from, to State)
+}
+
+// A CircuitBreaker for calls to a single method.  CircuitBreaker is safe for concurrent use.
+type CircuitBreaker struct {
+	name   string
+	config Config
+
+	mu          sync.Mutex
+	state       State
+	generation  uint64
+	windowStart time.Time
+	openedAt    time.Time
+	requests    int64
+	failures    int64
+	probes      int64
+	successes   int64
+}
+
+// Instantiates a [CircuitBreaker] in the [Closed] state.
+func NewCircuitBreaker(name string, config Config) *CircuitBreaker {
+	if config.OpenTimeout <= 0 {
+		config.OpenTimeout = config.Interval
+	}
+	if config.HalfOpenProbes <= 0 {
+		config.HalfOpenProbes = 1
+	}
+	return &CircuitBreaker{name: name, config: config, windowStart: time.Now()}
+}
+
+// Returns the name of the breaker
+func (cb *CircuitBreaker) Name() string {
+	return cb.name
+}
+
+// Returns the current state of the breaker
+func (cb *CircuitBreaker) State() State {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	cb.refresh()
+	return cb.state
+}
+
+// Reports whether a call may proceed.
+//
+// If the call is rejected, [ErrCircuitOpen] is returned.  Otherwise, the caller must invoke the returned
+// func with the result of the call once the call completes.
+func (cb *CircuitBreaker) Allow() (done func(error), err error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.refresh()
+	switch cb.state {
+	case Open:
+		return nil, ErrCircuitOpen
+	case HalfOpen:
+		if cb.probes+cb.successes >= cb.config.HalfOpenProbes {
+			return nil, ErrCircuitOpen
+		}
+		cb.probes++
+	}
+	generation := cb.generation
+	return func(err error) { cb.done(generation, err) }, nil
+}
+
+func (cb *CircuitBreaker) done(generation uint64, err error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	// Ignore results of calls that were admitted before the last state change
+	if generation != cb.generation {
+		return
+	}
+
+	switch cb.state {
+	case Closed:
+		cb.requests++
+		if err != nil {
+			cb.failures++
+		}
+		if cb.requests >= cb.config.MinRequests && float64(cb.failures)/float64(cb.requests) >= cb.config.FailureRate {
+			cb.setState(Open)
+		}
+	case HalfOpen:
+		cb.probes--
+		if err != nil {
+			cb.setState(Open)
+		} else if cb.successes++; cb.successes >= cb.config.HalfOpenProbes {
+			cb.setState(Closed)
+		}
+	}
+}
+
+// Applies any time-based state transitions.  Must hold mu.
+func (cb *CircuitBreaker) refresh() {
+	now := time.Now()
+	switch cb.state {
+	case Closed:
+		if cb.config.Interval > 0 && now.Sub(cb.windowStart) >= cb.config.Interval {
+			cb.windowStart = now
+			cb.requests = 0
+			cb.failures = 0
+		}
+	case Open:
+		if now.Sub(cb.openedAt) >= cb.config.OpenTimeout {
+			cb.setState(HalfOpen)
+		}
+	}
+}
+
+// Must hold mu.
+func (cb *CircuitBreaker) setState(to State) {
+	from := cb.state
+	if from == to {
+		return
+	}
+	cb.state = to
+	cb.generation++
+	cb.requests, cb.failures, cb.probes, cb.successes = 0, 0, 0, 0
+	cb.windowStart = time.Now()
+	if to == Open {
+		cb.openedAt = cb.windowStart
+	}
+	if cb.config.OnStateChange != nil {
+		cb.config.OnStateChange(cb.name, from, to)
+	}
+}
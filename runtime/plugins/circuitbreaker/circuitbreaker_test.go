@@ -0,0 +1,134 @@
+package circuitbreaker_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/circuitbreaker"
+	"github.com/stretchr/testify/require"
+)
+
+var errFailed = errors.New("failed")
+
+func call(t *testing.T, cb *circuitbreaker.CircuitBreaker, err error) {
+	done, allowErr := cb.Allow()
+	require.NoError(t, allowErr)
+	done(err)
+}
+
+func TestTrip(t *testing.T) {
+	cb := circuitbreaker.NewCircuitBreaker("m", circuitbreaker.Config{MinRequests: 4, FailureRate: 0.5, Interval: time.Minute})
+
+	call(t, cb, nil)
+	call(t, cb, errFailed)
+	call(t, cb, nil)
+	require.Equal(t, circuitbreaker.Closed, cb.State())
+
+	call(t, cb, errFailed)
+	require.Equal(t, circuitbreaker.Open, cb.State())
+
+	_, err := cb.Allow()
+	require.ErrorIs(t, err, circuitbreaker.ErrCircuitOpen)
+}
+
+func TestIntervalResetsCounters(t *testing.T) {
+	cb := circuitbreaker.NewCircuitBreaker("m", circuitbreaker.Config{MinRequests: 2, FailureRate: 0.5, Interval: 20 * time.Millisecond})
+
+	call(t, cb, errFailed)
+	time.Sleep(30 * time.Millisecond)
+	call(t, cb, nil)
+	require.Equal(t, circuitbreaker.Closed, cb.State())
+}
+
+func TestHalfOpenProbes(t *testing.T) {
+	cb := circuitbreaker.NewCircuitBreaker("m", circuitbreaker.Config{MinRequests: 1, FailureRate: 1, Interval: time.Minute, OpenTimeout: 20 * time.Millisecond, HalfOpenProbes: 2})
+
+	call(t, cb, errFailed)
+	require.Equal(t, circuitbreaker.Open, cb.State())
+
+	time.Sleep(30 * time.Millisecond)
+	require.Equal(t, circuitbreaker.HalfOpen, cb.State())
+
+	// Only HalfOpenProbes calls may proceed concurrently
+	done1, err := cb.Allow()
+	require.NoError(t, err)
+	done2, err := cb.Allow()
+	require.NoError(t, err)
+	_, err = cb.Allow()
+	require.ErrorIs(t, err, circuitbreaker.ErrCircuitOpen)
+
+	done1(nil)
+	require.Equal(t, circuitbreaker.HalfOpen, cb.State())
+	done2(nil)
+	require.Equal(t, circuitbreaker.Closed, cb.State())
+}
+
+func TestHalfOpenProbeFailure(t *testing.T) {
+	cb := circuitbreaker.NewCircuitBreaker("m", circuitbreaker.Config{MinRequests: 1, FailureRate: 1, Interval: time.Minute, OpenTimeout: 20 * time.Millisecond})
+
+	call(t, cb, errFailed)
+	time.Sleep(30 * time.Millisecond)
+
+	call(t, cb, errFailed)
+	require.Equal(t, circuitbreaker.Open, cb.State())
+}
+
+func TestStaleResultsIgnored(t *testing.T) {
+	cb := circuitbreaker.NewCircuitBreaker("m", circuitbreaker.Config{MinRequests: 1, FailureRate: 1, Interval: time.Minute, OpenTimeout: 20 * time.Millisecond})
+
+	slow, err := cb.Allow()
+	require.NoError(t, err)
+	call(t, cb, errFailed)
+	time.Sleep(30 * time.Millisecond)
+	require.Equal(t, circuitbreaker.HalfOpen, cb.State())
+
+	// A call admitted while closed should not affect the half-open breaker
+	slow(nil)
+	require.Equal(t, circuitbreaker.HalfOpen, cb.State())
+}
+
+func TestCircuitBreakersPerMethod(t *testing.T) {
+	ctx := context.Background()
+
+	var changes []string
+	config := circuitbreaker.Config{MinRequests: 1, FailureRate: 1, Interval: time.Minute}
+	config.OnStateChange = func(method string, from, to circuitbreaker.State) {
+		changes = append(changes, method+":"+from.String()+"->"+to.String())
+	}
+	cbs, err := circuitbreaker.NewCircuitBreakers(ctx, "svc", config, "A", "B")
+	require.NoError(t, err)
+
+	done, err := cbs.Allow(ctx, "A")
+	require.NoError(t, err)
+	done(errFailed)
+
+	_, err = cbs.Allow(ctx, "A")
+	require.ErrorIs(t, err, circuitbreaker.ErrCircuitOpen)
+
+	done, err = cbs.Allow(ctx, "B")
+	require.NoError(t, err)
+	done(nil)
+
+	require.Equal(t, []string{"A:closed->open"}, changes)
+	require.Equal(t, circuitbreaker.Closed, cbs.Get("B").State())
+}
+
+func TestFallbackCache(t *testing.T) {
+	c := circuitbreaker.NewFallbackCache(2)
+
+	c.Put(circuitbreaker.Key("M", 1, "a"), "one")
+	v, ok := c.Get(circuitbreaker.Key("M", 1, "a"))
+	require.True(t, ok)
+	require.Equal(t, "one", v)
+
+	_, ok = c.Get(circuitbreaker.Key("M", 2, "a"))
+	require.False(t, ok)
+
+	c.Put(circuitbreaker.Key("M", 2, "a"), "two")
+	c.Put(circuitbreaker.Key("M", 3, "a"), "three")
+	v, ok = c.Get(circuitbreaker.Key("M", 3, "a"))
+	require.True(t, ok)
+	require.Equal(t, "three", v)
+}
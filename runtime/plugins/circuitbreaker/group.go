@@ -0,0 +1,133 @@
+package circuitbreaker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// A set of per-method [CircuitBreaker] instances for the client of a single service.
+//
+// State changes and rejected calls are exported as OpenTelemetry metrics using [backend.MeterOrGlobal]:
+//   - circuitbreaker.state is a gauge of each breaker's current [State]
+//   - circuitbreaker.state_changes counts state changes, with from and to attributes
+//   - circuitbreaker.rejected counts calls rejected with [ErrCircuitOpen]
+//
+// Each metric has a service and a method attribute.
+type CircuitBreakers struct {
+	service  string
+	breakers map[string]*CircuitBreaker
+	rejected metric.Int64Counter
+}
+
+// Instantiates a [CircuitBreaker] for each of the specified methods of service.
+func NewCircuitBreakers(ctx context.Context, service string, config Config, methods ...string) (*CircuitBreakers, error) {
+	meter := backend.MeterOrGlobal(ctx, "circuitbreaker")
+
+	stateChanges, err := meter.Int64Counter("circuitbreaker.state_changes", metric.WithDescription("Number of circuit breaker state changes"))
+	if err != nil {
+		return nil, err
+	}
+	rejected, err := meter.Int64Counter("circuitbreaker.rejected", metric.WithDescription("Number of calls rejected by an open circuit breaker"))
+	if err != nil {
+		return nil, err
+	}
+	state, err := meter.Int64ObservableGauge("circuitbreaker.state", metric.WithDescription("Current circuit breaker state; 0 is closed, 1 is open, 2 is half-open"))
+	if err != nil {
+		return nil, err
+	}
+
+	cbs := &CircuitBreakers{service: service, breakers: make(map[string]*CircuitBreaker), rejected: rejected}
+
+	onStateChange := config.OnStateChange
+	config.OnStateChange = func(method string, from, to State) {
+		stateChanges.Add(context.Background(), 1, metric.WithAttributes(
+			attribute.String("service", service),
+			attribute.String("method", method),
+			attribute.String("from", from.String()),
+			attribute.String("to", to.String()),
+		))
+		if onStateChange != nil {
+			onStateChange(method, from, to)
+		}
+	}
+	for _, method := range methods {
+		cbs.breakers[method] = NewCircuitBreaker(method, config)
+	}
+
+	_, err = meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		for method, cb := range cbs.breakers {
+			o.ObserveInt64(state, int64(cb.State()), metric.WithAttributes(
+				attribute.String("service", service),
+				attribute.String("method", method),
+			))
+		}
+		return nil
+	}, state)
+	return cbs, err
+}
+
+// Returns the breaker for the specified method
+func (cbs *CircuitBreakers) Get(method string) *CircuitBreaker {
+	return cbs.breakers[method]
+}
+
+// Reports whether a call to the specified method may proceed.  See [CircuitBreaker.Allow].
+func (cbs *CircuitBreakers) Allow(ctx context.Context, method string) (done func(error), err error) {
+	done, err = cbs.breakers[method].Allow()
+	if err != nil {
+		cbs.rejected.Add(ctx, 1, metric.WithAttributes(
+			attribute.String("service", cbs.service),
+			attribute.String("method", method),
+		))
+	}
+	return
+}
+
+// A bounded cache of the most recent successful responses of calls, used to
+// serve fallback responses while a circuit breaker is open.
+type FallbackCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]any
+}
+
+// Instantiates a [FallbackCache] that holds up to capacity responses.
+func NewFallbackCache(capacity int) *FallbackCache {
+	return &FallbackCache{capacity: capacity, entries: make(map[string]any)}
+}
+
+// Caches the response for the specified key, evicting an arbitrary entry if the cache is full.
+func (c *FallbackCache) Put(key string, response any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, exists := c.entries[key]; !exists && len(c.entries) >= c.capacity {
+		for k := range c.entries {
+			delete(c.entries, k)
+			break
+		}
+	}
+	c.entries[key] = response
+}
+
+// Returns the cached response for the specified key, if any
+func (c *FallbackCache) Get(key string) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	response, exists := c.entries[key]
+	return response, exists
+}
+
+// Computes a cache key for a call to method with the specified args
+func Key(method string, args ...any) string {
+	encoded, err := json.Marshal(args)
+	if err != nil {
+		return fmt.Sprintf("%v%v", method, args)
+	}
+	return method + string(encoded)
+}
@@ -2,24 +2,52 @@
 //
 // ClientPools do not need to be used directly by application workflow specs.  Instead, this
 // code is included in a compiled application by applying the ClientPool modifier to the wiring spec.
+//
+// A ClientPool can also act as a bulkhead: callers can be limited in how long they wait for a
+// client, and once too many callers are waiting, further callers fail fast instead of queueing.
 package clientpool
 
 import (
 	"context"
 	"sync/atomic"
+	"time"
 
 	"errors"
 )
 
+// Returned by [ClientPool.Pop] when the context is done, or the pool's MaxWait elapses, before a client was available.
+var ErrTimeout = errors.New("timeout before client was available")
+
+// Returned by [ClientPool.Pop] when the pool is at capacity and already has MaxWaiting callers waiting for a client.
+var ErrQueueFull = errors.New("too many callers waiting for a client")
+
+// Configuration of a [ClientPool]
+type Config struct {
+	// Maximum number of clients in the pool
+	Capacity int
+
+	// Maximum number of callers that can wait for a client once all clients are in use.
+	// Further callers fail immediately with [ErrQueueFull].  Zero means unlimited.
+	MaxWaiting int
+
+	// Maximum time that a caller waits for a client before failing with [ErrTimeout].
+	// Zero means callers wait until their context is done.
+	MaxWait time.Duration
+}
+
 // A ClientPool that contains up to Capacity clients. Clients are acquired with
 // Pop and returned with Push.
 type ClientPool[T any] struct {
-	clients   chan T
-	build     func() (T, error)
-	capacity  int64
-	size      int64
-	available int64
-	waiting   int64
+	clients    chan T
+	build      func() (T, error)
+	capacity   int64
+	maxWaiting int64
+	maxWait    time.Duration
+	size       int64
+	available  int64
+	waiting    int64
+	timeouts   int64
+	rejected   int64
 }
 
 // Instantiates a [ClientPool] that will have up to maxClients client instances.
@@ -28,13 +56,21 @@ type ClientPool[T any] struct {
 // Callers acquire a client instance by calling [ClientPool.Pop], and when they
 // are finished with a client, return it to the pool by calling [ClientPool.Push]
 func NewClientPool[T any](capacity int, build func() (T, error)) *ClientPool[T] {
+	return NewClientPoolWithConfig(Config{Capacity: capacity}, build)
+}
+
+// Instantiates a [ClientPool] with the provided [Config].
+// The provided function fn is used to instantiate clients.
+func NewClientPoolWithConfig[T any](config Config, build func() (T, error)) *ClientPool[T] {
 	pool := &ClientPool[T]{
-		clients:   make(chan T, capacity),
-		build:     build,
-		capacity:  int64(capacity),
-		size:      0,
-		available: 0,
-		waiting:   0,
+		clients:    make(chan T, config.Capacity),
+		build:      build,
+		capacity:   int64(config.Capacity),
+		maxWaiting: int64(config.MaxWaiting),
+		maxWait:    config.MaxWait,
+		size:       0,
+		available:  0,
+		waiting:    0,
 	}
 	return pool
 }
@@ -43,11 +79,15 @@ func NewClientPool[T any](capacity int, build func() (T, error)) *ClientPool[T]
 //
 // When a caller has finished using a client, it *must* call [ClientPool.Push] to
 // return the client to the pool.
+//
+// Returns [ErrTimeout] if no client became available in time, or [ErrQueueFull] if
+// too many callers are already waiting for a client.
 func (pool *ClientPool[T]) Pop(ctx context.Context) (client T, err error) {
 	// Attempt to immediately reuse an existing client
 	select {
 	case <-ctx.Done():
-		err = errors.New("timeout before client was available")
+		atomic.AddInt64(&pool.timeouts, 1)
+		err = ErrTimeout
 		return
 	case client = <-pool.clients:
 		atomic.AddInt64(&pool.available, -1)
@@ -56,7 +96,7 @@ func (pool *ClientPool[T]) Pop(ctx context.Context) (client T, err error) {
 	}
 
 	// If the pool isn't at capacity, we can instantiate a new client
-	for curSize := pool.size; curSize < pool.capacity; {
+	for curSize := atomic.LoadInt64(&pool.size); curSize < pool.capacity; {
 		if atomic.CompareAndSwapInt64(&pool.size, curSize, curSize+1) {
 			client, err = pool.build()
 			if err != nil {
@@ -64,18 +104,35 @@ func (pool *ClientPool[T]) Pop(ctx context.Context) (client T, err error) {
 			}
 			return
 		}
-		curSize = pool.size
+		curSize = atomic.LoadInt64(&pool.size)
+	}
+
+	// Pool is at capacity; wait to reuse an existing client, unless too many callers are already waiting
+	waiting := atomic.AddInt64(&pool.waiting, 1)
+	defer atomic.AddInt64(&pool.waiting, -1)
+	if pool.maxWaiting > 0 && waiting > pool.maxWaiting {
+		atomic.AddInt64(&pool.rejected, 1)
+		err = ErrQueueFull
+		return
+	}
+
+	var expired <-chan time.Time
+	if pool.maxWait > 0 {
+		timer := time.NewTimer(pool.maxWait)
+		defer timer.Stop()
+		expired = timer.C
 	}
 
-	// Pool is at capacity; wait to reuse an existing client
-	atomic.AddInt64(&pool.waiting, 1)
 	select {
 	case <-ctx.Done():
-		err = errors.New("timeout before client was available")
+		atomic.AddInt64(&pool.timeouts, 1)
+		err = ErrTimeout
+	case <-expired:
+		atomic.AddInt64(&pool.timeouts, 1)
+		err = ErrTimeout
 	case client = <-pool.clients:
 		atomic.AddInt64(&pool.available, -1)
 	}
-	atomic.AddInt64(&pool.waiting, -1)
 	return
 }
 
@@ -92,10 +149,25 @@ func (pool *ClientPool[T]) Capacity() int {
 
 // Returns the current size of the client pool
 func (pool *ClientPool[T]) Size() int {
-	return int(pool.size)
+	return int(atomic.LoadInt64(&pool.size))
 }
 
 // Returns the current number of available clients in the client pool
 func (pool *ClientPool[T]) Available() int {
-	return int(pool.available)
+	return int(atomic.LoadInt64(&pool.available))
+}
+
+// Returns the current number of callers waiting for a client
+func (pool *ClientPool[T]) Waiting() int {
+	return int(atomic.LoadInt64(&pool.waiting))
+}
+
+// Returns the number of calls to [ClientPool.Pop] that failed with [ErrTimeout]
+func (pool *ClientPool[T]) Timeouts() int64 {
+	return atomic.LoadInt64(&pool.timeouts)
+}
+
+// Returns the number of calls to [ClientPool.Pop] that failed with [ErrQueueFull]
+func (pool *ClientPool[T]) Rejected() int64 {
+	return atomic.LoadInt64(&pool.rejected)
 }
@@ -117,3 +117,59 @@ func TestClientPool(t *testing.T) {
 		require.False(t, isDone(ctx), "iteration %v", i)
 	}
 }
+
+func TestClientPoolMaxWait(t *testing.T) {
+	build := func() (*element, error) {
+		return &element{}, nil
+	}
+
+	pool := clientpool.NewClientPoolWithConfig(clientpool.Config{Capacity: 1, MaxWait: 10 * time.Millisecond}, build)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	e, err := pool.Pop(ctx)
+	require.NoError(t, err)
+
+	_, err = pool.Pop(ctx)
+	require.ErrorIs(t, err, clientpool.ErrTimeout)
+	require.False(t, isDone(ctx))
+	require.Equal(t, int64(1), pool.Timeouts())
+	require.Equal(t, 0, pool.Waiting())
+
+	pool.Push(e)
+	_, err = pool.Pop(ctx)
+	require.NoError(t, err)
+}
+
+func TestClientPoolQueueFull(t *testing.T) {
+	build := func() (*element, error) {
+		return &element{}, nil
+	}
+
+	pool := clientpool.NewClientPoolWithConfig(clientpool.Config{Capacity: 1, MaxWaiting: 1}, build)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	e, err := pool.Pop(ctx)
+	require.NoError(t, err)
+
+	// One caller may wait for the client
+	waited := make(chan error)
+	go func() {
+		_, err := pool.Pop(ctx)
+		waited <- err
+	}()
+	require.Eventually(t, func() bool { return pool.Waiting() == 1 }, time.Second, time.Millisecond)
+
+	// Further callers fail fast
+	_, err = pool.Pop(ctx)
+	require.ErrorIs(t, err, clientpool.ErrQueueFull)
+	require.Equal(t, int64(1), pool.Rejected())
+	require.False(t, isDone(ctx))
+
+	pool.Push(e)
+	require.NoError(t, <-waited)
+	require.Equal(t, 0, pool.Waiting())
+}
@@ -0,0 +1,67 @@
+package clientpool
+
+import (
+	"context"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// The statistics of a pool that are exported as metrics
+type stats interface {
+	Capacity() int
+	Size() int
+	Available() int
+	Waiting() int
+	Timeouts() int64
+	Rejected() int64
+}
+
+// Exports the size, availability and queueing statistics of pool as OpenTelemetry metrics using [backend.MeterOrGlobal].
+//
+// The metrics are clientpool.capacity, clientpool.size, clientpool.available, clientpool.waiting,
+// clientpool.timeouts and clientpool.rejected, each with a pool attribute set to name.
+func ExportMetrics[T any](ctx context.Context, name string, pool *ClientPool[T]) error {
+	meter := backend.MeterOrGlobal(ctx, "clientpool")
+	return registerMetrics(meter, name, pool)
+}
+
+func registerMetrics(meter metric.Meter, name string, pool stats) error {
+	capacity, err := meter.Int64ObservableGauge("clientpool.capacity", metric.WithDescription("Maximum number of clients in the pool"))
+	if err != nil {
+		return err
+	}
+	size, err := meter.Int64ObservableGauge("clientpool.size", metric.WithDescription("Number of clients instantiated by the pool"))
+	if err != nil {
+		return err
+	}
+	available, err := meter.Int64ObservableGauge("clientpool.available", metric.WithDescription("Number of idle clients in the pool"))
+	if err != nil {
+		return err
+	}
+	waiting, err := meter.Int64ObservableGauge("clientpool.waiting", metric.WithDescription("Number of callers waiting for a client"))
+	if err != nil {
+		return err
+	}
+	timeouts, err := meter.Int64ObservableCounter("clientpool.timeouts", metric.WithDescription("Number of callers that timed out waiting for a client"))
+	if err != nil {
+		return err
+	}
+	rejected, err := meter.Int64ObservableCounter("clientpool.rejected", metric.WithDescription("Number of callers rejected because too many callers were waiting"))
+	if err != nil {
+		return err
+	}
+
+	attrs := metric.WithAttributes(attribute.String("pool", name))
+	_, err = meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		o.ObserveInt64(capacity, int64(pool.Capacity()), attrs)
+		o.ObserveInt64(size, int64(pool.Size()), attrs)
+		o.ObserveInt64(available, int64(pool.Available()), attrs)
+		o.ObserveInt64(waiting, int64(pool.Waiting()), attrs)
+		o.ObserveInt64(timeouts, pool.Timeouts(), attrs)
+		o.ObserveInt64(rejected, pool.Rejected(), attrs)
+		return nil
+	}, capacity, size, available, waiting, timeouts, rejected)
+	return err
+}
@@ -0,0 +1,226 @@
+// Package elasticsearch provides a client-wrapper implementation of the [backend.SearchIndex] interface for
+// Elasticsearch, and for OpenSearch, which has the same REST API.
+//
+// The client stores documents in a single index, which is created when the first document is indexed.  The index
+// analyzes text fields with the same rules as [backend.SearchTerms], so that queries match the same documents as
+// on other [backend.SearchIndex] implementations.  Indexed and deleted documents are visible to searches as soon
+// as Index and Delete return.
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+)
+
+// Implements a [backend.SearchIndex] that stores documents in an Elasticsearch index
+type ElasticsearchIndex struct {
+	backend.SearchIndex
+
+	url    string // The URL of the index, e.g. http://localhost:9200/my_index
+	client *http.Client
+
+	mu      sync.Mutex
+	created bool // Whether the index is known to exist
+}
+
+// Instantiates a new [ElasticsearchIndex] that stores documents in index, on the server at addr.
+//
+// addr is the host and port of the server, e.g. localhost:9200.  index must be a valid Elasticsearch
+// index name, i.e. lower case, without spaces or most punctuation.
+func NewElasticsearchIndex(ctx context.Context, addr string, index string) (*ElasticsearchIndex, error) {
+	if index == "" {
+		return nil, fmt.Errorf("an index name is required")
+	}
+	return &ElasticsearchIndex{
+		url:    "http://" + addr + "/" + url.PathEscape(index),
+		client: &http.Client{},
+	}, nil
+}
+
+// The settings and mappings of the index.  Text fields are analyzed by splitting on anything other than
+// letters and digits, and lower casing the terms; keyword fields are matched exactly.
+const indexDefinition = `{
+	"settings": {
+		"number_of_shards": 1,
+		"analysis": {
+			"tokenizer": {
+				"blueprint": {"type": "char_group", "tokenize_on_chars": ["whitespace", "punctuation", "symbol"]}
+			},
+			"analyzer": {
+				"blueprint": {"type": "custom", "tokenizer": "blueprint", "filter": ["lowercase"]}
+			}
+		}
+	},
+	"mappings": {
+		"dynamic_templates": [
+			{"text": {"path_match": "text.*", "mapping": {"type": "text", "analyzer": "blueprint"}}},
+			{"keywords": {"path_match": "keywords.*", "mapping": {"type": "keyword"}}}
+		],
+		"properties": {
+			"id": {"type": "keyword"}
+		}
+	}
+}`
+
+// An error response from the server
+type esError struct {
+	StatusCode int `json:"status"`
+	Err        struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+	} `json:"error"`
+}
+
+func (e *esError) Error() string {
+	return fmt.Sprintf("elasticsearch error %v (%v): %v", e.Err.Type, e.StatusCode, e.Err.Reason)
+}
+
+// The document stored in the index
+type source struct {
+	ID       string            `json:"id"`
+	Text     map[string]string `json:"text,omitempty"`
+	Keywords map[string]string `json:"keywords,omitempty"`
+}
+
+// Sends a request to path, relative to the index URL, and decodes the response into result if it is not nil.
+// Returns an [esError] if the response is not successful.
+func (index *ElasticsearchIndex) do(ctx context.Context, method string, path string, body []byte, result any) error {
+	req, err := http.NewRequestWithContext(ctx, method, index.url+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := index.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	contents, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		esErr := &esError{}
+		json.Unmarshal(contents, esErr)
+		esErr.StatusCode = resp.StatusCode
+		return esErr
+	}
+	if result != nil {
+		return json.Unmarshal(contents, result)
+	}
+	return nil
+}
+
+// Creates the index, if it does not already exist
+func (index *ElasticsearchIndex) createIndex(ctx context.Context) error {
+	index.mu.Lock()
+	defer index.mu.Unlock()
+	if index.created {
+		return nil
+	}
+	err := index.do(ctx, http.MethodPut, "", []byte(indexDefinition), nil)
+	if esErr, isErr := err.(*esError); isErr && esErr.Err.Type == "resource_already_exists_exception" {
+		err = nil
+	}
+	index.created = err == nil
+	return err
+}
+
+// Implements backend.SearchIndex
+func (index *ElasticsearchIndex) Index(ctx context.Context, id string, doc backend.SearchDocument) error {
+	if err := backend.ValidateSearchDocument(id, doc); err != nil {
+		return err
+	}
+	if err := index.createIndex(ctx); err != nil {
+		return err
+	}
+	body, err := json.Marshal(source{ID: id, Text: doc.Text, Keywords: doc.Keywords})
+	if err != nil {
+		return err
+	}
+	return index.do(ctx, http.MethodPut, "/_doc/"+url.PathEscape(id)+"?refresh=wait_for", body, nil)
+}
+
+// Implements backend.SearchIndex
+func (index *ElasticsearchIndex) Delete(ctx context.Context, id string) error {
+	if id == "" {
+		return nil
+	}
+	err := index.do(ctx, http.MethodDelete, "/_doc/"+url.PathEscape(id)+"?refresh=wait_for", nil, nil)
+	if esErr, isErr := err.(*esError); isErr && esErr.StatusCode == http.StatusNotFound {
+		// The document or the index does not exist
+		return nil
+	}
+	return err
+}
+
+// The response to a search request
+type searchResult struct {
+	Hits struct {
+		Hits []struct {
+			ID     string  `json:"_id"`
+			Score  float64 `json:"_score"`
+			Source source  `json:"_source"`
+		} `json:"hits"`
+	} `json:"hits"`
+}
+
+// Implements backend.SearchIndex
+func (index *ElasticsearchIndex) Search(ctx context.Context, query backend.SearchQuery) ([]backend.SearchHit, error) {
+	var must any = map[string]any{"match_all": map[string]any{}}
+	if len(backend.SearchTerms(query.Text)) > 0 {
+		fields := []string{"text.*"}
+		if len(query.Fields) > 0 {
+			fields = nil
+			for _, field := range query.Fields {
+				fields = append(fields, "text."+field)
+			}
+		}
+		must = map[string]any{"multi_match": map[string]any{"query": query.Text, "fields": fields}}
+	}
+	filters := []any{}
+	for name, value := range query.Filters {
+		filters = append(filters, map[string]any{"term": map[string]any{"keywords." + name: value}})
+	}
+	limit := query.Limit
+	if limit <= 0 {
+		limit = backend.DefaultSearchLimit
+	}
+	body, err := json.Marshal(map[string]any{
+		"size":  limit,
+		"query": map[string]any{"bool": map[string]any{"must": must, "filter": filters}},
+		"sort":  []any{"_score", map[string]any{"id": "asc"}},
+		// Scores are not computed for sorted searches unless requested
+		"track_scores": true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result searchResult
+	err = index.do(ctx, http.MethodPost, "/_search", body, &result)
+	if esErr, isErr := err.(*esError); isErr && esErr.Err.Type == "index_not_found_exception" {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var hits []backend.SearchHit
+	for _, hit := range result.Hits.Hits {
+		hits = append(hits, backend.SearchHit{
+			ID:       hit.ID,
+			Score:    hit.Score,
+			Document: backend.SearchDocument{Text: hit.Source.Text, Keywords: hit.Source.Keywords},
+		})
+	}
+	return hits, nil
+}
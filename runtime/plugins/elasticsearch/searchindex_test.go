@@ -0,0 +1,19 @@
+package elasticsearch
+
+import (
+	"context"
+	"testing"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend/backendtest"
+	"github.com/stretchr/testify/require"
+)
+
+// Test requires a functional elasticsearch instance to be already running
+func TestConformance(t *testing.T) {
+	backendtest.RunSearchIndexSuite(t, func(t *testing.T) backend.SearchIndex {
+		index, err := NewElasticsearchIndex(context.Background(), "localhost:9200", "blueprint_test")
+		require.NoError(t, err)
+		return index
+	})
+}
@@ -0,0 +1,176 @@
+// Package hedging implements the runtime components of Blueprint's hedging plugin.
+//
+// Hedgers do not need to be used directly by application workflow specs.  Instead, this
+// code is included in a compiled application by applying the hedging modifier to the wiring spec.
+package hedging
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// Number of recent call latencies retained by a percentile-based [Hedger]
+	windowSize = 1000
+
+	// Minimum number of observed latencies before a percentile-based [Hedger] starts issuing hedged requests
+	minSamples = 20
+
+	// Number of new observations after which a percentile-based [Hedger] recomputes its delay
+	refreshInterval = 50
+)
+
+// A Hedger decides how long to wait for an outstanding call before issuing a second, hedged, call.
+//
+// The delay is either fixed, or derived from a percentile of the latencies of recently completed calls.
+// A Hedger is safe for concurrent use.
+type Hedger struct {
+	fixed      time.Duration
+	percentile float64
+	adaptive   bool // Whether the delay is derived from observed latencies; immutable
+
+	mu        sync.Mutex
+	latencies []time.Duration
+	next      int
+	stale     int
+	delay     time.Duration
+
+	calls  int64
+	hedged int64
+}
+
+// Instantiates a [Hedger] that issues a hedged call once a call has been outstanding for the specified delay.
+func NewFixedHedger(delay time.Duration) *Hedger {
+	return &Hedger{fixed: delay, delay: delay}
+}
+
+// Instantiates a [Hedger] that issues a hedged call once a call has been outstanding for longer than
+// the specified percentile (e.g. 95) of recently observed call latencies.
+//
+// The hedger does not issue hedged calls until it has observed a minimum number of call latencies.
+func NewPercentileHedger(percentile float64) *Hedger {
+	return &Hedger{percentile: percentile, adaptive: true, latencies: make([]time.Duration, 0, windowSize)}
+}
+
+// Returns the current hedging delay, and whether hedging is currently enabled.
+func (h *Hedger) Delay() (time.Duration, bool) {
+	if !h.adaptive {
+		return h.fixed, true
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.delay, len(h.latencies) >= minSamples
+}
+
+// Records the latency of a successfully completed call.  Only used by percentile-based hedgers.
+func (h *Hedger) Observe(latency time.Duration) {
+	if !h.adaptive {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.latencies) < windowSize {
+		h.latencies = append(h.latencies, latency)
+	} else {
+		h.latencies[h.next] = latency
+		h.next = (h.next + 1) % windowSize
+	}
+	h.stale++
+	if len(h.latencies) == minSamples || h.stale >= refreshInterval {
+		h.delay = h.computePercentile()
+		h.stale = 0
+	}
+}
+
+func (h *Hedger) computePercentile() time.Duration {
+	sorted := make([]time.Duration, len(h.latencies))
+	copy(sorted, h.latencies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	idx := int(h.percentile / 100 * float64(len(sorted)))
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	} else if idx < 0 {
+		idx = 0
+	}
+	return sorted[idx]
+}
+
+// Returns the total number of calls made through the hedger
+func (h *Hedger) Calls() int64 {
+	return atomic.LoadInt64(&h.calls)
+}
+
+// Returns the number of calls for which a hedged call was issued
+func (h *Hedger) Hedged() int64 {
+	return atomic.LoadInt64(&h.hedged)
+}
+
+type attempt[T any] struct {
+	result  T
+	err     error
+	latency time.Duration
+}
+
+// Invokes call, and if call has not returned by the time the hedger's delay has elapsed, invokes
+// call a second time.  The first successful response is returned, and the context of the
+// other outstanding call is cancelled.
+//
+// If the first call returns an error before the hedged call has been issued, then no hedged call
+// is issued and the error is returned.  If both calls return errors, the error of the last call to
+// return is returned.
+//
+// call must be idempotent.
+func Do[T any](ctx context.Context, h *Hedger, call func(context.Context) (T, error)) (result T, err error) {
+	atomic.AddInt64(&h.calls, 1)
+
+	results := make(chan attempt[T], 2)
+	var cancels []context.CancelFunc
+	defer func() {
+		for _, cancel := range cancels {
+			cancel()
+		}
+	}()
+
+	launch := func() {
+		attemptCtx, cancel := context.WithCancel(ctx)
+		cancels = append(cancels, cancel)
+		go func() {
+			start := time.Now()
+			r, err := call(attemptCtx)
+			results <- attempt[T]{result: r, err: err, latency: time.Since(start)}
+		}()
+	}
+
+	launch()
+	outstanding := 1
+
+	var timer <-chan time.Time
+	if delay, enabled := h.Delay(); enabled {
+		t := time.NewTimer(delay)
+		defer t.Stop()
+		timer = t.C
+	}
+
+	for {
+		select {
+		case <-timer:
+			timer = nil
+			atomic.AddInt64(&h.hedged, 1)
+			launch()
+			outstanding++
+		case a := <-results:
+			outstanding--
+			if a.err == nil {
+				h.Observe(a.latency)
+				return a.result, nil
+			}
+			result, err = a.result, a.err
+			if outstanding == 0 {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package hedging_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/hedging"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFastCallNotHedged(t *testing.T) {
+	h := hedging.NewFixedHedger(50 * time.Millisecond)
+
+	var invocations int64
+	v, err := hedging.Do(context.Background(), h, func(ctx context.Context) (int, error) {
+		atomic.AddInt64(&invocations, 1)
+		return 5, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 5, v)
+	require.Equal(t, int64(1), atomic.LoadInt64(&invocations))
+	require.Equal(t, int64(1), h.Calls())
+	require.Equal(t, int64(0), h.Hedged())
+}
+
+func TestSlowCallHedged(t *testing.T) {
+	h := hedging.NewFixedHedger(10 * time.Millisecond)
+
+	var invocations int64
+	cancelled := make(chan bool, 1)
+	v, err := hedging.Do(context.Background(), h, func(ctx context.Context) (int64, error) {
+		i := atomic.AddInt64(&invocations, 1)
+		if i == 1 {
+			// The first call is slow and should be cancelled once the hedged call returns
+			select {
+			case <-ctx.Done():
+				cancelled <- true
+				return 0, ctx.Err()
+			case <-time.After(time.Second):
+				cancelled <- false
+				return i, nil
+			}
+		}
+		return i, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), v)
+	require.Equal(t, int64(1), h.Hedged())
+	require.True(t, <-cancelled)
+}
+
+func TestErrorBeforeDelayNotHedged(t *testing.T) {
+	h := hedging.NewFixedHedger(50 * time.Millisecond)
+
+	var invocations int64
+	_, err := hedging.Do(context.Background(), h, func(ctx context.Context) (int, error) {
+		atomic.AddInt64(&invocations, 1)
+		return 0, errors.New("failed")
+	})
+	require.Error(t, err)
+	require.Equal(t, int64(1), atomic.LoadInt64(&invocations))
+	require.Equal(t, int64(0), h.Hedged())
+}
+
+func TestFailedCallFallsBackToHedge(t *testing.T) {
+	h := hedging.NewFixedHedger(10 * time.Millisecond)
+
+	var invocations int64
+	v, err := hedging.Do(context.Background(), h, func(ctx context.Context) (string, error) {
+		if atomic.AddInt64(&invocations, 1) == 1 {
+			time.Sleep(30 * time.Millisecond)
+			return "", errors.New("failed")
+		}
+		time.Sleep(50 * time.Millisecond)
+		return "hedged", nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "hedged", v)
+}
+
+func TestBothCallsFail(t *testing.T) {
+	h := hedging.NewFixedHedger(5 * time.Millisecond)
+
+	_, err := hedging.Do(context.Background(), h, func(ctx context.Context) (int, error) {
+		time.Sleep(20 * time.Millisecond)
+		return 0, errors.New("failed")
+	})
+	require.Error(t, err)
+	require.Equal(t, int64(1), h.Hedged())
+}
+
+func TestPercentileHedger(t *testing.T) {
+	h := hedging.NewPercentileHedger(90)
+
+	_, enabled := h.Delay()
+	require.False(t, enabled)
+
+	for i := 1; i <= 120; i++ {
+		h.Observe(time.Duration(i) * time.Millisecond)
+	}
+
+	delay, enabled := h.Delay()
+	require.True(t, enabled)
+	require.Equal(t, 109*time.Millisecond, delay)
+}
+
+func TestPercentileHedgerConcurrentUse(t *testing.T) {
+	h := hedging.NewPercentileHedger(90)
+
+	// Calls observe their latencies while other calls read the delay; run with -race
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 1; j <= 200; j++ {
+				if i%2 == 0 {
+					h.Observe(time.Duration(j) * time.Millisecond)
+				} else {
+					h.Delay()
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	delay, enabled := h.Delay()
+	require.True(t, enabled)
+	require.Greater(t, delay, time.Duration(0))
+}
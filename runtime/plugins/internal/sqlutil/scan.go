@@ -0,0 +1,82 @@
+package sqlutil
+
+import "strings"
+
+// Returns the index after the string literal, quoted identifier, dollar-quoted string, or comment that
+// starts at position i of query, or i if none starts there.  If the section is not closed, returns
+// len(query).
+//
+// Sections are quoted with ', " or `, in which doubled quote characters are escaped quotes, and
+// backslashes escape characters in postgres's escape strings, e.g. E'it\'s'.  Dollar-quoted strings
+// are postgres's, e.g. $$ ... $$ or $body$ ... $body$.  Comments are -- line comments, whose newline is
+// not included, and /* block comments */.
+func SkipQuoted(query string, i int) int {
+	c := query[i]
+	switch {
+	case c == '\'' || c == '"' || c == '`':
+		escapes := c == '\'' && i > 0 && (query[i-1] == 'E' || query[i-1] == 'e')
+		return closingQuote(query, i+1, c, escapes)
+	case c == '-' && i+1 < len(query) && query[i+1] == '-':
+		return indexFrom(query, i+2, "\n")
+	case c == '/' && i+1 < len(query) && query[i+1] == '*':
+		return min(indexFrom(query, i+2, "*/")+2, len(query))
+	case c == '$':
+		if tag := dollarTag(query, i); tag != "" {
+			return min(indexFrom(query, i+len(tag), tag)+len(tag), len(query))
+		}
+	}
+	return i
+}
+
+// Returns the index after the quote character that closes a quoted section starting at i,
+// or len(query) if the section is not closed.  Doubled quote characters are escaped quotes.
+func closingQuote(query string, i int, quote byte, backslashEscapes bool) int {
+	for ; i < len(query); i++ {
+		switch query[i] {
+		case '\\':
+			if backslashEscapes {
+				i++
+			}
+		case quote:
+			if i+1 < len(query) && query[i+1] == quote {
+				i++
+			} else {
+				return i + 1
+			}
+		}
+	}
+	return len(query)
+}
+
+// Returns the index of s in query at or after i, or len(query) if there is none
+func indexFrom(query string, i int, s string) int {
+	if j := strings.Index(query[min(i, len(query)):], s); j >= 0 {
+		return i + j
+	}
+	return len(query)
+}
+
+// If a dollar-quoted string starts at i, e.g. $$ or $body$, returns its tag.  Otherwise returns the empty string.
+func dollarTag(query string, i int) string {
+	if i > 0 && isIdentChar(query[i-1]) {
+		// Identifiers can contain dollar signs
+		return ""
+	}
+	for j := i + 1; j < len(query); j++ {
+		c := query[j]
+		switch {
+		case c == '$':
+			return query[i : j+1]
+		case '0' <= c && c <= '9' && j == i+1:
+			// A positional parameter, e.g. $1
+			return ""
+		case !isIdentChar(c):
+			return ""
+		}
+	}
+	return ""
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c == '$' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') || c >= 0x80
+}
@@ -0,0 +1,63 @@
+// Package sqlutil implements functionality shared by the sqlx-based implementations of the
+// [backend.RelationalDB] interface.
+package sqlutil
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// The methods shared by [sqlx.DB] and [sqlx.Tx]
+type Queryer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	GetContext(ctx context.Context, dst interface{}, query string, args ...any) error
+	SelectContext(ctx context.Context, dst interface{}, query string, args ...any) error
+}
+
+// The context key of a transaction started by WithTransaction
+type txKey struct {
+	db *sqlx.DB
+}
+
+// Implements WithTransaction of the [backend.RelationalDB] interface for db.
+//
+// The transaction is stored in the context passed to fn; use [Conn] to run queries in it.  If ctx
+// already belongs to a transaction of db, fn joins that transaction.  The transaction is rolled back
+// if fn returns an error or panics, and committed otherwise.
+func WithTransaction(ctx context.Context, db *sqlx.DB, fn func(ctx context.Context) error) error {
+	if _, inTx := ctx.Value(txKey{db}).(*sqlx.Tx); inTx {
+		return fn(ctx)
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{db}, tx)); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return errors.Join(err, rollbackErr)
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+// Returns the transaction of db in ctx, if there is one, or else db
+func Conn(ctx context.Context, db *sqlx.DB) Queryer {
+	if tx, inTx := ctx.Value(txKey{db}).(*sqlx.Tx); inTx {
+		return tx
+	}
+	return db
+}
@@ -0,0 +1,184 @@
+package kafka
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	kafka "github.com/segmentio/kafka-go"
+)
+
+// Implements a [backend.PubSub] that uses a single kafka topic.
+//
+// Published items are written to the kafka topic, keyed by their pubsub topic, so items published
+// to the same pubsub topic are delivered in order.  Each subscriber group is a kafka consumer group
+// that receives every item and discards those that match none of the patterns its members subscribed
+// with.  The patterns of a group are only known to the subscriptions within one process, so members
+// of a group in different processes should subscribe with the same patterns.
+//
+// Each subscriber group has a retry topic with the suffix ".retry", to which negatively acknowledged
+// items are rewritten, and a dead-letter topic with the suffix ".dlq".  A new subscriber group only
+// receives items published after its first subscription.
+type KafkaPubSub struct {
+	addr   string
+	topic  string
+	opts   Options
+	writer *kafka.Writer
+
+	mu     sync.Mutex
+	groups map[string]*subscriberGroup // Named subscriber groups with subscriptions in this process
+}
+
+// The patterns that the members of a subscriber group subscribed with
+type subscriberGroup struct {
+	patterns []string
+}
+
+// Header used for the pubsub topic of a published item
+const topicHeader = "x-blueprint-topic"
+
+// Instantiates a new [KafkaPubSub] that publishes to and subscribes from the kafka topic topic.
+func NewKafkaPubSub(ctx context.Context, addr string, topic string) (*KafkaPubSub, error) {
+	return NewKafkaPubSubWithOptions(ctx, addr, topic, Options{})
+}
+
+// Instantiates a new [KafkaPubSub] with the specified options, which apply to each subscription.
+func NewKafkaPubSubWithOptions(ctx context.Context, addr string, topic string, opts Options) (*KafkaPubSub, error) {
+	if opts.Partitions == 0 {
+		opts.Partitions = 8
+	}
+	if err := createTopics(ctx, addr, opts.Partitions, topic); err != nil {
+		return nil, err
+	}
+	return &KafkaPubSub{addr: addr, topic: topic, opts: opts, writer: newWriter(addr), groups: make(map[string]*subscriberGroup)}, nil
+}
+
+// Publish implements backend.PubSub
+func (ps *KafkaPubSub) Publish(ctx context.Context, topic string, item interface{}) error {
+	raw_bytes, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	return ps.writer.WriteMessages(ctx, kafka.Message{
+		Topic:   ps.topic,
+		Key:     []byte(topic),
+		Value:   raw_bytes,
+		Headers: []kafka.Header{{Key: topicHeader, Value: []byte(topic)}},
+	})
+}
+
+// Subscribe implements backend.PubSub
+//
+// Anonymous subscriptions are consumer groups with randomly generated names, whose retry and
+// dead-letter topics are deleted when the subscription is closed.
+func (ps *KafkaPubSub) Subscribe(ctx context.Context, pattern string, group string) (backend.Subscription, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("invalid empty topic pattern")
+	}
+	anonymous := group == ""
+	if anonymous {
+		id := make([]byte, 8)
+		if _, err := rand.Read(id); err != nil {
+			return nil, err
+		}
+		group = "anonymous-" + hex.EncodeToString(id)
+	}
+
+	groupID := ps.topic + "." + group
+	retryTopic, dlqTopic := groupID+".retry", groupID+".dlq"
+	if err := createTopics(ctx, ps.addr, ps.opts.Partitions, retryTopic, dlqTopic); err != nil {
+		return nil, err
+	}
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     []string{ps.addr},
+		GroupID:     groupID,
+		GroupTopics: []string{ps.topic, retryTopic},
+		StartOffset: kafka.LastOffset,
+	})
+	return &kafkaSubscription{ps: ps, group: ps.join(group, anonymous, pattern), anonymous: anonymous, reader: reader, retryTopic: retryTopic, dlqTopic: dlqTopic}, nil
+}
+
+// Adds pattern to the patterns of the subscriber group named group
+func (ps *KafkaPubSub) join(group string, anonymous bool, pattern string) *subscriberGroup {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if anonymous {
+		return &subscriberGroup{patterns: []string{pattern}}
+	}
+	g, exists := ps.groups[group]
+	if !exists {
+		g = &subscriberGroup{}
+		ps.groups[group] = g
+	}
+	if !slices.Contains(g.patterns, pattern) {
+		g.patterns = append(g.patterns, pattern)
+	}
+	return g
+}
+
+// Reports whether any of the group's patterns match topic
+func (ps *KafkaPubSub) matches(g *subscriberGroup, topic string) bool {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	return slices.ContainsFunc(g.patterns, func(pattern string) bool {
+		return backend.MatchTopic(pattern, topic)
+	})
+}
+
+// Implements the [backend.Subscription] interface for a [KafkaPubSub]
+type kafkaSubscription struct {
+	ps         *KafkaPubSub
+	group      *subscriberGroup
+	anonymous  bool
+	reader     *kafka.Reader
+	retryTopic string
+	dlqTopic   string
+}
+
+// Receive implements backend.Subscription
+func (s *kafkaSubscription) Receive(ctx context.Context) (backend.Delivery, bool, error) {
+	for {
+		msg, err := s.reader.FetchMessage(ctx)
+		if ctx.Err() != nil {
+			return nil, false, nil
+		} else if err != nil {
+			return nil, false, err
+		}
+
+		if s.ps.matches(s.group, header(msg, topicHeader)) {
+			d := &kafkaDelivery{
+				reader:        s.reader,
+				writer:        s.ps.writer,
+				msg:           msg,
+				retryTopic:    s.retryTopic,
+				dlqTopic:      s.dlqTopic,
+				maxDeliveries: s.ps.opts.MaxDeliveries,
+			}
+			return d, true, nil
+		}
+
+		// Discard items that the group did not subscribe to
+		if err := s.reader.CommitMessages(ctx, msg); err != nil {
+			return nil, false, err
+		}
+	}
+}
+
+// Close implements backend.Subscription
+//
+// Unacknowledged items are redelivered to the subscription's group.
+func (s *kafkaSubscription) Close(ctx context.Context) error {
+	err := s.reader.Close()
+	if s.anonymous {
+		client := &kafka.Client{Addr: kafka.TCP(s.ps.addr)}
+		_, deleteErr := client.DeleteTopics(ctx, &kafka.DeleteTopicsRequest{Topics: []string{s.retryTopic, s.dlqTopic}})
+		err = errors.Join(err, deleteErr)
+	}
+	return err
+}
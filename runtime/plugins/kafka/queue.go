@@ -0,0 +1,311 @@
+// Package kafka provides client-wrapper implementations of the [backend.Queue] and [backend.PubSub]
+// interfaces for a kafka broker.
+//
+// Items are written to partitioned kafka topics.  Items pushed with the same [backend.PushOptions.Key]
+// are written to the same partition, so they are delivered in the order they were pushed.
+//
+// Items are received through kafka consumer groups.  Kafka tracks a consumer group's progress as
+// an offset per partition, so acknowledging an item also acknowledges any earlier items of the same
+// partition that the group has received.  An item that is received but not acknowledged is
+// redelivered if the consumer fails.  An item that is negatively acknowledged is rewritten to the
+// topic with an incremented delivery count, after any items that were written since.
+//
+// The clients create the topics that they use if they do not already exist.
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	kafka "github.com/segmentio/kafka-go"
+)
+
+// Implements a Queue that uses the kafka-go package.
+//
+// All clients of a queue are members of the same consumer group, so each item is received by only one client.
+// Each queue has a dead-letter topic with the suffix ".dlq", which can itself be used as a queue.
+type KafkaQueue struct {
+	addr   string
+	topic  string
+	opts   Options
+	writer *kafka.Writer
+
+	mu      sync.Mutex
+	reader  *kafka.Reader   // Created by the first call to Receive
+	pending []kafka.Message // Delayed items that were received but not yet due when Receive returned
+}
+
+// Options for a [KafkaQueue] or [KafkaPubSub]
+type Options struct {
+	// The number of partitions of topics created by the client.  The number of partitions limits the
+	// number of members of a consumer group that receive items concurrently.  Defaults to 8.
+	Partitions int
+
+	// The maximum number of times an item is delivered.  An item that is negatively acknowledged
+	// on its final delivery is moved to the dead-letter topic.  Zero means unlimited.
+	//
+	// Only negative acknowledgements count towards the limit; redeliveries due to consumer
+	// failures do not.
+	MaxDeliveries int
+}
+
+const (
+	// Header used to count the deliveries of an item that has been requeued
+	deliveryCountHeader = "x-blueprint-delivery-count"
+
+	// Header used for the time at which a delayed item is due, in unix milliseconds
+	deliverAtHeader = "x-blueprint-deliver-at"
+)
+
+// Instantiates a new [KafkaQueue] that provides a queue interface via the kafka topic topic.
+func NewKafkaQueue(ctx context.Context, addr string, topic string) (*KafkaQueue, error) {
+	return NewKafkaQueueWithOptions(ctx, addr, topic, Options{})
+}
+
+// Instantiates a new [KafkaQueue] with the specified options.
+//
+// Also creates the queue's dead-letter topic topic.dlq.
+func NewKafkaQueueWithOptions(ctx context.Context, addr string, topic string, opts Options) (*KafkaQueue, error) {
+	if opts.Partitions == 0 {
+		opts.Partitions = 8
+	}
+	if err := createTopics(ctx, addr, opts.Partitions, topic, topic+".dlq"); err != nil {
+		return nil, err
+	}
+	return &KafkaQueue{addr: addr, topic: topic, opts: opts, writer: newWriter(addr)}, nil
+}
+
+// Creates the specified topics, if they do not already exist
+func createTopics(ctx context.Context, addr string, partitions int, topics ...string) error {
+	client := &kafka.Client{Addr: kafka.TCP(addr)}
+	req := &kafka.CreateTopicsRequest{}
+	for _, topic := range topics {
+		req.Topics = append(req.Topics, kafka.TopicConfig{Topic: topic, NumPartitions: partitions, ReplicationFactor: 1})
+	}
+	res, err := client.CreateTopics(ctx, req)
+	if err != nil {
+		return err
+	}
+	for topic, err := range res.Errors {
+		if err != nil && !errors.Is(err, kafka.TopicAlreadyExists) {
+			return fmt.Errorf("unable to create kafka topic %v: %w", topic, err)
+		}
+	}
+	return nil
+}
+
+// Creates a writer that writes to the topic of each message, partitioning messages by their keys
+func newWriter(addr string) *kafka.Writer {
+	return &kafka.Writer{
+		Addr:         kafka.TCP(addr),
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		BatchTimeout: time.Millisecond,
+	}
+}
+
+// Push implements backend.Queue
+func (q *KafkaQueue) Push(ctx context.Context, item interface{}) (bool, error) {
+	return q.PushWithOptions(ctx, item, backend.PushOptions{})
+}
+
+// PushWithOptions implements backend.Queue
+//
+// A delayed item is received in order with the other items of its partition, so it also delays the
+// items of its partition that were pushed after it.
+func (q *KafkaQueue) PushWithOptions(ctx context.Context, item interface{}, opts backend.PushOptions) (bool, error) {
+	raw_bytes, err := json.Marshal(item)
+	if err != nil {
+		return false, err
+	}
+	msg := kafka.Message{Topic: q.topic, Value: raw_bytes}
+	if opts.Key != "" {
+		msg.Key = []byte(opts.Key)
+	}
+	for k, v := range opts.Headers {
+		msg.Headers = append(msg.Headers, kafka.Header{Key: k, Value: []byte(v)})
+	}
+	if opts.Delay > 0 {
+		deliverAt := time.Now().Add(opts.Delay).UnixMilli()
+		msg.Headers = append(msg.Headers, kafka.Header{Key: deliverAtHeader, Value: []byte(strconv.FormatInt(deliverAt, 10))})
+	}
+
+	err = q.writer.WriteMessages(ctx, msg)
+	if ctx.Err() != nil {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Pop implements backend.Queue
+func (q *KafkaQueue) Pop(ctx context.Context, dst interface{}) (bool, error) {
+	d, received, err := q.Receive(ctx)
+	if !received || err != nil {
+		return received, err
+	}
+	if err := d.Ack(ctx); err != nil {
+		return true, err
+	}
+	return true, d.Decode(dst)
+}
+
+// Receive implements backend.Queue
+//
+// The client joins the queue's consumer group on its first call to Receive.
+func (q *KafkaQueue) Receive(ctx context.Context) (backend.Delivery, bool, error) {
+	q.mu.Lock()
+	if q.reader == nil {
+		q.reader = kafka.NewReader(kafka.ReaderConfig{
+			Brokers:     []string{q.addr},
+			GroupID:     q.topic,
+			Topic:       q.topic,
+			StartOffset: kafka.FirstOffset,
+		})
+	}
+	reader := q.reader
+	var msg *kafka.Message
+	if len(q.pending) > 0 {
+		msg = &q.pending[0]
+		q.pending = q.pending[1:]
+	}
+	q.mu.Unlock()
+
+	if msg == nil {
+		m, err := reader.FetchMessage(ctx)
+		if ctx.Err() != nil {
+			return nil, false, nil
+		} else if err != nil {
+			return nil, false, err
+		}
+		msg = &m
+	}
+
+	if !waitUntilDue(ctx, *msg) {
+		q.mu.Lock()
+		q.pending = append(q.pending, *msg)
+		q.mu.Unlock()
+		return nil, false, nil
+	}
+	d := &kafkaDelivery{
+		reader:        reader,
+		writer:        q.writer,
+		msg:           *msg,
+		retryTopic:    q.topic,
+		dlqTopic:      q.topic + ".dlq",
+		maxDeliveries: q.opts.MaxDeliveries,
+	}
+	return d, true, nil
+}
+
+// Waits until msg is due to be delivered.  Reports false if ctx is done first.
+func waitUntilDue(ctx context.Context, msg kafka.Message) bool {
+	deliverAt, err := strconv.ParseInt(header(msg, deliverAtHeader), 10, 64)
+	if err != nil {
+		return true
+	}
+	delay := time.Until(time.UnixMilli(deliverAt))
+	if delay <= 0 {
+		return true
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Returns the value of the header key of msg, or the empty string if msg does not have the header
+func header(msg kafka.Message, key string) string {
+	for _, h := range msg.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+// Implements the [backend.Delivery] interface for a kafka message
+type kafkaDelivery struct {
+	reader        *kafka.Reader
+	writer        *kafka.Writer
+	msg           kafka.Message
+	retryTopic    string // The topic to which requeued items are written
+	dlqTopic      string // The topic to which dead-lettered items are written
+	maxDeliveries int
+	done          bool
+}
+
+// Decode implements backend.Delivery
+func (d *kafkaDelivery) Decode(dst interface{}) error {
+	var val interface{}
+	if err := json.Unmarshal(d.msg.Value, &val); err != nil {
+		return err
+	}
+	return backend.CopyResult(val, dst)
+}
+
+// Headers implements backend.Delivery
+func (d *kafkaDelivery) Headers() map[string]string {
+	headers := make(map[string]string)
+	for _, h := range d.msg.Headers {
+		if h.Key != deliveryCountHeader && h.Key != deliverAtHeader && h.Key != topicHeader {
+			headers[h.Key] = string(h.Value)
+		}
+	}
+	return headers
+}
+
+// DeliveryCount implements backend.Delivery
+func (d *kafkaDelivery) DeliveryCount() int {
+	count, err := strconv.Atoi(header(d.msg, deliveryCountHeader))
+	if err != nil {
+		return 1
+	}
+	return count + 1
+}
+
+// Ack implements backend.Delivery
+func (d *kafkaDelivery) Ack(ctx context.Context) error {
+	if d.done {
+		return backend.ErrDeliveryClosed
+	}
+	d.done = true
+	return d.reader.CommitMessages(ctx, d.msg)
+}
+
+// Nack implements backend.Delivery
+//
+// A requeued item is rewritten to the tail of its partition, with an incremented delivery count.
+func (d *kafkaDelivery) Nack(ctx context.Context, requeue bool) error {
+	if d.done {
+		return backend.ErrDeliveryClosed
+	}
+	d.done = true
+
+	count := d.DeliveryCount()
+	msg := kafka.Message{Topic: d.retryTopic, Key: d.msg.Key, Value: d.msg.Value}
+	if !requeue || (d.maxDeliveries > 0 && count >= d.maxDeliveries) {
+		msg.Topic = d.dlqTopic
+	}
+	for _, h := range d.msg.Headers {
+		if h.Key != deliveryCountHeader && h.Key != deliverAtHeader {
+			msg.Headers = append(msg.Headers, h)
+		}
+	}
+	msg.Headers = append(msg.Headers, kafka.Header{Key: deliveryCountHeader, Value: []byte(strconv.Itoa(count))})
+
+	if err := d.writer.WriteMessages(ctx, msg); err != nil {
+		// The item remains unacknowledged
+		return err
+	}
+	return d.reader.CommitMessages(ctx, d.msg)
+}
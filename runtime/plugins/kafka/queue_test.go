@@ -0,0 +1,129 @@
+package kafka
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend/backendtest"
+	"github.com/stretchr/testify/require"
+)
+
+// Test requires a functional kafka instance to be already running
+func TestConformance(t *testing.T) {
+	backendtest.RunQueueSuite(t, func(t *testing.T) backend.Queue {
+		// Topic names cannot contain slashes
+		q, err := NewKafkaQueue(context.Background(), "localhost:9092", strings.ReplaceAll(t.Name(), "/", "."))
+		require.NoError(t, err)
+		return q
+	})
+}
+
+func TestPushPop(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	q, err := NewKafkaQueue(ctx, "localhost:9092", "queue")
+	require.NoError(t, err)
+
+	snd := "hello"
+	{
+		// Send an item should succeed
+		success, err := q.Push(ctx, snd)
+		require.NoError(t, err)
+		require.True(t, success)
+	}
+
+	{
+		// Pop should return the item
+		var rcv string
+		success, err := q.Pop(ctx, &rcv)
+		require.NoError(t, err)
+		require.True(t, success)
+		require.Equal(t, snd, rcv)
+	}
+}
+
+func TestKeyOrdering(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	q, err := NewKafkaQueue(ctx, "localhost:9092", "ordered")
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		success, err := q.PushWithOptions(ctx, i, backend.PushOptions{Key: "user1"})
+		require.NoError(t, err)
+		require.True(t, success)
+	}
+
+	// Items with the same key are received in order
+	for i := 0; i < 10; i++ {
+		var rcv int
+		success, err := q.Pop(ctx, &rcv)
+		require.NoError(t, err)
+		require.True(t, success)
+		require.Equal(t, i, rcv)
+	}
+}
+
+func TestNackDeadLetter(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	q, err := NewKafkaQueueWithOptions(ctx, "localhost:9092", "nacked", Options{MaxDeliveries: 2})
+	require.NoError(t, err)
+	dlq, err := NewKafkaQueue(ctx, "localhost:9092", "nacked.dlq")
+	require.NoError(t, err)
+
+	success, err := q.PushWithOptions(ctx, "hello", backend.PushOptions{Headers: map[string]string{"trace": "abc"}})
+	require.NoError(t, err)
+	require.True(t, success)
+
+	for i := 1; i <= 2; i++ {
+		d, received, err := q.Receive(ctx)
+		require.NoError(t, err)
+		require.True(t, received)
+		require.Equal(t, i, d.DeliveryCount())
+		require.Equal(t, map[string]string{"trace": "abc"}, d.Headers())
+		require.NoError(t, d.Nack(ctx, true))
+	}
+
+	// The item reached the delivery limit, so it was moved to the dead-letter queue
+	var rcv string
+	success, err = dlq.Pop(ctx, &rcv)
+	require.NoError(t, err)
+	require.True(t, success)
+	require.Equal(t, "hello", rcv)
+}
+
+func TestPubSub(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	ps, err := NewKafkaPubSub(ctx, "localhost:9092", "events")
+	require.NoError(t, err)
+
+	timeline, err := ps.Subscribe(ctx, "post.*", "timeline")
+	require.NoError(t, err)
+	search, err := ps.Subscribe(ctx, "post.#", "search")
+	require.NoError(t, err)
+
+	require.NoError(t, ps.Publish(ctx, "user.created", "ignored"))
+	require.NoError(t, ps.Publish(ctx, "post.created", "hello"))
+
+	for _, sub := range []backend.Subscription{timeline, search} {
+		// Each group should receive the matching item
+		d, received, err := sub.Receive(ctx)
+		require.NoError(t, err)
+		require.True(t, received)
+
+		var rcv string
+		require.NoError(t, d.Decode(&rcv))
+		require.Equal(t, "hello", rcv)
+		require.NoError(t, d.Ack(ctx))
+		require.NoError(t, sub.Close(ctx))
+	}
+}
@@ -2,9 +2,13 @@
 package memcached
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"sync"
+	"time"
 
 	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
 	"github.com/bradfitz/gomemcache/memcache"
@@ -54,7 +58,12 @@ func (m *Memcached) Incr(ctx context.Context, key string) (int64, error) {
 
 // Implements the backend.Cache interface
 func (m *Memcached) Delete(ctx context.Context, key string) error {
-	return m.Client.Delete(key)
+	err := m.Client.Delete(key)
+	if err == memcache.ErrCacheMiss {
+		// Like other caches, deleting a nonexistent key is not an error
+		return nil
+	}
+	return err
 }
 
 // Implements the backend.Cache interface
@@ -94,3 +103,90 @@ func (m *Memcached) Mset(ctx context.Context, keys []string, values []interface{
 	}
 	return nil
 }
+
+// Memcached interprets expiration times of more than 30 days as absolute unix timestamps
+const maxRelativeExpiration = 30 * 24 * time.Hour
+
+// Converts ttl to a memcached expiration time, rounding up to whole seconds
+func expiration(ttl time.Duration) (int32, error) {
+	if ttl < 0 {
+		return 0, fmt.Errorf("invalid ttl %v", ttl)
+	}
+	if ttl > maxRelativeExpiration {
+		return int32(time.Now().Add(ttl).Unix()), nil
+	}
+	return int32((ttl + time.Second - 1) / time.Second), nil
+}
+
+// Implements the backend.Cache interface
+func (m *Memcached) PutWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	exp, err := expiration(ttl)
+	if err != nil {
+		return err
+	}
+	marshaled_val, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return m.Client.Set(&memcache.Item{Key: key, Value: marshaled_val, Expiration: exp})
+}
+
+// Implements the backend.Cache interface
+func (m *Memcached) Expire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	exp, err := expiration(ttl)
+	if err != nil {
+		return false, err
+	}
+	err = m.Client.Touch(key, exp)
+	if err == memcache.ErrCacheMiss {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Implements the backend.Cache interface
+func (m *Memcached) SetNX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
+	exp, err := expiration(ttl)
+	if err != nil {
+		return false, err
+	}
+	marshaled_val, err := json.Marshal(value)
+	if err != nil {
+		return false, err
+	}
+	err = m.Client.Add(&memcache.Item{Key: key, Value: marshaled_val, Expiration: exp})
+	if err == memcache.ErrNotStored {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Implements the backend.Cache interface
+//
+// Memcached does not report the remaining ttl of a key, so a replaced value does not expire.
+func (m *Memcached) CompareAndSwap(ctx context.Context, key string, old interface{}, new interface{}) (bool, error) {
+	oldVal, err := json.Marshal(old)
+	if err != nil {
+		return false, err
+	}
+	newVal, err := json.Marshal(new)
+	if err != nil {
+		return false, err
+	}
+	it, err := m.Client.Get(key)
+	if err == memcache.ErrCacheMiss {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if !bytes.Equal(it.Value, oldVal) {
+		return false, nil
+	}
+	it.Value = newVal
+	err = m.Client.CompareAndSwap(it)
+	if errors.Is(err, memcache.ErrCASConflict) || errors.Is(err, memcache.ErrNotStored) {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -4,7 +4,10 @@ import (
 	"context"
 	"testing"
 
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend/backendtest"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type someData struct {
@@ -159,3 +162,11 @@ func TestMemcachedMset(t *testing.T) {
 		t.Errorf("Incorrect value received from server. Expected: {7 NotVaastav}, Actual: %v", val1)
 	}
 }
+
+func TestMemcachedConformance(t *testing.T) {
+	backendtest.RunCacheSuite(t, func(t *testing.T) backend.Cache {
+		memcached, err := NewMemcachedClient(context.Background(), "localhost:11211")
+		require.NoError(t, err)
+		return memcached
+	})
+}
@@ -0,0 +1,241 @@
+// Package minio provides a client-wrapper implementation of the [backend.BlobStore] interface for MinIO, and
+// other S3-compatible object stores.
+//
+// The client stores objects in a single bucket, which is created when the first object is stored.  Requests
+// are signed with AWS Signature Version 4, and use path-style URLs, e.g. http://localhost:9000/bucket/key.
+package minio
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+)
+
+// The region used to sign requests.  MinIO accepts requests for its default region, us-east-1.
+const region = "us-east-1"
+
+// Implements a [backend.BlobStore] that stores objects in a bucket of an S3-compatible object store
+type MinioBlobStore struct {
+	backend.BlobStore
+
+	addr      string
+	bucket    string
+	accessKey string
+	secretKey string
+	client    *http.Client
+}
+
+// Instantiates a new [MinioBlobStore] that stores objects in bucket, on the server at addr.
+//
+// addr is the host and port of the server, e.g. localhost:9000.  accessKey and secretKey are the credentials
+// used to sign requests; for a MinIO server, these are the root user and password.
+func NewMinioBlobStore(ctx context.Context, addr string, bucket string, accessKey string, secretKey string) (*MinioBlobStore, error) {
+	if bucket == "" {
+		return nil, fmt.Errorf("a bucket name is required")
+	}
+	return &MinioBlobStore{
+		addr:      addr,
+		bucket:    bucket,
+		accessKey: accessKey,
+		secretKey: secretKey,
+		client:    &http.Client{},
+	}, nil
+}
+
+// An error response from the server
+type s3Error struct {
+	StatusCode int    `xml:"-"`
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+}
+
+func (e *s3Error) Error() string {
+	return fmt.Sprintf("s3 error %v (%v): %v", e.Code, e.StatusCode, e.Message)
+}
+
+// Reports whether err is an error response from the server with one of the given codes
+func isCode(err error, codes ...string) bool {
+	var s3err *s3Error
+	if errors.As(err, &s3err) {
+		for _, code := range codes {
+			if s3err.Code == code {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Sends a signed request for key, or for the bucket if key is empty.  Returns an [s3Error] if the
+// response is not successful.  The caller must close the body of a successful response.
+func (s *MinioBlobStore) do(ctx context.Context, method string, key string, query url.Values, header http.Header, body []byte) (*http.Response, error) {
+	path := "/" + s.bucket
+	if key != "" {
+		path += "/" + key
+	}
+	u := &url.URL{Scheme: "http", Host: s.addr, Path: path, RawPath: encodePath(path), RawQuery: encodeQuery(query)}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	sum := sha256.Sum256(body)
+	sign(req, hex.EncodeToString(sum[:]), s.accessKey, s.secretKey, region, time.Now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	s3err := &s3Error{StatusCode: resp.StatusCode}
+	if contents, _ := io.ReadAll(resp.Body); len(contents) > 0 {
+		xml.Unmarshal(contents, s3err)
+	}
+	if s3err.Code == "" {
+		// Responses to HEAD requests, and some errors, have no body
+		s3err.Code = http.StatusText(resp.StatusCode)
+	}
+	return nil, s3err
+}
+
+// Creates the bucket, if it does not already exist
+func (s *MinioBlobStore) createBucket(ctx context.Context) error {
+	resp, err := s.do(ctx, http.MethodPut, "", nil, nil, nil)
+	if isCode(err, "BucketAlreadyOwnedByYou", "BucketAlreadyExists") {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// Implements backend.BlobStore
+func (s *MinioBlobStore) Put(ctx context.Context, key string, data io.Reader, opts backend.BlobOptions) error {
+	if err := backend.ValidateBlobKey(key); err != nil {
+		return err
+	}
+	metadata, err := backend.ValidateBlobMetadata(opts.Metadata)
+	if err != nil {
+		return err
+	}
+	contents, err := io.ReadAll(data)
+	if err != nil {
+		return err
+	}
+
+	header := make(http.Header)
+	header.Set("Content-Type", opts.ContentType)
+	if opts.ContentType == "" {
+		header.Set("Content-Type", backend.DefaultBlobContentType)
+	}
+	for k, v := range metadata {
+		header.Set("X-Amz-Meta-"+k, v)
+	}
+
+	resp, err := s.do(ctx, http.MethodPut, key, nil, header, contents)
+	if isCode(err, "NoSuchBucket") {
+		if err := s.createBucket(ctx); err != nil {
+			return err
+		}
+		resp, err = s.do(ctx, http.MethodPut, key, nil, header, contents)
+	}
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// Implements backend.BlobStore
+func (s *MinioBlobStore) Get(ctx context.Context, key string) (io.ReadCloser, backend.BlobInfo, error) {
+	if err := backend.ValidateBlobKey(key); err != nil {
+		return nil, backend.BlobInfo{}, backend.ErrBlobNotFound
+	}
+	resp, err := s.do(ctx, http.MethodGet, key, nil, nil, nil)
+	if isCode(err, "NoSuchKey", "NoSuchBucket") {
+		return nil, backend.BlobInfo{}, backend.ErrBlobNotFound
+	} else if err != nil {
+		return nil, backend.BlobInfo{}, err
+	}
+
+	info := backend.BlobInfo{Key: key, ContentType: resp.Header.Get("Content-Type")}
+	info.Size, _ = strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64)
+	info.LastModified, _ = http.ParseTime(resp.Header.Get("Last-Modified"))
+	for k, v := range resp.Header {
+		if name, isMeta := strings.CutPrefix(strings.ToLower(k), "x-amz-meta-"); isMeta && len(v) > 0 {
+			if info.Metadata == nil {
+				info.Metadata = make(map[string]string)
+			}
+			info.Metadata[name] = v[0]
+		}
+	}
+	return resp.Body, info, nil
+}
+
+// Implements backend.BlobStore
+func (s *MinioBlobStore) Delete(ctx context.Context, key string) error {
+	if backend.ValidateBlobKey(key) != nil {
+		return nil
+	}
+	resp, err := s.do(ctx, http.MethodDelete, key, nil, nil, nil)
+	if isCode(err, "NoSuchKey", "NoSuchBucket") {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// The response to a ListObjectsV2 request
+type listBucketResult struct {
+	IsTruncated           bool
+	NextContinuationToken string
+	Contents              []struct {
+		Key          string
+		Size         int64
+		LastModified time.Time
+	}
+}
+
+// Implements backend.BlobStore
+func (s *MinioBlobStore) List(ctx context.Context, prefix string) ([]backend.BlobInfo, error) {
+	var infos []backend.BlobInfo
+	query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+	for {
+		resp, err := s.do(ctx, http.MethodGet, "", query, nil, nil)
+		if isCode(err, "NoSuchBucket") {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+		var result listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range result.Contents {
+			infos = append(infos, backend.BlobInfo{Key: c.Key, Size: c.Size, LastModified: c.LastModified})
+		}
+		if !result.IsTruncated {
+			return infos, nil
+		}
+		query.Set("continuation-token", result.NextContinuationToken)
+	}
+}
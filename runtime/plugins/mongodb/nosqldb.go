@@ -4,6 +4,8 @@ package mongodb
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
 	"go.mongodb.org/mongo-driver/bson"
@@ -23,19 +25,59 @@ type MongoCollection struct {
 }
 
 // Instantiates a new MongoDB client-wrapper instance which connects to a mongodb server running at `addr`.
+// If username is not empty, the client authenticates as username with password.
 // REQUIRED: A mongodb server should be running at `addr`
-func NewMongoDB(ctx context.Context, addr string) (*MongoDB, error) {
-	clientOptions := options.Client().ApplyURI("mongodb://" + addr)
+//
+// NewMongoDB waits up to a minute for the server to accept writes, e.g. while a replica set is initiated.
+func NewMongoDB(ctx context.Context, addr string, username string, password string) (*MongoDB, error) {
+	// Connect directly to the server, rather than to the hosts of its replica set, whose
+	// addresses may not be reachable from the client
+	clientOptions := options.Client().ApplyURI("mongodb://" + addr + "/?directConnection=true")
+	if username != "" {
+		clientOptions.SetAuth(options.Credential{Username: username, Password: password})
+	}
 	client, err := mongo.Connect(ctx, clientOptions)
 
 	if err != nil {
 		return nil, err
 	}
+	if err := waitForPrimary(ctx, client); err != nil {
+		client.Disconnect(ctx)
+		return nil, err
+	}
 	return &MongoDB{
 		client: client,
 	}, nil
 }
 
+// How long [NewMongoDB] waits for the server to accept writes
+const primaryTimeout = time.Minute
+
+// Waits until the server accepts writes.  A server that runs as a replica set, such as the server
+// deployed by the mongodb plugin's container, rejects writes until the replica set has been initiated
+// and the server has been elected primary.  Standalone servers accept writes as soon as they are up.
+func waitForPrimary(ctx context.Context, client *mongo.Client) error {
+	ctx, cancel := context.WithTimeout(ctx, primaryTimeout)
+	defer cancel()
+	for {
+		var hello struct {
+			IsWritablePrimary bool `bson:"isWritablePrimary"`
+		}
+		err := client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello)
+		if err == nil && hello.IsWritablePrimary {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			if err == nil {
+				err = errors.New("server is not a writable primary")
+			}
+			return fmt.Errorf("mongodb server is not accepting writes: %w", err)
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
+
 // Implements the [backend.NoSQLDatabase] interface
 func (md *MongoDB) GetCollection(ctx context.Context, db_name string, collectionName string) (backend.NoSQLCollection, error) {
 	db := md.client.Database(db_name)
@@ -45,6 +87,28 @@ func (md *MongoDB) GetCollection(ctx context.Context, db_name string, collection
 	}, nil
 }
 
+// Implements the [backend.NoSQLDatabase] interface
+//
+// Transactions use a mongodb session.  MongoDB only supports transactions when the server is
+// deployed as a replica set or sharded cluster.  fn is retried if the transaction fails with
+// a transient error.
+func (md *MongoDB) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	session, err := md.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessCtx)
+	})
+	return err
+}
+
 // Implements the [backend.NoSQLCollection] interface
 func (mc *MongoCollection) DeleteOne(ctx context.Context, filter bson.D) error {
 
@@ -64,14 +128,14 @@ func (mc *MongoCollection) DeleteMany(ctx context.Context, filter bson.D) error
 func (mc *MongoCollection) InsertOne(ctx context.Context, document interface{}) error {
 	_, err := mc.collection.InsertOne(ctx, document)
 
-	return err
+	return wrapError(err)
 }
 
 // Implements the [backend.NoSQLCollection] interface
 func (mc *MongoCollection) InsertMany(ctx context.Context, documents []interface{}) error {
 	_, err := mc.collection.InsertMany(ctx, documents)
 
-	return err
+	return wrapError(err)
 }
 
 // Implements the [backend.NoSQLCollection] interface
@@ -131,13 +195,61 @@ func (mc *MongoCollection) FindMany(ctx context.Context, filter bson.D, projecti
 	return &MongoCursor{underlyingResult: cursor}, nil
 }
 
+// Implements the [backend.NoSQLCollection] interface
+func (mc *MongoCollection) FindManyWithOptions(ctx context.Context, filter bson.D, opts backend.FindOptions) (backend.NoSQLCursor, error) {
+	findOpts := options.Find()
+	if opts.Projection != nil {
+		findOpts.SetProjection(opts.Projection)
+	}
+	sortKeys, err := opts.SortKeys()
+	if err != nil {
+		return nil, err
+	}
+	if len(sortKeys) > 0 {
+		findOpts.SetSort(sortKeys)
+	}
+	if opts.Skip > 0 {
+		findOpts.SetSkip(opts.Skip)
+	}
+	if opts.Limit > 0 {
+		findOpts.SetLimit(opts.Limit)
+	}
+	filter, err = opts.PageFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := mc.collection.Find(ctx, filter, findOpts)
+	if err != nil {
+		return nil, err
+	}
+	if cursor.Err() != nil {
+		return nil, cursor.Err()
+	}
+
+	return &MongoCursor{underlyingResult: cursor}, nil
+}
+
+// Implements the [backend.NoSQLCollection] interface
+func (mc *MongoCollection) Aggregate(ctx context.Context, pipeline bson.A) (backend.NoSQLCursor, error) {
+	cursor, err := mc.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	if cursor.Err() != nil {
+		return nil, cursor.Err()
+	}
+
+	return &MongoCursor{underlyingResult: cursor}, nil
+}
+
 // Implements the [backend.NoSQLCollection] interface
 func (mc *MongoCollection) UpdateOne(ctx context.Context, filter bson.D, update bson.D) (int, error) {
 	result, err := mc.collection.UpdateOne(ctx, filter, update)
 	if result == nil {
-		return 0, err
+		return 0, wrapError(err)
 	} else {
-		return int(result.ModifiedCount), err
+		return int(result.ModifiedCount), wrapError(err)
 	}
 }
 
@@ -145,9 +257,9 @@ func (mc *MongoCollection) UpdateOne(ctx context.Context, filter bson.D, update
 func (mc *MongoCollection) UpdateMany(ctx context.Context, filter bson.D, update bson.D) (int, error) {
 	result, err := mc.collection.UpdateMany(ctx, filter, update)
 	if result == nil {
-		return 0, err
+		return 0, wrapError(err)
 	} else {
-		return int(result.ModifiedCount), err
+		return int(result.ModifiedCount), wrapError(err)
 	}
 }
 
@@ -157,9 +269,9 @@ func (mc *MongoCollection) Upsert(ctx context.Context, filter bson.D, document i
 	opts := options.Update().SetUpsert(true)
 	result, err := mc.collection.UpdateOne(ctx, filter, update, opts)
 	if result == nil {
-		return false, err
+		return false, wrapError(err)
 	} else {
-		return result.MatchedCount == 1, err
+		return result.MatchedCount == 1, wrapError(err)
 	}
 }
 
@@ -173,9 +285,9 @@ func (mc *MongoCollection) UpsertID(ctx context.Context, id primitive.ObjectID,
 func (mc *MongoCollection) ReplaceOne(ctx context.Context, filter bson.D, replacement interface{}) (int, error) {
 	result, err := mc.collection.ReplaceOne(ctx, filter, replacement)
 	if result == nil {
-		return 0, err
+		return 0, wrapError(err)
 	} else {
-		return int(result.MatchedCount), err
+		return int(result.MatchedCount), wrapError(err)
 	}
 }
 
@@ -184,6 +296,25 @@ func (mc *MongoCollection) ReplaceMany(ctx context.Context, filter bson.D, repla
 	return 0, errors.New("ReplaceMany not implemented")
 }
 
+// Implements the [backend.NoSQLCollection] interface
+func (mc *MongoCollection) CreateIndex(ctx context.Context, keys bson.D, unique bool) error {
+	keys, err := backend.NormalizeKeys(keys)
+	if err != nil {
+		return err
+	}
+	model := mongo.IndexModel{Keys: keys, Options: options.Index().SetUnique(unique)}
+	_, err = mc.collection.Indexes().CreateOne(ctx, model)
+	return wrapError(err)
+}
+
+// Converts mongo duplicate key errors into a [backend.DuplicateKeyError]
+func wrapError(err error) error {
+	if err != nil && mongo.IsDuplicateKeyError(err) {
+		return &backend.DuplicateKeyError{Err: err}
+	}
+	return err
+}
+
 // Implements the [backend.NoSQLCursor] interface as a client-wrapper to the Cursor returned by a mongodb server
 type MongoCursor struct {
 	underlyingResult interface{}
@@ -0,0 +1,126 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Implements the [backend.NoSQLChangeStream] interface as a client-wrapper to a mongodb change stream
+type MongoChangeStream struct {
+	stream *mongo.ChangeStream
+	closed bool
+}
+
+// How long each request for the next change waits on the server, which bounds how long Next takes to
+// return once its context is done
+const changeStreamMaxAwait = 500 * time.Millisecond
+
+// Implements the [backend.NoSQLCollection] interface
+//
+// Uses a mongodb change stream, which requires the server to be deployed as a replica set or sharded cluster.
+// Updated documents are looked up when the change is received, so the document of an update event can
+// include subsequent changes.
+func (mc *MongoCollection) Watch(ctx context.Context, filter bson.D) (backend.NoSQLChangeStream, error) {
+	changed, err := prefixFilter(filter, "fullDocument.")
+	if err != nil {
+		return nil, err
+	}
+	match := bson.D{{Key: "operationType", Value: bson.D{{Key: "$in", Value: bson.A{"insert", "update", "replace"}}}}}
+	if len(changed) > 0 {
+		match = bson.D{{Key: "$and", Value: bson.A{match, changed}}}
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{{Key: "$or", Value: bson.A{match, bson.D{{Key: "operationType", Value: "delete"}}}}}}},
+	}
+	opts := options.ChangeStream().SetFullDocument(options.UpdateLookup).SetMaxAwaitTime(changeStreamMaxAwait)
+	stream, err := mc.collection.Watch(ctx, pipeline, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &MongoChangeStream{stream: stream}, nil
+}
+
+// Rewrites filter to apply to the fields of the document at prefix, e.g. the full document of a change event.
+// Supports the $and, $or, and $nor logical operators; other top-level operators cannot be rewritten.
+func prefixFilter(filter bson.D, prefix string) (bson.D, error) {
+	prefixed := make(bson.D, 0, len(filter))
+	for _, e := range filter {
+		if !strings.HasPrefix(e.Key, "$") {
+			prefixed = append(prefixed, bson.E{Key: prefix + e.Key, Value: e.Value})
+			continue
+		}
+		if e.Key != "$and" && e.Key != "$or" && e.Key != "$nor" {
+			return nil, fmt.Errorf("unsupported operator %v in change stream filter", e.Key)
+		}
+		clauses, isA := e.Value.(bson.A)
+		if !isA {
+			return nil, fmt.Errorf("invalid %v in change stream filter; expected a bson.A, got %v", e.Key, e.Value)
+		}
+		var prefixedClauses bson.A
+		for _, clause := range clauses {
+			d, isD := clause.(bson.D)
+			if !isD {
+				return nil, fmt.Errorf("invalid %v clause in change stream filter; expected a bson.D, got %v", e.Key, clause)
+			}
+			prefixedClause, err := prefixFilter(d, prefix)
+			if err != nil {
+				return nil, err
+			}
+			prefixedClauses = append(prefixedClauses, prefixedClause)
+		}
+		prefixed = append(prefixed, bson.E{Key: e.Key, Value: prefixedClauses})
+	}
+	return prefixed, nil
+}
+
+// The fields of a change event that are used by [MongoChangeStream]
+type changeEvent struct {
+	OperationType string `bson:"operationType"`
+	DocumentKey   struct {
+		ID any `bson:"_id"`
+	} `bson:"documentKey"`
+	FullDocument bson.RawValue `bson:"fullDocument"` // null if an updated document has since been deleted
+}
+
+// Implements the [backend.NoSQLChangeStream] interface
+func (s *MongoChangeStream) Next(ctx context.Context) (backend.NoSQLChangeEvent, bool, error) {
+	if s.closed {
+		return backend.NoSQLChangeEvent{}, false, fmt.Errorf("change stream is closed")
+	}
+	// Cancelling a request closes the change stream, so each request waits at most changeStreamMaxAwait,
+	// and is not cancelled when ctx is done
+	for ctx.Err() == nil {
+		if !s.stream.TryNext(context.WithoutCancel(ctx)) {
+			if err := s.stream.Err(); err != nil {
+				return backend.NoSQLChangeEvent{}, false, err
+			}
+			continue
+		}
+		var change changeEvent
+		if err := s.stream.Decode(&change); err != nil {
+			return backend.NoSQLChangeEvent{}, false, err
+		}
+		event := backend.NoSQLChangeEvent{
+			Type:       backend.NoSQLChangeType(change.OperationType),
+			DocumentID: change.DocumentKey.ID,
+		}
+		if change.FullDocument.Type == bson.TypeEmbeddedDocument {
+			event.Document = change.FullDocument.Document()
+		}
+		return event, true, nil
+	}
+	return backend.NoSQLChangeEvent{}, false, nil
+}
+
+// Implements the [backend.NoSQLChangeStream] interface
+func (s *MongoChangeStream) Close(ctx context.Context) error {
+	s.closed = true
+	return s.stream.Close(ctx)
+}
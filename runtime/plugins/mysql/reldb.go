@@ -5,6 +5,7 @@ import (
 	"context"
 	"database/sql"
 
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/internal/sqlutil"
 	"github.com/jmoiron/sqlx"
 
 	_ "github.com/go-sql-driver/mysql"
@@ -44,25 +45,30 @@ func NewMySqlDB(ctx context.Context, addr string, name string, username string,
 
 // Exec implements backend.RelationalDB
 func (s *MySqlDB) Exec(ctx context.Context, query string, args ...any) (sql.Result, error) {
-	return s.db.ExecContext(ctx, query, args...)
+	return sqlutil.Conn(ctx, s.db).ExecContext(ctx, query, args...)
 }
 
 // Query implements backend.RelationalDB
 func (s *MySqlDB) Query(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
-	return s.db.QueryContext(ctx, query, args...)
+	return sqlutil.Conn(ctx, s.db).QueryContext(ctx, query, args...)
 }
 
 // Prepare implements backend.RelationalDB
 func (s *MySqlDB) Prepare(ctx context.Context, query string) (*sql.Stmt, error) {
-	return s.db.PrepareContext(ctx, query)
+	return sqlutil.Conn(ctx, s.db).PrepareContext(ctx, query)
 }
 
 // Select implements backend.RelationalDB
 func (s *MySqlDB) Select(ctx context.Context, dst interface{}, query string, args ...any) error {
-	return s.db.SelectContext(ctx, dst, query, args...)
+	return sqlutil.Conn(ctx, s.db).SelectContext(ctx, dst, query, args...)
 }
 
 // Get implements backend.RelationalDB
 func (s *MySqlDB) Get(ctx context.Context, dst interface{}, query string, args ...any) error {
-	return s.db.GetContext(ctx, dst, query, args...)
+	return sqlutil.Conn(ctx, s.db).GetContext(ctx, dst, query, args...)
+}
+
+// WithTransaction implements backend.RelationalDB
+func (s *MySqlDB) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return sqlutil.WithTransaction(ctx, s.db, fn)
 }
@@ -4,9 +4,20 @@ import (
 	"context"
 	"testing"
 
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend/backendtest"
 	"github.com/stretchr/testify/require"
 )
 
+// Test requires a functional mysql instance to be already running
+func TestConformance(t *testing.T) {
+	backendtest.RunRelDBSuite(t, func(t *testing.T) backend.RelationalDB {
+		db, err := NewMySqlDB(context.Background(), "127.0.0.1:3306", "TestConformance", "root", "pass")
+		require.NoError(t, err)
+		return db
+	})
+}
+
 // Test requires a functional mysql instance to be already running
 func TestRelDB(t *testing.T) {
 	ctx := context.Background()
@@ -0,0 +1,30 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRebind(t *testing.T) {
+	cases := []struct {
+		query    string
+		expected string
+	}{
+		{`SELECT * FROM address WHERE id = ?`, `SELECT * FROM address WHERE id = $1`},
+		{`INSERT INTO address (id, street) VALUES (?, ?)`, `INSERT INTO address (id, street) VALUES ($1, $2)`},
+		{`SELECT * FROM address WHERE id = $1`, `SELECT * FROM address WHERE id = $1`},
+		{`SELECT '?', "weird?column" FROM t WHERE a = ?`, `SELECT '?', "weird?column" FROM t WHERE a = $1`},
+		{`SELECT 'it''s ?' WHERE a = ?`, `SELECT 'it''s ?' WHERE a = $1`},
+		{`SELECT E'\'?' WHERE a = ?`, `SELECT E'\'?' WHERE a = $1`},
+		{"SELECT 1 -- why?\nWHERE a = ?", "SELECT 1 -- why?\nWHERE a = $1"},
+		{`SELECT /* why? */ 1 WHERE a = ?`, `SELECT /* why? */ 1 WHERE a = $1`},
+		{`SELECT $$what?$$, $tag$ $$ ? $tag$ WHERE a = ?`, `SELECT $$what?$$, $tag$ $$ ? $tag$ WHERE a = $1`},
+		{`SELECT a$b FROM t WHERE c = ?`, `SELECT a$b FROM t WHERE c = $1`},
+		{`SELECT * FROM t WHERE data ?? 'key' AND id = ?`, `SELECT * FROM t WHERE data ? 'key' AND id = $1`},
+		{`SELECT 'unterminated ?`, `SELECT 'unterminated ?`},
+	}
+	for _, c := range cases {
+		require.Equal(t, c.expected, Rebind(c.query), c.query)
+	}
+}
@@ -0,0 +1,120 @@
+// Package postgres provides a client-wrapper implementation of the [backend.RelationalDB] interface for a postgres server.
+//
+// Queries use the same "?" placeholder syntax as the other [backend.RelationalDB] implementations, so the same
+// workflow SQL works on SQLite, MySQL, and Postgres.  Placeholders are rewritten to postgres's positional "$1"
+// syntax before queries are sent to the server; see [Rebind].
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/internal/sqlutil"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// Implements a RelationalDB that uses the postgres package
+type PostgresDB struct {
+	name string
+	db   *sqlx.DB
+}
+
+// Instantiates a new [PostgresDB] instance that stores query data in the database name of a postgres server,
+// creating the database if it does not already exist.
+func NewPostgresDB(ctx context.Context, addr string, name string, username string, password string) (*PostgresDB, error) {
+	db, err := sqlx.Open("postgres", dataSourceName(addr, "postgres", username, password))
+	if err != nil {
+		return nil, err
+	}
+
+	var exists bool
+	err = db.GetContext(ctx, &exists, "SELECT EXISTS (SELECT 1 FROM pg_database WHERE datname = $1)", name)
+	if err == nil && !exists {
+		_, err = db.ExecContext(ctx, "CREATE DATABASE "+pq.QuoteIdentifier(name))
+	}
+	db.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	db, err = sqlx.Open("postgres", dataSourceName(addr, name, username, password))
+	if err != nil {
+		return nil, err
+	}
+
+	return &PostgresDB{name: name, db: db}, nil
+}
+
+func dataSourceName(addr string, name string, username string, password string) string {
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(username, password),
+		Host:     addr,
+		Path:     "/" + name,
+		RawQuery: "sslmode=disable",
+	}
+	return dsn.String()
+}
+
+// Exec implements backend.RelationalDB
+func (s *PostgresDB) Exec(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return sqlutil.Conn(ctx, s.db).ExecContext(ctx, Rebind(query), args...)
+}
+
+// Query implements backend.RelationalDB
+func (s *PostgresDB) Query(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return sqlutil.Conn(ctx, s.db).QueryContext(ctx, Rebind(query), args...)
+}
+
+// Prepare implements backend.RelationalDB
+func (s *PostgresDB) Prepare(ctx context.Context, query string) (*sql.Stmt, error) {
+	return sqlutil.Conn(ctx, s.db).PrepareContext(ctx, Rebind(query))
+}
+
+// Select implements backend.RelationalDB
+func (s *PostgresDB) Select(ctx context.Context, dst interface{}, query string, args ...any) error {
+	return sqlutil.Conn(ctx, s.db).SelectContext(ctx, dst, Rebind(query), args...)
+}
+
+// Get implements backend.RelationalDB
+func (s *PostgresDB) Get(ctx context.Context, dst interface{}, query string, args ...any) error {
+	return sqlutil.Conn(ctx, s.db).GetContext(ctx, dst, Rebind(query), args...)
+}
+
+// WithTransaction implements backend.RelationalDB
+func (s *PostgresDB) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return sqlutil.WithTransaction(ctx, s.db, fn)
+}
+
+// Rebind rewrites the "?" placeholders of query to postgres's positional "$1", "$2", ... placeholders.
+//
+// Question marks within string literals, quoted identifiers, dollar-quoted strings, and comments are
+// not placeholders and are left as they are.  "??" is rewritten to a literal "?", e.g. for use with
+// postgres's jsonb operators.
+func Rebind(query string) string {
+	var out []byte
+	n := 0
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		if c == '?' {
+			if i+1 < len(query) && query[i+1] == '?' {
+				out = append(out, '?')
+				i++
+			} else {
+				n++
+				out = append(out, fmt.Sprintf("$%d", n)...)
+			}
+			continue
+		}
+		if end := sqlutil.SkipQuoted(query, i); end > i {
+			out = append(out, query[i:end]...)
+			i = end - 1
+			continue
+		}
+		out = append(out, c)
+	}
+	return string(out)
+}
@@ -0,0 +1,124 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend/backendtest"
+	"github.com/stretchr/testify/require"
+)
+
+// Test requires a functional postgres instance to be already running
+func TestConformance(t *testing.T) {
+	backendtest.RunRelDBSuite(t, func(t *testing.T) backend.RelationalDB {
+		db, err := NewPostgresDB(context.Background(), "127.0.0.1:5432", "TestConformance", "postgres", "pass")
+		require.NoError(t, err)
+		return db
+	})
+}
+
+// Test requires a functional postgres instance to be already running
+func TestRelDB(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := NewPostgresDB(ctx, "127.0.0.1:5432", "TestRelDB", "postgres", "pass")
+	require.NoError(t, err)
+
+	batch := []string{
+		`CREATE TABLE IF NOT EXISTS address (id INT PRIMARY KEY, street TEXT, street_number INT);`,
+		`CREATE TABLE IF NOT EXISTS  user_addresses (address_id INT, user_id INT);`,
+		`DELETE FROM address;`,
+		`DELETE FROM user_addresses;`,
+		`INSERT INTO address (id, street, street_number) VALUES (1, 'rue Victor Hugo', 32);`,
+		`INSERT INTO address (id, street, street_number) VALUES (2, 'boulevard de la République', 23);`,
+		`INSERT INTO address (id, street, street_number) VALUES (3, 'rue Charles Martel', 5);`,
+		`INSERT INTO address (id, street, street_number) VALUES (4, 'chemin du bout du monde', 323);`,
+		`INSERT INTO address (id, street, street_number) VALUES (5, 'boulevard de la liberté', 2);`,
+		`INSERT INTO address (id, street, street_number) VALUES (6, 'avenue des champs', 12);`,
+		`INSERT INTO user_addresses (address_id, user_id) VALUES (2, 1);`,
+		`INSERT INTO user_addresses (address_id, user_id) VALUES (4, 1);`,
+		`INSERT INTO user_addresses (address_id, user_id) VALUES (2, 2);`,
+		`INSERT INTO user_addresses (address_id, user_id) VALUES (2, 3);`,
+		`INSERT INTO user_addresses (address_id, user_id) VALUES (4, 4);`,
+		`INSERT INTO user_addresses (address_id, user_id) VALUES (4, 5);`,
+	}
+
+	for _, b := range batch {
+		_, err = db.Exec(ctx, b)
+		t.Log(b)
+		require.NoError(t, err)
+	}
+
+	query := `SELECT address.street_number, address.street FROM address 
+							JOIN user_addresses ON address.id=user_addresses.address_id 
+							WHERE user_addresses.user_id = ?;`
+	userID := 1
+	rows, err := db.Query(ctx, query, userID)
+	require.NoError(t, err)
+
+	var number int
+	var street string
+
+	require.True(t, rows.Next())
+	require.NoError(t, rows.Scan(&number, &street))
+	require.Equal(t, 23, number)
+	require.Equal(t, "boulevard de la République", street)
+
+	require.True(t, rows.Next())
+	require.NoError(t, rows.Scan(&number, &street))
+	require.Equal(t, 323, number)
+	require.Equal(t, "chemin du bout du monde", street)
+
+	require.False(t, rows.Next())
+}
+
+// Test requires a functional postgres instance to be already running
+func TestTransaction(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := NewPostgresDB(ctx, "127.0.0.1:5432", "TestTransaction", "postgres", "pass")
+	require.NoError(t, err)
+
+	_, err = db.Exec(ctx, `DROP TABLE IF EXISTS account;`)
+	require.NoError(t, err)
+	_, err = db.Exec(ctx, `CREATE TABLE account (name TEXT PRIMARY KEY, balance INT);`)
+	require.NoError(t, err)
+	_, err = db.Exec(ctx, `INSERT INTO account (name, balance) VALUES (?, ?), (?, ?);`, "alice", 100, "bob", 0)
+	require.NoError(t, err)
+
+	transfer := func(ctx context.Context, amount int) error {
+		if _, err := db.Exec(ctx, `UPDATE account SET balance = balance - ? WHERE name = 'alice';`, amount); err != nil {
+			return err
+		}
+		if _, err := db.Exec(ctx, `UPDATE account SET balance = balance + ? WHERE name = 'bob';`, amount); err != nil {
+			return err
+		}
+		var balance int
+		if err := db.Get(ctx, &balance, `SELECT balance FROM account WHERE name = ?;`, "alice"); err != nil {
+			return err
+		}
+		if balance < 0 {
+			return errors.New("insufficient funds")
+		}
+		return nil
+	}
+
+	balances := func() []int {
+		var balances []int
+		require.NoError(t, db.Select(ctx, &balances, `SELECT balance FROM account ORDER BY name;`))
+		return balances
+	}
+
+	require.NoError(t, db.WithTransaction(ctx, func(ctx context.Context) error {
+		return transfer(ctx, 60)
+	}))
+	require.Equal(t, []int{40, 60}, balances())
+
+	err = db.WithTransaction(ctx, func(ctx context.Context) error {
+		return transfer(ctx, 60)
+	})
+	require.Error(t, err)
+	require.Equal(t, []int{40, 60}, balances())
+}
@@ -0,0 +1,137 @@
+package rabbitmq
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Implements a [backend.PubSub] that uses a rabbitmq topic exchange.
+//
+// Each subscriber group is a queue named exchange.group that is bound to the exchange with the
+// patterns of the group's subscriptions.  Like a [RabbitMQ] queue, each group's queue has a
+// dead-letter queue with the suffix ".dlq".  Anonymous subscriptions use exclusive, server-named
+// queues that are deleted when the subscription is closed.
+type RabbitPubSub struct {
+	exchange string
+	opts     Options
+	conn     *amqp.Connection
+	ch       *amqp.Channel
+}
+
+// Instantiates a new [RabbitPubSub] that publishes to and subscribes from the topic exchange
+// exchange, declaring the exchange if it does not already exist.  username and password are empty to use
+// rabbitmq's guest user.
+func NewRabbitPubSub(ctx context.Context, addr string, exchange string, username string, password string) (*RabbitPubSub, error) {
+	return NewRabbitPubSubWithOptions(addr, exchange, Options{Username: username, Password: password})
+}
+
+// Instantiates a new [RabbitPubSub] with the specified options, which apply to each subscription.
+func NewRabbitPubSubWithOptions(addr string, exchange string, opts Options) (*RabbitPubSub, error) {
+	opts = opts.withDefaults()
+	conn, err := dial(addr, opts)
+	if err != nil {
+		return nil, err
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, err
+	}
+	if err := ch.ExchangeDeclare(exchange, "topic", false, false, false, false, nil); err != nil {
+		return nil, err
+	}
+	return &RabbitPubSub{exchange: exchange, opts: opts, conn: conn, ch: ch}, nil
+}
+
+// Publish implements backend.PubSub
+func (ps *RabbitPubSub) Publish(ctx context.Context, topic string, item interface{}) error {
+	raw_bytes, err := getBytes(item)
+	if err != nil {
+		return err
+	}
+	publish_msg := amqp.Publishing{ContentType: "text/plain", Body: raw_bytes}
+	return ps.ch.PublishWithContext(ctx, ps.exchange, topic, false, false, publish_msg)
+}
+
+// Subscribe implements backend.PubSub
+//
+// Each subscription consumes on its own channel, so that its prefetched items are redelivered to
+// the other members of its group when it is closed.
+func (ps *RabbitPubSub) Subscribe(ctx context.Context, pattern string, group string) (backend.Subscription, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("invalid empty topic pattern")
+	}
+	ch, err := ps.conn.Channel()
+	if err != nil {
+		return nil, err
+	}
+	sub, err := ps.subscribe(ch, pattern, group)
+	if err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// Subscribes on ch, which is closed if the subscription cannot be made
+func (ps *RabbitPubSub) subscribe(ch *amqp.Channel, pattern string, group string) (sub *rabbitSubscription, err error) {
+	defer func() {
+		if err != nil {
+			ch.Close()
+		}
+	}()
+	var q amqp.Queue
+	if group == "" {
+		q, err = ch.QueueDeclare("", false, true, true, false, nil)
+	} else {
+		name := ps.exchange + "." + group
+		if ch, _, err = declareQueue(ps.conn, ch, name+".dlq"); err != nil {
+			return nil, err
+		}
+		ch, q, err = declareQueue(ps.conn, ch, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ch.QueueBind(q.Name, pattern, ps.exchange, false, nil); err != nil {
+		return nil, err
+	}
+	if err := ch.Qos(ps.opts.Prefetch, 0, false); err != nil {
+		return nil, err
+	}
+	msgs, err := ch.Consume(q.Name, "", false, false, false, false, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &rabbitSubscription{ch: ch, queue: q.Name, maxDeliveries: ps.opts.MaxDeliveries, msgs: msgs}, nil
+}
+
+// Implements the [backend.Subscription] interface for a [RabbitPubSub]
+type rabbitSubscription struct {
+	ch            *amqp.Channel
+	queue         string
+	maxDeliveries int
+	msgs          <-chan amqp.Delivery
+}
+
+// Receive implements backend.Subscription
+func (s *rabbitSubscription) Receive(ctx context.Context) (backend.Delivery, bool, error) {
+	select {
+	case v, open := <-s.msgs:
+		if !open {
+			return nil, false, fmt.Errorf("subscription to %v closed", s.queue)
+		}
+		return &rabbitDelivery{ch: s.ch, queue: s.queue, maxDeliveries: s.maxDeliveries, d: v}, true, nil
+	case <-ctx.Done():
+		return nil, false, nil
+	}
+}
+
+// Close implements backend.Subscription
+//
+// Unacknowledged items are redelivered to the subscription's group.
+func (s *rabbitSubscription) Close(ctx context.Context) error {
+	return s.ch.Close()
+}
@@ -1,26 +1,84 @@
-// Package rabbitmq provides a client-wrapper implementation of the [backend.Queue] interface for a rabbitmq server.
+// Package rabbitmq provides client-wrapper implementations of the [backend.Queue] and [backend.PubSub]
+// interfaces for a rabbitmq server.
+//
+// Items are consumed with manual acknowledgements, so an item that is received but not acknowledged
+// is redelivered by rabbitmq if the consumer's connection fails.  Each queue has a dead-letter queue
+// with the suffix ".dlq", and a queue with the suffix ".delay" that holds delayed items until they are due.
 package rabbitmq
 
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
 
 	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
 	amqp "github.com/rabbitmq/amqp091-go"
+	"golang.org/x/exp/slog"
 )
 
 // Implements a Queue that uses the rabbitmq package
 type RabbitMQ struct {
 	name  string
+	opts  Options
 	queue amqp.Queue
 	ch    *amqp.Channel
 	conn  *amqp.Connection
 	msgs  <-chan amqp.Delivery
 }
 
-// Instantiates a new [Queue] instances that provides a queue interface via a RabbitMQ instance
-func NewRabbitMQ(ctx context.Context, addr string, queue_name string) (*RabbitMQ, error) {
-	conn, err := amqp.Dial("amqp://guest:guest@" + addr + "/")
+// Options for a [RabbitMQ] queue
+type Options struct {
+	// The maximum number of times an item is delivered.  An item that is negatively acknowledged
+	// on its final delivery is moved to the dead-letter queue.  Zero means unlimited.
+	//
+	// Only negative acknowledgements count towards the limit; redeliveries due to consumer
+	// failures do not.
+	MaxDeliveries int
+
+	// The maximum number of unacknowledged items delivered to this client at a time.  Defaults to 10.
+	Prefetch int
+
+	// The credentials of the client.  Defaults to rabbitmq's guest user.
+	Username string
+	Password string
+}
+
+func (opts Options) withDefaults() Options {
+	if opts.Prefetch == 0 {
+		opts.Prefetch = 10
+	}
+	if opts.Username == "" {
+		opts.Username, opts.Password = "guest", "guest"
+	}
+	return opts
+}
+
+// Connects to the rabbitmq server at addr with the credentials in opts
+func dial(addr string, opts Options) (*amqp.Connection, error) {
+	uri := url.URL{Scheme: "amqp", User: url.UserPassword(opts.Username, opts.Password), Host: addr, Path: "/"}
+	return amqp.Dial(uri.String())
+}
+
+// Header used to count the deliveries of an item that has been requeued
+const deliveryCountHeader = "x-blueprint-delivery-count"
+
+// Instantiates a new [Queue] instances that provides a queue interface via a RabbitMQ instance.
+// username and password are empty to use rabbitmq's guest user.
+func NewRabbitMQ(ctx context.Context, addr string, queue_name string, username string, password string) (*RabbitMQ, error) {
+	return NewRabbitMQWithOptions(addr, queue_name, Options{Username: username, Password: password})
+}
+
+// Instantiates a new [RabbitMQ] client to the queue queue_name with the specified options.
+//
+// Also declares the queue's dead-letter queue queue_name.dlq and its delay queue queue_name.delay.  A queue
+// that already exists without a dead-letter queue is used as it is, and its rejected items are discarded;
+// delete the queue to have it redeclared with one.
+func NewRabbitMQWithOptions(addr string, queue_name string, opts Options) (*RabbitMQ, error) {
+	opts = opts.withDefaults()
+	conn, err := dial(addr, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -28,15 +86,62 @@ func NewRabbitMQ(ctx context.Context, addr string, queue_name string) (*RabbitMQ
 	if err != nil {
 		return nil, err
 	}
-	q, err := ch.QueueDeclare(queue_name, false, false, false, false, nil)
+
+	// The dead-letter queue is declared the same way as any other queue, so that clients can consume from it
+	ch, _, err = declareQueue(conn, ch, queue_name+".dlq")
+	if err != nil {
+		return nil, err
+	}
+	ch, q, err := declareQueue(conn, ch, queue_name)
 	if err != nil {
 		return nil, err
 	}
-	msgs, err := ch.Consume(q.Name, "", true, false, false, false, nil)
+
+	// Delayed items expire from the delay queue into the queue
+	_, err = ch.QueueDeclare(queue_name+".delay", false, false, false, false, amqp.Table{
+		"x-dead-letter-exchange":    "",
+		"x-dead-letter-routing-key": q.Name,
+	})
 	if err != nil {
 		return nil, err
 	}
-	return &RabbitMQ{name: queue_name, conn: conn, ch: ch, queue: q, msgs: msgs}, nil
+
+	if err := ch.Qos(opts.Prefetch, 0, false); err != nil {
+		return nil, err
+	}
+	msgs, err := ch.Consume(q.Name, "", false, false, false, false, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &RabbitMQ{name: queue_name, opts: opts, conn: conn, ch: ch, queue: q, msgs: msgs}, nil
+}
+
+// Declares the named queue, routing its rejected items to the queue name.dlq, and returns the channel
+// to continue using.
+//
+// A queue that already exists without a dead-letter queue, e.g. because it was declared by an earlier
+// version of this package, cannot be redeclared with one, and rabbitmq closes ch.  Such a queue is used
+// as it is on a new channel, and its rejected items are discarded rather than dead-lettered.  Deleting
+// the queue allows it to be redeclared with a dead-letter queue.
+func declareQueue(conn *amqp.Connection, ch *amqp.Channel, name string) (*amqp.Channel, amqp.Queue, error) {
+	q, err := ch.QueueDeclare(name, false, false, false, false, amqp.Table{
+		"x-dead-letter-exchange":    "",
+		"x-dead-letter-routing-key": name + ".dlq",
+	})
+	var amqpErr *amqp.Error
+	if !errors.As(err, &amqpErr) || amqpErr.Code != amqp.PreconditionFailed {
+		return ch, q, err
+	}
+	passive, chErr := conn.Channel()
+	if chErr != nil {
+		return ch, q, err
+	}
+	q, err = passive.QueueDeclarePassive(name, false, false, false, false, nil)
+	if err != nil {
+		return passive, q, err
+	}
+	slog.Warn(fmt.Sprintf("Queue %v already exists without a dead-letter queue; its rejected items will be discarded", name))
+	return passive, q, nil
 }
 
 func getBytes(key interface{}) ([]byte, error) {
@@ -51,35 +156,141 @@ func decodeBytes(val []byte) (interface{}, error) {
 
 // Push implements backend.Queue
 func (q *RabbitMQ) Push(ctx context.Context, item interface{}) (bool, error) {
+	return q.PushWithOptions(ctx, item, backend.PushOptions{})
+}
+
+// PushWithOptions implements backend.Queue
+//
+// Delayed items wait in the delay queue, which only expires items from its head.  An item is
+// therefore not delivered before any item that was pushed to the delay queue before it.
+func (q *RabbitMQ) PushWithOptions(ctx context.Context, item interface{}, opts backend.PushOptions) (bool, error) {
 	raw_bytes, err := getBytes(item)
 	if err != nil {
 		return false, err
 	}
 	publish_msg := amqp.Publishing{ContentType: "text/plain", Body: raw_bytes}
-	return true, q.ch.Publish("", q.queue.Name, false, false, publish_msg)
+	if len(opts.Headers) > 0 {
+		publish_msg.Headers = make(amqp.Table)
+		for k, v := range opts.Headers {
+			publish_msg.Headers[k] = v
+		}
+	}
+
+	queue := q.queue.Name
+	if opts.Delay > 0 {
+		queue = q.name + ".delay"
+		publish_msg.Expiration = strconv.FormatInt(opts.Delay.Milliseconds(), 10)
+	}
+	return true, q.ch.PublishWithContext(ctx, "", queue, false, false, publish_msg)
 }
 
 // Pop implements backend.Queue
 func (q *RabbitMQ) Pop(ctx context.Context, dst interface{}) (bool, error) {
+	d, received, err := q.Receive(ctx)
+	if !received || err != nil {
+		return received, err
+	}
+	if err := d.Ack(ctx); err != nil {
+		return true, err
+	}
+	return true, d.Decode(dst)
+}
+
+// Receive implements backend.Queue
+func (q *RabbitMQ) Receive(ctx context.Context) (backend.Delivery, bool, error) {
 	select {
 	case v := <-q.msgs:
-		val, err := decodeBytes(v.Body)
-		if err != nil {
-			return true, err
-		}
-		return true, backend.CopyResult(val, dst)
+		return q.delivery(v), true, nil
 	default:
 		{
 			select {
 			case v := <-q.msgs:
-				val, err := decodeBytes(v.Body)
-				if err != nil {
-					return true, err
-				}
-				return true, backend.CopyResult(val, dst)
+				return q.delivery(v), true, nil
 			case <-ctx.Done():
-				return false, nil
+				return nil, false, nil
 			}
 		}
 	}
 }
+
+func (q *RabbitMQ) delivery(d amqp.Delivery) *rabbitDelivery {
+	return &rabbitDelivery{ch: q.ch, queue: q.queue.Name, maxDeliveries: q.opts.MaxDeliveries, d: d}
+}
+
+// Implements the [backend.Delivery] interface for a rabbitmq delivery
+type rabbitDelivery struct {
+	ch            *amqp.Channel // The channel the item was consumed on
+	queue         string        // The queue the item was consumed from, to which requeued items are pushed
+	maxDeliveries int
+	d             amqp.Delivery
+	done          bool
+}
+
+// Decode implements backend.Delivery
+func (d *rabbitDelivery) Decode(dst interface{}) error {
+	val, err := decodeBytes(d.d.Body)
+	if err != nil {
+		return err
+	}
+	return backend.CopyResult(val, dst)
+}
+
+// Headers implements backend.Delivery
+func (d *rabbitDelivery) Headers() map[string]string {
+	headers := make(map[string]string)
+	for k, v := range d.d.Headers {
+		if s, isString := v.(string); isString && k != deliveryCountHeader {
+			headers[k] = s
+		}
+	}
+	return headers
+}
+
+// DeliveryCount implements backend.Delivery
+func (d *rabbitDelivery) DeliveryCount() int {
+	switch count := d.d.Headers[deliveryCountHeader].(type) {
+	case int32:
+		return int(count) + 1
+	case int64:
+		return int(count) + 1
+	default:
+		return 1
+	}
+}
+
+// Ack implements backend.Delivery
+func (d *rabbitDelivery) Ack(ctx context.Context) error {
+	if d.done {
+		return backend.ErrDeliveryClosed
+	}
+	d.done = true
+	return d.d.Ack(false)
+}
+
+// Nack implements backend.Delivery
+//
+// A requeued item is pushed to the tail of the queue, with an incremented delivery count.
+func (d *rabbitDelivery) Nack(ctx context.Context, requeue bool) error {
+	if d.done {
+		return backend.ErrDeliveryClosed
+	}
+	d.done = true
+
+	count := d.DeliveryCount()
+	if !requeue || (d.maxDeliveries > 0 && count >= d.maxDeliveries) {
+		// Routed to the dead-letter queue
+		return d.d.Reject(false)
+	}
+
+	headers := make(amqp.Table)
+	for k, v := range d.d.Headers {
+		headers[k] = v
+	}
+	headers[deliveryCountHeader] = int32(count)
+	msg := amqp.Publishing{ContentType: d.d.ContentType, Headers: headers, Body: d.d.Body}
+	if err := d.ch.PublishWithContext(ctx, "", d.queue, false, false, msg); err != nil {
+		// Fall back to rabbitmq's requeue, which does not count the delivery
+		return errors.Join(err, d.d.Nack(false, true))
+	}
+	return d.d.Ack(false)
+}
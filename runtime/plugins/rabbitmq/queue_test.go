@@ -5,13 +5,24 @@ import (
 	"testing"
 	"time"
 
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend/backendtest"
 	"github.com/stretchr/testify/require"
 )
 
+// Test requires a functional rabbitmq instance to be already running
+func TestConformance(t *testing.T) {
+	backendtest.RunQueueSuite(t, func(t *testing.T) backend.Queue {
+		q, err := NewRabbitMQ(context.Background(), "localhost:5672", t.Name(), "", "")
+		require.NoError(t, err)
+		return q
+	})
+}
+
 func TestPushPop(t *testing.T) {
 	ctx := context.Background()
 
-	q, err := NewRabbitMQ(ctx, "localhost:5672", "queue")
+	q, err := NewRabbitMQ(ctx, "localhost:5672", "queue", "", "")
 	require.NoError(t, err)
 
 	snd := "hello"
@@ -36,7 +47,7 @@ func TestTryTimeout(t *testing.T) {
 
 	ctx := context.Background()
 
-	q, err := NewRabbitMQ(ctx, "localhost:5672", "queue")
+	q, err := NewRabbitMQ(ctx, "localhost:5672", "queue", "", "")
 	require.NoError(t, err)
 
 	first := "hello"
@@ -97,3 +108,61 @@ func TestTryTimeout(t *testing.T) {
 		require.Equal(t, second, rcv)
 	}
 }
+
+func TestNackDeadLetter(t *testing.T) {
+	ctx := context.Background()
+
+	q, err := NewRabbitMQWithOptions("localhost:5672", "nackqueue", Options{MaxDeliveries: 2})
+	require.NoError(t, err)
+	dlq, err := NewRabbitMQ(ctx, "localhost:5672", "nackqueue.dlq", "", "")
+	require.NoError(t, err)
+
+	headers := map[string]string{"trace": "abc"}
+	success, err := q.PushWithOptions(ctx, "hello", backend.PushOptions{Headers: headers})
+	require.NoError(t, err)
+	require.True(t, success)
+
+	for i := 1; i <= 2; i++ {
+		d, received, err := q.Receive(ctx)
+		require.NoError(t, err)
+		require.True(t, received)
+		require.Equal(t, i, d.DeliveryCount())
+		require.Equal(t, headers, d.Headers())
+		require.NoError(t, d.Nack(ctx, true))
+	}
+
+	var rcv string
+	success, err = dlq.Pop(ctx, &rcv)
+	require.NoError(t, err)
+	require.True(t, success)
+	require.Equal(t, "hello", rcv)
+}
+
+func TestPubSub(t *testing.T) {
+	ctx := context.Background()
+
+	ps, err := NewRabbitPubSub(ctx, "localhost:5672", "events", "", "")
+	require.NoError(t, err)
+
+	timeline, err := ps.Subscribe(ctx, "post.*", "timeline")
+	require.NoError(t, err)
+	search, err := ps.Subscribe(ctx, "post.#", "search")
+	require.NoError(t, err)
+
+	require.NoError(t, ps.Publish(ctx, "post.created", "hello"))
+
+	for _, sub := range []backend.Subscription{timeline, search} {
+		// Each group should receive the item
+		timeoutCtx, cancel := context.WithTimeout(ctx, time.Second)
+		d, received, err := sub.Receive(timeoutCtx)
+		cancel()
+		require.NoError(t, err)
+		require.True(t, received)
+
+		var rcv string
+		require.NoError(t, d.Decode(&rcv))
+		require.Equal(t, "hello", rcv)
+		require.NoError(t, d.Ack(ctx))
+		require.NoError(t, sub.Close(ctx))
+	}
+}
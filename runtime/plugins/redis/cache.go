@@ -1,9 +1,12 @@
-// Package redis implements a key-value [backend.Cache] client interface to a vanilla redis implementation.
+// Package redis implements a key-value [backend.Cache] client interface, and a [backend.LockService] client
+// interface, to a vanilla redis implementation.
 package redis
 
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"time"
 
 	redis_impl "github.com/go-redis/redis/v8"
 )
@@ -13,12 +16,13 @@ type RedisCache struct {
 	client *redis_impl.Client
 }
 
-// Instantiates a new redis client to a memcached instance running at `serverAddress`
-func NewRedisCacheClient(ctx context.Context, addr string) (*RedisCache, error) {
+// Instantiates a new redis client to a redis instance running at `addr`.  password is empty
+// if the redis instance does not require authentication.
+func NewRedisCacheClient(ctx context.Context, addr string, password string) (*RedisCache, error) {
 	conn_addr := addr
 	client := redis_impl.NewClient(&redis_impl.Options{
 		Addr:     conn_addr,
-		Password: "",
+		Password: password,
 		DB:       0,
 	})
 	return &RedisCache{client: client}, nil
@@ -84,3 +88,66 @@ func (r *RedisCache) Mset(ctx context.Context, keys []string, values []interface
 	}
 	return r.client.MSet(ctx, kv_map).Err()
 }
+
+// Implements the backend.Cache interface
+func (r *RedisCache) PutWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	if ttl < 0 {
+		return fmt.Errorf("invalid ttl %v", ttl)
+	}
+	val, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return r.client.Set(ctx, key, string(val), ttl).Err()
+}
+
+// Implements the backend.Cache interface
+func (r *RedisCache) Expire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	if ttl < 0 {
+		return false, fmt.Errorf("invalid ttl %v", ttl)
+	}
+	if ttl == 0 {
+		// PERSIST reports false for a key without a ttl, so check that the key exists
+		n, err := r.client.Exists(ctx, key).Result()
+		if err != nil || n == 0 {
+			return false, err
+		}
+		return true, r.client.Persist(ctx, key).Err()
+	}
+	return r.client.Expire(ctx, key, ttl).Result()
+}
+
+// Implements the backend.Cache interface
+func (r *RedisCache) SetNX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
+	if ttl < 0 {
+		return false, fmt.Errorf("invalid ttl %v", ttl)
+	}
+	val, err := json.Marshal(value)
+	if err != nil {
+		return false, err
+	}
+	return r.client.SetNX(ctx, key, string(val), ttl).Result()
+}
+
+// Replaces the value if it matches, retaining the key's ttl; requires redis 6.0 or later
+var compareAndSwapScript = redis_impl.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	redis.call("SET", KEYS[1], ARGV[2], "KEEPTTL")
+	return 1
+end
+return 0
+`)
+
+// Implements the backend.Cache interface
+func (r *RedisCache) CompareAndSwap(ctx context.Context, key string, old interface{}, new interface{}) (bool, error) {
+	oldVal, err := json.Marshal(old)
+	if err != nil {
+		return false, err
+	}
+	newVal, err := json.Marshal(new)
+	if err != nil {
+		return false, err
+	}
+	swapped, err := compareAndSwapScript.Run(ctx, r.client, []string{key}, string(oldVal), string(newVal)).Int()
+	return swapped == 1, err
+}
@@ -9,7 +9,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend/backendtest"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type someData struct {
@@ -23,7 +26,7 @@ func equal(d1 someData, d2 someData) bool {
 
 func TestRedisPut(t *testing.T) {
 	ctx := context.Background()
-	redis, err := NewRedisCacheClient(ctx, "localhost:6379")
+	redis, err := NewRedisCacheClient(ctx, "localhost:6379", "")
 	if err != nil {
 		t.Error(err)
 	}
@@ -45,7 +48,7 @@ func TestRedisPut(t *testing.T) {
 
 func TestRedisGet(t *testing.T) {
 	ctx := context.Background()
-	redis, err := NewRedisCacheClient(ctx, "localhost:6379")
+	redis, err := NewRedisCacheClient(ctx, "localhost:6379", "")
 	if err != nil {
 		t.Error(err)
 	}
@@ -62,7 +65,7 @@ func TestRedisGet(t *testing.T) {
 
 func TestRedisIncr(t *testing.T) {
 	ctx := context.Background()
-	redis, err := NewRedisCacheClient(ctx, "localhost:6379")
+	redis, err := NewRedisCacheClient(ctx, "localhost:6379", "")
 	if err != nil {
 		t.Error(err)
 	}
@@ -81,7 +84,7 @@ func TestRedisIncr(t *testing.T) {
 
 func TestRedisDelete(t *testing.T) {
 	ctx := context.Background()
-	redis, err := NewRedisCacheClient(ctx, "localhost:6379")
+	redis, err := NewRedisCacheClient(ctx, "localhost:6379", "")
 	if err != nil {
 		t.Error(err)
 	}
@@ -117,7 +120,7 @@ func TestRedisMget(t *testing.T) {
 	keys := []string{"testData", "intKey"}
 	vals := []interface{}{&val1, &val2}
 	ctx := context.Background()
-	redis, err := NewRedisCacheClient(ctx, "localhost:6379")
+	redis, err := NewRedisCacheClient(ctx, "localhost:6379", "")
 	if err != nil {
 		t.Error(err)
 	}
@@ -135,7 +138,7 @@ func TestRedisMget(t *testing.T) {
 
 func TestRedisMset(t *testing.T) {
 	ctx := context.Background()
-	redis, err := NewRedisCacheClient(ctx, "localhost:6379")
+	redis, err := NewRedisCacheClient(ctx, "localhost:6379", "")
 	if err != nil {
 		t.Error(err)
 	}
@@ -170,7 +173,7 @@ func TestRedisMset(t *testing.T) {
 
 func TestRedisPerformance(t *testing.T) {
 	ctx := context.Background()
-	redis, err := NewRedisCacheClient(ctx, "localhost:6379")
+	redis, err := NewRedisCacheClient(ctx, "localhost:6379", "")
 	if err != nil {
 		t.Error(err)
 	}
@@ -205,3 +208,11 @@ func TestRedisPerformance(t *testing.T) {
 	}
 	wg.Wait()
 }
+
+func TestRedisConformance(t *testing.T) {
+	backendtest.RunCacheSuite(t, func(t *testing.T) backend.Cache {
+		redis, err := NewRedisCacheClient(context.Background(), "localhost:6379", "")
+		require.NoError(t, err)
+		return redis
+	})
+}
@@ -0,0 +1,92 @@
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	redis_impl "github.com/go-redis/redis/v8"
+)
+
+// A redis client wrapper that implements the [backend.LockService] interface.
+//
+// Each lock is a redis key, prefixed with "lock:", whose value is the token of the current lease and whose
+// ttl is the ttl of the lease.
+type RedisLockService struct {
+	backend.LockService
+
+	client *redis_impl.Client
+}
+
+// Instantiates a new redis client to a redis instance running at addr, that implements [backend.LockService].
+// password is empty if the redis instance does not require authentication.
+func NewRedisLockService(ctx context.Context, addr string, password string) (*RedisLockService, error) {
+	client := redis_impl.NewClient(&redis_impl.Options{
+		Addr:     addr,
+		Password: password,
+		DB:       0,
+	})
+	return &RedisLockService{client: client}, nil
+}
+
+func lockKey(name string) string {
+	return "lock:" + name
+}
+
+// Implements the backend.LockService interface
+func (r *RedisLockService) TryAcquire(ctx context.Context, name string, ttl time.Duration) (backend.Lease, error) {
+	if err := backend.ValidateLock(name, ttl); err != nil {
+		return backend.Lease{}, err
+	}
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return backend.Lease{}, err
+	}
+	lease := backend.Lease{Name: name, Token: hex.EncodeToString(token), Expires: time.Now().Add(ttl)}
+	acquired, err := r.client.SetNX(ctx, lockKey(name), lease.Token, ttl).Result()
+	if err != nil {
+		return backend.Lease{}, err
+	} else if !acquired {
+		return backend.Lease{}, backend.ErrLockHeld
+	}
+	return lease, nil
+}
+
+// Extends the ttl of the lock if it is held by the lease
+var renewScript = redis_impl.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// Implements the backend.LockService interface
+func (r *RedisLockService) Renew(ctx context.Context, lease backend.Lease, ttl time.Duration) (backend.Lease, error) {
+	if err := backend.ValidateLock(lease.Name, ttl); err != nil {
+		return backend.Lease{}, err
+	}
+	expires := time.Now().Add(ttl)
+	renewed, err := renewScript.Run(ctx, r.client, []string{lockKey(lease.Name)}, lease.Token, ttl.Milliseconds()).Int()
+	if err != nil {
+		return backend.Lease{}, err
+	} else if renewed != 1 {
+		return backend.Lease{}, backend.ErrLeaseLost
+	}
+	lease.Expires = expires
+	return lease, nil
+}
+
+// Deletes the lock if it is held by the lease
+var releaseScript = redis_impl.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// Implements the backend.LockService interface
+func (r *RedisLockService) Release(ctx context.Context, lease backend.Lease) error {
+	return releaseScript.Run(ctx, r.client, []string{lockKey(lease.Name)}, lease.Token).Err()
+}
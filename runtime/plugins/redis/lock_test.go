@@ -0,0 +1,19 @@
+package redis
+
+import (
+	"context"
+	"testing"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend/backendtest"
+	"github.com/stretchr/testify/require"
+)
+
+// Test requires a functional redis instance to be already running
+func TestRedisLockConformance(t *testing.T) {
+	backendtest.RunLockServiceSuite(t, func(t *testing.T) backend.LockService {
+		locks, err := NewRedisLockService(context.Background(), "localhost:6379", "")
+		require.NoError(t, err)
+		return locks
+	})
+}
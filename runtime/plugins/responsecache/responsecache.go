@@ -0,0 +1,133 @@
+// Package responsecache implements the runtime components of Blueprint's responsecache plugin.
+//
+// ResponseCaches do not need to be used directly by application workflow specs.  Instead, this
+// code is included in a compiled application by applying the responsecache modifier to the wiring spec.
+//
+// Responses are stored in a [backend.Cache], keyed on the service name, the method name, and the
+// serialized arguments of the call.  Each method additionally has a version that is stored in the
+// cache and included in the key.  A version is a random token; invalidating a method replaces its
+// version with a new token, so that subsequent calls no longer see responses that were cached before
+// the invalidation.  If the version is evicted from the cache, a new token is chosen, so responses
+// cached under the evicted version are not seen either.
+package responsecache
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"golang.org/x/exp/slog"
+)
+
+// A ResponseCache caches the responses of calls to the methods of a single service.
+type ResponseCache struct {
+	service string
+	cache   backend.Cache
+	ttl     time.Duration
+}
+
+// The value stored in the cache for a cached response
+type entry[T any] struct {
+	// Time in unix nanoseconds after which the entry is stale, or zero if the entry never expires
+	Expires  int64
+	Response T
+}
+
+// Instantiates a [ResponseCache] for service that stores responses in cache.
+//
+// Cached responses expire after ttl.  If ttl is zero, cached responses only become stale when
+// they are invalidated.
+func NewResponseCache(service string, cache backend.Cache, ttl time.Duration) *ResponseCache {
+	return &ResponseCache{service: service, cache: cache, ttl: ttl}
+}
+
+// Returns the cached response for the call to method with args if there is one; otherwise invokes call and,
+// if call succeeds, caches its response.
+//
+// Errors returned by call are not cached.  Errors when accessing the cache are not returned to the caller;
+// instead the response is not cached.
+func Do[T any](ctx context.Context, c *ResponseCache, method string, args []any, call func(context.Context) (T, error)) (T, error) {
+	key, err := c.key(ctx, method, args)
+	if err == nil {
+		var cached entry[T]
+		hit, err := c.cache.Get(ctx, key, &cached)
+		if err == nil && hit && (cached.Expires == 0 || time.Now().UnixNano() < cached.Expires) {
+			return cached.Response, nil
+		}
+	}
+
+	response, err := call(ctx)
+	if err != nil || key == "" {
+		return response, err
+	}
+
+	fresh := entry[T]{Response: response}
+	if c.ttl > 0 {
+		fresh.Expires = time.Now().Add(c.ttl).UnixNano()
+	}
+	// The entry also expires from the cache; Expires is still checked because caches may round up the ttl
+	c.cache.PutWithTTL(ctx, key, fresh, c.ttl)
+	return response, nil
+}
+
+// Invalidates all cached responses of the specified methods.
+//
+// Invalidate is called after a call has succeeded, so errors when accessing the cache are logged rather
+// than returned to the caller.
+func (c *ResponseCache) Invalidate(ctx context.Context, methods ...string) {
+	for _, method := range methods {
+		if err := c.cache.Put(ctx, c.versionKey(method), newVersion()); err != nil {
+			slog.Error(fmt.Sprintf("unable to invalidate cached responses of %v.%v: %v", c.service, method, err))
+		}
+	}
+}
+
+func (c *ResponseCache) versionKey(method string) string {
+	return fmt.Sprintf("responsecache/%v/%v/version", c.service, method)
+}
+
+// Returns the current version of method, choosing a new version if there is none
+func (c *ResponseCache) version(ctx context.Context, method string) (string, error) {
+	var version string
+	if hit, err := c.cache.Get(ctx, c.versionKey(method), &version); err != nil || hit {
+		return version, err
+	}
+	// Another caller might concurrently choose a version; only the first is stored
+	if _, err := c.cache.SetNX(ctx, c.versionKey(method), newVersion(), 0); err != nil {
+		return "", err
+	}
+	if hit, err := c.cache.Get(ctx, c.versionKey(method), &version); err != nil {
+		return "", err
+	} else if !hit {
+		return "", fmt.Errorf("version of %v.%v is missing from the cache", c.service, method)
+	}
+	return version, nil
+}
+
+// Returns a new random version
+func newVersion() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Computes the cache key for a call to method with args.
+//
+// The args are hashed, so that keys are of bounded length and are valid for all cache backends.
+func (c *ResponseCache) key(ctx context.Context, method string, args []any) (string, error) {
+	version, err := c.version(ctx, method)
+	if err != nil {
+		return "", err
+	}
+	encoded, err := json.Marshal(args)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(encoded)
+	return fmt.Sprintf("responsecache/%v/%v/%v/%v", c.service, method, version, hex.EncodeToString(hash[:])), nil
+}
@@ -0,0 +1,147 @@
+package responsecache_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/responsecache"
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/simplecache"
+	"github.com/stretchr/testify/require"
+)
+
+type response struct {
+	Ret0 string
+	Ret1 int64
+}
+
+// Returns a call that counts its invocations
+func counter(calls *int, ret response, err error) func(context.Context) (response, error) {
+	return func(ctx context.Context) (response, error) {
+		*calls++
+		return ret, err
+	}
+}
+
+func newCache(t *testing.T, ttl time.Duration) *responsecache.ResponseCache {
+	cache, err := simplecache.NewSimpleCache(context.Background())
+	require.NoError(t, err)
+	return responsecache.NewResponseCache("svc", cache, ttl)
+}
+
+func TestCachesResponses(t *testing.T) {
+	ctx := context.Background()
+	c := newCache(t, 0)
+
+	calls := 0
+	for i := 0; i < 3; i++ {
+		res, err := responsecache.Do(ctx, c, "M", []any{"a", 1}, counter(&calls, response{"hello", 5}, nil))
+		require.NoError(t, err)
+		require.Equal(t, response{"hello", 5}, res)
+	}
+	require.Equal(t, 1, calls)
+
+	// Different args are cached separately
+	_, err := responsecache.Do(ctx, c, "M", []any{"a", 2}, counter(&calls, response{}, nil))
+	require.NoError(t, err)
+	require.Equal(t, 2, calls)
+
+	// Different methods are cached separately
+	_, err = responsecache.Do(ctx, c, "N", []any{"a", 1}, counter(&calls, response{}, nil))
+	require.NoError(t, err)
+	require.Equal(t, 3, calls)
+}
+
+func TestErrorsNotCached(t *testing.T) {
+	ctx := context.Background()
+	c := newCache(t, 0)
+
+	calls := 0
+	failed := errors.New("failed")
+	_, err := responsecache.Do(ctx, c, "M", nil, counter(&calls, response{}, failed))
+	require.ErrorIs(t, err, failed)
+
+	res, err := responsecache.Do(ctx, c, "M", nil, counter(&calls, response{"ok", 1}, nil))
+	require.NoError(t, err)
+	require.Equal(t, response{"ok", 1}, res)
+	require.Equal(t, 2, calls)
+}
+
+func TestTTL(t *testing.T) {
+	ctx := context.Background()
+	c := newCache(t, 20*time.Millisecond)
+
+	calls := 0
+	_, err := responsecache.Do(ctx, c, "M", nil, counter(&calls, response{}, nil))
+	require.NoError(t, err)
+	_, err = responsecache.Do(ctx, c, "M", nil, counter(&calls, response{}, nil))
+	require.NoError(t, err)
+	require.Equal(t, 1, calls)
+
+	time.Sleep(30 * time.Millisecond)
+	_, err = responsecache.Do(ctx, c, "M", nil, counter(&calls, response{}, nil))
+	require.NoError(t, err)
+	require.Equal(t, 2, calls)
+}
+
+func TestInvalidate(t *testing.T) {
+	ctx := context.Background()
+	c := newCache(t, 0)
+
+	calls := 0
+	_, err := responsecache.Do(ctx, c, "M", []any{1}, counter(&calls, response{"old", 0}, nil))
+	require.NoError(t, err)
+	_, err = responsecache.Do(ctx, c, "N", []any{1}, counter(&calls, response{"old", 0}, nil))
+	require.NoError(t, err)
+	require.Equal(t, 2, calls)
+
+	c.Invalidate(ctx, "M")
+
+	res, err := responsecache.Do(ctx, c, "M", []any{1}, counter(&calls, response{"new", 0}, nil))
+	require.NoError(t, err)
+	require.Equal(t, "new", res.Ret0)
+	require.Equal(t, 3, calls)
+
+	// N was not invalidated
+	res, err = responsecache.Do(ctx, c, "N", []any{1}, counter(&calls, response{"new", 0}, nil))
+	require.NoError(t, err)
+	require.Equal(t, "old", res.Ret0)
+	require.Equal(t, 3, calls)
+}
+
+func TestInvalidateUncachedMethod(t *testing.T) {
+	ctx := context.Background()
+	c := newCache(t, 0)
+
+	// Invalidating a method that has never been called doesn't prevent caching it
+	c.Invalidate(ctx, "M")
+	calls := 0
+	for i := 0; i < 2; i++ {
+		_, err := responsecache.Do(ctx, c, "M", []any{1}, counter(&calls, response{"hello", 0}, nil))
+		require.NoError(t, err)
+	}
+	require.Equal(t, 1, calls)
+}
+
+func TestVersionEvicted(t *testing.T) {
+	ctx := context.Background()
+	cache, err := simplecache.NewSimpleCache(ctx)
+	require.NoError(t, err)
+	c := responsecache.NewResponseCache("svc", cache, 0)
+
+	calls := 0
+	_, err = responsecache.Do(ctx, c, "M", []any{1}, counter(&calls, response{"old", 0}, nil))
+	require.NoError(t, err)
+	c.Invalidate(ctx, "M")
+	_, err = responsecache.Do(ctx, c, "M", []any{1}, counter(&calls, response{"new", 0}, nil))
+	require.NoError(t, err)
+	require.Equal(t, 2, calls)
+
+	// If the version is evicted, responses cached before an invalidation are still not seen
+	require.NoError(t, cache.Delete(ctx, "responsecache/svc/M/version"))
+	res, err := responsecache.Do(ctx, c, "M", []any{1}, counter(&calls, response{"newer", 0}, nil))
+	require.NoError(t, err)
+	require.Equal(t, "newer", res.Ret0)
+	require.Equal(t, 3, calls)
+}
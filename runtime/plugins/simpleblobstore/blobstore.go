@@ -0,0 +1,214 @@
+// Package simpleblobstore implements a [backend.BlobStore] that stores objects in memory, or in a directory on disk.
+//
+// A store in a directory keeps each object in two files, named by the SHA-256 hash of the object's key: one
+// holds the contents of the object, and one holds the object's info as JSON.  The info of every object is also
+// held in memory, and is loaded from the directory when the store is instantiated.  The directory should only
+// be used by one store at a time.
+package simpleblobstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+)
+
+// A simple [backend.BlobStore] that stores objects in memory or in a directory
+type SimpleBlobStore struct {
+	backend.BlobStore
+
+	dir string // Empty if objects are stored in memory
+
+	mu      sync.RWMutex
+	objects map[string]*object
+}
+
+type object struct {
+	info backend.BlobInfo
+	data []byte // Nil if the object is stored in the directory
+}
+
+// Instantiates a [SimpleBlobStore].
+//
+// If dir is empty, objects are stored in memory.  Otherwise, objects are stored in the directory dir, which is
+// created if it does not exist, and any objects already in the directory are loaded.
+func NewSimpleBlobStore(ctx context.Context, dir string) (*SimpleBlobStore, error) {
+	store := &SimpleBlobStore{dir: dir, objects: make(map[string]*object)}
+	if dir == "" {
+		return store, nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		contents, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var info backend.BlobInfo
+		if err := json.Unmarshal(contents, &info); err != nil {
+			return nil, fmt.Errorf("invalid object info %v: %w", entry.Name(), err)
+		}
+		store.objects[info.Key] = &object{info: info}
+	}
+	return store, nil
+}
+
+// Returns the path of the file that holds the contents of key, and the path of the file that holds its info
+func (store *SimpleBlobStore) paths(key string) (string, string) {
+	hash := sha256.Sum256([]byte(key))
+	name := filepath.Join(store.dir, hex.EncodeToString(hash[:]))
+	return name, name + ".json"
+}
+
+// Implements backend.BlobStore
+func (store *SimpleBlobStore) Put(ctx context.Context, key string, data io.Reader, opts backend.BlobOptions) error {
+	if err := backend.ValidateBlobKey(key); err != nil {
+		return err
+	}
+	metadata, err := backend.ValidateBlobMetadata(opts.Metadata)
+	if err != nil {
+		return err
+	}
+	contents, err := io.ReadAll(data)
+	if err != nil {
+		return err
+	}
+	obj := &object{info: backend.BlobInfo{
+		Key:          key,
+		Size:         int64(len(contents)),
+		ContentType:  opts.ContentType,
+		Metadata:     metadata,
+		LastModified: time.Now().UTC(),
+	}}
+	if obj.info.ContentType == "" {
+		obj.info.ContentType = backend.DefaultBlobContentType
+	}
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if store.dir == "" {
+		obj.data = contents
+	} else {
+		dataPath, infoPath := store.paths(key)
+		info, err := json.Marshal(obj.info)
+		if err != nil {
+			return err
+		}
+		if err := writeFile(dataPath, contents); err != nil {
+			return err
+		}
+		if err := writeFile(infoPath, info); err != nil {
+			return err
+		}
+	}
+	store.objects[key] = obj
+	return nil
+}
+
+// Writes contents to a temporary file that then replaces path
+func writeFile(path string, contents []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(contents); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// Implements backend.BlobStore
+func (store *SimpleBlobStore) Get(ctx context.Context, key string) (io.ReadCloser, backend.BlobInfo, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+	obj, exists := store.objects[key]
+	if !exists {
+		return nil, backend.BlobInfo{}, backend.ErrBlobNotFound
+	}
+	info := copyInfo(obj.info)
+	if store.dir == "" {
+		return io.NopCloser(bytes.NewReader(obj.data)), info, nil
+	}
+
+	// Put replaces the file rather than modifying it, so the opened file is unaffected by later writes
+	dataPath, _ := store.paths(key)
+	f, err := os.Open(dataPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, backend.BlobInfo{}, backend.ErrBlobNotFound
+	}
+	return f, info, err
+}
+
+// Implements backend.BlobStore
+func (store *SimpleBlobStore) Delete(ctx context.Context, key string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if _, exists := store.objects[key]; !exists {
+		return nil
+	}
+	if store.dir != "" {
+		dataPath, infoPath := store.paths(key)
+		// Remove the info first, so that a partially deleted object is not loaded
+		for _, path := range []string{infoPath, dataPath} {
+			if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+		}
+	}
+	delete(store.objects, key)
+	return nil
+}
+
+// Implements backend.BlobStore
+func (store *SimpleBlobStore) List(ctx context.Context, prefix string) ([]backend.BlobInfo, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+	var infos []backend.BlobInfo
+	for key, obj := range store.objects {
+		if strings.HasPrefix(key, prefix) {
+			info := obj.info
+			info.Metadata = nil
+			infos = append(infos, info)
+		}
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Key < infos[j].Key })
+	return infos, nil
+}
+
+// Returns a copy of info, so that callers cannot modify the stored metadata
+func copyInfo(info backend.BlobInfo) backend.BlobInfo {
+	if info.Metadata != nil {
+		metadata := make(map[string]string, len(info.Metadata))
+		for k, v := range info.Metadata {
+			metadata[k] = v
+		}
+		info.Metadata = metadata
+	}
+	return info
+}
@@ -0,0 +1,61 @@
+package simpleblobstore
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend/backendtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConformance(t *testing.T) {
+	backendtest.RunBlobStoreSuite(t, func(t *testing.T) backend.BlobStore {
+		store, err := NewSimpleBlobStore(context.Background(), "")
+		require.NoError(t, err)
+		return store
+	})
+}
+
+func TestDirConformance(t *testing.T) {
+	backendtest.RunBlobStoreSuite(t, func(t *testing.T) backend.BlobStore {
+		store, err := NewSimpleBlobStore(context.Background(), t.TempDir())
+		require.NoError(t, err)
+		return store
+	})
+}
+
+func TestPersistence(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	store, err := NewSimpleBlobStore(ctx, dir)
+	require.NoError(t, err)
+	opts := backend.BlobOptions{ContentType: "text/plain", Metadata: map[string]string{"owner": "alice"}}
+	require.NoError(t, store.Put(ctx, "a/../../escape", strings.NewReader("hello"), opts))
+	require.NoError(t, store.Put(ctx, "deleted", strings.NewReader("gone"), backend.BlobOptions{}))
+	require.NoError(t, store.Delete(ctx, "deleted"))
+
+	// Keys do not determine paths outside the directory
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	restored, err := NewSimpleBlobStore(ctx, dir)
+	require.NoError(t, err)
+	infos, err := restored.List(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, infos, 1)
+
+	r, info, err := restored.Get(ctx, "a/../../escape")
+	require.NoError(t, err)
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(data))
+	require.Equal(t, "text/plain", info.ContentType)
+	require.Equal(t, map[string]string{"owner": "alice"}, info.Metadata)
+}
@@ -1,38 +1,164 @@
 // Package simplecache implements a key-value [backend.Cache] using a golang map.
+//
+// By default the cache is unbounded.  A cache created with [NewSimpleCacheWithCapacity] holds at most
+// a fixed number of entries, and evicts entries according to an [EvictionPolicy] when it is full.
+//
+// The contents of a cache can be saved with [SimpleCache.Snapshot] and restored with [SimpleCache.Restore],
+// e.g. to pre-populate the cache from a fixture file.  A snapshot is a JSON array of entries:
+//
+//	[
+//	  {"key": "user:1", "value": {"name": "alice"}},
+//	  {"key": "session:7", "value": "token", "expires": "2024-05-01T12:00:00Z"}
+//	]
+//
+// Values are stored in a snapshot as JSON, so they must be JSON-serializable.
 package simplecache
 
 import (
+	"bytes"
+	"cmp"
+	"container/heap"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"slices"
 	"sync"
+	"time"
 
 	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
 )
 
+// Determines which entry a bounded [SimpleCache] evicts when it is full
+type EvictionPolicy int
+
+const (
+	// Evicts the least recently used entry
+	LRU EvictionPolicy = iota
+
+	// Evicts the least frequently used entry, or of those, the least recently used
+	LFU
+)
+
 // A simple map-based cache that implements the [backend.Cache] interface
 type SimpleCache struct {
 	backend.Cache
 	sync.RWMutex
-	values map[string]any
+	values map[string]*entry
+
+	capacity int           // Maximum number of entries, or zero if unbounded
+	order    evictionOrder // Entries ordered by eviction priority; only maintained if capacity is nonzero
+	clock    uint64        // Incremented on every access; used for recency
+}
+
+type entry struct {
+	key      string
+	value    any
+	expires  time.Time // Zero if the entry does not expire
+	lastUsed uint64
+	uses     uint64
+	index    int // Position in the eviction order
 }
 
 // Instantiates a map-based [SimpleCache]
 func NewSimpleCache(ctx context.Context) (*SimpleCache, error) {
 	cache := &SimpleCache{}
-	cache.values = make(map[string]any)
+	cache.values = make(map[string]*entry)
 	return cache, nil
 }
 
+// Instantiates a map-based [SimpleCache] that holds at most capacity entries.
+// When a new key is stored in a full cache, an entry is first evicted according to policy.
+func NewSimpleCacheWithCapacity(capacity int, policy EvictionPolicy) (*SimpleCache, error) {
+	if capacity <= 0 {
+		return nil, fmt.Errorf("invalid cache capacity %v", capacity)
+	}
+	if policy != LRU && policy != LFU {
+		return nil, fmt.Errorf("unknown eviction policy %v", policy)
+	}
+	cache := &SimpleCache{capacity: capacity}
+	cache.values = make(map[string]*entry)
+	cache.order.policy = policy
+	return cache, nil
+}
+
+// Returns the unexpired entry for key, or nil.  The caller must hold the write lock.
+func (cache *SimpleCache) lookup(key string) *entry {
+	e, exists := cache.values[key]
+	if !exists {
+		return nil
+	}
+	if !e.expires.IsZero() && !time.Now().Before(e.expires) {
+		cache.remove(e)
+		return nil
+	}
+	return e
+}
+
+// Records an access of e.  The caller must hold the write lock.
+func (cache *SimpleCache) touch(e *entry) {
+	cache.clock++
+	e.lastUsed = cache.clock
+	e.uses++
+	if cache.capacity > 0 {
+		heap.Fix(&cache.order, e.index)
+	}
+}
+
+// The caller must hold the write lock.
+func (cache *SimpleCache) remove(e *entry) {
+	delete(cache.values, e.key)
+	if cache.capacity > 0 {
+		heap.Remove(&cache.order, e.index)
+	}
+}
+
+// Stores value for key, evicting an entry if the cache is full.  The caller must hold the write lock.
+func (cache *SimpleCache) store(key string, value any, ttl time.Duration) {
+	var expires time.Time
+	if ttl > 0 {
+		expires = time.Now().Add(ttl)
+	}
+	if e := cache.lookup(key); e != nil {
+		e.value = value
+		e.expires = expires
+		cache.touch(e)
+		return
+	}
+	if cache.capacity > 0 && len(cache.values) >= cache.capacity {
+		cache.remove(cache.order.entries[0])
+	}
+	e := &entry{key: key, value: value, expires: expires}
+	cache.values[key] = e
+	if cache.capacity > 0 {
+		heap.Push(&cache.order, e)
+	}
+	cache.touch(e)
+}
+
 func (cache *SimpleCache) Put(ctx context.Context, key string, value interface{}) error {
 	cache.Lock()
 	defer cache.Unlock()
-	cache.values[key] = value
+	cache.store(key, value, 0)
+	return nil
+}
+
+func (cache *SimpleCache) PutWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	if ttl < 0 {
+		return fmt.Errorf("invalid ttl %v", ttl)
+	}
+	cache.Lock()
+	defer cache.Unlock()
+	cache.store(key, value, ttl)
 	return nil
 }
 
 func (cache *SimpleCache) Get(ctx context.Context, key string, val interface{}) (bool, error) {
-	if v, exists := cache.values[key]; exists {
-		return true, backend.CopyResult(v, val)
+	cache.Lock()
+	defer cache.Unlock()
+	if e := cache.lookup(key); e != nil {
+		cache.touch(e)
+		return true, copyValue(e.value, val)
 	}
 	return false, nil
 }
@@ -68,16 +194,193 @@ func (cache *SimpleCache) Mget(ctx context.Context, keys []string, values []inte
 func (cache *SimpleCache) Delete(ctx context.Context, key string) error {
 	cache.Lock()
 	defer cache.Unlock()
-	delete(cache.values, key)
+	if e, exists := cache.values[key]; exists {
+		cache.remove(e)
+	}
 	return nil
 }
 
+// Increments the value mapped to key, retaining its ttl
 func (cache *SimpleCache) Incr(ctx context.Context, key string) (int64, error) {
+	cache.Lock()
+	defer cache.Unlock()
 	cur := int64(0)
-	_, err := cache.Get(ctx, key, &cur)
-	if err != nil {
-		return cur, err
+	e := cache.lookup(key)
+	if e != nil {
+		if err := copyValue(e.value, &cur); err != nil {
+			return cur, err
+		}
 	}
 	cur += 1
-	return cur, cache.Put(ctx, key, cur)
+	if e != nil {
+		e.value = cur
+		cache.touch(e)
+	} else {
+		cache.store(key, cur, 0)
+	}
+	return cur, nil
+}
+
+func (cache *SimpleCache) Expire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	if ttl < 0 {
+		return false, fmt.Errorf("invalid ttl %v", ttl)
+	}
+	cache.Lock()
+	defer cache.Unlock()
+	e := cache.lookup(key)
+	if e == nil {
+		return false, nil
+	}
+	if ttl > 0 {
+		e.expires = time.Now().Add(ttl)
+	} else {
+		e.expires = time.Time{}
+	}
+	return true, nil
+}
+
+func (cache *SimpleCache) SetNX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
+	if ttl < 0 {
+		return false, fmt.Errorf("invalid ttl %v", ttl)
+	}
+	cache.Lock()
+	defer cache.Unlock()
+	if cache.lookup(key) != nil {
+		return false, nil
+	}
+	cache.store(key, value, ttl)
+	return true, nil
+}
+
+// Replaces the value mapped to key, retaining its ttl
+func (cache *SimpleCache) CompareAndSwap(ctx context.Context, key string, old interface{}, new interface{}) (bool, error) {
+	oldBytes, err := json.Marshal(old)
+	if err != nil {
+		return false, err
+	}
+	cache.Lock()
+	defer cache.Unlock()
+	e := cache.lookup(key)
+	if e == nil {
+		return false, nil
+	}
+	curBytes, err := json.Marshal(e.value)
+	if err != nil {
+		return false, err
+	}
+	if !bytes.Equal(curBytes, oldBytes) {
+		return false, nil
+	}
+	e.value = new
+	cache.touch(e)
+	return true, nil
+}
+
+// Copies value to dst.  Restored values are JSON, and are decoded into dst.
+func copyValue(value any, dst any) error {
+	if raw, isRaw := value.(json.RawMessage); isRaw {
+		return json.Unmarshal(raw, dst)
+	}
+	return backend.CopyResult(value, dst)
+}
+
+// An entry of a snapshot
+type snapshotEntry struct {
+	Key     string          `json:"key"`
+	Value   json.RawMessage `json:"value"`
+	Expires *time.Time      `json:"expires,omitempty"`
+}
+
+// Writes the unexpired entries of the cache to w as JSON, from least to most recently used.
+//
+// Implements the snapshot.Snapshotter interface.
+func (cache *SimpleCache) Snapshot(w io.Writer) error {
+	cache.RLock()
+	defer cache.RUnlock()
+	now := time.Now()
+	var entries []*entry
+	for _, e := range cache.values {
+		if e.expires.IsZero() || now.Before(e.expires) {
+			entries = append(entries, e)
+		}
+	}
+	slices.SortFunc(entries, func(a, b *entry) int { return cmp.Compare(a.lastUsed, b.lastUsed) })
+
+	snapshot := make([]snapshotEntry, 0, len(entries))
+	for _, e := range entries {
+		value, err := json.Marshal(e.value)
+		if err != nil {
+			return fmt.Errorf("unable to snapshot value of %v: %w", e.key, err)
+		}
+		s := snapshotEntry{Key: e.key, Value: value}
+		if !e.expires.IsZero() {
+			s.Expires = &e.expires
+		}
+		snapshot = append(snapshot, s)
+	}
+	return json.NewEncoder(w).Encode(snapshot)
+}
+
+// Replaces the contents of the cache with the JSON entries read from r.  Entries that have
+// expired are omitted.  If the cache has a capacity, later entries are considered more recently
+// used, and earlier entries are evicted if there are too many entries.
+//
+// Implements the snapshot.Snapshotter interface.
+func (cache *SimpleCache) Restore(r io.Reader) error {
+	var snapshot []snapshotEntry
+	if err := json.NewDecoder(r).Decode(&snapshot); err != nil {
+		return err
+	}
+	cache.Lock()
+	defer cache.Unlock()
+	cache.values = make(map[string]*entry)
+	cache.order.entries = nil
+	now := time.Now()
+	for _, s := range snapshot {
+		if s.Value == nil {
+			return fmt.Errorf("entry %v has no value", s.Key)
+		}
+		var ttl time.Duration
+		if s.Expires != nil {
+			if ttl = s.Expires.Sub(now); ttl <= 0 {
+				continue
+			}
+		}
+		cache.store(s.Key, s.Value, ttl)
+	}
+	return nil
+}
+
+// A min-heap of entries; the first entry is the next to be evicted
+type evictionOrder struct {
+	entries []*entry
+	policy  EvictionPolicy
+}
+
+func (o evictionOrder) Len() int { return len(o.entries) }
+
+func (o evictionOrder) Less(i, j int) bool {
+	a, b := o.entries[i], o.entries[j]
+	if o.policy == LFU && a.uses != b.uses {
+		return a.uses < b.uses
+	}
+	return a.lastUsed < b.lastUsed
+}
+
+func (o evictionOrder) Swap(i, j int) {
+	o.entries[i], o.entries[j] = o.entries[j], o.entries[i]
+	o.entries[i].index = i
+	o.entries[j].index = j
+}
+
+func (o *evictionOrder) Push(x any) {
+	e := x.(*entry)
+	e.index = len(o.entries)
+	o.entries = append(o.entries, e)
+}
+
+func (o *evictionOrder) Pop() any {
+	e := o.entries[len(o.entries)-1]
+	o.entries = o.entries[:len(o.entries)-1]
+	return e
 }
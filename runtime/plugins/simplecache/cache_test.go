@@ -1,10 +1,16 @@
 package simplecache
 
 import (
+	"bytes"
 	"context"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend/backendtest"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestGet(t *testing.T) {
@@ -73,3 +79,178 @@ func TestMget(t *testing.T) {
 	err = cache.Mget(ctx, []string{}, getvalues)
 	assert.Error(t, err)
 }
+
+func TestConformance(t *testing.T) {
+	backendtest.RunCacheSuite(t, func(t *testing.T) backend.Cache {
+		cache, err := NewSimpleCache(context.Background())
+		require.NoError(t, err)
+		return cache
+	})
+}
+
+func TestBoundedConformance(t *testing.T) {
+	backendtest.RunCacheSuite(t, func(t *testing.T) backend.Cache {
+		cache, err := NewSimpleCacheWithCapacity(100, LRU)
+		require.NoError(t, err)
+		return cache
+	})
+}
+
+func exists(t *testing.T, cache *SimpleCache, key string) bool {
+	var v int
+	exists, err := cache.Get(context.Background(), key, &v)
+	require.NoError(t, err)
+	return exists
+}
+
+func TestLRU(t *testing.T) {
+	ctx := context.Background()
+	cache, err := NewSimpleCacheWithCapacity(3, LRU)
+	require.NoError(t, err)
+
+	require.NoError(t, cache.Put(ctx, "a", 1))
+	require.NoError(t, cache.Put(ctx, "b", 2))
+	require.NoError(t, cache.Put(ctx, "c", 3))
+
+	// Use a, so that b is the least recently used
+	assert.True(t, exists(t, cache, "a"))
+	require.NoError(t, cache.Put(ctx, "d", 4))
+
+	assert.False(t, exists(t, cache, "b"))
+	assert.True(t, exists(t, cache, "c"))
+	assert.True(t, exists(t, cache, "a"))
+	assert.True(t, exists(t, cache, "d"))
+
+	// Overwriting an existing key does not evict
+	require.NoError(t, cache.Put(ctx, "d", 5))
+	assert.True(t, exists(t, cache, "c"))
+	assert.Len(t, cache.values, 3)
+}
+
+func TestLFU(t *testing.T) {
+	ctx := context.Background()
+	cache, err := NewSimpleCacheWithCapacity(3, LFU)
+	require.NoError(t, err)
+
+	require.NoError(t, cache.Put(ctx, "a", 1))
+	require.NoError(t, cache.Put(ctx, "b", 2))
+	require.NoError(t, cache.Put(ctx, "c", 3))
+
+	// a and c are used more often than b, even though b is the most recently used
+	assert.True(t, exists(t, cache, "a"))
+	assert.True(t, exists(t, cache, "c"))
+	assert.True(t, exists(t, cache, "a"))
+	assert.True(t, exists(t, cache, "c"))
+	assert.True(t, exists(t, cache, "b"))
+
+	// Of a, b, and c, b has the fewest uses
+	require.NoError(t, cache.Put(ctx, "d", 4))
+	assert.False(t, exists(t, cache, "b"))
+
+	// d has fewer uses than a and c
+	require.NoError(t, cache.Put(ctx, "e", 5))
+	assert.False(t, exists(t, cache, "d"))
+	assert.True(t, exists(t, cache, "a"))
+	assert.True(t, exists(t, cache, "c"))
+	assert.True(t, exists(t, cache, "e"))
+}
+
+func TestDeleteBounded(t *testing.T) {
+	ctx := context.Background()
+	cache, err := NewSimpleCacheWithCapacity(2, LRU)
+	require.NoError(t, err)
+
+	require.NoError(t, cache.Put(ctx, "a", 1))
+	require.NoError(t, cache.Put(ctx, "b", 2))
+	require.NoError(t, cache.Delete(ctx, "a"))
+	require.NoError(t, cache.Put(ctx, "c", 3))
+
+	// Deleting a freed capacity, so b is not evicted
+	assert.True(t, exists(t, cache, "b"))
+	assert.True(t, exists(t, cache, "c"))
+}
+
+func TestInvalidCapacity(t *testing.T) {
+	_, err := NewSimpleCacheWithCapacity(0, LRU)
+	assert.Error(t, err)
+	_, err = NewSimpleCacheWithCapacity(10, EvictionPolicy(5))
+	assert.Error(t, err)
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	ctx := context.Background()
+	cache, _ := NewSimpleCache(ctx)
+
+	type user struct {
+		Name string
+		Age  int
+	}
+	require.NoError(t, cache.Put(ctx, "user", user{"alice", 30}))
+	require.NoError(t, cache.Put(ctx, "count", int64(5)))
+	require.NoError(t, cache.PutWithTTL(ctx, "session", "token", time.Hour))
+	require.NoError(t, cache.PutWithTTL(ctx, "expired", "token", time.Millisecond))
+	time.Sleep(5 * time.Millisecond)
+
+	var buf bytes.Buffer
+	require.NoError(t, cache.Snapshot(&buf))
+
+	restored, _ := NewSimpleCache(ctx)
+	require.NoError(t, restored.Put(ctx, "stale", "value"))
+	require.NoError(t, restored.Restore(&buf))
+
+	var u user
+	exists, err := restored.Get(ctx, "user", &u)
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.Equal(t, user{"alice", 30}, u)
+
+	count, err := restored.Incr(ctx, "count")
+	require.NoError(t, err)
+	require.Equal(t, int64(6), count)
+
+	swapped, err := restored.CompareAndSwap(ctx, "user", user{"alice", 30}, user{"bob", 40})
+	require.NoError(t, err)
+	require.True(t, swapped)
+
+	var s string
+	exists, err = restored.Get(ctx, "session", &s)
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.Equal(t, "token", s)
+
+	for _, key := range []string{"expired", "stale"} {
+		exists, err = restored.Get(ctx, key, &s)
+		require.NoError(t, err)
+		require.False(t, exists, key)
+	}
+}
+
+func TestRestoreFixture(t *testing.T) {
+	ctx := context.Background()
+	cache, _ := NewSimpleCacheWithCapacity(2, LRU)
+
+	fixture := `[
+		{"key": "a", "value": 1},
+		{"key": "b", "value": {"name": "bob"}},
+		{"key": "c", "value": "three"},
+		{"key": "d", "value": 4, "expires": "2000-01-01T00:00:00Z"}
+	]`
+	require.NoError(t, cache.Restore(strings.NewReader(fixture)))
+
+	// a is evicted because the cache is full; d has expired
+	var v map[string]string
+	exists, err := cache.Get(ctx, "b", &v)
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.Equal(t, map[string]string{"name": "bob"}, v)
+
+	var n int
+	for _, key := range []string{"a", "d"} {
+		exists, err = cache.Get(ctx, key, &n)
+		require.NoError(t, err)
+		require.False(t, exists, key)
+	}
+
+	require.Error(t, cache.Restore(strings.NewReader(`[{"key": "a"}]`)))
+	require.Error(t, cache.Restore(strings.NewReader(`{"a": 1}`)))
+}
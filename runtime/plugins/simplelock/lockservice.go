@@ -0,0 +1,81 @@
+// Package simplelock implements an in-memory [backend.LockService], for coordinating services within a single process.
+package simplelock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+)
+
+// A simple [backend.LockService] that holds leases in memory
+type SimpleLockService struct {
+	backend.LockService
+
+	mu     sync.Mutex
+	leases map[string]backend.Lease // The current lease on each lock; leases are removed when they are released
+}
+
+// Instantiates a [SimpleLockService]
+func NewSimpleLockService(ctx context.Context) (*SimpleLockService, error) {
+	return &SimpleLockService{leases: make(map[string]backend.Lease)}, nil
+}
+
+// Returns the current lease on the lock name, if it has not expired
+func (s *SimpleLockService) current(name string) (backend.Lease, bool) {
+	lease, exists := s.leases[name]
+	if exists && !time.Now().Before(lease.Expires) {
+		delete(s.leases, name)
+		return backend.Lease{}, false
+	}
+	return lease, exists
+}
+
+// Implements backend.LockService
+func (s *SimpleLockService) TryAcquire(ctx context.Context, name string, ttl time.Duration) (backend.Lease, error) {
+	if err := backend.ValidateLock(name, ttl); err != nil {
+		return backend.Lease{}, err
+	}
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return backend.Lease{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, held := s.current(name); held {
+		return backend.Lease{}, backend.ErrLockHeld
+	}
+	lease := backend.Lease{Name: name, Token: hex.EncodeToString(token), Expires: time.Now().Add(ttl)}
+	s.leases[name] = lease
+	return lease, nil
+}
+
+// Implements backend.LockService
+func (s *SimpleLockService) Renew(ctx context.Context, lease backend.Lease, ttl time.Duration) (backend.Lease, error) {
+	if err := backend.ValidateLock(lease.Name, ttl); err != nil {
+		return backend.Lease{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if current, held := s.current(lease.Name); !held || current.Token != lease.Token {
+		return backend.Lease{}, backend.ErrLeaseLost
+	}
+	lease.Expires = time.Now().Add(ttl)
+	s.leases[lease.Name] = lease
+	return lease, nil
+}
+
+// Implements backend.LockService
+func (s *SimpleLockService) Release(ctx context.Context, lease backend.Lease) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if current, held := s.current(lease.Name); held && current.Token == lease.Token {
+		delete(s.leases, lease.Name)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package simplelock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend/backendtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConformance(t *testing.T) {
+	backendtest.RunLockServiceSuite(t, func(t *testing.T) backend.LockService {
+		locks, err := NewSimpleLockService(context.Background())
+		require.NoError(t, err)
+		return locks
+	})
+}
+
+// A lock service whose leases are lost on the first renewal
+type losingLockService struct {
+	*SimpleLockService
+	lost int
+}
+
+func (s *losingLockService) Renew(ctx context.Context, lease backend.Lease, ttl time.Duration) (backend.Lease, error) {
+	s.lost++
+	if s.lost == 1 {
+		s.SimpleLockService.Release(ctx, lease)
+		return backend.Lease{}, backend.ErrLeaseLost
+	}
+	return s.SimpleLockService.Renew(ctx, lease, ttl)
+}
+
+func TestRunAsLeaderLosesLease(t *testing.T) {
+	simple, err := NewSimpleLockService(context.Background())
+	require.NoError(t, err)
+	locks := &losingLockService{SimpleLockService: simple}
+
+	// The first run is cancelled when its lease is lost; the second run is elected again and completes
+	var runs int
+	err = backend.RunAsLeader(context.Background(), locks, "leader", 30*time.Millisecond, func(ctx context.Context) error {
+		runs++
+		if runs == 1 {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, runs)
+}
@@ -0,0 +1,220 @@
+package simplenosqldb_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func aggregate(t *testing.T, ctx context.Context, db backend.NoSQLCollection, pipeline bson.A, results any) {
+	cursor, err := db.Aggregate(ctx, pipeline)
+	require.NoError(t, err)
+	require.NoError(t, cursor.All(ctx, results))
+}
+
+type VendorStats struct {
+	Vendor string `bson:"_id"`
+	Count  int
+	Total  int
+	Avg    float64
+	Min    int
+	Max    int
+	Teas   []string
+}
+
+func TestAggregateGroup(t *testing.T) {
+	ctx, db := MakeTestDB(t)
+
+	var stats []VendorStats
+	aggregate(t, ctx, db, bson.A{
+		bson.D{{"$unwind", "$vendor"}},
+		bson.D{{"$group", bson.D{
+			{"_id", "$vendor"},
+			{"count", bson.D{{"$sum", 1}}},
+			{"total", bson.D{{"$sum", "$rating"}}},
+			{"avg", bson.D{{"$avg", "$rating"}}},
+			{"min", bson.D{{"$min", "$rating"}}},
+			{"max", bson.D{{"$max", "$rating"}}},
+			{"teas", bson.D{{"$push", "$type"}}},
+		}}},
+		bson.D{{"$sort", bson.D{{"_id", 1}}}},
+	}, &stats)
+
+	require.Equal(t, []VendorStats{
+		{Vendor: "A", Count: 2, Total: 18, Avg: 9, Min: 8, Max: 10, Teas: []string{"Masala", "Earl Grey"}},
+		{Vendor: "B", Count: 1, Total: 8, Avg: 8, Min: 8, Max: 8, Teas: []string{"Earl Grey"}},
+		{Vendor: "C", Count: 2, Total: 17, Avg: 8.5, Min: 7, Max: 10, Teas: []string{"Masala", "Oolong"}},
+	}, stats)
+}
+
+func TestAggregateGroupAll(t *testing.T) {
+	ctx, db := MakeTestDB(t)
+
+	var results []bson.M
+	aggregate(t, ctx, db, bson.A{
+		bson.D{{"$group", bson.D{
+			{"_id", nil},
+			{"count", bson.D{{"$sum", 1}}},
+			{"avg", bson.D{{"$avg", "$rating"}}},
+		}}},
+	}, &results)
+
+	require.Len(t, results, 1)
+	require.Nil(t, results[0]["_id"])
+	require.EqualValues(t, 5, results[0]["count"])
+	require.InDelta(t, 7.2, results[0]["avg"], 0.0001)
+}
+
+func TestAggregateGroupCompoundID(t *testing.T) {
+	ctx, db := MakeTestDB(t)
+
+	type Result struct {
+		ID struct {
+			Kind      string
+			Recommend bool
+		} `bson:"_id"`
+		Count int
+	}
+	var results []Result
+	aggregate(t, ctx, db, bson.A{
+		bson.D{{"$group", bson.D{
+			{"_id", bson.D{{"kind", "$packaging.kind"}, {"recommend", bson.D{{"$literal", true}}}}},
+			{"count", bson.D{{"$sum", 1}}},
+		}}},
+		bson.D{{"$sort", bson.D{{"_id.kind", 1}}}},
+	}, &results)
+
+	require.Len(t, results, 3)
+	require.Equal(t, "", results[0].ID.Kind)
+	require.Equal(t, 3, results[0].Count)
+	require.Equal(t, "Cardboard", results[1].ID.Kind)
+	require.Equal(t, "Paper", results[2].ID.Kind)
+	require.True(t, results[2].ID.Recommend)
+}
+
+func TestAggregateMatchSortLimit(t *testing.T) {
+	ctx, db := MakeTestDB(t)
+
+	var gotteas []Tea
+	aggregate(t, ctx, db, bson.A{
+		bson.D{{"$match", bson.D{{"rating", bson.D{{"$gte", 6}}}}}},
+		bson.D{{"$sort", bson.D{{"rating", -1}}}},
+		bson.D{{"$skip", 1}},
+		bson.D{{"$limit", 2}},
+	}, &gotteas)
+
+	require.Equal(t, []Tea{teas[4], teas[2]}, gotteas)
+}
+
+func TestAggregateProject(t *testing.T) {
+	ctx, db := MakeTestDB(t)
+
+	var results []bson.M
+	aggregate(t, ctx, db, bson.A{
+		bson.D{{"$match", bson.D{{"type", "Masala"}}}},
+		bson.D{{"$project", bson.D{{"_id", 0}, {"type", 1}, {"score", "$rating"}, {"kind", "$packaging.kind"}, {"missing", "$nothing"}}}},
+	}, &results)
+	require.Len(t, results, 1)
+	require.Equal(t, bson.M{"type": "Masala", "score": int64(10), "kind": "Paper"}, normalizeInts(results[0]))
+
+	var gotteas []Tea
+	aggregate(t, ctx, db, bson.A{
+		bson.D{{"$project", bson.D{{"sizes", 0}, {"packaging.width", 0}}}},
+		bson.D{{"$limit", 1}},
+	}, &gotteas)
+	expected := teas[0]
+	expected.Sizes = nil
+	expected.Packaging.Width = 0
+	require.Equal(t, []Tea{expected}, gotteas)
+
+	_, err := db.Aggregate(ctx, bson.A{bson.D{{"$project", bson.D{{"type", 1}, {"rating", 0}}}}})
+	require.Error(t, err)
+}
+
+func normalizeInts(m bson.M) bson.M {
+	for k, v := range m {
+		if i, isInt := v.(int32); isInt {
+			m[k] = int64(i)
+		}
+	}
+	return m
+}
+
+func TestAggregateUnwind(t *testing.T) {
+	ctx, db := MakeTestDB(t)
+
+	type Result struct {
+		Type  string
+		Sizes int32
+		Index int64
+	}
+	var results []Result
+	aggregate(t, ctx, db, bson.A{
+		bson.D{{"$unwind", bson.D{{"path", "$sizes"}, {"includeArrayIndex", "index"}}}},
+	}, &results)
+	require.Len(t, results, 8)
+	require.Equal(t, Result{Type: "English Breakfast", Sizes: 16, Index: 2}, results[3])
+
+	var gotteas []bson.M
+	aggregate(t, ctx, db, bson.A{bson.D{{"$unwind", "$vendor"}}}, &gotteas)
+	require.Len(t, gotteas, 5)
+
+	aggregate(t, ctx, db, bson.A{bson.D{{"$unwind", bson.D{{"path", "$vendor"}, {"preserveNullAndEmptyArrays", true}}}}}, &gotteas)
+	require.Len(t, gotteas, 7)
+}
+
+func TestAggregateLookup(t *testing.T) {
+	ctx, db := getDB(t)
+	teaColl, err := db.GetCollection(ctx, "testdb", fmt.Sprintf("testcollection%v", collectionid))
+	require.NoError(t, err)
+	vendorName := fmt.Sprintf("vendors%v", collectionid)
+	vendorColl, err := db.GetCollection(ctx, "testdb", vendorName)
+	require.NoError(t, err)
+	collectionid += 1
+
+	for _, tea := range teas {
+		require.NoError(t, teaColl.InsertOne(ctx, tea))
+	}
+	type Vendor struct {
+		Name    string
+		Country string
+	}
+	for _, vendor := range []Vendor{{"A", "India"}, {"B", "England"}, {"C", "China"}} {
+		require.NoError(t, vendorColl.InsertOne(ctx, vendor))
+	}
+
+	type Result struct {
+		Type    string
+		Vendors []Vendor
+	}
+	var results []Result
+	aggregate(t, ctx, teaColl, bson.A{
+		bson.D{{"$match", bson.D{{"type", bson.D{{"$in", bson.A{"Masala", "Assam"}}}}}}},
+		bson.D{{"$lookup", bson.D{{"from", vendorName}, {"localField", "vendor"}, {"foreignField", "name"}, {"as", "vendors"}}}},
+		bson.D{{"$project", bson.D{{"_id", 0}, {"type", 1}, {"vendors.name", 1}, {"vendors.country", 1}}}},
+		bson.D{{"$sort", bson.D{{"type", 1}}}},
+	}, &results)
+
+	require.Equal(t, []Result{
+		{Type: "Assam", Vendors: []Vendor{}},
+		{Type: "Masala", Vendors: []Vendor{{"A", "India"}, {"C", "China"}}},
+	}, results)
+}
+
+func TestAggregateInvalid(t *testing.T) {
+	ctx, db := MakeTestDB(t)
+
+	for _, pipeline := range []bson.A{
+		{bson.D{{"$nosuchstage", bson.D{}}}},
+		{bson.D{{"$group", bson.D{{"count", bson.D{{"$sum", 1}}}}}}},
+		{bson.D{{"$group", bson.D{{"_id", nil}, {"count", bson.D{{"$nosuchaccumulator", 1}}}}}}},
+		{bson.D{{"$limit", -1}}},
+	} {
+		_, err := db.Aggregate(ctx, pipeline)
+		require.Error(t, err, "pipeline %v", pipeline)
+	}
+}
@@ -0,0 +1,158 @@
+package simplenosqldb_test
+
+import (
+	"testing"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func teaTypes(teas []Tea) []string {
+	var types []string
+	for _, tea := range teas {
+		types = append(types, tea.Type)
+	}
+	return types
+}
+
+func findTeas(t *testing.T, db backend.NoSQLCollection, filter bson.D, opts backend.FindOptions) []Tea {
+	ctx, _ := getDB(t)
+	cursor, err := db.FindManyWithOptions(ctx, filter, opts)
+	require.NoError(t, err)
+
+	gotteas := []Tea{}
+	require.NoError(t, cursor.All(ctx, &gotteas))
+	return gotteas
+}
+
+func TestFindSort(t *testing.T) {
+	_, db := MakeTestDB(t)
+
+	gotteas := findTeas(t, db, bson.D{}, backend.FindOptions{Sort: bson.D{{"rating", 1}}})
+	require.Equal(t, []string{"Assam", "English Breakfast", "Oolong", "Earl Grey", "Masala"}, teaTypes(gotteas))
+
+	gotteas = findTeas(t, db, bson.D{}, backend.FindOptions{Sort: bson.D{{"type", -1}}})
+	require.Equal(t, []string{"Oolong", "Masala", "English Breakfast", "Earl Grey", "Assam"}, teaTypes(gotteas))
+
+	gotteas = findTeas(t, db, bson.D{{"rating", bson.D{{"$gte", 7}}}}, backend.FindOptions{Sort: bson.D{{"rating", -1}}})
+	require.Equal(t, []string{"Masala", "Earl Grey", "Oolong"}, teaTypes(gotteas))
+}
+
+func TestFindSortNested(t *testing.T) {
+	_, db := MakeTestDB(t)
+
+	// Teas without packaging sort first, ordered by _id
+	gotteas := findTeas(t, db, bson.D{}, backend.FindOptions{Sort: bson.D{{"packaging.length", -1}}})
+	require.Equal(t, []string{"Assam", "Masala"}, teaTypes(gotteas[:2]))
+}
+
+func TestFindSortMultipleKeys(t *testing.T) {
+	_, db := MakeTestDB(t)
+	ctx, _ := getDB(t)
+	require.NoError(t, db.InsertOne(ctx, Tea{Type: "Darjeeling", Rating: 7}))
+
+	gotteas := findTeas(t, db, bson.D{}, backend.FindOptions{Sort: bson.D{{"rating", -1}, {"type", 1}}})
+	require.Equal(t, []string{"Masala", "Earl Grey", "Darjeeling", "Oolong", "English Breakfast", "Assam"}, teaTypes(gotteas))
+}
+
+func TestFindSkipLimit(t *testing.T) {
+	_, db := MakeTestDB(t)
+
+	sort := bson.D{{"rating", 1}}
+	gotteas := findTeas(t, db, bson.D{}, backend.FindOptions{Sort: sort, Limit: 2})
+	require.Equal(t, []string{"Assam", "English Breakfast"}, teaTypes(gotteas))
+
+	gotteas = findTeas(t, db, bson.D{}, backend.FindOptions{Sort: sort, Skip: 2, Limit: 2})
+	require.Equal(t, []string{"Oolong", "Earl Grey"}, teaTypes(gotteas))
+
+	gotteas = findTeas(t, db, bson.D{}, backend.FindOptions{Sort: sort, Skip: 4, Limit: 2})
+	require.Equal(t, []string{"Masala"}, teaTypes(gotteas))
+
+	gotteas = findTeas(t, db, bson.D{}, backend.FindOptions{Sort: sort, Skip: 10})
+	require.Empty(t, gotteas)
+}
+
+func TestFindPagination(t *testing.T) {
+	ctx, db := MakeTestDB(t)
+
+	opts := backend.FindOptions{Sort: bson.D{{"rating", -1}}, Limit: 2}
+	var pages [][]string
+	for {
+		cursor, err := db.FindManyWithOptions(ctx, bson.D{}, opts)
+		require.NoError(t, err)
+		var page []bson.D
+		require.NoError(t, cursor.All(ctx, &page))
+		if len(page) == 0 {
+			break
+		}
+
+		var types []string
+		for _, doc := range page {
+			types = append(types, doc.Map()["type"].(string))
+		}
+		pages = append(pages, types)
+
+		opts.PageToken, err = backend.NextPageToken(opts, page[len(page)-1])
+		require.NoError(t, err)
+	}
+	require.Equal(t, [][]string{{"Masala", "Earl Grey"}, {"Oolong", "English Breakfast"}, {"Assam"}}, pages)
+}
+
+func TestFindPaginationStableUnderInsert(t *testing.T) {
+	ctx, db := MakeTestDB(t)
+
+	opts := backend.FindOptions{Sort: bson.D{{"type", 1}}, Limit: 2}
+	cursor, err := db.FindManyWithOptions(ctx, bson.D{}, opts)
+	require.NoError(t, err)
+	var page []bson.D
+	require.NoError(t, cursor.All(ctx, &page))
+	require.Len(t, page, 2)
+
+	opts.PageToken, err = backend.NextPageToken(opts, page[1])
+	require.NoError(t, err)
+
+	// A document inserted before the page token does not shift subsequent pages
+	require.NoError(t, db.InsertOne(ctx, Tea{Type: "Chai", Rating: 1}))
+	gotteas := findTeas(t, db, bson.D{}, opts)
+	require.Equal(t, []string{"English Breakfast", "Masala"}, teaTypes(gotteas))
+}
+
+func TestFindInvalidOptions(t *testing.T) {
+	ctx, db := MakeTestDB(t)
+
+	_, err := db.FindManyWithOptions(ctx, bson.D{}, backend.FindOptions{Sort: bson.D{{"rating", 2}}})
+	require.Error(t, err)
+
+	_, err = db.FindManyWithOptions(ctx, bson.D{}, backend.FindOptions{PageToken: "not a token"})
+	require.Error(t, err)
+}
+
+func TestFindProjection(t *testing.T) {
+	ctx, db := MakeTestDB(t)
+
+	cursor, err := db.FindOne(ctx, bson.D{{"type", "Masala"}}, bson.D{{"type", 1}, {"rating", 1}})
+	require.NoError(t, err)
+	var tea Tea
+	found, err := cursor.One(ctx, &tea)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, Tea{Type: "Masala", Rating: 10}, tea)
+
+	cursor, err = db.FindMany(ctx, bson.D{{"rating", bson.D{{"$gte", 8}}}}, bson.D{{"sizes", 0}, {"packaging", 0}})
+	require.NoError(t, err)
+	var gotteas []Tea
+	require.NoError(t, cursor.All(ctx, &gotteas))
+	require.ElementsMatch(t, []Tea{{Type: "Masala", Rating: 10, Vendor: []string{"A", "C"}}, {Type: "Earl Grey", Rating: 8, Vendor: []string{"A", "B"}}}, gotteas)
+
+	// The projection is applied after sorting and limiting
+	gotteas = findTeas(t, db, bson.D{}, backend.FindOptions{Sort: bson.D{{"rating", -1}}, Limit: 2, Projection: bson.D{{"rating", 1}}})
+	require.Equal(t, []Tea{{Rating: 10}, {Rating: 8}}, gotteas)
+}
+
+func TestFindInvalidProjection(t *testing.T) {
+	ctx, db := MakeTestDB(t)
+
+	_, err := db.FindMany(ctx, bson.D{}, bson.D{{"type", 1}, {"rating", 0}})
+	require.Error(t, err)
+}
@@ -0,0 +1,319 @@
+package simplenosqldb
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/simplenosqldb/query"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// An index over the items of a [SimpleCollection].
+//
+// Entries refer to the position of items in the collection.  Each index is both a hash index, used for
+// equality filters on all of its keys, and an ordered index on its first key, used for equality and
+// range filters on that key.
+type simpleIndex struct {
+	name   string
+	keys   bson.D
+	unique bool
+
+	// Positions of items, by the encoded values of their keys
+	hash map[string][]int
+
+	// Positions of items, sorted by the value of the first key; nil when it needs to be recomputed.
+	// Queries only hold the collection's read lock, so ordered is computed under orderedMu.
+	ordered   []int
+	orderedMu sync.Mutex
+
+	// Number of items that have an array along the path to one of the keys.  Filters on such items
+	// match elements of the array, which the index does not support, so the index is not used while
+	// this is non-zero.
+	multikey int
+}
+
+// Returns the default name of an index on keys, following mongodb's naming convention
+func indexName(keys bson.D) string {
+	var parts []string
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf("%v_%v", key.Key, key.Value))
+	}
+	return strings.Join(parts, "_")
+}
+
+func newIndex(keys bson.D, unique bool) *simpleIndex {
+	return &simpleIndex{name: indexName(keys), keys: keys, unique: unique, hash: make(map[string][]int)}
+}
+
+// Returns the values of the index keys of doc, and whether any of them is reached through an array
+func (idx *simpleIndex) values(doc bson.D) ([]any, bool) {
+	var values []any
+	multikey := false
+	for _, key := range idx.keys {
+		value, isArray := indexedValue(doc, key.Key)
+		values = append(values, value)
+		multikey = multikey || isArray
+	}
+	return values, multikey
+}
+
+// Returns the value selected by path, and whether an array was encountered along the path
+func indexedValue(doc bson.D, path string) (any, bool) {
+	var current any = doc
+	for _, name := range strings.Split(path, ".") {
+		switch d := current.(type) {
+		case bson.D:
+			current = nil
+			for _, e := range d {
+				if e.Key == name {
+					current = e.Value
+					break
+				}
+			}
+		case bson.A:
+			return nil, true
+		default:
+			return nil, false
+		}
+	}
+	_, isArray := current.(bson.A)
+	return current, isArray
+}
+
+// Encodes values as a hash key.  Numeric values are normalized so that equal numbers of different types
+// have the same key.
+func hashKey(values []any) string {
+	normalized := make(bson.A, 0, len(values))
+	for _, v := range values {
+		normalized = append(normalized, normalizeNumber(v))
+	}
+	b, err := bson.Marshal(bson.D{{"k", normalized}})
+	if err != nil {
+		return fmt.Sprintf("%#v", normalized)
+	}
+	return string(b)
+}
+
+func normalizeNumber(v any) any {
+	var f float64
+	switch n := v.(type) {
+	case int:
+		return int64(n)
+	case int32:
+		return int64(n)
+	case float32:
+		f = float64(n)
+	case float64:
+		f = n
+	default:
+		return v
+	}
+	if f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+		return int64(f)
+	}
+	return f
+}
+
+func (idx *simpleIndex) add(doc bson.D, pos int) {
+	values, multikey := idx.values(doc)
+	key := hashKey(values)
+	idx.hash[key] = append(idx.hash[key], pos)
+	if multikey {
+		idx.multikey++
+	}
+	idx.ordered = nil
+}
+
+func (idx *simpleIndex) remove(doc bson.D, pos int) {
+	values, multikey := idx.values(doc)
+	key := hashKey(values)
+	positions := idx.hash[key]
+	for i, p := range positions {
+		if p == pos {
+			positions = append(positions[:i], positions[i+1:]...)
+			break
+		}
+	}
+	if len(positions) == 0 {
+		delete(idx.hash, key)
+	} else {
+		idx.hash[key] = positions
+	}
+	if multikey {
+		idx.multikey--
+	}
+	idx.ordered = nil
+}
+
+// Reports whether doc would violate the index's uniqueness constraint.  The item at position
+// exclude, which doc is replacing, is ignored; use -1 for new items.
+func (idx *simpleIndex) conflicts(doc bson.D, exclude int) bool {
+	if !idx.unique {
+		return false
+	}
+	values, _ := idx.values(doc)
+	for _, pos := range idx.hash[hashKey(values)] {
+		if pos != exclude {
+			return true
+		}
+	}
+	return false
+}
+
+func (idx *simpleIndex) duplicateKeyError(doc bson.D) error {
+	values, _ := idx.values(doc)
+	return &backend.DuplicateKeyError{
+		Index: idx.name,
+		Err:   fmt.Errorf("duplicate key error: index %v dup key %v", idx.name, values),
+	}
+}
+
+// Returns the positions of items sorted by the value of the first key
+func (idx *simpleIndex) sorted(items []bson.D) []int {
+	idx.orderedMu.Lock()
+	defer idx.orderedMu.Unlock()
+	if idx.ordered == nil {
+		idx.ordered = make([]int, 0, len(items))
+		for _, positions := range idx.hash {
+			idx.ordered = append(idx.ordered, positions...)
+		}
+		key := idx.keys[0].Key
+		sort.Slice(idx.ordered, func(i, j int) bool {
+			a, b := idx.ordered[i], idx.ordered[j]
+			if c := query.Compare(query.LookupField(items[a], key), query.LookupField(items[b], key)); c != 0 {
+				return c < 0
+			}
+			return a < b
+		})
+	}
+	return idx.ordered
+}
+
+// Bounds on the value of a field, extracted from a filter
+type bounds struct {
+	eq        any
+	hasEq     bool
+	lower     any
+	hasLower  bool
+	lowerIncl bool
+	upper     any
+	hasUpper  bool
+	upperIncl bool
+}
+
+// Reports whether v is a value that the index can look up
+func indexable(v any) bool {
+	switch v.(type) {
+	case nil, int, int32, int64, float32, float64, string, bool, primitive.ObjectID, primitive.DateTime:
+		return true
+	}
+	return false
+}
+
+// Extracts the bounds on top-level fields from filter.  Fields with conditions that cannot be used
+// with an index are omitted.
+func filterBounds(filter bson.D) map[string]*bounds {
+	fields := make(map[string]*bounds)
+	for _, e := range filter {
+		if strings.HasPrefix(e.Key, "$") {
+			continue
+		}
+		ops, isD := e.Value.(bson.D)
+		if !isD {
+			if indexable(e.Value) {
+				fields[e.Key] = &bounds{eq: e.Value, hasEq: true}
+			}
+			continue
+		}
+		b := &bounds{}
+		for _, op := range ops {
+			if !strings.HasPrefix(op.Key, "$") {
+				// Exact sub-document match
+				b = &bounds{}
+				break
+			}
+			if !indexable(op.Value) {
+				continue
+			}
+			switch op.Key {
+			case "$eq":
+				b.eq, b.hasEq = op.Value, true
+			case "$gt", "$gte":
+				b.lower, b.hasLower, b.lowerIncl = op.Value, true, op.Key == "$gte"
+			case "$lt", "$lte":
+				b.upper, b.hasUpper, b.upperIncl = op.Value, true, op.Key == "$lte"
+			}
+		}
+		if b.hasEq || b.hasLower || b.hasUpper {
+			fields[e.Key] = b
+		}
+	}
+	return fields
+}
+
+// Returns the positions, in ascending order, of a superset of the items that match filter, using an
+// index if possible.  Reports false if no index can be used, in which case all items must be scanned.
+func (db *SimpleCollection) plan(filter bson.D) ([]int, bool) {
+	fields := filterBounds(filter)
+	if len(fields) == 0 {
+		return nil, false
+	}
+
+	// Prefer an index with equality conditions on all of its keys
+	for _, idx := range db.indexes {
+		if idx.multikey > 0 {
+			continue
+		}
+		var values []any
+		for _, key := range idx.keys {
+			if b, exists := fields[key.Key]; exists && b.hasEq {
+				values = append(values, b.eq)
+			} else {
+				break
+			}
+		}
+		if len(values) == len(idx.keys) {
+			positions := append([]int(nil), idx.hash[hashKey(values)]...)
+			sort.Ints(positions)
+			return positions, true
+		}
+	}
+
+	// Otherwise use the ordered index on the first key of an index
+	for _, idx := range db.indexes {
+		b, exists := fields[idx.keys[0].Key]
+		if idx.multikey > 0 || !exists {
+			continue
+		}
+		if b.hasEq {
+			b = &bounds{lower: b.eq, hasLower: true, lowerIncl: true, upper: b.eq, hasUpper: true, upperIncl: true}
+		}
+		key := idx.keys[0].Key
+		ordered := idx.sorted(db.items)
+		start, end := 0, len(ordered)
+		if b.hasLower {
+			start = sort.Search(len(ordered), func(i int) bool {
+				c := query.Compare(query.LookupField(db.items[ordered[i]], key), b.lower)
+				return c > 0 || (c == 0 && b.lowerIncl)
+			})
+		}
+		if b.hasUpper {
+			end = sort.Search(len(ordered), func(i int) bool {
+				c := query.Compare(query.LookupField(db.items[ordered[i]], key), b.upper)
+				return c > 0 || (c == 0 && !b.upperIncl)
+			})
+		}
+		if end < start {
+			end = start
+		}
+		positions := append([]int(nil), ordered[start:end]...)
+		sort.Ints(positions)
+		return positions, true
+	}
+	return nil, false
+}
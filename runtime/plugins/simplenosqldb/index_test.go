@@ -0,0 +1,172 @@
+package simplenosqldb_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func requireDuplicateKey(t *testing.T, err error) {
+	require.ErrorIs(t, err, backend.ErrDuplicateKey)
+	var dupErr *backend.DuplicateKeyError
+	require.True(t, errors.As(err, &dupErr))
+}
+
+func TestUniqueIndexInsert(t *testing.T) {
+	ctx, db := MakeTestDB(t)
+	require.NoError(t, db.CreateIndex(ctx, bson.D{{"type", 1}}, true))
+
+	err := db.InsertOne(ctx, Tea{Type: "Masala", Rating: 1})
+	requireDuplicateKey(t, err)
+
+	require.NoError(t, db.InsertOne(ctx, Tea{Type: "Chai", Rating: 1}))
+
+	// Creating the same index again is a no-op
+	require.NoError(t, db.CreateIndex(ctx, bson.D{{"type", 1}}, true))
+}
+
+func TestUniqueIndexExistingDuplicates(t *testing.T) {
+	ctx, db := MakeTestDB(t)
+	require.NoError(t, db.InsertOne(ctx, Tea{Type: "Chai", Rating: 10}))
+
+	err := db.CreateIndex(ctx, bson.D{{"rating", 1}}, true)
+	requireDuplicateKey(t, err)
+
+	// A non-unique index can be created
+	require.NoError(t, db.CreateIndex(ctx, bson.D{{"rating", -1}}, false))
+}
+
+func TestUniqueCompoundIndex(t *testing.T) {
+	ctx, db := MakeTestDB(t)
+	require.NoError(t, db.CreateIndex(ctx, bson.D{{"type", 1}, {"rating", 1}}, true))
+
+	require.NoError(t, db.InsertOne(ctx, Tea{Type: "Masala", Rating: 1}))
+	requireDuplicateKey(t, db.InsertOne(ctx, Tea{Type: "Masala", Rating: 10}))
+}
+
+func TestUniqueIndexUpdate(t *testing.T) {
+	ctx, db := MakeTestDB(t)
+	require.NoError(t, db.CreateIndex(ctx, bson.D{{"type", 1}}, true))
+
+	_, err := db.UpdateOne(ctx, bson.D{{"type", "Assam"}}, bson.D{{"$set", bson.D{{"type", "Oolong"}}}})
+	requireDuplicateKey(t, err)
+
+	// The document is unmodified
+	cursor, err := db.FindOne(ctx, bson.D{{"type", "Assam"}})
+	require.NoError(t, err)
+	var tea Tea
+	found, err := cursor.One(ctx, &tea)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, teas[3], tea)
+
+	// Updating a document without changing its key is allowed
+	_, err = db.UpdateOne(ctx, bson.D{{"type", "Assam"}}, bson.D{{"$set", bson.D{{"rating", 9}}}})
+	require.NoError(t, err)
+}
+
+func TestUniqueIndexReplace(t *testing.T) {
+	ctx, db := MakeTestDB(t)
+	require.NoError(t, db.CreateIndex(ctx, bson.D{{"type", 1}}, true))
+
+	_, err := db.ReplaceOne(ctx, bson.D{{"type", "Assam"}}, Tea{Type: "Oolong"})
+	requireDuplicateKey(t, err)
+
+	_, err = db.ReplaceOne(ctx, bson.D{{"type", "Assam"}}, Tea{Type: "Assam", Rating: 1})
+	require.NoError(t, err)
+}
+
+func TestDuplicateID(t *testing.T) {
+	ctx, db := getDB(t)
+	coll, err := db.GetCollection(ctx, "testdb", "duplicateids")
+	require.NoError(t, err)
+	require.NoError(t, coll.DeleteMany(ctx, bson.D{}))
+
+	id := primitive.NewObjectID()
+	require.NoError(t, coll.InsertOne(ctx, bson.D{{"_id", id}, {"type", "Masala"}}))
+	requireDuplicateKey(t, coll.InsertOne(ctx, bson.D{{"_id", id}, {"type", "Chai"}}))
+}
+
+func TestIndexedQueries(t *testing.T) {
+	ctx, db := MakeTestDB(t)
+	require.NoError(t, db.CreateIndex(ctx, bson.D{{"rating", 1}}, false))
+	require.NoError(t, db.CreateIndex(ctx, bson.D{{"type", 1}, {"rating", -1}}, false))
+	require.NoError(t, db.CreateIndex(ctx, bson.D{{"packaging.kind", 1}}, false))
+
+	check := func(filter bson.D, expected ...string) {
+		gotteas := findTeas(t, db, filter, backend.FindOptions{})
+		require.ElementsMatch(t, expected, teaTypes(gotteas), "filter %v", filter)
+	}
+
+	check(bson.D{{"rating", 7}}, "Oolong")
+	check(bson.D{{"rating", bson.D{{"$eq", 7}}}}, "Oolong")
+	check(bson.D{{"rating", 3}})
+	check(bson.D{{"rating", bson.D{{"$gt", 6}}}}, "Masala", "Oolong", "Earl Grey")
+	check(bson.D{{"rating", bson.D{{"$gte", 6}, {"$lt", 8}}}}, "English Breakfast", "Oolong")
+	check(bson.D{{"rating", bson.D{{"$lte", 5}}}}, "Assam")
+	check(bson.D{{"type", "Assam"}, {"rating", 5}}, "Assam")
+	check(bson.D{{"type", "Assam"}, {"rating", 6}})
+	check(bson.D{{"packaging.kind", "Paper"}}, "Masala")
+
+	// Queries remain correct after documents are modified and removed
+	_, err := db.UpdateOne(ctx, bson.D{{"type", "Oolong"}}, bson.D{{"$set", bson.D{{"rating", 9}}}})
+	require.NoError(t, err)
+	require.NoError(t, db.DeleteOne(ctx, bson.D{{"type", "Masala"}}))
+	require.NoError(t, db.InsertOne(ctx, Tea{Type: "Chai", Rating: 7}))
+
+	check(bson.D{{"rating", 7}}, "Chai")
+	check(bson.D{{"rating", bson.D{{"$gt", 6}}}}, "Oolong", "Earl Grey", "Chai")
+	check(bson.D{{"packaging.kind", "Paper"}})
+}
+
+func TestIndexedArrayQueries(t *testing.T) {
+	ctx, db := MakeTestDB(t)
+	require.NoError(t, db.CreateIndex(ctx, bson.D{{"sizes", 1}}, false))
+
+	// Equality on an array field matches elements of the array
+	gotteas := findTeas(t, db, bson.D{{"sizes", 16}}, backend.FindOptions{})
+	require.ElementsMatch(t, []string{"English Breakfast", "Oolong", "Assam"}, teaTypes(gotteas))
+}
+
+func TestConcurrentIndexedQueries(t *testing.T) {
+	ctx, db := MakeTestDB(t)
+	require.NoError(t, db.CreateIndex(ctx, bson.D{{"rating", 1}}, false))
+
+	workers := 8
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if i%2 == 0 {
+					if err := db.InsertOne(ctx, Tea{Type: "Green", Rating: 100 + j}); err != nil {
+						errs <- err
+						return
+					}
+				} else {
+					cursor, err := db.FindMany(ctx, bson.D{{"rating", bson.D{{"$gte", 5}}}})
+					if err == nil {
+						var teas []Tea
+						err = cursor.All(ctx, &teas)
+					}
+					if err != nil {
+						errs <- err
+						return
+					}
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+}
@@ -2,13 +2,18 @@
 //
 // Only a small set of common basic filter and update operators are supported, but typically this is sufficient
 // for most applications and enables writing service-level unit tests.
+//
+// The contents of a database can be saved with [SimpleNoSQLDB.Snapshot] and restored with [SimpleNoSQLDB.Restore],
+// e.g. to pre-populate the database from a fixture file.
 package simplenosqldb
 
 import (
 	"context"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
 	"github.com/blueprint-uservices/blueprint/runtime/plugins/simplenosqldb/query"
@@ -25,11 +30,29 @@ type (
 	// Only a small set of common basic filter and update operators are supported, but typically this is sufficient
 	// for most applications and enables writing service-level unit tests.
 	SimpleNoSQLDB struct {
+		// Guards the collections and their items
+		mu          sync.RWMutex
 		collections map[string]map[string]*SimpleCollection
+
+		// The active transaction, if any
+		tx *simpleTransaction
+
+		// Closed when the active transaction ends; waited on by writes made outside of it
+		txDone chan struct{}
 	}
 
 	SimpleCollection struct {
-		items []bson.D
+		items   []bson.D
+		indexes []*simpleIndex
+
+		// The collections of the database that this collection belongs to; used by $lookup
+		database map[string]*SimpleCollection
+
+		// The SimpleNoSQLDB that this collection belongs to; used by transactions
+		owner *SimpleNoSQLDB
+
+		// The open change streams of the collection
+		streams []*SimpleChangeStream
 	}
 
 	SimpleCursor struct {
@@ -45,6 +68,13 @@ func NewSimpleNoSQLDB(ctx context.Context) (*SimpleNoSQLDB, error) {
 }
 
 func (impl *SimpleNoSQLDB) GetCollection(ctx context.Context, db_name string, collection_name string) (backend.NoSQLCollection, error) {
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+	return impl.collection(db_name, collection_name), nil
+}
+
+// Returns the named collection, creating it if it does not exist.  The caller must hold the write lock.
+func (impl *SimpleNoSQLDB) collection(db_name string, collection_name string) *SimpleCollection {
 	db, dbExists := impl.collections[db_name]
 	if !dbExists {
 		db = make(map[string]*SimpleCollection)
@@ -53,11 +83,17 @@ func (impl *SimpleNoSQLDB) GetCollection(ctx context.Context, db_name string, co
 
 	collection, collectionExists := db[collection_name]
 	if !collectionExists {
-		collection = &SimpleCollection{}
+		collection = newSimpleCollection(impl, db)
 		db[collection_name] = collection
 	}
+	return collection
+}
 
-	return collection, nil
+func newSimpleCollection(owner *SimpleNoSQLDB, database map[string]*SimpleCollection) *SimpleCollection {
+	// Like mongodb, every collection has a unique index on _id
+	idIndex := newIndex(bson.D{{"_id", int32(1)}}, true)
+	idIndex.name = "_id_"
+	return &SimpleCollection{indexes: []*simpleIndex{idIndex}, database: database, owner: owner}
 }
 
 func (c *SimpleCursor) One(ctx context.Context, obj interface{}) (bool, error) {
@@ -96,6 +132,15 @@ func (c *SimpleCursor) All(ctx context.Context, obj interface{}) error {
 }
 
 func (db *SimpleCollection) InsertOne(ctx context.Context, document interface{}) error {
+	if err := db.owner.lockForWrite(ctx); err != nil {
+		return err
+	}
+	defer db.owner.mu.Unlock()
+	return db.insert(document)
+}
+
+// The caller must hold the write lock.
+func (db *SimpleCollection) insert(document interface{}) error {
 	d, isD := document.(bson.D)
 	if !isD {
 		var err error
@@ -115,13 +160,114 @@ func (db *SimpleCollection) InsertOne(ctx context.Context, document interface{})
 		d = append(bson.D{{"_id", primitive.NewObjectID()}}, d...)
 	}
 
+	for _, idx := range db.indexes {
+		if idx.conflicts(d, -1) {
+			return idx.duplicateKeyError(d)
+		}
+	}
+	db.beforeWrite()
 	db.items = append(db.items, d)
+	for _, idx := range db.indexes {
+		idx.add(d, len(db.items)-1)
+	}
+	db.notify(backend.NoSQLInsert, d)
+	return nil
+}
+
+// Replaces the item at position i with d, maintaining indexes.
+// Returns a [backend.DuplicateKeyError] if d would violate a unique index.
+func (db *SimpleCollection) setItem(i int, d bson.D) error {
+	for _, idx := range db.indexes {
+		if idx.conflicts(d, i) {
+			return idx.duplicateKeyError(d)
+		}
+	}
+	db.beforeWrite()
+	for _, idx := range db.indexes {
+		idx.remove(db.items[i], i)
+		idx.add(d, i)
+	}
+	db.items[i] = d
+	return nil
+}
+
+// Rebuilds all indexes; used after items are removed
+func (db *SimpleCollection) reindex() {
+	for _, idx := range db.indexes {
+		idx.hash = make(map[string][]int)
+		idx.ordered = nil
+		idx.multikey = 0
+		for i, item := range db.items {
+			idx.add(item, i)
+		}
+	}
+}
+
+// Returns the positions of up to limit items that match filter, in order; zero means no limit.
+// Uses an index to find candidate items if possible.
+func (db *SimpleCollection) match(filterOp query.Filter, filter bson.D, limit int) []int {
+	candidates, indexed := db.plan(filter)
+	if !indexed {
+		candidates = make([]int, len(db.items))
+		for i := range db.items {
+			candidates[i] = i
+		}
+	}
+	var matches []int
+	for _, i := range candidates {
+		if filterOp.Apply(db.items[i]) {
+			matches = append(matches, i)
+			if verbose {
+				fmt.Printf("MATCH: %v\n", db.items[i])
+			}
+			if limit > 0 && len(matches) == limit {
+				break
+			}
+		} else if verbose {
+			fmt.Printf("       %v\n", db.items[i])
+		}
+	}
+	return matches
+}
+
+func (db *SimpleCollection) CreateIndex(ctx context.Context, keys bson.D, unique bool) error {
+	if err := db.owner.lockForWrite(ctx); err != nil {
+		return err
+	}
+	defer db.owner.mu.Unlock()
+	keys, err := backend.NormalizeKeys(keys)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("cannot create an index with no keys")
+	}
+	idx := newIndex(keys, unique)
+	for _, existing := range db.indexes {
+		if existing.name == idx.name {
+			if existing.unique != unique {
+				return fmt.Errorf("index %v already exists with different options", idx.name)
+			}
+			return nil
+		}
+	}
+	for i, item := range db.items {
+		if idx.conflicts(item, i) {
+			return idx.duplicateKeyError(item)
+		}
+		idx.add(item, i)
+	}
+	db.indexes = append(db.indexes, idx)
 	return nil
 }
 
 func (db *SimpleCollection) InsertMany(ctx context.Context, documents []interface{}) error {
+	if err := db.owner.lockForWrite(ctx); err != nil {
+		return err
+	}
+	defer db.owner.mu.Unlock()
 	for _, d := range documents {
-		err := db.InsertOne(ctx, d)
+		err := db.insert(d)
 		if err != nil {
 			return err
 		}
@@ -130,6 +276,8 @@ func (db *SimpleCollection) InsertMany(ctx context.Context, documents []interfac
 }
 
 func (db *SimpleCollection) FindOne(ctx context.Context, filter bson.D, projection ...bson.D) (backend.NoSQLCursor, error) {
+	db.owner.mu.RLock()
+	defer db.owner.mu.RUnlock()
 	query, err := query.ParseFilter(filter)
 	if err != nil {
 		return nil, err
@@ -138,14 +286,11 @@ func (db *SimpleCollection) FindOne(ctx context.Context, filter bson.D, projecti
 		fmt.Printf("---- FindOne\n%v\n", query)
 	}
 	cursor := &SimpleCursor{}
-	for _, item := range db.items {
-		if query.Apply(item) {
-			cursor.results = append(cursor.results, item)
-			if verbose {
-				fmt.Printf("MATCH: %v\n", item)
-			}
-			break
-		}
+	for _, i := range db.match(query, filter, 1) {
+		cursor.results = append(cursor.results, db.items[i])
+	}
+	if err := cursor.project(projection...); err != nil {
+		return nil, err
 	}
 	return cursor, nil
 }
@@ -160,6 +305,8 @@ func SetVerbose(enabled bool) bool {
 }
 
 func (db *SimpleCollection) FindMany(ctx context.Context, filter bson.D, projection ...bson.D) (backend.NoSQLCursor, error) {
+	db.owner.mu.RLock()
+	defer db.owner.mu.RUnlock()
 	query, err := query.ParseFilter(filter)
 	if err != nil {
 		return nil, err
@@ -168,36 +315,122 @@ func (db *SimpleCollection) FindMany(ctx context.Context, filter bson.D, project
 		fmt.Printf("---- FindMany\n%v\n", query)
 	}
 	cursor := &SimpleCursor{}
-	for _, item := range db.items {
-		if query.Apply(item) {
-			cursor.results = append(cursor.results, item)
-			if verbose {
-				fmt.Printf("MATCH: %v\n", item)
-			}
-		} else {
-			if verbose {
-				fmt.Printf("       %v\n", item)
-			}
+	for _, i := range db.match(query, filter, 0) {
+		cursor.results = append(cursor.results, db.items[i])
+	}
+	if err := cursor.project(projection...); err != nil {
+		return nil, err
+	}
+	return cursor, nil
+}
+
+// Applies the projections to the cursor's results
+func (cursor *SimpleCursor) project(projections ...bson.D) error {
+	for _, projection := range projections {
+		if len(projection) == 0 {
+			continue
 		}
+		p, err := query.ParseProjection(projection)
+		if err != nil {
+			return err
+		}
+		cursor.results = p.Apply(cursor.results)
+	}
+	return nil
+}
+
+func (db *SimpleCollection) FindManyWithOptions(ctx context.Context, filter bson.D, opts backend.FindOptions) (backend.NoSQLCursor, error) {
+	db.owner.mu.RLock()
+	defer db.owner.mu.RUnlock()
+	filterOp, err := query.ParseFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	sortKeys, err := opts.SortKeys()
+	if err != nil {
+		return nil, err
+	}
+	if verbose {
+		fmt.Printf("---- FindManyWithOptions\n%v\nsort=%v skip=%v limit=%v\n", filterOp, sortKeys, opts.Skip, opts.Limit)
+	}
+
+	var results []bson.D
+	for _, i := range db.match(filterOp, filter, 0) {
+		results = append(results, db.items[i])
+	}
+	query.Sort(results, sortKeys)
+
+	if opts.PageToken != "" {
+		after, err := backend.DecodePageToken(opts.PageToken)
+		if err != nil {
+			return nil, err
+		}
+		if len(after) != len(sortKeys) {
+			return nil, fmt.Errorf("page token does not match sort order %v", sortKeys)
+		}
+		start := sort.Search(len(results), func(i int) bool {
+			return compareToKeys(results[i], after, sortKeys) > 0
+		})
+		results = results[start:]
+	}
+	if opts.Skip > 0 {
+		results = results[min(opts.Skip, int64(len(results))):]
+	}
+	if opts.Limit > 0 {
+		results = results[:min(opts.Limit, int64(len(results)))]
+	}
+
+	cursor := &SimpleCursor{results: results}
+	if err := cursor.project(opts.Projection); err != nil {
+		return nil, err
 	}
 	return cursor, nil
 }
 
+func (db *SimpleCollection) Aggregate(ctx context.Context, pipeline bson.A) (backend.NoSQLCursor, error) {
+	db.owner.mu.RLock()
+	defer db.owner.mu.RUnlock()
+	stages, err := query.ParsePipeline(pipeline, db.lookupCollection)
+	if err != nil {
+		return nil, err
+	}
+	if verbose {
+		fmt.Printf("---- Aggregate\n%v\n", stages)
+	}
+	return &SimpleCursor{results: stages.Apply(db.items)}, nil
+}
+
+// Returns the items of the named collection in the same database, for $lookup
+func (db *SimpleCollection) lookupCollection(name string) []bson.D {
+	if collection, exists := db.database[name]; exists {
+		return collection.items
+	}
+	return nil
+}
+
 func (db *SimpleCollection) DeleteOne(ctx context.Context, filter bson.D) error {
+	if err := db.owner.lockForWrite(ctx); err != nil {
+		return err
+	}
+	defer db.owner.mu.Unlock()
 	query, err := query.ParseFilter(filter)
 	if err != nil {
 		return err
 	}
-	for i, item := range db.items {
-		if query.Apply(item) {
-			db.items = append(db.items[:i], db.items[i+1:]...)
-			return nil
-		}
+	for _, i := range db.match(query, filter, 1) {
+		db.beforeWrite()
+		db.notify(backend.NoSQLDelete, db.items[i])
+		db.items = append(db.items[:i], db.items[i+1:]...)
+		db.reindex()
 	}
 	return nil
 }
 
 func (db *SimpleCollection) DeleteMany(ctx context.Context, filter bson.D) error {
+	if err := db.owner.lockForWrite(ctx); err != nil {
+		return err
+	}
+	defer db.owner.mu.Unlock()
 	query, err := query.ParseFilter(filter)
 	if err != nil {
 		return err
@@ -206,6 +439,7 @@ func (db *SimpleCollection) DeleteMany(ctx context.Context, filter bson.D) error
 	newitems := make([]bson.D, 0, len(db.items))
 	for i, item := range db.items {
 		if query.Apply(item) {
+			db.notify(backend.NoSQLDelete, item)
 			if i > copyrangebegin {
 				newitems = append(newitems, db.items[copyrangebegin:i]...)
 			}
@@ -215,17 +449,23 @@ func (db *SimpleCollection) DeleteMany(ctx context.Context, filter bson.D) error
 	if copyrangebegin < len(db.items) {
 		newitems = append(newitems, db.items[copyrangebegin:len(db.items)]...)
 	}
+	db.beforeWrite()
 	db.items = newitems
+	db.reindex()
 	return nil
 
 }
 
 func (db *SimpleCollection) UpdateOne(ctx context.Context, filter bson.D, update bson.D) (int, error) {
+	if err := db.owner.lockForWrite(ctx); err != nil {
+		return 0, err
+	}
+	defer db.owner.mu.Unlock()
 	filterOp, err := query.ParseFilter(filter)
 	if err != nil {
 		return 0, err
 	}
-	updateOp, err := query.ParseUpdate(update)
+	updateOp, err := query.ParseUpdateWithFilter(update, filterOp)
 	if err != nil {
 		return 0, err
 	}
@@ -234,27 +474,39 @@ func (db *SimpleCollection) UpdateOne(ctx context.Context, filter bson.D, update
 		fmt.Printf("---- UpdateOne\n%v\n%v\n", filter, update)
 	}
 
-	for i := range db.items {
-		if filterOp.Apply(db.items[i]) {
-			if verbose {
-				fmt.Printf("MATCH: %v\n", db.items[i])
-			}
-			return 1, updateOp.Apply(&db.items[i])
-		} else {
-			if verbose {
-				fmt.Printf("      %v\n", db.items[i])
-			}
-		}
+	for _, i := range db.match(filterOp, filter, 1) {
+		return 1, db.update(i, updateOp)
 	}
 	return 0, nil
 }
 
+// Applies updateOp to the item at position i
+func (db *SimpleCollection) update(i int, updateOp query.Update) error {
+	// Update a copy, so that the item is unmodified if the update would violate a unique index
+	d, err := toBson(db.items[i])
+	if err != nil {
+		return err
+	}
+	if err := updateOp.Apply(&d); err != nil {
+		return err
+	}
+	if err := db.setItem(i, d); err != nil {
+		return err
+	}
+	db.notify(backend.NoSQLUpdate, d)
+	return nil
+}
+
 func (db *SimpleCollection) UpdateMany(ctx context.Context, filter bson.D, update bson.D) (int, error) {
+	if err := db.owner.lockForWrite(ctx); err != nil {
+		return 0, err
+	}
+	defer db.owner.mu.Unlock()
 	filterOp, err := query.ParseFilter(filter)
 	if err != nil {
 		return 0, err
 	}
-	updateOp, err := query.ParseUpdate(update)
+	updateOp, err := query.ParseUpdateWithFilter(update, filterOp)
 	if err != nil {
 		return 0, err
 	}
@@ -265,39 +517,43 @@ func (db *SimpleCollection) UpdateMany(ctx context.Context, filter bson.D, updat
 	}
 
 	updated := 0
-	for i := range db.items {
-		if filterOp.Apply(db.items[i]) {
-			if verbose {
-				fmt.Printf("UPDATING: %v\n", db.items[i])
-			}
-			err := updateOp.Apply(&db.items[i])
-			if err != nil {
-				return updated, err
-			}
-			if verbose {
-				fmt.Printf("      --> %v\n", db.items[i])
-			}
-			updated += 1
-		} else {
-			if verbose {
-				fmt.Printf("          %v\n", db.items[i])
-			}
+	for _, i := range db.match(filterOp, filter, 0) {
+		err := db.update(i, updateOp)
+		if err != nil {
+			return updated, err
 		}
+		if verbose {
+			fmt.Printf("      --> %v\n", db.items[i])
+		}
+		updated += 1
 	}
 	return updated, nil
 }
 
 func (db *SimpleCollection) Upsert(ctx context.Context, filter bson.D, document interface{}) (bool, error) {
-	updatedCount, err := db.ReplaceOne(ctx, filter, document)
+	if err := db.owner.lockForWrite(ctx); err != nil {
+		return false, err
+	}
+	defer db.owner.mu.Unlock()
+	return db.upsert(filter, document)
+}
+
+// The caller must hold the write lock.
+func (db *SimpleCollection) upsert(filter bson.D, document interface{}) (bool, error) {
+	updatedCount, err := db.replaceOne(filter, document)
 	if updatedCount == 1 || err != nil {
 		return true, err
 	}
-	return false, db.InsertOne(ctx, document)
+	return false, db.insert(document)
 }
 
 func (db *SimpleCollection) UpsertID(ctx context.Context, id primitive.ObjectID, document interface{}) (bool, error) {
+	if err := db.owner.lockForWrite(ctx); err != nil {
+		return false, err
+	}
+	defer db.owner.mu.Unlock()
 	filter := bson.D{{"_id", id}}
-	updated, err := db.Upsert(ctx, filter, document)
+	updated, err := db.upsert(filter, document)
 	if updated && verbose && err == nil {
 		fmt.Printf("Upsert replaced existing %v\n", id)
 	}
@@ -305,20 +561,46 @@ func (db *SimpleCollection) UpsertID(ctx context.Context, id primitive.ObjectID,
 }
 
 func (db *SimpleCollection) ReplaceOne(ctx context.Context, filter bson.D, replacement interface{}) (int, error) {
+	if err := db.owner.lockForWrite(ctx); err != nil {
+		return 0, err
+	}
+	defer db.owner.mu.Unlock()
+	return db.replaceOne(filter, replacement)
+}
+
+// The caller must hold the write lock.
+func (db *SimpleCollection) replaceOne(filter bson.D, replacement interface{}) (int, error) {
 	query, err := query.ParseFilter(filter)
 	if err != nil {
 		return 0, err
 	}
-	for i, item := range db.items {
-		if query.Apply(item) {
-			db.items[i], err = toBson(replacement)
-			return 1, err
-		}
+	for _, i := range db.match(query, filter, 1) {
+		return 1, db.replace(i, replacement)
 	}
 	return 0, nil
 }
 
+// Replaces the item at position i with replacement, retaining the item's _id if replacement has none
+func (db *SimpleCollection) replace(i int, replacement interface{}) error {
+	d, err := toBson(replacement)
+	if err != nil {
+		return err
+	}
+	if query.LookupField(d, "_id") == nil {
+		d = append(bson.D{{"_id", query.LookupField(db.items[i], "_id")}}, d...)
+	}
+	if err := db.setItem(i, d); err != nil {
+		return err
+	}
+	db.notify(backend.NoSQLReplace, d)
+	return nil
+}
+
 func (db *SimpleCollection) ReplaceMany(ctx context.Context, filter bson.D, replacements ...interface{}) (int, error) {
+	if err := db.owner.lockForWrite(ctx); err != nil {
+		return 0, err
+	}
+	defer db.owner.mu.Unlock()
 	query, err := query.ParseFilter(filter)
 	if err != nil {
 		return 0, nil
@@ -326,7 +608,7 @@ func (db *SimpleCollection) ReplaceMany(ctx context.Context, filter bson.D, repl
 	updateCount := 0
 	for i := 0; updateCount < len(replacements) && i < len(db.items); i++ {
 		if query.Apply(db.items[i]) {
-			db.items[i], err = toBson(replacements[updateCount])
+			err = db.replace(i, replacements[updateCount])
 			if err != nil {
 				return updateCount, err
 			}
@@ -355,6 +637,8 @@ func fromBson(d bson.D, dst any) error {
 }
 
 func (db *SimpleCollection) String() string {
+	db.owner.mu.RLock()
+	defer db.owner.mu.RUnlock()
 	var strs []string
 	for i := range db.items {
 		strs = append(strs, fmt.Sprintf("%v", db.items[i]))
@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend/backendtest"
 	"github.com/blueprint-uservices/blueprint/runtime/plugins/mongodb"
 	"github.com/blueprint-uservices/blueprint/runtime/plugins/simplenosqldb"
 	"github.com/stretchr/testify/require"
@@ -58,7 +59,7 @@ func getDB(t *testing.T) (context.Context, backend.NoSQLDatabase) {
 	var db backend.NoSQLDatabase
 	var err error
 	if *dbtype == "mongodb" || *dbtype == "mongo" {
-		db, err = mongodb.NewMongoDB(ctx, "localhost:27017")
+		db, err = mongodb.NewMongoDB(ctx, "localhost:27017", "", "")
 	} else if *dbtype == "" || *dbtype == "simple" {
 		simplenosqldb.SetVerbose(true)
 		db, err = simplenosqldb.NewSimpleNoSQLDB(ctx)
@@ -70,6 +71,14 @@ func getDB(t *testing.T) (context.Context, backend.NoSQLDatabase) {
 	return ctx, db
 }
 
+// Run with -db=mongodb to check that mongodb passes the same suite
+func TestConformance(t *testing.T) {
+	backendtest.RunNoSQLSuite(t, func(t *testing.T) backend.NoSQLDatabase {
+		_, db := getDB(t)
+		return db
+	})
+}
+
 func MakeTestDB(t *testing.T) (context.Context, backend.NoSQLCollection) {
 	ctx, db := getDB(t)
 	coll, err := db.GetCollection(ctx, "testdb", fmt.Sprintf("testcollection%v", collectionid))
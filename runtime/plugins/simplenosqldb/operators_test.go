@@ -0,0 +1,226 @@
+package simplenosqldb_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/simplenosqldb"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+/*
+The tests in this file are differential: each query and update runs against simplenosqldb and,
+with -db=mongodb, against the MongoDB server used by the other tests, and the results of both must
+match the expected results.  To run them against a local MongoDB container:
+
+	docker run -d -p 27017:27017 mongo
+	go test -run Operators -db=mongodb
+*/
+
+type namedCollection struct {
+	name string
+	backend.NoSQLCollection
+}
+
+// Returns collections containing docs for each backend under test
+func differentialCollections(t *testing.T, docs ...any) []namedCollection {
+	ctx := context.Background()
+	simpledb, err := simplenosqldb.NewSimpleNoSQLDB(ctx)
+	require.NoError(t, err)
+	dbs := map[string]backend.NoSQLDatabase{"simplenosqldb": simpledb}
+	if *dbtype == "mongodb" || *dbtype == "mongo" {
+		_, dbs["mongodb"] = getDB(t)
+	}
+
+	var collections []namedCollection
+	for name, db := range dbs {
+		coll, err := db.GetCollection(ctx, "testdb", fmt.Sprintf("operators%v", collectionid))
+		require.NoError(t, err)
+		require.NoError(t, coll.DeleteMany(ctx, bson.D{}))
+		require.NoError(t, coll.InsertMany(ctx, docs))
+		collections = append(collections, namedCollection{name, coll})
+	}
+	collectionid += 1
+	return collections
+}
+
+func teaDocs() []any {
+	var docs []any
+	for _, tea := range teas {
+		docs = append(docs, tea)
+	}
+	return docs
+}
+
+var filterCases = []struct {
+	name     string
+	filter   bson.D
+	expected []string
+}{
+	{"nin", bson.D{{"sizes", bson.D{{"$nin", bson.A{16, 32}}}}}, []string{"Masala"}},
+	{"nin missing", bson.D{{"vendor", bson.D{{"$nin", bson.A{"A"}}}}}, []string{"English Breakfast", "Oolong", "Assam"}},
+	{"nin with gt", bson.D{{"sizes", bson.D{{"$nin", bson.A{16, 32}}, {"$gt", 2}}}}, []string{"Masala"}},
+	{"ne array", bson.D{{"vendor", bson.D{{"$ne", "C"}}}}, []string{"English Breakfast", "Assam", "Earl Grey"}},
+	{"ne with gte", bson.D{{"rating", bson.D{{"$gte", 6}, {"$ne", 8}}}}, []string{"Masala", "English Breakfast", "Oolong"}},
+	{"eq null", bson.D{{"vendor", nil}}, []string{"English Breakfast", "Assam"}},
+	{"ne null", bson.D{{"vendor", bson.D{{"$ne", nil}}}}, []string{"Masala", "Oolong", "Earl Grey"}},
+	{"exists", bson.D{{"vendor", bson.D{{"$exists", true}}}}, []string{"Masala", "Oolong", "Earl Grey"}},
+	{"not exists", bson.D{{"vendor", bson.D{{"$exists", false}}}}, []string{"English Breakfast", "Assam"}},
+	{"nested exists", bson.D{{"packaging.kind", bson.D{{"$exists", true}}}}, []string{"Masala", "English Breakfast", "Oolong", "Assam", "Earl Grey"}},
+	{"type int", bson.D{{"rating", bson.D{{"$type", "int"}}}}, []string{"Masala", "English Breakfast", "Oolong", "Assam", "Earl Grey"}},
+	{"type array", bson.D{{"vendor", bson.D{{"$type", "array"}}}}, []string{"Masala", "Oolong", "Earl Grey"}},
+	{"type array elements", bson.D{{"vendor", bson.D{{"$type", "string"}}}}, []string{"Masala", "Oolong", "Earl Grey"}},
+	{"type number", bson.D{{"type", bson.D{{"$type", "number"}}}}, nil},
+	{"type code", bson.D{{"packaging", bson.D{{"$type", 3}}}}, []string{"Masala", "English Breakfast", "Oolong", "Assam", "Earl Grey"}},
+	{"type list", bson.D{{"packaging.kind", bson.D{{"$type", bson.A{"double", "string"}}}}}, []string{"Masala", "English Breakfast", "Oolong", "Assam", "Earl Grey"}},
+	{"mod", bson.D{{"rating", bson.D{{"$mod", bson.A{2, 0}}}}}, []string{"Masala", "English Breakfast", "Earl Grey"}},
+	{"mod array", bson.D{{"sizes", bson.D{{"$mod", bson.A{16, 0}}}}}, []string{"English Breakfast", "Oolong", "Assam", "Earl Grey"}},
+	{"size", bson.D{{"sizes", bson.D{{"$size", 3}}}}, []string{"English Breakfast"}},
+	{"string gt", bson.D{{"type", bson.D{{"$gt", "Masala"}}}}, []string{"Oolong"}},
+	{"string range", bson.D{{"type", bson.D{{"$gte", "E"}, {"$lt", "F"}}}}, []string{"English Breakfast", "Earl Grey"}},
+	{"expr fields", bson.D{{"$expr", bson.D{{"$gt", bson.A{"$rating", "$packaging.length"}}}}}, []string{"Masala", "English Breakfast", "Oolong", "Earl Grey"}},
+	{"expr nested fields", bson.D{{"$expr", bson.D{{"$lt", bson.A{"$packaging.width", "$packaging.length"}}}}}, []string{"Assam"}},
+	{"expr and", bson.D{{"$expr", bson.D{{"$and", bson.A{
+		bson.D{{"$eq", bson.A{"$packaging.kind", "Paper"}}},
+		bson.D{{"$gte", bson.A{"$rating", 10}}},
+	}}}}}, []string{"Masala"}},
+	{"expr or not", bson.D{{"$expr", bson.D{{"$or", bson.A{
+		bson.D{{"$not", bson.A{bson.D{{"$ne", bson.A{"$type", "Assam"}}}}}},
+		bson.D{{"$lte", bson.A{"$rating", 6}}},
+	}}}}}, []string{"English Breakfast", "Assam"}},
+}
+
+func TestFilterOperators(t *testing.T) {
+	for _, c := range filterCases {
+		t.Run(c.name, func(t *testing.T) {
+			for _, db := range differentialCollections(t, teaDocs()...) {
+				gotteas := findTeas(t, db, c.filter, backend.FindOptions{})
+				require.ElementsMatch(t, c.expected, teaTypes(gotteas), "%v filter %v", db.name, c.filter)
+			}
+		})
+	}
+}
+
+func teaWith(i int, modify func(tea *Tea)) Tea {
+	tea := teas[i]
+	tea.Vendor = append([]string(nil), tea.Vendor...)
+	tea.Sizes = append([]int32(nil), tea.Sizes...)
+	modify(&tea)
+	return tea
+}
+
+var updateCases = []struct {
+	name     string
+	filter   bson.D
+	update   bson.D
+	expected Tea
+}{
+	{"addToSet each", bson.D{{"type", "Masala"}}, bson.D{{"$addToSet", bson.D{{"vendor", bson.D{{"$each", bson.A{"A", "B", "D"}}}}}}},
+		teaWith(0, func(tea *Tea) { tea.Vendor = []string{"A", "C", "B", "D"} })},
+	{"push each", bson.D{{"type", "Assam"}}, bson.D{{"$push", bson.D{{"sizes", bson.D{{"$each", bson.A{16, 32}}}}}}},
+		teaWith(3, func(tea *Tea) { tea.Sizes = []int32{16, 16, 32} })},
+	{"pull value", bson.D{{"type", "Earl Grey"}}, bson.D{{"$pull", bson.D{{"vendor", "A"}}}},
+		teaWith(4, func(tea *Tea) { tea.Vendor = []string{"B"} })},
+	{"pull condition", bson.D{{"type", "English Breakfast"}}, bson.D{{"$pull", bson.D{{"sizes", bson.D{{"$gte", 8}}}}}},
+		teaWith(1, func(tea *Tea) { tea.Sizes = []int32{4} })},
+	{"pull in", bson.D{{"type", "English Breakfast"}}, bson.D{{"$pull", bson.D{{"sizes", bson.D{{"$in", bson.A{4, 16}}}}}}},
+		teaWith(1, func(tea *Tea) { tea.Sizes = []int32{8} })},
+	{"pop last", bson.D{{"type", "English Breakfast"}}, bson.D{{"$pop", bson.D{{"sizes", 1}}}},
+		teaWith(1, func(tea *Tea) { tea.Sizes = []int32{4, 8} })},
+	{"pop first", bson.D{{"type", "English Breakfast"}}, bson.D{{"$pop", bson.D{{"sizes", -1}}}},
+		teaWith(1, func(tea *Tea) { tea.Sizes = []int32{8, 16} })},
+	{"min lower", bson.D{{"type", "Oolong"}}, bson.D{{"$min", bson.D{{"rating", 5}}}},
+		teaWith(2, func(tea *Tea) { tea.Rating = 5 })},
+	{"min higher", bson.D{{"type", "Oolong"}}, bson.D{{"$min", bson.D{{"rating", 9}}}},
+		teaWith(2, func(tea *Tea) {})},
+	{"max", bson.D{{"type", "Oolong"}}, bson.D{{"$max", bson.D{{"rating", 9}, {"packaging.kind", "Tin"}}}},
+		teaWith(2, func(tea *Tea) { tea.Rating = 9; tea.Packaging.Kind = "Tin" })},
+	{"mul", bson.D{{"type", "Assam"}}, bson.D{{"$mul", bson.D{{"rating", 3}, {"packaging.length", 2}}}},
+		teaWith(3, func(tea *Tea) { tea.Rating = 15; tea.Packaging.Length = 16 })},
+	{"inc float", bson.D{{"type", "Assam"}}, bson.D{{"$inc", bson.D{{"rating", 2.0}}}},
+		teaWith(3, func(tea *Tea) { tea.Rating = 7 })},
+	{"rename", bson.D{{"type", "Assam"}}, bson.D{{"$rename", bson.D{{"packaging.width", "rating"}}}},
+		teaWith(3, func(tea *Tea) { tea.Rating = 5; tea.Packaging.Width = 0 })},
+	{"setOnInsert", bson.D{{"type", "Assam"}}, bson.D{{"$set", bson.D{{"rating", 1}}}, {"$setOnInsert", bson.D{{"type", "Chai"}}}},
+		teaWith(3, func(tea *Tea) { tea.Rating = 1 })},
+	{"positional", bson.D{{"type", "English Breakfast"}, {"sizes", 8}}, bson.D{{"$set", bson.D{{"sizes.$", 10}}}},
+		teaWith(1, func(tea *Tea) { tea.Sizes = []int32{4, 10, 16} })},
+	{"positional condition", bson.D{{"type", "English Breakfast"}, {"sizes", bson.D{{"$gte", 8}}}}, bson.D{{"$inc", bson.D{{"sizes.$", 1}}}},
+		teaWith(1, func(tea *Tea) { tea.Sizes = []int32{4, 9, 16} })},
+}
+
+func TestUpdateOperators(t *testing.T) {
+	for _, c := range updateCases {
+		t.Run(c.name, func(t *testing.T) {
+			for _, db := range differentialCollections(t, teaDocs()...) {
+				ctx := context.Background()
+				updated, err := db.UpdateMany(ctx, c.filter, c.update)
+				require.NoError(t, err, "%v update %v", db.name, c.update)
+				require.Equal(t, 1, updated, "%v update %v", db.name, c.update)
+
+				gotteas := findTeas(t, db, bson.D{{"type", c.expected.Type}}, backend.FindOptions{})
+				require.Equal(t, []Tea{c.expected}, gotteas, "%v update %v", db.name, c.update)
+			}
+		})
+	}
+}
+
+func TestRenameField(t *testing.T) {
+	for _, db := range differentialCollections(t, teaDocs()...) {
+		ctx := context.Background()
+		_, err := db.UpdateMany(ctx, bson.D{}, bson.D{{"$rename", bson.D{{"rating", "score"}, {"vendor", "sellers"}}}})
+		require.NoError(t, err)
+
+		var results []bson.M
+		cursor, err := db.FindMany(ctx, bson.D{{"type", "Masala"}})
+		require.NoError(t, err)
+		require.NoError(t, cursor.All(ctx, &results))
+		require.Len(t, results, 1)
+		require.NotContains(t, results[0], "rating")
+		require.EqualValues(t, 10, results[0]["score"])
+		require.Equal(t, bson.A{"A", "C"}, results[0]["sellers"])
+	}
+}
+
+func TestPositionalDocumentUpdate(t *testing.T) {
+	var docs []any
+	for _, c := range teacollections {
+		docs = append(docs, c)
+	}
+	for _, db := range differentialCollections(t, docs...) {
+		ctx := context.Background()
+		_, err := db.UpdateOne(ctx, bson.D{{"teas.type", "Assam"}}, bson.D{{"$set", bson.D{{"teas.$.rating", 1}}}})
+		require.NoError(t, err)
+
+		cursor, err := db.FindOne(ctx, bson.D{{"name", "fun collection"}})
+		require.NoError(t, err)
+		var collection TeaCollection
+		found, err := cursor.One(ctx, &collection)
+		require.NoError(t, err)
+		require.True(t, found)
+		require.Equal(t, 1, collection.Teas[0].Rating)
+		require.Equal(t, teas[4], collection.Teas[1])
+	}
+}
+
+func TestUnsupportedOperators(t *testing.T) {
+	ctx, db := MakeTestDB(t)
+
+	_, err := db.FindMany(ctx, bson.D{{"type", bson.D{{"$where", "true"}}}})
+	require.Error(t, err)
+
+	_, err = db.FindMany(ctx, bson.D{{"rating", bson.D{{"$type", "nosuchtype"}}}})
+	require.Error(t, err)
+
+	_, err = db.FindMany(ctx, bson.D{{"rating", bson.D{{"$mod", bson.A{0, 1}}}}})
+	require.Error(t, err)
+
+	_, err = db.UpdateOne(ctx, bson.D{{"type", "Assam"}}, bson.D{{"$set", bson.D{{"sizes.$", 1}}}})
+	require.Error(t, err)
+
+	_, err = db.UpdateOne(ctx, bson.D{{"type", "Assam"}}, bson.D{{"$pop", bson.D{{"sizes", 2}}}})
+	require.Error(t, err)
+}
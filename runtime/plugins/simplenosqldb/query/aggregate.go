@@ -0,0 +1,361 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+/*
+Simple evaluation of aggregation pipelines on bson documents
+*/
+
+type Stage interface {
+	// Applies the stage to docs, returning the resulting documents.  docs is not modified.
+	Apply(docs []bson.D) []bson.D
+	String() string
+}
+
+// Returns the documents of the named collection in the same database; used by $lookup
+type CollectionResolver func(name string) []bson.D
+
+type (
+	pipeline struct {
+		stages []Stage
+	}
+
+	match struct {
+		filter Filter
+	}
+
+	group struct {
+		id     Expression
+		fields []string
+		accs   []*accumulator
+	}
+
+	sortStage struct {
+		keys bson.D
+	}
+
+	limit struct {
+		n int64
+	}
+
+	skip struct {
+		n int64
+	}
+
+	unwind struct {
+		path       string
+		indexField string
+		preserve   bool
+	}
+
+	lookup struct {
+		from         string
+		localField   Expression
+		foreignField Expression
+		as           string
+		resolve      CollectionResolver
+	}
+
+	AccumulatorType int
+
+	accumulator struct {
+		op   AccumulatorType
+		expr Expression
+	}
+)
+
+const (
+	Sum AccumulatorType = iota
+	Avg
+	Min
+	Max
+	Push
+)
+
+var accumulatorNames = map[AccumulatorType]string{Sum: "$sum", Avg: "$avg", Min: "$min", Max: "$max", Push: "$push"}
+
+func Pipeline(stages ...Stage) Stage {
+	return &pipeline{stages: stages}
+}
+
+func Match(filter Filter) Stage {
+	return &match{filter: filter}
+}
+
+func SortBy(keys bson.D) Stage {
+	return &sortStage{keys: keys}
+}
+
+func Limit(n int64) Stage {
+	return &limit{n: n}
+}
+
+func Skip(n int64) Stage {
+	return &skip{n: n}
+}
+
+func Unwind(path string, indexField string, preserveNullAndEmptyArrays bool) Stage {
+	return &unwind{path: path, indexField: indexField, preserve: preserveNullAndEmptyArrays}
+}
+
+func LookupCollection(from string, localField string, foreignField string, as string, resolve CollectionResolver) Stage {
+	return &lookup{from: from, localField: FieldPath(localField), foreignField: FieldPath(foreignField), as: as, resolve: resolve}
+}
+
+func (s *pipeline) Apply(docs []bson.D) []bson.D {
+	for _, stage := range s.stages {
+		docs = stage.Apply(docs)
+	}
+	return docs
+}
+
+func (s *match) Apply(docs []bson.D) []bson.D {
+	var results []bson.D
+	for _, doc := range docs {
+		if s.filter.Apply(doc) {
+			results = append(results, doc)
+		}
+	}
+	return results
+}
+
+func (s *group) Apply(docs []bson.D) []bson.D {
+	var ids []any
+	var members [][]bson.D
+	positions := make(map[string]int)
+	for _, doc := range docs {
+		id, _ := s.id.Evaluate(doc)
+		key := groupKey(id)
+		i, exists := positions[key]
+		if !exists {
+			i = len(ids)
+			positions[key] = i
+			ids = append(ids, id)
+			members = append(members, nil)
+		}
+		members[i] = append(members[i], doc)
+	}
+
+	var results []bson.D
+	for i, id := range ids {
+		result := bson.D{{Key: "_id", Value: id}}
+		for j, acc := range s.accs {
+			result = append(result, bson.E{Key: s.fields[j], Value: acc.apply(members[i])})
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// Encodes a group _id so that equal values have equal keys
+func groupKey(id any) string {
+	b, err := bson.Marshal(bson.D{{Key: "k", Value: normalizeInts(id)}})
+	if err != nil {
+		return fmt.Sprintf("%#v", id)
+	}
+	return string(b)
+}
+
+func normalizeInts(v any) any {
+	switch x := v.(type) {
+	case bson.D:
+		result := make(bson.D, 0, len(x))
+		for _, e := range x {
+			result = append(result, bson.E{Key: e.Key, Value: normalizeInts(e.Value)})
+		}
+		return result
+	case bson.A:
+		result := make(bson.A, 0, len(x))
+		for _, elem := range x {
+			result = append(result, normalizeInts(elem))
+		}
+		return result
+	}
+	if i, isInt := intValue(v); isInt {
+		return i
+	}
+	return v
+}
+
+func (acc *accumulator) apply(docs []bson.D) any {
+	var values []any
+	for _, doc := range docs {
+		if value, found := acc.expr.Evaluate(doc); found {
+			values = append(values, value)
+		}
+	}
+
+	switch acc.op {
+	case Sum, Avg:
+		var intSum int64
+		var floatSum float64
+		count, isFloat := 0, false
+		for _, value := range values {
+			if i, isInt := intValue(value); isInt {
+				intSum += i
+			} else if f, isNumber := floatValue(value); isNumber {
+				floatSum += f
+				isFloat = true
+			} else {
+				continue
+			}
+			count++
+		}
+		if acc.op == Avg {
+			if count == 0 {
+				return nil
+			}
+			return (float64(intSum) + floatSum) / float64(count)
+		}
+		if isFloat {
+			return float64(intSum) + floatSum
+		}
+		return intSum
+	case Min, Max:
+		var result any
+		for _, value := range values {
+			if value == nil {
+				continue
+			}
+			c := Compare(value, result)
+			if result == nil || (acc.op == Min && c < 0) || (acc.op == Max && c > 0) {
+				result = value
+			}
+		}
+		return result
+	case Push:
+		return append(bson.A{}, values...)
+	}
+	return nil
+}
+
+func (s *sortStage) Apply(docs []bson.D) []bson.D {
+	results := append([]bson.D(nil), docs...)
+	Sort(results, s.keys)
+	return results
+}
+
+func (s *limit) Apply(docs []bson.D) []bson.D {
+	return docs[:min(s.n, int64(len(docs)))]
+}
+
+func (s *skip) Apply(docs []bson.D) []bson.D {
+	return docs[min(s.n, int64(len(docs))):]
+}
+
+func (s *unwind) Apply(docs []bson.D) []bson.D {
+	var results []bson.D
+	for _, doc := range docs {
+		value, found := resolvePath(doc, strings.Split(s.path, "."))
+		a, isA := value.(bson.A)
+		switch {
+		case isA && len(a) > 0:
+			for i, elem := range a {
+				result := withField(doc, s.path, elem)
+				if s.indexField != "" {
+					result = withField(result, s.indexField, int64(i))
+				}
+				results = append(results, result)
+			}
+		case isA || !found || value == nil:
+			if s.preserve {
+				if isA {
+					doc = withoutField(doc, s.path)
+				}
+				if s.indexField != "" {
+					doc = withField(doc, s.indexField, nil)
+				}
+				results = append(results, doc)
+			}
+		default:
+			// Non-array values are treated as a single-element array
+			if s.indexField != "" {
+				doc = withField(doc, s.indexField, nil)
+			}
+			results = append(results, doc)
+		}
+	}
+	return results
+}
+
+func (s *lookup) Apply(docs []bson.D) []bson.D {
+	foreignDocs := s.resolve(s.from)
+	var results []bson.D
+	for _, doc := range docs {
+		localValues := lookupValues(s.localField, doc)
+		matches := bson.A{}
+		for _, foreignDoc := range foreignDocs {
+			if anyEqual(localValues, lookupValues(s.foreignField, foreignDoc)) {
+				matches = append(matches, foreignDoc)
+			}
+		}
+		results = append(results, withField(doc, s.as, matches))
+	}
+	return results
+}
+
+// Returns the values that $lookup matches against: a missing field is treated as null,
+// and arrays match on their elements as well as on the array itself.
+func lookupValues(expr Expression, doc bson.D) []any {
+	value, _ := expr.Evaluate(doc)
+	if a, isA := value.(bson.A); isA {
+		return append([]any{value}, a...)
+	}
+	return []any{value}
+}
+
+func anyEqual(as []any, bs []any) bool {
+	for _, a := range as {
+		for _, b := range bs {
+			if Compare(a, b) == 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *pipeline) String() string {
+	var stageStrings []string
+	for _, stage := range s.stages {
+		stageStrings = append(stageStrings, stage.String())
+	}
+	return strings.Join(stageStrings, "\n")
+}
+
+func (s *match) String() string {
+	return fmt.Sprintf("match(%v)", s.filter)
+}
+
+func (s *group) String() string {
+	fieldStrings := []string{fmt.Sprintf("_id: %v", s.id)}
+	for i, acc := range s.accs {
+		fieldStrings = append(fieldStrings, fmt.Sprintf("%v: %v(%v)", s.fields[i], accumulatorNames[acc.op], acc.expr))
+	}
+	return fmt.Sprintf("group(%v)", strings.Join(fieldStrings, ", "))
+}
+
+func (s *sortStage) String() string {
+	return fmt.Sprintf("sort(%v)", s.keys)
+}
+
+func (s *limit) String() string {
+	return fmt.Sprintf("limit(%v)", s.n)
+}
+
+func (s *skip) String() string {
+	return fmt.Sprintf("skip(%v)", s.n)
+}
+
+func (s *unwind) String() string {
+	return fmt.Sprintf("unwind(%v)", s.path)
+}
+
+func (s *lookup) String() string {
+	return fmt.Sprintf("lookup(%v.%v = %v as %v)", s.from, s.foreignField, s.localField, s.as)
+}
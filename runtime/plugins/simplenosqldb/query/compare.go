@@ -0,0 +1,185 @@
+package query
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Returns the value of the possibly-nested field selected by path, or nil if there is no such field
+func LookupField(doc any, path string) any {
+	current := doc
+	for _, name := range strings.Split(path, ".") {
+		switch d := current.(type) {
+		case bson.D:
+			current = nil
+			for _, e := range d {
+				if e.Key == name {
+					current = e.Value
+					break
+				}
+			}
+		case bson.M:
+			current = d[name]
+		default:
+			return nil
+		}
+	}
+	return current
+}
+
+// Returns the rank of the type of v in mongodb's comparison order for values of different types
+// https://www.mongodb.com/docs/manual/reference/bson-type-comparison-order/
+func typeRank(v any) int {
+	switch v.(type) {
+	case primitive.MinKey:
+		return 0
+	case nil, primitive.Null, primitive.Undefined:
+		return 1
+	case int, int32, int64, float32, float64, primitive.Decimal128:
+		return 2
+	case string, primitive.Symbol:
+		return 3
+	case bson.D, bson.M:
+		return 4
+	case bson.A:
+		return 5
+	case primitive.Binary, []byte:
+		return 6
+	case primitive.ObjectID:
+		return 7
+	case bool:
+		return 8
+	case primitive.DateTime, time.Time:
+		return 9
+	case primitive.Timestamp:
+		return 10
+	case primitive.Regex:
+		return 11
+	case primitive.MaxKey:
+		return 13
+	default:
+		return 12
+	}
+}
+
+// Compares two bson values using mongodb's comparison order.  Returns -1, 0, or 1.
+func Compare(a, b any) int {
+	ra, rb := typeRank(a), typeRank(b)
+	if ra != rb {
+		return cmp(ra, rb)
+	}
+	switch av := a.(type) {
+	case string:
+		return strings.Compare(av, b.(string))
+	case primitive.Symbol:
+		return strings.Compare(string(av), string(b.(primitive.Symbol)))
+	case primitive.ObjectID:
+		bv := b.(primitive.ObjectID)
+		return bytes.Compare(av[:], bv[:])
+	case bool:
+		bv := b.(bool)
+		if av == bv {
+			return 0
+		} else if bv {
+			return -1
+		}
+		return 1
+	case primitive.DateTime, time.Time:
+		return cmp(dateValue(a), dateValue(b))
+	case primitive.Timestamp:
+		return primitive.CompareTimestamp(av, b.(primitive.Timestamp))
+	case primitive.Binary:
+		if bv, ok := b.(primitive.Binary); ok {
+			return bytes.Compare(av.Data, bv.Data)
+		}
+	case []byte:
+		if bv, ok := b.([]byte); ok {
+			return bytes.Compare(av, bv)
+		}
+	case bson.D:
+		if bv, ok := b.(bson.D); ok {
+			for i := 0; i < len(av) && i < len(bv); i++ {
+				if c := strings.Compare(av[i].Key, bv[i].Key); c != 0 {
+					return c
+				}
+				if c := Compare(av[i].Value, bv[i].Value); c != 0 {
+					return c
+				}
+			}
+			return cmp(len(av), len(bv))
+		}
+	case bson.A:
+		bv := b.(bson.A)
+		for i := 0; i < len(av) && i < len(bv); i++ {
+			if c := Compare(av[i], bv[i]); c != 0 {
+				return c
+			}
+		}
+		return cmp(len(av), len(bv))
+	}
+	if ra == 2 {
+		if ai, aIsInt := intValue(a); aIsInt {
+			if bi, bIsInt := intValue(b); bIsInt {
+				return cmp(ai, bi)
+			}
+		}
+		return cmp(numberValue(a), numberValue(b))
+	}
+	return 0
+}
+
+// Returns the value of a numeric type as a float, including decimals
+func numberValue(v any) float64 {
+	if d, isDecimal := v.(primitive.Decimal128); isDecimal {
+		f, _ := strconv.ParseFloat(d.String(), 64)
+		return f
+	}
+	f, _ := floatValue(v)
+	return f
+}
+
+func dateValue(v any) int64 {
+	switch t := v.(type) {
+	case primitive.DateTime:
+		return int64(t)
+	case time.Time:
+		return t.UnixMilli()
+	}
+	return 0
+}
+
+func cmp[T int | int64 | float64](a, b T) int {
+	if a < b {
+		return -1
+	} else if a > b {
+		return 1
+	}
+	return 0
+}
+
+// Sorts docs in the order given by keys, which must have directions normalized to 1 or -1
+// as returned by [backend.NormalizeKeys].  The sort is stable.
+func Sort(docs []bson.D, keys bson.D) {
+	sort.SliceStable(docs, func(i, j int) bool {
+		return compareDocs(docs[i], docs[j], keys) < 0
+	})
+}
+
+// Compares the sort keys of a and b
+func compareDocs(a, b bson.D, keys bson.D) int {
+	for _, key := range keys {
+		if c := Compare(LookupField(a, key.Key), LookupField(b, key.Key)); c != 0 {
+			if key.Value == int32(-1) {
+				return -c
+			}
+			return c
+		}
+	}
+	return 0
+}
@@ -0,0 +1,99 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+/*
+Simple evaluation of aggregation expressions on bson documents
+*/
+
+type Expression interface {
+	// Evaluates the expression on doc.  Returns false if the expression
+	// refers to a field that is missing from doc.
+	Evaluate(doc bson.D) (any, bool)
+	String() string
+}
+
+type (
+	fieldPath struct {
+		path []string
+	}
+
+	literal struct {
+		value any
+	}
+
+	document struct {
+		keys   []string
+		fields []Expression
+	}
+
+	array struct {
+		elements []Expression
+	}
+)
+
+// An expression that selects the possibly-nested field of a document.  If the path
+// traverses an array, the result is an array of the selected values.
+func FieldPath(path string) Expression {
+	return &fieldPath{path: strings.Split(path, ".")}
+}
+
+func Literal(value any) Expression {
+	return &literal{value: value}
+}
+
+func (e *fieldPath) Evaluate(doc bson.D) (any, bool) {
+	return resolvePath(doc, e.path)
+}
+
+func (e *literal) Evaluate(doc bson.D) (any, bool) {
+	return e.value, true
+}
+
+func (e *document) Evaluate(doc bson.D) (any, bool) {
+	result := bson.D{}
+	for i, field := range e.fields {
+		if value, found := field.Evaluate(doc); found {
+			result = append(result, bson.E{Key: e.keys[i], Value: value})
+		}
+	}
+	return result, true
+}
+
+func (e *array) Evaluate(doc bson.D) (any, bool) {
+	result := bson.A{}
+	for _, elem := range e.elements {
+		value, _ := elem.Evaluate(doc)
+		result = append(result, value)
+	}
+	return result, true
+}
+
+func (e *fieldPath) String() string {
+	return "$" + strings.Join(e.path, ".")
+}
+
+func (e *literal) String() string {
+	return fmt.Sprintf("%v", e.value)
+}
+
+func (e *document) String() string {
+	var fieldStrings []string
+	for i, field := range e.fields {
+		fieldStrings = append(fieldStrings, fmt.Sprintf("%v: %v", e.keys[i], field))
+	}
+	return fmt.Sprintf("{%v}", strings.Join(fieldStrings, ", "))
+}
+
+func (e *array) String() string {
+	var elemStrings []string
+	for _, elem := range e.elements {
+		elemStrings = append(elemStrings, elem.String())
+	}
+	return fmt.Sprintf("[%v]", strings.Join(elemStrings, ", "))
+}
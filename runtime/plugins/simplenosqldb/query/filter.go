@@ -2,12 +2,16 @@ package query
 
 import (
 	"fmt"
+	"math"
 	"reflect"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 /*
@@ -79,6 +83,30 @@ type (
 	elemMatch struct {
 		queries []Filter
 	}
+
+	cmpValue struct {
+		value any
+		cmp   CmpType
+	}
+
+	hasType struct {
+		types []bsontype.Type
+	}
+
+	mod struct {
+		divisor   int64
+		remainder int64
+	}
+
+	size struct {
+		n int
+	}
+
+	cmpExpr struct {
+		left  Expression
+		right Expression
+		cmp   CmpType
+	}
 )
 
 const (
@@ -89,6 +117,8 @@ const (
 	Lte
 )
 
+var cmpStrings = map[CmpType]string{Eq: "=", Gt: ">", Gte: ">=", Lt: "<", Lte: "<="}
+
 func Lookup(selector string, next Filter) Filter {
 	splits := strings.Split(selector, ".")
 	for i := len(splits) - 1; i >= 0; i-- {
@@ -185,6 +215,33 @@ func ElemMatch(queries ...Filter) Filter {
 	return &elemMatch{queries: queries}
 }
 
+// Compares values of the same type bracket as value, using mongodb's comparison order.
+// Numeric values should use [CmpInt] or [CmpFloat].
+func CmpValue(value any, cmp CmpType) Filter {
+	return &cmpValue{value: value, cmp: cmp}
+}
+
+// Matches values of any of the specified types.  Arrays match if any element matches, or if
+// one of the types is [bsontype.Array].
+func HasType(types ...bsontype.Type) Filter {
+	return &hasType{types: types}
+}
+
+// Matches numeric values whose remainder when divided by divisor is remainder
+func Mod(divisor int64, remainder int64) Filter {
+	return &mod{divisor: divisor, remainder: remainder}
+}
+
+// Matches arrays with n elements
+func Size(n int) Filter {
+	return &size{n: n}
+}
+
+// Compares the results of evaluating two expressions on a document, as used by $expr
+func CmpExpr(left Expression, right Expression, cmp CmpType) Filter {
+	return &cmpExpr{left: left, right: right, cmp: cmp}
+}
+
 func (f *selectFilter) Apply(item any) bool {
 	if d, isD := item.(bson.D); isD {
 		for _, e := range d {
@@ -337,6 +394,73 @@ outer:
 	return false
 }
 
+func (f *cmpValue) Apply(item any) bool {
+	if typeRank(item) != typeRank(f.value) {
+		return false
+	}
+	return compareResult(Compare(item, f.value), f.cmp)
+}
+
+func compareResult(c int, cmp CmpType) bool {
+	switch cmp {
+	case Eq:
+		return c == 0
+	case Gt:
+		return c > 0
+	case Gte:
+		return c >= 0
+	case Lt:
+		return c < 0
+	case Lte:
+		return c <= 0
+	}
+	return false
+}
+
+func (f *hasType) Apply(item any) bool {
+	t := bsonType(item)
+	for _, ty := range f.types {
+		if t == ty {
+			return true
+		}
+	}
+	if a, isA := item.(bson.A); isA {
+		for _, e := range a {
+			if f.Apply(e) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (f *mod) Apply(item any) bool {
+	v, isInt := intValue(item)
+	if !isInt {
+		fv, isFloat := floatValue(item)
+		if !isFloat || math.IsNaN(fv) || math.IsInf(fv, 0) {
+			return false
+		}
+		v = int64(fv)
+	}
+	return v%f.divisor == f.remainder
+}
+
+func (f *size) Apply(item any) bool {
+	a, isA := item.(bson.A)
+	return isA && len(a) == f.n
+}
+
+func (f *cmpExpr) Apply(item any) bool {
+	d, isD := item.(bson.D)
+	if !isD {
+		return false
+	}
+	left, _ := f.left.Evaluate(d)
+	right, _ := f.right.Evaluate(d)
+	return compareResult(Compare(left, right), f.cmp)
+}
+
 func (f *selectFilter) String() string {
 	return fmt.Sprintf(".%v %v", f.fieldName, f.next.String())
 }
@@ -426,6 +550,80 @@ func (f *elemMatch) String() string {
 	return fmt.Sprintf("elemMatch(%v)", strings.Join(queryStrings, ", "))
 }
 
+func (f *cmpValue) String() string {
+	return fmt.Sprintf("%v %v", cmpStrings[f.cmp], f.value)
+}
+
+func (f *hasType) String() string {
+	var typeStrings []string
+	for _, t := range f.types {
+		typeStrings = append(typeStrings, t.String())
+	}
+	return fmt.Sprintf("type(%v)", strings.Join(typeStrings, ", "))
+}
+
+func (f *mod) String() string {
+	return fmt.Sprintf("%% %v == %v", f.divisor, f.remainder)
+}
+
+func (f *size) String() string {
+	return fmt.Sprintf("size(%v)", f.n)
+}
+
+func (f *cmpExpr) String() string {
+	return fmt.Sprintf("expr(%v %v %v)", f.left, cmpStrings[f.cmp], f.right)
+}
+
+// Returns the BSON type that v is stored as
+func bsonType(v any) bsontype.Type {
+	switch x := v.(type) {
+	case float32, float64:
+		return bsontype.Double
+	case string:
+		return bsontype.String
+	case bson.D, bson.M:
+		return bsontype.EmbeddedDocument
+	case bson.A:
+		return bsontype.Array
+	case primitive.Binary, []byte:
+		return bsontype.Binary
+	case primitive.Undefined:
+		return bsontype.Undefined
+	case primitive.ObjectID:
+		return bsontype.ObjectID
+	case bool:
+		return bsontype.Boolean
+	case primitive.DateTime, time.Time:
+		return bsontype.DateTime
+	case nil, primitive.Null:
+		return bsontype.Null
+	case primitive.Regex:
+		return bsontype.Regex
+	case primitive.JavaScript:
+		return bsontype.JavaScript
+	case primitive.Symbol:
+		return bsontype.Symbol
+	case int8, int16, int32:
+		return bsontype.Int32
+	case int:
+		if x >= math.MinInt32 && x <= math.MaxInt32 {
+			return bsontype.Int32
+		}
+		return bsontype.Int64
+	case int64:
+		return bsontype.Int64
+	case primitive.Timestamp:
+		return bsontype.Timestamp
+	case primitive.Decimal128:
+		return bsontype.Decimal128
+	case primitive.MinKey:
+		return bsontype.MinKey
+	case primitive.MaxKey:
+		return bsontype.MaxKey
+	}
+	return 0
+}
+
 func intValue(item any) (int64, bool) {
 	switch v := item.(type) {
 	case int:
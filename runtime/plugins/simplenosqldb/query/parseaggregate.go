@@ -0,0 +1,235 @@
+package query
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+/*
+Simple BSON aggregation pipeline parser
+*/
+
+// Parses an aggregation pipeline.  resolve is used by $lookup stages to access other collections.
+func ParsePipeline(p bson.A, resolve CollectionResolver) (Stage, error) {
+	var stages []Stage
+	for _, v := range p {
+		d, isD := v.(bson.D)
+		if !isD || len(d) != 1 {
+			return nil, fmt.Errorf("invalid pipeline stage %v; expected a bson.D with a single stage operator", v)
+		}
+		stage, err := parseStage(d[0], resolve)
+		if err != nil {
+			return nil, err
+		}
+		stages = append(stages, stage)
+	}
+	return Pipeline(stages...), nil
+}
+
+func parseStage(e bson.E, resolve CollectionResolver) (Stage, error) {
+	switch e.Key {
+	case "$match":
+		d, isD := e.Value.(bson.D)
+		if !isD {
+			return nil, fmt.Errorf("invalid $match stage; expected a bson.D, got %v", e.Value)
+		}
+		filter, err := ParseFilter(d)
+		if err != nil {
+			return nil, err
+		}
+		return Match(filter), nil
+	case "$group":
+		return parseGroup(e.Value)
+	case "$sort":
+		d, isD := e.Value.(bson.D)
+		if !isD || len(d) == 0 {
+			return nil, fmt.Errorf("invalid $sort stage; expected a non-empty bson.D, got %v", e.Value)
+		}
+		keys, err := backend.NormalizeKeys(d)
+		if err != nil {
+			return nil, err
+		}
+		return SortBy(keys), nil
+	case "$limit":
+		n, isInt := intValue(e.Value)
+		if !isInt || n <= 0 {
+			return nil, fmt.Errorf("invalid $limit stage; expected a positive integer, got %v", e.Value)
+		}
+		return Limit(n), nil
+	case "$skip":
+		n, isInt := intValue(e.Value)
+		if !isInt || n < 0 {
+			return nil, fmt.Errorf("invalid $skip stage; expected a non-negative integer, got %v", e.Value)
+		}
+		return Skip(n), nil
+	case "$project":
+		return parseProject(e.Value)
+	case "$unwind":
+		return parseUnwind(e.Value)
+	case "$lookup":
+		return parseLookup(e.Value, resolve)
+	default:
+		return nil, fmt.Errorf("unsupported pipeline stage %v", e.Key)
+	}
+}
+
+/*
+Parses an aggregation expression.  Supported expressions are field paths such as "$field.subfield",
+documents and arrays of expressions, $literal, and constant values.
+*/
+func ParseExpression(value any) (Expression, error) {
+	switch v := value.(type) {
+	case string:
+		if strings.HasPrefix(v, "$$") {
+			return nil, fmt.Errorf("unsupported expression variable %v", v)
+		}
+		if strings.HasPrefix(v, "$") {
+			return FieldPath(v[1:]), nil
+		}
+	case bson.D:
+		if len(v) == 1 && strings.HasPrefix(v[0].Key, "$") {
+			if v[0].Key == "$literal" {
+				return Literal(v[0].Value), nil
+			}
+			return nil, fmt.Errorf("unsupported expression operator %v", v[0].Key)
+		}
+		expr := &document{}
+		for _, e := range v {
+			if strings.HasPrefix(e.Key, "$") {
+				return nil, fmt.Errorf("invalid expression %v; field names cannot start with $", v)
+			}
+			field, err := ParseExpression(e.Value)
+			if err != nil {
+				return nil, err
+			}
+			expr.keys = append(expr.keys, e.Key)
+			expr.fields = append(expr.fields, field)
+		}
+		return expr, nil
+	case bson.A:
+		expr := &array{}
+		for _, elem := range v {
+			elemExpr, err := ParseExpression(elem)
+			if err != nil {
+				return nil, err
+			}
+			expr.elements = append(expr.elements, elemExpr)
+		}
+		return expr, nil
+	}
+	return Literal(value), nil
+}
+
+func parseGroup(args any) (Stage, error) {
+	d, isD := args.(bson.D)
+	if !isD {
+		return nil, fmt.Errorf("invalid $group stage; expected a bson.D, got %v", args)
+	}
+	g := &group{}
+	for _, e := range d {
+		if e.Key == "_id" {
+			id, err := ParseExpression(e.Value)
+			if err != nil {
+				return nil, err
+			}
+			g.id = id
+			continue
+		}
+		acc, err := parseAccumulator(e.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid $group field %v; %v", e.Key, err)
+		}
+		g.fields = append(g.fields, e.Key)
+		g.accs = append(g.accs, acc)
+	}
+	if g.id == nil {
+		return nil, fmt.Errorf("invalid $group stage %v; an _id must be specified", d)
+	}
+	return g, nil
+}
+
+func parseAccumulator(value any) (*accumulator, error) {
+	d, isD := value.(bson.D)
+	if !isD || len(d) != 1 {
+		return nil, fmt.Errorf("expected a bson.D with a single accumulator, got %v", value)
+	}
+	expr, err := ParseExpression(d[0].Value)
+	if err != nil {
+		return nil, err
+	}
+	for op, name := range accumulatorNames {
+		if d[0].Key == name {
+			return &accumulator{op: op, expr: expr}, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported accumulator %v", d[0].Key)
+}
+
+func parseUnwind(args any) (Stage, error) {
+	var path, indexField string
+	preserve := false
+	switch v := args.(type) {
+	case string:
+		path = v
+	case bson.D:
+		for _, e := range v {
+			switch e.Key {
+			case "path":
+				path, _ = e.Value.(string)
+			case "includeArrayIndex":
+				s, isString := e.Value.(string)
+				if !isString {
+					return nil, fmt.Errorf("invalid $unwind includeArrayIndex; expected a string, got %v", e.Value)
+				}
+				indexField = s
+			case "preserveNullAndEmptyArrays":
+				b, isBool := e.Value.(bool)
+				if !isBool {
+					return nil, fmt.Errorf("invalid $unwind preserveNullAndEmptyArrays; expected a bool, got %v", e.Value)
+				}
+				preserve = b
+			default:
+				return nil, fmt.Errorf("unsupported $unwind option %v", e.Key)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("invalid $unwind stage; expected a string or bson.D, got %v %v", reflect.TypeOf(args), args)
+	}
+	if !strings.HasPrefix(path, "$") || len(path) == 1 {
+		return nil, fmt.Errorf("invalid $unwind path %v; expected a field path beginning with $", path)
+	}
+	return Unwind(path[1:], indexField, preserve), nil
+}
+
+func parseLookup(args any, resolve CollectionResolver) (Stage, error) {
+	d, isD := args.(bson.D)
+	if !isD {
+		return nil, fmt.Errorf("invalid $lookup stage; expected a bson.D, got %v", args)
+	}
+	fields := make(map[string]string)
+	for _, e := range d {
+		switch e.Key {
+		case "from", "localField", "foreignField", "as":
+			s, isString := e.Value.(string)
+			if !isString || s == "" {
+				return nil, fmt.Errorf("invalid $lookup %v; expected a non-empty string, got %v", e.Key, e.Value)
+			}
+			fields[e.Key] = s
+		default:
+			return nil, fmt.Errorf("unsupported $lookup option %v", e.Key)
+		}
+	}
+	for _, name := range []string{"from", "localField", "foreignField", "as"} {
+		if _, exists := fields[name]; !exists {
+			return nil, fmt.Errorf("invalid $lookup stage %v; %v must be specified", d, name)
+		}
+	}
+	if resolve == nil {
+		return nil, fmt.Errorf("$lookup is not supported without access to other collections")
+	}
+	return LookupCollection(fields["from"], fields["localField"], fields["foreignField"], fields["as"], resolve), nil
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
 )
 
 /*
@@ -141,6 +142,9 @@ func parseFilterCondition(e bson.E) (Filter, error) {
 	if f, err := parseLogicalOperator(e); f != nil || err != nil {
 		return f, err
 	}
+	if e.Key == "$expr" {
+		return parseExpr(e.Value)
+	}
 	if strings.HasPrefix(e.Key, "$") {
 		return nil, fmt.Errorf("encountered unexpected condition key %v in %v", e.Key, e)
 	}
@@ -175,8 +179,7 @@ func parseFilterCondition(e bson.E) (Filter, error) {
 			}
 
 			// We have regular value operators
-			filter, err := parseValueOperators(v)
-			return Lookup(e.Key, Broadcast(filter)), err
+			return parseFieldOperators(e.Key, v)
 		}
 	case bson.A:
 		{
@@ -189,10 +192,18 @@ func parseFilterCondition(e bson.E) (Filter, error) {
 	case bson.M:
 		return nil, fmt.Errorf("condition values should be specified using bson.D not bson.M")
 	default:
+		if v == nil {
+			return matchesNull(e.Key), nil
+		}
 		return Lookup(e.Key, Broadcast(Equals(v))), nil
 	}
 }
 
+// Like mongodb, equality with null also matches documents where the field is missing
+func matchesNull(key string) Filter {
+	return Or(Not(Lookup(key, Exists())), Lookup(key, Broadcast(Equals(nil))))
+}
+
 /*
 If the element has a logical operator as key, parses it, or returns nil.
 */
@@ -295,6 +306,13 @@ func parseArrayOperator(e bson.E) (Filter, error) {
 			return Broadcast(filter), err
 		}
 	case "$size":
+		{
+			n, isInt := intValue(e.Value)
+			if !isInt || n < 0 {
+				return nil, fmt.Errorf("$size must be a non-negative integer but got %v", e)
+			}
+			return Size(int(n)), nil
+		}
 	}
 	return nil, nil
 }
@@ -306,7 +324,7 @@ func parseExistsOperators(key string, d bson.D) (Filter, error) {
 		if e.Key == "$exists" {
 			if v, isBool := e.Value.(bool); isBool {
 				if !v {
-					return Not(Lookup(e.Key, Exists())), nil
+					return Not(Lookup(key, Exists())), nil
 				} else {
 					return nil, nil
 				}
@@ -318,6 +336,75 @@ func parseExistsOperators(key string, d bson.D) (Filter, error) {
 	return nil, nil
 }
 
+/*
+Parses the operators applied to the field selected by key.
+
+Most operators apply to each value of the field, i.e. to each element if the field is an array.
+$exists and $type apply to the field itself.  The negated operators $ne and $nin match only if
+no value of the field matches, including if the field is missing.
+*/
+func parseFieldOperators(key string, d bson.D) (Filter, error) {
+	var valueOps bson.D
+	var filters []Filter
+	for _, e := range d {
+		switch e.Key {
+		case "$exists":
+			// $exists: false is handled by parseExistsOperators
+			filters = append(filters, Lookup(key, Exists()))
+		case "$type":
+			filter, err := parseValueOperator(e)
+			if err != nil {
+				return nil, err
+			}
+			filters = append(filters, Lookup(key, filter))
+		case "$eq":
+			if e.Value == nil {
+				filters = append(filters, matchesNull(key))
+			} else {
+				valueOps = append(valueOps, e)
+			}
+		case "$ne", "$nin":
+			op := bson.E{Key: "$eq", Value: e.Value}
+			if e.Key == "$nin" {
+				op.Key = "$in"
+			}
+			filter, err := parseValueOperator(op)
+			if err != nil {
+				return nil, err
+			}
+			filters = append(filters, Not(Lookup(key, Broadcast(filter))))
+			if containsNull(op) {
+				filters = append(filters, Lookup(key, Exists()))
+			}
+		default:
+			valueOps = append(valueOps, e)
+		}
+	}
+	if len(valueOps) > 0 {
+		filter, err := parseValueOperators(valueOps)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, Lookup(key, Broadcast(filter)))
+	}
+	return And(filters...), nil
+}
+
+// Reports whether an $eq or $in operator matches null
+func containsNull(e bson.E) bool {
+	if e.Key == "$eq" {
+		return e.Value == nil
+	}
+	if a, isA := e.Value.(bson.A); isA {
+		for _, v := range a {
+			if v == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 /*
 Parses a value that has some operators in it
 */
@@ -369,9 +456,39 @@ func parseValueOperator(e bson.E) (Filter, error) {
 		}
 	case "$nin":
 		{
-			return nil, fmt.Errorf("$nin not implemented; use $nor and $in instead")
-			// filter, err := parseValueOperator(bson.E{"$in", e.Value})
-			// return Not(filter), err
+			filter, err := parseValueOperator(bson.E{Key: "$in", Value: e.Value})
+			return Not(filter), err
+		}
+	case "$gt", "$gte", "$lt", "$lte":
+		// Numeric values are handled by parseNumericOperator
+		return CmpValue(e.Value, cmpTypes[e.Key]), nil
+	case "$exists":
+		{
+			if v, isBool := e.Value.(bool); isBool {
+				if v {
+					return Exists(), nil
+				}
+				return Not(Exists()), nil
+			}
+			return nil, fmt.Errorf("$exists requires a bool value but got %v", e)
+		}
+	case "$type":
+		return parseTypeOperator(e.Value)
+	case "$mod":
+		{
+			a, isA := e.Value.(bson.A)
+			if !isA || len(a) != 2 {
+				return nil, fmt.Errorf("$mod requires an array of [divisor, remainder] but got %v", e)
+			}
+			divisor, isDivisorNumber := truncatedValue(a[0])
+			remainder, isRemainderNumber := truncatedValue(a[1])
+			if !isDivisorNumber || !isRemainderNumber {
+				return nil, fmt.Errorf("$mod requires numeric divisor and remainder but got %v", e)
+			}
+			if divisor == 0 {
+				return nil, fmt.Errorf("$mod divisor cannot be 0")
+			}
+			return Mod(divisor, remainder), nil
 		}
 	case "$regex":
 		{
@@ -384,10 +501,6 @@ func parseValueOperator(e bson.E) (Filter, error) {
 		fallthrough
 	case "$where": // not supported
 		fallthrough
-	case "$type": // not supported
-		fallthrough
-	case "$expr": // not supported
-		fallthrough
 	default:
 		return nil, fmt.Errorf("unsupported operator %v", e)
 	}
@@ -423,3 +536,129 @@ func parseNumericOperator(e bson.E) (Filter, error) {
 	}
 	return nil, nil
 }
+
+var cmpTypes = map[string]CmpType{"$eq": Eq, "$gt": Gt, "$gte": Gte, "$lt": Lt, "$lte": Lte}
+
+// Returns the integer value of a number, truncating floats
+func truncatedValue(v any) (int64, bool) {
+	if i, isInt := intValue(v); isInt {
+		return i, true
+	}
+	f, isFloat := floatValue(v)
+	return int64(f), isFloat
+}
+
+// Aliases accepted by $type; see https://www.mongodb.com/docs/manual/reference/operator/query/type/
+var typeAliases = map[string][]bsontype.Type{
+	"double":     {bsontype.Double},
+	"string":     {bsontype.String},
+	"object":     {bsontype.EmbeddedDocument},
+	"array":      {bsontype.Array},
+	"binData":    {bsontype.Binary},
+	"undefined":  {bsontype.Undefined},
+	"objectId":   {bsontype.ObjectID},
+	"bool":       {bsontype.Boolean},
+	"date":       {bsontype.DateTime},
+	"null":       {bsontype.Null},
+	"regex":      {bsontype.Regex},
+	"javascript": {bsontype.JavaScript},
+	"symbol":     {bsontype.Symbol},
+	"int":        {bsontype.Int32},
+	"timestamp":  {bsontype.Timestamp},
+	"long":       {bsontype.Int64},
+	"decimal":    {bsontype.Decimal128},
+	"minKey":     {bsontype.MinKey},
+	"maxKey":     {bsontype.MaxKey},
+	"number":     {bsontype.Double, bsontype.Int32, bsontype.Int64, bsontype.Decimal128},
+}
+
+/*
+Parses the value of a $type operator, which is a type alias, a BSON type number, or an array of them
+*/
+func parseTypeOperator(value any) (Filter, error) {
+	values, isA := value.(bson.A)
+	if !isA {
+		values = bson.A{value}
+	}
+	var types []bsontype.Type
+	for _, v := range values {
+		if alias, isString := v.(string); isString {
+			aliasTypes, exists := typeAliases[alias]
+			if !exists {
+				return nil, fmt.Errorf("unknown $type alias %v", alias)
+			}
+			types = append(types, aliasTypes...)
+		} else if n, isNumber := truncatedValue(v); isNumber {
+			switch {
+			case n == -1:
+				types = append(types, bsontype.MinKey)
+			case n >= 1 && n <= 19, n == 127:
+				types = append(types, bsontype.Type(n))
+			default:
+				return nil, fmt.Errorf("invalid $type number %v", n)
+			}
+		} else {
+			return nil, fmt.Errorf("$type requires a type alias or number but got %v", v)
+		}
+	}
+	return HasType(types...), nil
+}
+
+/*
+Parses the value of an $expr condition.  The supported expressions are comparisons
+($eq, $ne, $gt, $gte, $lt, $lte) between aggregation expressions, such as between two
+fields of the document, and combinations of them using $and, $or, and $not.
+*/
+func parseExpr(value any) (Filter, error) {
+	d, isD := value.(bson.D)
+	if !isD || len(d) != 1 {
+		return nil, fmt.Errorf("unsupported $expr %v; expected a bson.D with a single operator", value)
+	}
+	op := d[0]
+	args, isA := op.Value.(bson.A)
+	switch op.Key {
+	case "$and", "$or":
+		if !isA {
+			return nil, fmt.Errorf("%v expression requires an array but got %v", op.Key, op.Value)
+		}
+		var filters []Filter
+		for _, arg := range args {
+			filter, err := parseExpr(arg)
+			if err != nil {
+				return nil, err
+			}
+			filters = append(filters, filter)
+		}
+		if op.Key == "$and" {
+			return And(filters...), nil
+		}
+		return Or(filters...), nil
+	case "$not":
+		if isA {
+			if len(args) != 1 {
+				return nil, fmt.Errorf("$not expression requires a single argument but got %v", args)
+			}
+			op.Value = args[0]
+		}
+		filter, err := parseExpr(op.Value)
+		return Not(filter), err
+	case "$eq", "$ne", "$gt", "$gte", "$lt", "$lte":
+		if !isA || len(args) != 2 {
+			return nil, fmt.Errorf("%v expression requires an array of two arguments but got %v", op.Key, op.Value)
+		}
+		left, err := ParseExpression(args[0])
+		if err != nil {
+			return nil, err
+		}
+		right, err := ParseExpression(args[1])
+		if err != nil {
+			return nil, err
+		}
+		if op.Key == "$ne" {
+			return Not(CmpExpr(left, right, Eq)), nil
+		}
+		return CmpExpr(left, right, cmpTypes[op.Key]), nil
+	default:
+		return nil, fmt.Errorf("unsupported $expr operator %v", op.Key)
+	}
+}
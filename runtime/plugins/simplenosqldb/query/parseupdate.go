@@ -6,68 +6,76 @@ import (
 	"strings"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
 )
 
 func ParseUpdate(update bson.D) (Update, error) {
+	return ParseUpdateWithFilter(update, nil)
+}
+
+/*
+Parses an update that is applied to documents matching filter.  filter is used to resolve the
+positional operator $ in field paths, and may be nil if the update doesn't use it.
+*/
+func ParseUpdateWithFilter(update bson.D, filter Filter) (Update, error) {
+	parsers := map[string]func(args any, filter Filter) ([]Update, error){
+		"$set":         parseSet,
+		"$unset":       parseUnset,
+		"$inc":         parseInc,
+		"$mul":         parseMul,
+		"$min":         parseMin,
+		"$max":         parseMax,
+		"$push":        parsePush,
+		"$pull":        parsePull,
+		"$pop":         parsePop,
+		"$addToSet":    parseAddToSet,
+		"$rename":      parseRename,
+		"$setOnInsert": parseSetOnInsert,
+	}
 	var updates []Update
 	for _, op := range update {
-		switch op.Key {
-		case "$set":
-			{
-				sets, err := parseSet(op.Value)
-				if err != nil {
-					return nil, err
-				}
-				updates = append(updates, sets...)
-			}
-		case "$unset":
-			{
-				unsets, err := parseUnset(op.Value)
-				if err != nil {
-					return nil, err
-				}
-				updates = append(updates, unsets...)
-			}
-		case "$inc":
-			{
-				incs, err := parseInc(op.Value)
-				if err != nil {
-					return nil, err
-				}
-				updates = append(updates, incs...)
-			}
-		case "$push":
-			{
-				pushes, err := parsePush(op.Value)
-				if err != nil {
-					return nil, err
-				}
-				updates = append(updates, pushes...)
-			}
-		case "$pull":
-			{
-				pulls, err := parsePull(op.Value)
-				if err != nil {
-					return nil, err
-				}
-				updates = append(updates, pulls...)
-			}
-		case "$addToSet":
-			{
-				adds, err := parseAddToSet(op.Value)
-				if err != nil {
-					return nil, err
-				}
-				updates = append(updates, adds...)
-			}
-		default:
+		parse, exists := parsers[op.Key]
+		if !exists {
 			return nil, fmt.Errorf("unsupported update op %v", op.Key)
 		}
+		opUpdates, err := parse(op.Value, filter)
+		if err != nil {
+			return nil, err
+		}
+		updates = append(updates, opUpdates...)
 	}
 	return UpdateAll(updates), nil
 }
 
-func parseSet(args any) ([]Update, error) {
+/*
+Returns the update built for selector.  If selector uses the positional operator $, then the
+update is applied to the first array element that matches filter.
+*/
+func pathUpdate(selector string, filter Filter, build func(selector string) Update) (Update, error) {
+	prefix, suffix, isPositional := strings.Cut(selector, ".$")
+	if !isPositional {
+		return build(selector), nil
+	}
+	if suffix != "" && !strings.HasPrefix(suffix, ".") {
+		return nil, fmt.Errorf("unsupported positional operator in %v", selector)
+	}
+	if strings.Contains(suffix, ".$") {
+		return nil, fmt.Errorf("too many positional elements in %v", selector)
+	}
+	if filter == nil {
+		return nil, fmt.Errorf("the positional operator in %v requires a query filter", selector)
+	}
+	return Positional(prefix, suffix, filter, build), nil
+}
+
+// Returns an update that applies update to the field selected by selector
+func updatePath(selector string, update Update, filter Filter) (Update, error) {
+	return pathUpdate(selector, filter, func(selector string) Update {
+		return UpdatePath(selector, update)
+	})
+}
+
+func parseSet(args any, filter Filter) ([]Update, error) {
 	d, isD := args.(bson.D)
 	if !isD {
 		return nil, fmt.Errorf("invalid $set operator; expected a bson.D, got %v", args)
@@ -78,135 +86,285 @@ func parseSet(args any) ([]Update, error) {
 		if err != nil {
 			return nil, err
 		}
-		updates = append(updates, UpdatePath(e.Key, update))
+		update, err = updatePath(e.Key, update, filter)
+		if err != nil {
+			return nil, err
+		}
+		updates = append(updates, update)
 	}
 	return updates, nil
 }
 
-func parseUnset(args any) ([]Update, error) {
+/*
+$setOnInsert only applies when an update inserts a new document.  Updates of existing
+documents ignore it, so we only validate its arguments.
+*/
+func parseSetOnInsert(args any, filter Filter) ([]Update, error) {
+	if _, isD := args.(bson.D); !isD {
+		return nil, fmt.Errorf("invalid $setOnInsert operator; expected a bson.D, got %v", args)
+	}
+	return nil, nil
+}
+
+func parseUnset(args any, filter Filter) ([]Update, error) {
 	d, isD := args.(bson.D)
 	if !isD {
 		return nil, fmt.Errorf("invalid $unset operator; expected a bson.D, got %v", args)
 	}
 	var updates []Update
 	for _, e := range d {
-		updates = append(updates, UnsetPath(e.Key))
+		update, err := pathUpdate(e.Key, filter, UnsetPath)
+		if err != nil {
+			return nil, err
+		}
+		updates = append(updates, update)
 	}
 	return updates, nil
 }
 
-func parseInc(args any) ([]Update, error) {
+func parseInc(args any, filter Filter) ([]Update, error) {
 	d, isD := args.(bson.D)
 	if !isD {
 		return nil, fmt.Errorf("invalid $inc operator; expected a bson.D, got %v", args)
 	}
 	var updates []Update
 	for _, e := range d {
+		var update Update
 		switch v := e.Value.(type) {
 		case int:
-			updates = append(updates, UpdatePath(e.Key, IncInt(int64(v))))
+			update = IncInt(int64(v))
 		case int64:
-			updates = append(updates, UpdatePath(e.Key, IncInt(v)))
+			update = IncInt(v)
 		case int32:
-			updates = append(updates, UpdatePath(e.Key, IncInt(int64(v))))
+			update = IncInt(int64(v))
 		case int16:
-			updates = append(updates, UpdatePath(e.Key, IncInt(int64(v))))
+			update = IncInt(int64(v))
 		case int8:
-			updates = append(updates, UpdatePath(e.Key, IncInt(int64(v))))
-		// case float64:
-		// 	updates = append(updates, IncFloat(v))
-		// case float32:
-		// 	updates = append(updates, IncFloat(float64(v)))
+			update = IncInt(int64(v))
+		case float64:
+			update = IncFloat(v)
+		case float32:
+			update = IncFloat(float64(v))
 		default:
-			return nil, fmt.Errorf("invalid $inc argument; expect an int, got %v %v", reflect.TypeOf(e.Value), e.Value)
+			return nil, fmt.Errorf("invalid $inc argument; expect a number, got %v %v", reflect.TypeOf(e.Value), e.Value)
 		}
+		update, err := updatePath(e.Key, update, filter)
+		if err != nil {
+			return nil, err
+		}
+		updates = append(updates, update)
 	}
 	return updates, nil
 }
 
-func parsePush(args any) ([]Update, error) {
+func parseMul(args any, filter Filter) ([]Update, error) {
 	d, isD := args.(bson.D)
 	if !isD {
-		return nil, fmt.Errorf("invalid $push operator; expected a bson.D, got %v", args)
+		return nil, fmt.Errorf("invalid $mul operator; expected a bson.D, got %v", args)
 	}
 	var updates []Update
 	for _, e := range d {
-		// Check that the value isn't a bunch of modifiers; not currently implemented
-		if ed, eIsD := e.Value.(bson.D); eIsD {
-			for _, e2 := range ed {
-				if strings.HasPrefix(e2.Key, "$") {
-					return nil, fmt.Errorf("modifiers not currently supported for $push operation; got %v", e2)
-				}
-			}
+		// Normalize the factor to the type it would be stored as
+		var factor any
+		switch bsonType(e.Value) {
+		case bsontype.Int32:
+			i, _ := intValue(e.Value)
+			factor = int32(i)
+		case bsontype.Int64:
+			factor, _ = intValue(e.Value)
+		case bsontype.Double:
+			factor, _ = floatValue(e.Value)
+		default:
+			return nil, fmt.Errorf("invalid $mul argument; expect a number, got %v %v", reflect.TypeOf(e.Value), e.Value)
 		}
-
-		update, err := PushValue(e.Value)
+		update, err := updatePath(e.Key, Mul(factor), filter)
 		if err != nil {
 			return nil, err
 		}
-		updates = append(updates, UpdatePath(e.Key, update))
+		updates = append(updates, update)
 	}
 	return updates, nil
 }
 
-func parseAddToSet(args any) ([]Update, error) {
+func parseMin(args any, filter Filter) ([]Update, error) {
+	return parseMinMax("$min", args, filter, SetMin)
+}
+
+func parseMax(args any, filter Filter) ([]Update, error) {
+	return parseMinMax("$max", args, filter, SetMax)
+}
+
+func parseMinMax(op string, args any, filter Filter, newUpdate func(value any) (Update, error)) ([]Update, error) {
 	d, isD := args.(bson.D)
 	if !isD {
-		return nil, fmt.Errorf("invalid $addToSet operator; expected a bson.D, got %v", args)
+		return nil, fmt.Errorf("invalid %v operator; expected a bson.D, got %v", op, args)
 	}
 	var updates []Update
 	for _, e := range d {
-		// Check that the value isn't a bunch of modifiers; not currently implemented
-		if ed, eIsD := e.Value.(bson.D); eIsD {
-			for _, e2 := range ed {
-				if strings.HasPrefix(e2.Key, "$") {
-					return nil, fmt.Errorf("modifiers not currently supported for $push operation; got %v", e2)
-				}
-			}
+		update, err := newUpdate(e.Value)
+		if err != nil {
+			return nil, err
 		}
+		update, err = updatePath(e.Key, update, filter)
+		if err != nil {
+			return nil, err
+		}
+		updates = append(updates, update)
+	}
+	return updates, nil
+}
 
-		update, err := AddToSet(e.Value)
+/*
+Returns the values to add for $push or $addToSet.  The value is either a single value, or a
+bson.D with the $each modifier and a bson.A of values.  Other modifiers are not supported.
+*/
+func parseEach(op string, value any) ([]any, error) {
+	d, isD := value.(bson.D)
+	if !isD || len(d) == 0 || !strings.HasPrefix(d[0].Key, "$") {
+		return []any{value}, nil
+	}
+	var values []any
+	for _, e := range d {
+		if e.Key != "$each" {
+			return nil, fmt.Errorf("modifier %v not currently supported for %v operation", e.Key, op)
+		}
+		a, isA := e.Value.(bson.A)
+		if !isA {
+			return nil, fmt.Errorf("$each modifier requires a bson.A; got %v", e.Value)
+		}
+		values = append(values, a...)
+	}
+	return values, nil
+}
+
+func parsePush(args any, filter Filter) ([]Update, error) {
+	d, isD := args.(bson.D)
+	if !isD {
+		return nil, fmt.Errorf("invalid $push operator; expected a bson.D, got %v", args)
+	}
+	var updates []Update
+	for _, e := range d {
+		values, err := parseEach("$push", e.Value)
 		if err != nil {
 			return nil, err
 		}
-		updates = append(updates, UpdatePath(e.Key, update))
+		for _, value := range values {
+			update, err := PushValue(value)
+			if err != nil {
+				return nil, err
+			}
+			update, err = updatePath(e.Key, update, filter)
+			if err != nil {
+				return nil, err
+			}
+			updates = append(updates, update)
+		}
 	}
 	return updates, nil
 }
 
-func parsePull(args any) ([]Update, error) {
+func parseAddToSet(args any, filter Filter) ([]Update, error) {
 	d, isD := args.(bson.D)
 	if !isD {
-		return nil, fmt.Errorf("invalid $pull operator; expected a bson.D, got %v", args)
+		return nil, fmt.Errorf("invalid $addToSet operator; expected a bson.D, got %v", args)
 	}
 	var updates []Update
 	for _, e := range d {
-		// Check that the value isn't a bunch of modifiers; not currently implemented
-		if ed, eIsD := e.Value.(bson.D); eIsD {
-			for _, e2 := range ed {
-				if strings.HasPrefix(e2.Key, "$") {
-					return nil, fmt.Errorf("modifiers not currently supported for $pull operation; got %v", e2)
-				}
+		values, err := parseEach("$addToSet", e.Value)
+		if err != nil {
+			return nil, err
+		}
+		for _, value := range values {
+			update, err := AddToSet(value)
+			if err != nil {
+				return nil, err
 			}
+			update, err = updatePath(e.Key, update, filter)
+			if err != nil {
+				return nil, err
+			}
+			updates = append(updates, update)
 		}
+	}
+	return updates, nil
+}
 
-		var filter Filter
+func parsePull(args any, filter Filter) ([]Update, error) {
+	d, isD := args.(bson.D)
+	if !isD {
+		return nil, fmt.Errorf("invalid $pull operator; expected a bson.D, got %v", args)
+	}
+	var updates []Update
+	for _, e := range d {
+		var pullFilter Filter
 		var err error
 		switch v := e.Value.(type) {
 		case bson.D:
-			filter, err = parseQuery(v)
+			if len(v) > 0 && strings.HasPrefix(v[0].Key, "$") {
+				// A condition on the array elements, e.g. {$gte: 6}
+				pullFilter, err = parseValueOperators(v)
+			} else {
+				// A query on the fields of document array elements
+				pullFilter, err = parseQuery(v)
+			}
 		default:
-			filter = Equals(v)
+			pullFilter = Equals(v)
 		}
 		if err != nil {
 			return nil, err
 		}
 
-		update, err := PullMatches(filter)
+		update, err := PullMatches(pullFilter)
 		if err != nil {
 			return nil, err
 		}
-		updates = append(updates, UpdatePath(e.Key, update))
+		update, err = updatePath(e.Key, update, filter)
+		if err != nil {
+			return nil, err
+		}
+		updates = append(updates, update)
+	}
+	return updates, nil
+}
+
+func parsePop(args any, filter Filter) ([]Update, error) {
+	d, isD := args.(bson.D)
+	if !isD {
+		return nil, fmt.Errorf("invalid $pop operator; expected a bson.D, got %v", args)
+	}
+	var updates []Update
+	for _, e := range d {
+		v, isInt := intValue(e.Value)
+		if !isInt || (v != 1 && v != -1) {
+			return nil, fmt.Errorf("invalid $pop argument; expect 1 or -1, got %v", e.Value)
+		}
+		update, err := updatePath(e.Key, Pop(v == -1), filter)
+		if err != nil {
+			return nil, err
+		}
+		updates = append(updates, update)
+	}
+	return updates, nil
+}
+
+func parseRename(args any, filter Filter) ([]Update, error) {
+	d, isD := args.(bson.D)
+	if !isD {
+		return nil, fmt.Errorf("invalid $rename operator; expected a bson.D, got %v", args)
+	}
+	var updates []Update
+	for _, e := range d {
+		to, isString := e.Value.(string)
+		if !isString || to == "" {
+			return nil, fmt.Errorf("invalid $rename argument; expect a field name, got %v", e.Value)
+		}
+		if strings.Contains(e.Key, "$") || strings.Contains(to, "$") {
+			return nil, fmt.Errorf("the positional operator is not supported by $rename")
+		}
+		if e.Key == to {
+			return nil, fmt.Errorf("invalid $rename; source and destination cannot be the same: %v", to)
+		}
+		updates = append(updates, Rename(e.Key, to))
 	}
 	return updates, nil
 }
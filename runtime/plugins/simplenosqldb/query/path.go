@@ -0,0 +1,135 @@
+package query
+
+import (
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+/*
+Helpers for reading and constructing the possibly-nested fields of bson documents
+*/
+
+// Returns the value of the possibly-nested field selected by path.  If the path traverses
+// an array, the result is an array of the values selected from its elements.
+func resolvePath(value any, path []string) (any, bool) {
+	if len(path) == 0 {
+		return value, true
+	}
+	switch v := value.(type) {
+	case bson.D:
+		for _, e := range v {
+			if e.Key == path[0] {
+				return resolvePath(e.Value, path[1:])
+			}
+		}
+	case bson.M:
+		if fieldValue, exists := v[path[0]]; exists {
+			return resolvePath(fieldValue, path[1:])
+		}
+	case bson.A:
+		results := bson.A{}
+		for _, elem := range v {
+			if result, found := resolvePath(elem, path); found {
+				results = append(results, result)
+			}
+		}
+		return results, true
+	}
+	return nil, false
+}
+
+// Returns a copy of doc with the possibly-nested field selected by path set to value.
+// Intermediate documents are created if they do not exist.  doc is not modified.
+func withField(doc bson.D, path string, value any) bson.D {
+	name, rest, nested := strings.Cut(path, ".")
+	result := make(bson.D, 0, len(doc)+1)
+	found := false
+	for _, e := range doc {
+		if e.Key == name {
+			found = true
+			if nested {
+				sub, _ := e.Value.(bson.D)
+				e = bson.E{Key: name, Value: withField(sub, rest, value)}
+			} else {
+				e = bson.E{Key: name, Value: value}
+			}
+		}
+		result = append(result, e)
+	}
+	if !found {
+		if nested {
+			value = withField(nil, rest, value)
+		}
+		result = append(result, bson.E{Key: name, Value: value})
+	}
+	return result
+}
+
+// Returns a copy of doc without the possibly-nested field selected by path.  If the path traverses
+// an array, the field is removed from each document in the array.  doc is not modified.
+func withoutField(doc bson.D, path string) bson.D {
+	name, rest, nested := strings.Cut(path, ".")
+	result := make(bson.D, 0, len(doc))
+	for _, e := range doc {
+		if e.Key == name {
+			if !nested {
+				continue
+			}
+			e = bson.E{Key: name, Value: withoutNestedField(e.Value, rest)}
+		}
+		result = append(result, e)
+	}
+	return result
+}
+
+func withoutNestedField(value any, path string) any {
+	switch v := value.(type) {
+	case bson.D:
+		return withoutField(v, path)
+	case bson.A:
+		result := make(bson.A, 0, len(v))
+		for _, elem := range v {
+			result = append(result, withoutNestedField(elem, path))
+		}
+		return result
+	}
+	return value
+}
+
+// Copies the possibly-nested field selected by path from src to dst, returning the updated dst.
+// If the path traverses an array, the field is copied from each document in the array, and other
+// array elements are omitted.  Returns false if src has no such field.  dst is not modified.
+func includeField(dst any, src any, path []string) (any, bool) {
+	switch s := src.(type) {
+	case bson.D:
+		value, found := resolvePath(s, path[:1])
+		if !found {
+			return dst, false
+		}
+		d, _ := dst.(bson.D)
+		if len(path) > 1 {
+			existing, _ := resolvePath(d, path[:1])
+			if value, found = includeField(existing, value, path[1:]); !found {
+				return dst, false
+			}
+		}
+		return withField(d, path[0], value), true
+	case bson.A:
+		d, _ := dst.(bson.A)
+		result := bson.A{}
+		for _, elem := range s {
+			if _, isDoc := elem.(bson.D); !isDoc {
+				continue
+			}
+			var existing any = bson.D{}
+			if len(result) < len(d) {
+				existing = d[len(result)]
+			}
+			included, _ := includeField(existing, elem, path)
+			result = append(result, included)
+		}
+		return result, true
+	}
+	return dst, false
+}
@@ -0,0 +1,119 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+/*
+Simple evaluation of projections on bson documents.  Projections are used by find queries
+and by $project aggregation stages.
+*/
+
+type project struct {
+	exclude   bool
+	excludeID bool
+	paths     []string
+	exprs     []Expression // nil for included or excluded paths
+}
+
+// Parses the projection of a find query, which has the same semantics as a $project stage.
+func ParseProjection(projection bson.D) (Stage, error) {
+	return parseProject(projection)
+}
+
+func parseProject(args any) (Stage, error) {
+	d, isD := args.(bson.D)
+	if !isD || len(d) == 0 {
+		return nil, fmt.Errorf("invalid $project stage; expected a non-empty bson.D, got %v", args)
+	}
+	p := &project{exclude: true}
+	for _, e := range d {
+		include, isFlag := projectionFlag(e.Value)
+		if e.Key == "_id" && isFlag {
+			p.excludeID = !include
+			continue
+		}
+		var expr Expression
+		if !isFlag {
+			var err error
+			if expr, err = ParseExpression(e.Value); err != nil {
+				return nil, err
+			}
+			include = true
+		}
+		if len(p.paths) > 0 && include == p.exclude {
+			return nil, fmt.Errorf("invalid $project stage %v; cannot mix inclusion and exclusion", d)
+		}
+		p.exclude = !include
+		p.paths = append(p.paths, e.Key)
+		p.exprs = append(p.exprs, expr)
+	}
+	return p, nil
+}
+
+// Interprets a projection value as an inclusion or exclusion flag, if it is one
+func projectionFlag(value any) (include bool, isFlag bool) {
+	if b, isBool := value.(bool); isBool {
+		return b, true
+	}
+	if f, isNumber := floatValue(value); isNumber {
+		return f != 0, true
+	}
+	return false, false
+}
+
+func (s *project) Apply(docs []bson.D) []bson.D {
+	var results []bson.D
+	for _, doc := range docs {
+		results = append(results, s.projectDoc(doc))
+	}
+	return results
+}
+
+func (s *project) projectDoc(doc bson.D) bson.D {
+	if s.exclude {
+		for _, path := range s.paths {
+			doc = withoutField(doc, path)
+		}
+		if s.excludeID {
+			doc = withoutField(doc, "_id")
+		}
+		return doc
+	}
+
+	result := bson.D{}
+	if id, found := resolvePath(doc, []string{"_id"}); found && !s.excludeID {
+		result = append(result, bson.E{Key: "_id", Value: id})
+	}
+	for i, path := range s.paths {
+		if s.exprs[i] == nil {
+			if included, found := includeField(result, doc, strings.Split(path, ".")); found {
+				result = included.(bson.D)
+			}
+		} else if value, found := s.exprs[i].Evaluate(doc); found {
+			result = withField(result, path, value)
+		}
+	}
+	return result
+}
+
+func (s *project) String() string {
+	var fieldStrings []string
+	if s.excludeID {
+		fieldStrings = append(fieldStrings, "-_id")
+	}
+	for i, path := range s.paths {
+		switch {
+		case s.exclude:
+			fieldStrings = append(fieldStrings, "-"+path)
+		case s.exprs[i] != nil:
+			fieldStrings = append(fieldStrings, fmt.Sprintf("%v: %v", path, s.exprs[i]))
+		default:
+			fieldStrings = append(fieldStrings, path)
+		}
+	}
+	return fmt.Sprintf("project(%v)", strings.Join(fieldStrings, ", "))
+}
@@ -2,6 +2,7 @@ package query
 
 import (
 	"fmt"
+	"math"
 	"reflect"
 	"strconv"
 	"strings"
@@ -67,6 +68,36 @@ type (
 	broadcastupdate struct {
 		update Update
 	}
+
+	incfloat struct {
+		amount float64
+	}
+
+	mul struct {
+		factor any
+	}
+
+	minmax struct {
+		t     bsontype.Type
+		value []byte
+		max   bool
+	}
+
+	pop struct {
+		first bool
+	}
+
+	rename struct {
+		from string
+		to   string
+	}
+
+	positional struct {
+		prefix string
+		suffix string
+		filter Filter
+		build  func(selector string) Update
+	}
 )
 
 func SetValue(value any) (Update, error) {
@@ -100,6 +131,46 @@ func IncInt(amount int64) Update {
 	return &incint{amount: amount}
 }
 
+func IncFloat(amount float64) Update {
+	return &incfloat{amount: amount}
+}
+
+// Multiplies a number by factor, which must be an int32, int64, or float64
+func Mul(factor any) Update {
+	return &mul{factor: factor}
+}
+
+// Sets the value if it is less than the current value, or if there is no current value
+func SetMin(value any) (Update, error) {
+	t, v, err := bson.MarshalValue(value)
+	return &minmax{t: t, value: v}, err
+}
+
+// Sets the value if it is greater than the current value, or if there is no current value
+func SetMax(value any) (Update, error) {
+	t, v, err := bson.MarshalValue(value)
+	return &minmax{t: t, value: v, max: true}, err
+}
+
+// Removes the first or last element of an array
+func Pop(first bool) Update {
+	return &pop{first: first}
+}
+
+// Moves the possibly-nested field selected by from to to.  Must be applied to the root document.
+func Rename(from string, to string) Update {
+	return &rename{from: from, to: to}
+}
+
+/*
+Applies an update to the first element of the array selected by prefix that matches filter,
+for the positional operator $.  The selector passed to build is prefix.<index>suffix.
+Must be applied to the root document.
+*/
+func Positional(prefix string, suffix string, filter Filter, build func(selector string) Update) Update {
+	return &positional{prefix: prefix, suffix: suffix, filter: filter, build: build}
+}
+
 func UpdateField(fieldName string, update Update, createIfAbsent bool) Update {
 	return &updatefield{fieldName: fieldName, update: update, createIfAbsent: createIfAbsent}
 }
@@ -400,12 +471,9 @@ func (s *updateindex) Apply(itemRef any) error {
 	if !s.createIfAbsent && len(v) <= s.index {
 		return nil
 	}
-	if cap(v) <= s.index {
-		newv := make(bson.A, 0, s.index+1)
-		newv = append(newv, v...)
-		v = newv
+	if len(v) <= s.index {
+		v = append(v, make(bson.A, s.index+1-len(v))...)
 	}
-	v = v[:s.index+1]
 
 	// Update at the specified index and copy back to ptr
 	err = s.update.Apply(&v[s.index])
@@ -445,6 +513,166 @@ func (b *broadcastupdate) Apply(itemRef any) error {
 	return nil
 }
 
+func (i *incfloat) Apply(itemRef any) error {
+	itemVal, err := backend.GetPointerValue(itemRef)
+	if err != nil {
+		return err
+	}
+
+	if itemVal == nil {
+		itemVal = float64(0)
+	}
+
+	if v, isInt := intValue(itemVal); isInt {
+		return setPointerValue(float64(v)+i.amount, itemRef)
+	} else if v, isFloat := floatValue(itemVal); isFloat {
+		return setPointerValue(v+i.amount, itemRef)
+	}
+	return fmt.Errorf("incfloat unable to increment non-number %v", itemVal)
+}
+
+func (m *mul) Apply(itemRef any) error {
+	itemVal, err := backend.GetPointerValue(itemRef)
+	if err != nil {
+		return err
+	}
+
+	if itemVal == nil {
+		// Like mongodb, a missing field is set to zero of the same type as the factor
+		switch m.factor.(type) {
+		case int32:
+			return setPointerValue(int32(0), itemRef)
+		case int64:
+			return setPointerValue(int64(0), itemRef)
+		default:
+			return setPointerValue(float64(0), itemRef)
+		}
+	}
+
+	a, aIsInt := intValue(itemVal)
+	b, bIsInt := intValue(m.factor)
+	if aIsInt && bIsInt {
+		product := a * b
+		if a != 0 && product/a != b {
+			return fmt.Errorf("mul integer overflow multiplying %v by %v", itemVal, m.factor)
+		}
+		_, aIsInt32 := itemVal.(int32)
+		_, bIsInt32 := m.factor.(int32)
+		if aIsInt32 && bIsInt32 && product >= math.MinInt32 && product <= math.MaxInt32 {
+			return setPointerValue(int32(product), itemRef)
+		}
+		return setPointerValue(product, itemRef)
+	}
+
+	af, aIsNumber := floatValue(itemVal)
+	bf, _ := floatValue(m.factor)
+	if !aIsNumber {
+		return fmt.Errorf("mul unable to multiply non-number %v", itemVal)
+	}
+	return setPointerValue(af*bf, itemRef)
+}
+
+func (m *minmax) Apply(itemRef any) error {
+	var v any
+	err := bson.UnmarshalValue(m.t, m.value, &v)
+	if err != nil {
+		return err
+	}
+
+	itemVal, err := backend.GetPointerValue(itemRef)
+	if err != nil {
+		return err
+	}
+
+	c := Compare(v, itemVal)
+	if itemVal == nil || (m.max && c > 0) || (!m.max && c < 0) {
+		return setPointerValue(v, itemRef)
+	}
+	return nil
+}
+
+func (p *pop) Apply(itemRef any) error {
+	itemVal, err := backend.GetPointerValue(itemRef)
+	if err != nil || itemVal == nil {
+		return err
+	}
+
+	a, isA := itemVal.(bson.A)
+	if !isA {
+		return fmt.Errorf("pop expected a bson.A but instead found a %v %v", reflect.TypeOf(itemVal), itemVal)
+	}
+	if len(a) == 0 {
+		return nil
+	}
+	if p.first {
+		return backend.CopyResult(a[1:], itemRef)
+	}
+	return backend.CopyResult(a[:len(a)-1], itemRef)
+}
+
+func (r *rename) Apply(itemRef any) error {
+	itemVal, err := backend.GetPointerValue(itemRef)
+	if err != nil {
+		return err
+	}
+
+	d, isD := itemVal.(bson.D)
+	if !isD {
+		return fmt.Errorf("rename expected a bson.D but instead found a %v %v", reflect.TypeOf(itemVal), itemVal)
+	}
+	value, found := resolvePath(d, strings.Split(r.from, "."))
+	if !found {
+		return nil
+	}
+
+	if err := UnsetPath(r.from).Apply(itemRef); err != nil {
+		return err
+	}
+	set, err := SetValue(value)
+	if err != nil {
+		return err
+	}
+	return UpdatePath(r.to, set).Apply(itemRef)
+}
+
+func (p *positional) Apply(itemRef any) error {
+	itemVal, err := backend.GetPointerValue(itemRef)
+	if err != nil {
+		return err
+	}
+
+	d, isD := itemVal.(bson.D)
+	if !isD {
+		return fmt.Errorf("positional update expected a bson.D but instead found a %v %v", reflect.TypeOf(itemVal), itemVal)
+	}
+
+	// Find the first element that, on its own, satisfies the query.  Like mongodb, the query must
+	// have a condition on the array, i.e. it must not match if the array is empty.
+	a, isA := LookupField(d, p.prefix).(bson.A)
+	if isA && !p.filter.Apply(withField(d, p.prefix, bson.A{})) {
+		for i, elem := range a {
+			if p.filter.Apply(withField(d, p.prefix, bson.A{elem})) {
+				return p.build(fmt.Sprintf("%v.%v%v", p.prefix, i, p.suffix)).Apply(itemRef)
+			}
+		}
+	}
+	return fmt.Errorf("the positional operator did not find the match needed from the query for %v.$%v", p.prefix, p.suffix)
+}
+
+// Sets the value pointed to by itemRef, which may be nil
+func setPointerValue(v any, itemRef any) error {
+	dst_ptr := reflect.ValueOf(itemRef)
+	if dst_ptr.Kind() != reflect.Pointer || dst_ptr.IsNil() {
+		return fmt.Errorf("unable to apply update to non-pointer type %v", reflect.TypeOf(itemRef))
+	}
+	dst_val := reflect.Indirect(dst_ptr)
+	if v == nil {
+		dst_val.Set(reflect.Zero(dst_val.Type()))
+		return nil
+	}
+	return backend.CopyResult(v, itemRef)
+}
+
 func (s *set) String() string {
 	var v interface{}
 	bson.UnmarshalValue(s.t, s.value, &v)
@@ -498,3 +726,35 @@ func (a *updateall) String() string {
 func (b *broadcastupdate) String() string {
 	return fmt.Sprintf("broadcast %v ", b.update)
 }
+
+func (i *incfloat) String() string {
+	return fmt.Sprintf(" += %v", i.amount)
+}
+
+func (m *mul) String() string {
+	return fmt.Sprintf(" *= %v", m.factor)
+}
+
+func (m *minmax) String() string {
+	var v interface{}
+	bson.UnmarshalValue(m.t, m.value, &v)
+	if m.max {
+		return fmt.Sprintf("max %v", v)
+	}
+	return fmt.Sprintf("min %v", v)
+}
+
+func (p *pop) String() string {
+	if p.first {
+		return "pop first"
+	}
+	return "pop last"
+}
+
+func (r *rename) String() string {
+	return fmt.Sprintf("rename %v to %v", r.from, r.to)
+}
+
+func (p *positional) String() string {
+	return fmt.Sprintf("positional %v.$%v", p.prefix, p.suffix)
+}
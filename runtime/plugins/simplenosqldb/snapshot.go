@@ -0,0 +1,132 @@
+package simplenosqldb
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Writes the documents of every collection to w as MongoDB canonical Extended JSON, in the form
+//
+//	{"my_db": {"my_collection": [{"_id": ...}, ...]}}
+//
+// Indexes other than the _id index are not included; typically they are re-created by the
+// services that use the database.
+//
+// Implements the snapshot.Snapshotter interface.
+func (impl *SimpleNoSQLDB) Snapshot(w io.Writer) error {
+	impl.mu.RLock()
+	snapshot := impl.snapshot()
+	impl.mu.RUnlock()
+
+	bytes, err := bson.MarshalExtJSONIndent(snapshot, true, false, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(bytes, '\n'))
+	return err
+}
+
+// Returns the documents of every collection.  The caller must hold the read lock.
+func (impl *SimpleNoSQLDB) snapshot() bson.D {
+	snapshot := bson.D{}
+	for _, dbName := range sortedKeys(impl.collections) {
+		collections := bson.D{}
+		for _, collectionName := range sortedKeys(impl.collections[dbName]) {
+			items := impl.collections[dbName][collectionName].items
+			documents := make(bson.A, len(items))
+			for i, item := range items {
+				documents[i] = item
+			}
+			collections = append(collections, bson.E{Key: collectionName, Value: documents})
+		}
+		snapshot = append(snapshot, bson.E{Key: dbName, Value: collections})
+	}
+	return snapshot
+}
+
+// Replaces the contents of the database with the documents read from r, which are MongoDB
+// Extended JSON in the form written by [SimpleNoSQLDB.Snapshot].  Both the canonical and
+// relaxed forms of Extended JSON are accepted, so plain JSON documents can be used for fixtures.
+// Documents that do not have an _id are given a new ObjectID.
+//
+// Implements the snapshot.Snapshotter interface.
+func (impl *SimpleNoSQLDB) Restore(r io.Reader) error {
+	bytes, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	var snapshot bson.D
+	if err := bson.UnmarshalExtJSON(bytes, false, &snapshot); err != nil {
+		return err
+	}
+	return impl.restore(snapshot)
+}
+
+// Like [SimpleNoSQLDB.Restore], but the contents are read from r as a single BSON document
+// rather than Extended JSON.
+func (impl *SimpleNoSQLDB) RestoreBSON(r io.Reader) error {
+	bytes, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	var snapshot bson.D
+	if err := bson.Unmarshal(bytes, &snapshot); err != nil {
+		return err
+	}
+	return impl.restore(snapshot)
+}
+
+func (impl *SimpleNoSQLDB) restore(snapshot bson.D) error {
+	if err := impl.lockForWrite(context.Background()); err != nil {
+		return err
+	}
+	defer impl.mu.Unlock()
+	if impl.tx != nil {
+		return fmt.Errorf("cannot restore a snapshot during a transaction")
+	}
+
+	// Replace the contents of existing collections, which might have been returned to callers
+	for _, db := range impl.collections {
+		for _, collection := range db {
+			collection.items = nil
+			collection.reindex()
+		}
+	}
+	for _, db := range snapshot {
+		collections, isD := db.Value.(bson.D)
+		if !isD {
+			return fmt.Errorf("expected database %v to be a document of collections, got %v", db.Key, db.Value)
+		}
+		for _, c := range collections {
+			documents, isA := c.Value.(bson.A)
+			if !isA {
+				return fmt.Errorf("expected collection %v.%v to be an array of documents, got %v", db.Key, c.Key, c.Value)
+			}
+			collection := impl.collection(db.Key, c.Key)
+			for _, document := range documents {
+				d, isD := document.(bson.D)
+				if !isD {
+					return fmt.Errorf("expected collection %v.%v to be an array of documents, got %v", db.Key, c.Key, document)
+				}
+				if err := collection.insert(d); err != nil {
+					return fmt.Errorf("unable to restore collection %v.%v: %w", db.Key, c.Key, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Returns the keys of m in sorted order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,106 @@
+package simplenosqldb_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/simplenosqldb"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	ctx := context.Background()
+	db, err := simplenosqldb.NewSimpleNoSQLDB(ctx)
+	require.NoError(t, err)
+
+	coll, err := db.GetCollection(ctx, "shop", "teas")
+	require.NoError(t, err)
+	var docs []interface{}
+	for _, t := range teas {
+		docs = append(docs, t)
+	}
+	require.NoError(t, coll.InsertMany(ctx, docs))
+
+	var buf bytes.Buffer
+	require.NoError(t, db.Snapshot(&buf))
+
+	restored, err := simplenosqldb.NewSimpleNoSQLDB(ctx)
+	require.NoError(t, err)
+
+	// Collections obtained before restoring see the restored contents
+	restoredColl, err := restored.GetCollection(ctx, "shop", "teas")
+	require.NoError(t, err)
+	require.NoError(t, restoredColl.InsertOne(ctx, newtea))
+	require.NoError(t, restored.Restore(&buf))
+
+	var expected, got []Tea
+	cursor, err := coll.FindMany(ctx, bson.D{})
+	require.NoError(t, err)
+	require.NoError(t, cursor.All(ctx, &expected))
+	cursor, err = restoredColl.FindMany(ctx, bson.D{})
+	require.NoError(t, err)
+	require.NoError(t, cursor.All(ctx, &got))
+	require.Equal(t, expected, got)
+}
+
+func TestRestoreFixture(t *testing.T) {
+	ctx := context.Background()
+	db, err := simplenosqldb.NewSimpleNoSQLDB(ctx)
+	require.NoError(t, err)
+
+	fixture := `{
+		"shop": {
+			"teas": [
+				{"_id": {"$oid": "65f1a2b3c4d5e6f708192a3b"}, "type": "Masala", "rating": 10},
+				{"type": "Assam", "rating": 5}
+			],
+			"vendors": []
+		}
+	}`
+	require.NoError(t, db.Restore(strings.NewReader(fixture)))
+
+	coll, err := db.GetCollection(ctx, "shop", "teas")
+	require.NoError(t, err)
+	id, _ := primitive.ObjectIDFromHex("65f1a2b3c4d5e6f708192a3b")
+	cursor, err := coll.FindOne(ctx, bson.D{{"_id", id}})
+	require.NoError(t, err)
+	var tea Tea
+	found, err := cursor.One(ctx, &tea)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, "Masala", tea.Type)
+
+	// Documents without an _id are given one
+	cursor, err = coll.FindOne(ctx, bson.D{{"type", "Assam"}})
+	require.NoError(t, err)
+	var doc bson.M
+	found, err = cursor.One(ctx, &doc)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.IsType(t, primitive.ObjectID{}, doc["_id"])
+
+	require.Error(t, db.Restore(strings.NewReader(`{"shop": {"teas": {"type": "Masala"}}}`)))
+	require.Error(t, db.Restore(strings.NewReader(`{"shop": {"teas": [{"_id": 1}, {"_id": 1}]}}`)))
+}
+
+func TestRestoreBSON(t *testing.T) {
+	ctx := context.Background()
+	db, err := simplenosqldb.NewSimpleNoSQLDB(ctx)
+	require.NoError(t, err)
+
+	fixture, err := bson.Marshal(bson.D{{"shop", bson.D{{"teas", bson.A{teas[0], teas[1]}}}}})
+	require.NoError(t, err)
+	require.NoError(t, db.RestoreBSON(bytes.NewReader(fixture)))
+
+	coll, err := db.GetCollection(ctx, "shop", "teas")
+	require.NoError(t, err)
+	cursor, err := coll.FindMany(ctx, bson.D{})
+	require.NoError(t, err)
+	var got []Tea
+	require.NoError(t, cursor.All(ctx, &got))
+	require.Equal(t, teas[:2], got)
+}
@@ -0,0 +1,19 @@
+package simplenosqldb
+
+import (
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/simplenosqldb/query"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Compares doc to the sort key values decoded from a page token
+func compareToKeys(doc bson.D, values bson.D, keys bson.D) int {
+	for i, key := range keys {
+		if c := query.Compare(query.LookupField(doc, key.Key), values[i].Value); c != 0 {
+			if key.Value == int32(-1) {
+				return -c
+			}
+			return c
+		}
+	}
+	return 0
+}
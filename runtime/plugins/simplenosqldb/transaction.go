@@ -0,0 +1,114 @@
+package simplenosqldb
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// The state of an active transaction; the items of each collection before its first write, and the
+// changes to deliver to change streams when the transaction commits
+type simpleTransaction struct {
+	saved   map[*SimpleCollection][]bson.D
+	changes []simpleChange
+}
+
+// The context key of a transaction started by WithTransaction
+type txKey struct {
+	db *SimpleNoSQLDB
+}
+
+// Implements the [backend.NoSQLDatabase] interface.
+//
+// The first write to each collection within the transaction takes a copy of the collection's
+// documents, which are restored if fn returns an error or panics.  Transactions provide atomicity
+// but not isolation: other callers see uncommitted writes.  Indexes created during a transaction
+// are not removed by rollback.
+//
+// Only one transaction is active at a time; other transactions wait until it ends, as do writes
+// made outside of the transaction, so that rollback cannot discard them.  Writes within fn must
+// therefore use the context passed to fn; a write made with another context, e.g. the ctx passed to
+// WithTransaction, waits until that context is done and then returns its error.
+func (impl *SimpleNoSQLDB) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	impl.mu.RLock()
+	joined := impl.inTransaction(ctx)
+	impl.mu.RUnlock()
+	if joined {
+		return fn(ctx)
+	}
+
+	if err := impl.lockForWrite(ctx); err != nil {
+		return err
+	}
+	tx := &simpleTransaction{saved: make(map[*SimpleCollection][]bson.D)}
+	impl.tx = tx
+	impl.txDone = make(chan struct{})
+	impl.mu.Unlock()
+
+	defer func() {
+		impl.mu.Lock()
+		defer impl.mu.Unlock()
+		impl.tx = nil
+		close(impl.txDone)
+		if r := recover(); r != nil {
+			tx.rollback()
+			panic(r)
+		}
+	}()
+
+	err := fn(context.WithValue(ctx, txKey{impl}, tx))
+	impl.mu.Lock()
+	defer impl.mu.Unlock()
+	if err != nil {
+		tx.rollback()
+		return err
+	}
+	for _, change := range tx.changes {
+		change.deliver()
+	}
+	return nil
+}
+
+// Reports whether ctx belongs to the active transaction.  The caller must hold the read or write lock.
+func (impl *SimpleNoSQLDB) inTransaction(ctx context.Context) bool {
+	tx, isTx := ctx.Value(txKey{impl}).(*simpleTransaction)
+	return isTx && tx == impl.tx
+}
+
+// Acquires the write lock for a write made with ctx.  While a transaction is active, writes that are
+// not part of it wait until it ends.  Returns ctx's error, without the lock, if ctx is done first.
+func (impl *SimpleNoSQLDB) lockForWrite(ctx context.Context) error {
+	for {
+		impl.mu.Lock()
+		if impl.tx == nil || impl.inTransaction(ctx) {
+			return nil
+		}
+		done := impl.txDone
+		impl.mu.Unlock()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Restores the saved items of every collection that was written during the transaction.
+// The caller must hold the write lock.
+func (tx *simpleTransaction) rollback() {
+	for collection, items := range tx.saved {
+		collection.items = items
+		collection.reindex()
+	}
+}
+
+// Saves a copy of the collection's items, if this is its first write in an active transaction
+func (db *SimpleCollection) beforeWrite() {
+	if db.owner == nil || db.owner.tx == nil {
+		return
+	}
+	if _, saved := db.owner.tx.saved[db]; saved {
+		return
+	}
+	db.owner.tx.saved[db] = append([]bson.D(nil), db.items...)
+}
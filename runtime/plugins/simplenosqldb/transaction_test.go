@@ -0,0 +1,166 @@
+package simplenosqldb_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Returns a database and a collection within it, populated with teas
+func makeTransactionDB(t *testing.T) (context.Context, backend.NoSQLDatabase, backend.NoSQLCollection) {
+	ctx, db := getDB(t)
+	coll, err := db.GetCollection(ctx, "testdb", fmt.Sprintf("testcollection%v", collectionid))
+	collectionid += 1
+	require.NoError(t, err)
+
+	var docs []interface{}
+	for _, t := range teas {
+		docs = append(docs, t)
+	}
+	require.NoError(t, coll.InsertMany(ctx, docs))
+	return ctx, db, coll
+}
+
+func TestTransactionCommit(t *testing.T) {
+	ctx, db, coll := makeTransactionDB(t)
+
+	err := db.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := coll.InsertOne(ctx, Tea{Type: "Chai", Rating: 3}); err != nil {
+			return err
+		}
+		_, err := coll.UpdateOne(ctx, bson.D{{"type", "Assam"}}, bson.D{{"$set", bson.D{{"rating", 9}}}})
+		return err
+	})
+	require.NoError(t, err)
+
+	found := findTeas(t, coll, bson.D{{"rating", bson.D{{"$gte", 9}}}}, backend.FindOptions{})
+	require.ElementsMatch(t, []string{"Masala", "Assam"}, teaTypes(found))
+	require.Len(t, findTeas(t, coll, bson.D{}, backend.FindOptions{}), len(teas)+1)
+}
+
+func TestTransactionRollback(t *testing.T) {
+	ctx, db, coll := makeTransactionDB(t)
+
+	failure := errors.New("failure")
+	err := db.WithTransaction(ctx, func(ctx context.Context) error {
+		require.NoError(t, coll.InsertOne(ctx, Tea{Type: "Chai", Rating: 3}))
+		_, err := coll.UpdateMany(ctx, bson.D{}, bson.D{{"$inc", bson.D{{"rating", 1}}}})
+		require.NoError(t, err)
+		require.NoError(t, coll.DeleteOne(ctx, bson.D{{"type", "Oolong"}}))
+		require.NoError(t, coll.DeleteMany(ctx, bson.D{{"rating", bson.D{{"$lt", 8}}}}))
+		_, err = coll.ReplaceOne(ctx, bson.D{{"type", "Masala"}}, Tea{Type: "Rooibos"})
+		require.NoError(t, err)
+		return failure
+	})
+	require.ErrorIs(t, err, failure)
+
+	require.Equal(t, teas, findTeas(t, coll, bson.D{}, backend.FindOptions{}))
+
+	// Indexes are consistent with the restored documents
+	found := findTeas(t, coll, bson.D{{"type", "Oolong"}}, backend.FindOptions{})
+	require.Equal(t, []Tea{teas[2]}, found)
+}
+
+func TestTransactionRollbackOnPanic(t *testing.T) {
+	ctx, db, coll := makeTransactionDB(t)
+
+	require.Panics(t, func() {
+		db.WithTransaction(ctx, func(ctx context.Context) error {
+			require.NoError(t, coll.InsertOne(ctx, Tea{Type: "Chai", Rating: 3}))
+			panic("failure")
+		})
+	})
+	require.Equal(t, teas, findTeas(t, coll, bson.D{}, backend.FindOptions{}))
+}
+
+func TestNestedTransaction(t *testing.T) {
+	ctx, db, coll := makeTransactionDB(t)
+
+	failure := errors.New("failure")
+	err := db.WithTransaction(ctx, func(ctx context.Context) error {
+		err := db.WithTransaction(ctx, func(ctx context.Context) error {
+			return coll.InsertOne(ctx, Tea{Type: "Chai", Rating: 3})
+		})
+		require.NoError(t, err)
+		return failure
+	})
+	require.ErrorIs(t, err, failure)
+
+	// The nested transaction joined the enclosing transaction, so its insert is rolled back
+	require.Equal(t, teas, findTeas(t, coll, bson.D{}, backend.FindOptions{}))
+}
+
+func TestConcurrentTransactions(t *testing.T) {
+	ctx, db, coll := makeTransactionDB(t)
+
+	// Concurrent transactions wait for each other rather than failing
+	workers := 10
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- db.WithTransaction(ctx, func(ctx context.Context) error {
+				_, err := coll.UpdateOne(ctx, bson.D{{"type", "Masala"}}, bson.D{{"$inc", bson.D{{"rating", 1}}}})
+				return err
+			})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	found := findTeas(t, coll, bson.D{{"type", "Masala"}}, backend.FindOptions{})
+	require.Len(t, found, 1)
+	require.Equal(t, teas[0].Rating+workers, found[0].Rating)
+}
+
+func TestRollbackKeepsWritesOutsideTransaction(t *testing.T) {
+	ctx, db, coll := makeTransactionDB(t)
+
+	failure := errors.New("failure")
+	written := make(chan error)
+	err := db.WithTransaction(ctx, func(txCtx context.Context) error {
+		require.NoError(t, coll.InsertOne(txCtx, Tea{Type: "Chai", Rating: 3}))
+
+		// A write outside of the transaction waits until the transaction ends
+		go func() { written <- coll.InsertOne(ctx, Tea{Type: "Rooibos", Rating: 4}) }()
+		select {
+		case <-written:
+			t.Fatal("write outside of the transaction did not wait for it")
+		case <-time.After(50 * time.Millisecond):
+		}
+		return failure
+	})
+	require.ErrorIs(t, err, failure)
+	require.NoError(t, <-written)
+
+	require.Equal(t, append(append([]Tea(nil), teas...), Tea{Type: "Rooibos", Rating: 4}), findTeas(t, coll, bson.D{}, backend.FindOptions{}))
+}
+
+func TestWriteWithOuterContextInTransaction(t *testing.T) {
+	ctx, db, coll := makeTransactionDB(t)
+
+	// Writes and transactions within fn that don't use fn's context wait for the transaction to end,
+	// which it can't until fn returns, so they fail once their context is done
+	err := db.WithTransaction(ctx, func(txCtx context.Context) error {
+		outerCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		require.ErrorIs(t, coll.InsertOne(outerCtx, Tea{Type: "Rooibos", Rating: 4}), context.DeadlineExceeded)
+		require.ErrorIs(t, db.WithTransaction(outerCtx, func(ctx context.Context) error { return nil }), context.DeadlineExceeded)
+		return coll.InsertOne(txCtx, Tea{Type: "Chai", Rating: 3})
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, append(append([]Tea(nil), teas...), Tea{Type: "Chai", Rating: 3}), findTeas(t, coll, bson.D{}, backend.FindOptions{}))
+}
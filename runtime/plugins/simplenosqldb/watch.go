@@ -0,0 +1,138 @@
+package simplenosqldb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/simplenosqldb/query"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// A stream of changes to a [SimpleCollection], returned by [SimpleCollection.Watch].
+//
+// Changes are buffered in memory until they are received, so a stream that is not read grows without bound.
+type SimpleChangeStream struct {
+	collection *SimpleCollection
+	filter     query.Filter
+
+	mu      sync.Mutex
+	events  []backend.NoSQLChangeEvent
+	changed chan struct{} // Closed and replaced when an event is added or the stream is closed
+	closed  bool
+}
+
+// A change to a collection, before it is delivered to the collection's streams
+type simpleChange struct {
+	collection *SimpleCollection
+	changeType backend.NoSQLChangeType
+	document   bson.D // The document after the change, or before a delete
+}
+
+// Implements the [backend.NoSQLCollection] interface.
+//
+// Filters support the same operators as FindMany.
+func (db *SimpleCollection) Watch(ctx context.Context, filter bson.D) (backend.NoSQLChangeStream, error) {
+	db.owner.mu.Lock()
+	defer db.owner.mu.Unlock()
+	filterOp, err := query.ParseFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	stream := &SimpleChangeStream{collection: db, filter: filterOp, changed: make(chan struct{})}
+	db.streams = append(db.streams, stream)
+	return stream, nil
+}
+
+// Records a change to document.  If a transaction is active, the change is delivered when the transaction
+// commits; otherwise it is delivered immediately.  The caller must hold the write lock.
+func (db *SimpleCollection) notify(changeType backend.NoSQLChangeType, document bson.D) {
+	if len(db.streams) == 0 {
+		return
+	}
+	change := simpleChange{collection: db, changeType: changeType, document: document}
+	if db.owner != nil && db.owner.tx != nil {
+		db.owner.tx.changes = append(db.owner.tx.changes, change)
+	} else {
+		change.deliver()
+	}
+}
+
+// Delivers the change to the streams of its collection whose filter matches the document.
+// The caller must hold the write lock.
+func (change simpleChange) deliver() {
+	raw, err := bson.Marshal(change.document)
+	if err != nil {
+		// Documents in the collection were converted from bson, so this should not happen
+		panic(fmt.Sprintf("unable to marshal changed document %v: %v", change.document, err))
+	}
+	event := backend.NoSQLChangeEvent{Type: change.changeType}
+	if err := bson.Raw(raw).Lookup("_id").Unmarshal(&event.DocumentID); err != nil {
+		panic(fmt.Sprintf("unable to unmarshal _id of changed document %v: %v", change.document, err))
+	}
+	if change.changeType != backend.NoSQLDelete {
+		event.Document = raw
+	}
+	for _, stream := range change.collection.streams {
+		if change.changeType == backend.NoSQLDelete || stream.filter.Apply(change.document) {
+			stream.add(event)
+		}
+	}
+}
+
+func (stream *SimpleChangeStream) add(event backend.NoSQLChangeEvent) {
+	stream.mu.Lock()
+	defer stream.mu.Unlock()
+	stream.events = append(stream.events, event)
+	close(stream.changed)
+	stream.changed = make(chan struct{})
+}
+
+// Implements the [backend.NoSQLChangeStream] interface
+func (stream *SimpleChangeStream) Next(ctx context.Context) (backend.NoSQLChangeEvent, bool, error) {
+	for {
+		stream.mu.Lock()
+		if stream.closed {
+			stream.mu.Unlock()
+			return backend.NoSQLChangeEvent{}, false, errors.New("change stream is closed")
+		}
+		if len(stream.events) > 0 {
+			event := stream.events[0]
+			stream.events = stream.events[1:]
+			stream.mu.Unlock()
+			return event, true, nil
+		}
+		changed := stream.changed
+		stream.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return backend.NoSQLChangeEvent{}, false, nil
+		case <-changed:
+		}
+	}
+}
+
+// Implements the [backend.NoSQLChangeStream] interface
+func (stream *SimpleChangeStream) Close(ctx context.Context) error {
+	db := stream.collection
+	db.owner.mu.Lock()
+	defer db.owner.mu.Unlock()
+	for i, s := range db.streams {
+		if s == stream {
+			db.streams = append(db.streams[:i:i], db.streams[i+1:]...)
+			break
+		}
+	}
+
+	stream.mu.Lock()
+	defer stream.mu.Unlock()
+	if !stream.closed {
+		stream.closed = true
+		stream.events = nil
+		close(stream.changed)
+	}
+	return nil
+}
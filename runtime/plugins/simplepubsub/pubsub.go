@@ -0,0 +1,140 @@
+// Package simplepubsub implements a simple in-memory [backend.PubSub].
+//
+// Each subscriber group is backed by a [simplequeue.SimpleQueue] with capacity 1000.  Calls to
+// [backend.PubSub.Publish] block while a matching subscriber group has 1000 undelivered items.
+package simplepubsub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/simplequeue"
+)
+
+// The capacity of each subscriber group's queue
+const groupCapacity = 1000
+
+// Returned by [backend.Subscription.Receive] after the subscription has been closed
+var errClosed = errors.New("subscription closed")
+
+// A simple in-memory pubsub that implements the [backend.PubSub] interface
+type SimplePubSub struct {
+	backend.PubSub
+
+	mu        sync.Mutex
+	groups    map[string]*group   // Named subscriber groups
+	anonymous map[*group]struct{} // Subscriber groups of open anonymous subscriptions
+}
+
+type group struct {
+	queue    *simplequeue.SimpleQueue
+	patterns []string
+}
+
+// Instantiates an in-memory [SimplePubSub]
+func NewSimplePubSub(ctx context.Context) (*SimplePubSub, error) {
+	return &SimplePubSub{
+		groups:    make(map[string]*group),
+		anonymous: make(map[*group]struct{}),
+	}, nil
+}
+
+// Publish implements backend.PubSub
+func (ps *SimplePubSub) Publish(ctx context.Context, topic string, item interface{}) error {
+	ps.mu.Lock()
+	var matched []*group
+	for _, g := range ps.groups {
+		if g.matches(topic) {
+			matched = append(matched, g)
+		}
+	}
+	for g := range ps.anonymous {
+		if g.matches(topic) {
+			matched = append(matched, g)
+		}
+	}
+	ps.mu.Unlock()
+
+	for _, g := range matched {
+		pushed, err := g.queue.Push(ctx, item)
+		if err != nil {
+			return err
+		} else if !pushed {
+			return fmt.Errorf("unable to publish to %v: %w", topic, ctx.Err())
+		}
+	}
+	return nil
+}
+
+// Subscribe implements backend.PubSub
+func (ps *SimplePubSub) Subscribe(ctx context.Context, pattern string, groupName string) (backend.Subscription, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("invalid empty topic pattern")
+	}
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	g, exists := ps.groups[groupName]
+	if groupName == "" || !exists {
+		queue, err := simplequeue.NewSimpleQueueWithOptions(simplequeue.Options{Capacity: groupCapacity})
+		if err != nil {
+			return nil, err
+		}
+		g = &group{queue: queue}
+		if groupName == "" {
+			ps.anonymous[g] = struct{}{}
+		} else {
+			ps.groups[groupName] = g
+		}
+	}
+	if !slices.Contains(g.patterns, pattern) {
+		g.patterns = append(g.patterns, pattern)
+	}
+	return &simpleSubscription{ps: ps, g: g, anonymous: groupName == ""}, nil
+}
+
+// Reports whether any of the group's patterns match topic.  The caller must hold the pubsub's lock.
+func (g *group) matches(topic string) bool {
+	return slices.ContainsFunc(g.patterns, func(pattern string) bool {
+		return backend.MatchTopic(pattern, topic)
+	})
+}
+
+// Implements the [backend.Subscription] interface for a [SimplePubSub]
+type simpleSubscription struct {
+	ps        *SimplePubSub
+	g         *group
+	anonymous bool
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// Receive implements backend.Subscription
+func (s *simpleSubscription) Receive(ctx context.Context) (backend.Delivery, bool, error) {
+	s.mu.Lock()
+	closed := s.closed
+	s.mu.Unlock()
+	if closed {
+		return nil, false, errClosed
+	}
+	return s.g.queue.Receive(ctx)
+}
+
+// Close implements backend.Subscription
+func (s *simpleSubscription) Close(ctx context.Context) error {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+
+	if s.anonymous {
+		s.ps.mu.Lock()
+		delete(s.ps.anonymous, s.g)
+		s.ps.mu.Unlock()
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package simplepubsub
+
+import (
+	"context"
+	"testing"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"github.com/stretchr/testify/require"
+)
+
+// Receives and acknowledges all available items from sub
+func receiveAll(t *testing.T, sub backend.Subscription) []string {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var items []string
+	for {
+		d, received, err := sub.Receive(ctx)
+		require.NoError(t, err)
+		if !received {
+			return items
+		}
+		var item string
+		require.NoError(t, d.Decode(&item))
+		require.NoError(t, d.Ack(ctx))
+		items = append(items, item)
+	}
+}
+
+func TestFanOut(t *testing.T) {
+	ctx := context.Background()
+	ps, err := NewSimplePubSub(ctx)
+	require.NoError(t, err)
+
+	timeline, err := ps.Subscribe(ctx, "post.created", "timeline")
+	require.NoError(t, err)
+	search, err := ps.Subscribe(ctx, "post.created", "search")
+	require.NoError(t, err)
+
+	require.NoError(t, ps.Publish(ctx, "post.created", "hello"))
+	require.NoError(t, ps.Publish(ctx, "post.created", "world"))
+
+	// Each group receives every item
+	require.Equal(t, []string{"hello", "world"}, receiveAll(t, timeline))
+	require.Equal(t, []string{"hello", "world"}, receiveAll(t, search))
+}
+
+func TestGroupMembersShareItems(t *testing.T) {
+	ctx := context.Background()
+	ps, err := NewSimplePubSub(ctx)
+	require.NoError(t, err)
+
+	first, err := ps.Subscribe(ctx, "post.created", "timeline")
+	require.NoError(t, err)
+	second, err := ps.Subscribe(ctx, "post.created", "timeline")
+	require.NoError(t, err)
+
+	require.NoError(t, ps.Publish(ctx, "post.created", "hello"))
+	require.NoError(t, ps.Publish(ctx, "post.created", "world"))
+
+	require.Equal(t, []string{"hello"}, receiveAll(t, first)[:1])
+	require.Empty(t, receiveAll(t, second))
+}
+
+func TestTopicPatterns(t *testing.T) {
+	ctx := context.Background()
+	ps, err := NewSimplePubSub(ctx)
+	require.NoError(t, err)
+
+	star, err := ps.Subscribe(ctx, "post.*", "star")
+	require.NoError(t, err)
+	hash, err := ps.Subscribe(ctx, "post.#", "hash")
+	require.NoError(t, err)
+	multi, err := ps.Subscribe(ctx, "*.deleted", "multi")
+	require.NoError(t, err)
+	_, err = ps.Subscribe(ctx, "post.deleted", "multi")
+	require.NoError(t, err)
+
+	for _, topic := range []string{"post", "post.created", "post.deleted", "post.comment.created", "user.deleted"} {
+		require.NoError(t, ps.Publish(ctx, topic, topic))
+	}
+
+	require.Equal(t, []string{"post.created", "post.deleted"}, receiveAll(t, star))
+	require.Equal(t, []string{"post", "post.created", "post.deleted", "post.comment.created"}, receiveAll(t, hash))
+
+	// An item that matches more than one pattern of a group is delivered once
+	require.Equal(t, []string{"post.deleted", "user.deleted"}, receiveAll(t, multi))
+}
+
+func TestGroupAccumulatesItems(t *testing.T) {
+	ctx := context.Background()
+	ps, err := NewSimplePubSub(ctx)
+	require.NoError(t, err)
+
+	// Items published before a group exists are not delivered to it
+	require.NoError(t, ps.Publish(ctx, "post.created", "before"))
+
+	sub, err := ps.Subscribe(ctx, "post.created", "timeline")
+	require.NoError(t, err)
+	require.NoError(t, sub.Close(ctx))
+	_, _, err = sub.Receive(ctx)
+	require.Error(t, err)
+
+	// The group accumulates items while it has no open subscriptions
+	require.NoError(t, ps.Publish(ctx, "post.created", "after"))
+	sub, err = ps.Subscribe(ctx, "post.created", "timeline")
+	require.NoError(t, err)
+	require.Equal(t, []string{"after"}, receiveAll(t, sub))
+}
+
+func TestAnonymousSubscription(t *testing.T) {
+	ctx := context.Background()
+	ps, err := NewSimplePubSub(ctx)
+	require.NoError(t, err)
+
+	first, err := ps.Subscribe(ctx, "post.created", "")
+	require.NoError(t, err)
+	second, err := ps.Subscribe(ctx, "post.created", "")
+	require.NoError(t, err)
+
+	require.NoError(t, ps.Publish(ctx, "post.created", "hello"))
+	require.Equal(t, []string{"hello"}, receiveAll(t, first))
+	require.Equal(t, []string{"hello"}, receiveAll(t, second))
+
+	require.NoError(t, first.Close(ctx))
+	require.Empty(t, ps.anonymous[first.(*simpleSubscription).g])
+	require.Len(t, ps.anonymous, 1)
+}
+
+func TestNackRedelivers(t *testing.T) {
+	ctx := context.Background()
+	ps, err := NewSimplePubSub(ctx)
+	require.NoError(t, err)
+
+	sub, err := ps.Subscribe(ctx, "post.created", "timeline")
+	require.NoError(t, err)
+	require.NoError(t, ps.Publish(ctx, "post.created", "hello"))
+
+	d, received, err := sub.Receive(ctx)
+	require.NoError(t, err)
+	require.True(t, received)
+	require.NoError(t, d.Nack(ctx, true))
+
+	d, received, err = sub.Receive(ctx)
+	require.NoError(t, err)
+	require.True(t, received)
+	require.Equal(t, 2, d.DeliveryCount())
+	require.NoError(t, d.Ack(ctx))
+}
@@ -1,19 +1,71 @@
-// Package simplequeue implements an simple in-memory [backend.Queue] that internally
-// uses a golang channel of capacity 10 for passing items from producer to consumer.
+// Package simplequeue implements an simple in-memory [backend.Queue].
 //
-// Calls to [backend.Queue.Push] will block once the queue capacity reaches 10.
+// By default the queue has capacity 10, and calls to [backend.Queue.Push] will block once the
+// queue capacity reaches 10.  [NewSimpleQueueWithOptions] can be used to configure the capacity,
+// the delivery limit and dead-letter queue, and the acknowledgement timeout of a queue.
+//
+// The contents of a queue can be saved with [SimpleQueue.Snapshot] and restored with [SimpleQueue.Restore],
+// e.g. to pre-populate the queue from a fixture file.  A snapshot is a JSON array of items, in delivery order:
+//
+//	[
+//	  {"item": {"order_id": "1"}},
+//	  {"item": {"order_id": "2"}, "headers": {"source": "fixture"}, "deliver_at": "2024-05-01T12:00:00Z"}
+//	]
+//
+// Items are stored in a snapshot as JSON, so they must be JSON-serializable.
 package simplequeue
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
 )
 
-// A simple chan-based queue that implements the [backend.Queue] interface
+// Options for a [SimpleQueue]
+type Options struct {
+	// The maximum number of items waiting in the queue; Push blocks while the queue is full.
+	// Items that have been received but not yet acknowledged do not count towards the capacity.
+	// Defaults to 10.
+	Capacity int
+
+	// The maximum number of times an item is delivered by Receive.  An item that is negatively
+	// acknowledged on its final delivery is moved to the DeadLetter queue.  Zero means unlimited.
+	MaxDeliveries int
+
+	// Receives items that are rejected, or that reach MaxDeliveries.  If nil, such items are discarded.
+	DeadLetter backend.Queue
+
+	// If nonzero, an item that is not acknowledged within AckTimeout of being received is
+	// redelivered, as if the consumer had failed.  Zero means items are never redelivered
+	// unless they are negatively acknowledged.
+	AckTimeout time.Duration
+}
+
+// A simple in-memory queue that implements the [backend.Queue] interface
 type SimpleQueue struct {
 	backend.Queue
-	q chan any
+	opts Options
+
+	mu      sync.Mutex
+	ready   []*message            // Items that can be delivered, in order
+	delayed []*message            // Items pushed with a delay, ordered by deliverAt
+	unacked map[*message]struct{} // Items that have been delivered but not acknowledged
+	changed chan struct{}         // Closed and replaced whenever the contents of the queue change
+}
+
+type message struct {
+	item        any
+	headers     map[string]string
+	deliverAt   time.Time
+	deliveries  int
+	ackDeadline time.Time       // Only set if the queue has an AckTimeout
+	delivery    *simpleDelivery // The outstanding delivery of the item, if any
 }
 
 // Instantiates a [backend.Queue] that internally uses a golang channel of capacity 10.
@@ -23,43 +75,305 @@ func NewSimpleQueue(ctx context.Context) (q *SimpleQueue, err error) {
 	return newSimpleQueueWithCapacity(10), nil
 }
 
+// Instantiates a [SimpleQueue] with the specified options.
+func NewSimpleQueueWithOptions(opts Options) (*SimpleQueue, error) {
+	if opts.Capacity == 0 {
+		opts.Capacity = 10
+	}
+	if opts.Capacity < 0 || opts.MaxDeliveries < 0 || opts.AckTimeout < 0 {
+		return nil, fmt.Errorf("invalid queue options %+v", opts)
+	}
+	return &SimpleQueue{
+		opts:    opts,
+		unacked: make(map[*message]struct{}),
+		changed: make(chan struct{}),
+	}, nil
+}
+
 // Instantiates a [simpleQueue] with the specified capacity.
 func newSimpleQueueWithCapacity(capacity int) *SimpleQueue {
-	return &SimpleQueue{
-		q: make(chan any, capacity),
-	}
+	q, _ := NewSimpleQueueWithOptions(Options{Capacity: capacity})
+	return q
 }
 
-// Pop implements backend.Queue.
-func (q *SimpleQueue) Pop(ctx context.Context, dst interface{}) (bool, error) {
+// Wakes up any callers blocked in wait.  The caller must hold q.mu.
+func (q *SimpleQueue) notify() {
+	close(q.changed)
+	q.changed = make(chan struct{})
+}
+
+// Releases q.mu until the queue changes, until deadline if it is nonzero, or until ctx is done.
+// Reports false if ctx is done.  The caller must hold q.mu.
+func (q *SimpleQueue) wait(ctx context.Context, deadline time.Time) bool {
+	changed := q.changed
+	var timeout <-chan time.Time
+	if !deadline.IsZero() {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	q.mu.Unlock()
+	defer q.mu.Lock()
 	select {
-	case v := <-q.q:
-		return true, backend.CopyResult(v, dst)
-	default:
-		{
-			select {
-			case v := <-q.q:
-				return true, backend.CopyResult(v, dst)
-			case <-ctx.Done():
-				return false, nil
+	case <-changed:
+		return true
+	case <-timeout:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Moves delayed items that are due to the ready queue, and redelivers items whose acknowledgement
+// timed out.  Returns the time of the next scheduled change, or zero if there is none.
+// The caller must hold q.mu.
+func (q *SimpleQueue) update(now time.Time) time.Time {
+	due := sort.Search(len(q.delayed), func(i int) bool { return q.delayed[i].deliverAt.After(now) })
+	if due > 0 {
+		q.ready = append(q.ready, q.delayed[:due]...)
+		q.delayed = q.delayed[due:]
+	}
+
+	var next time.Time
+	if len(q.delayed) > 0 {
+		next = q.delayed[0].deliverAt
+	}
+	for m := range q.unacked {
+		if m.ackDeadline.IsZero() {
+			continue
+		}
+		if m.ackDeadline.After(now) {
+			if next.IsZero() || m.ackDeadline.Before(next) {
+				next = m.ackDeadline
 			}
+			continue
+		}
+		if q.release(m, true) {
+			go q.deadLetter(context.Background(), m)
 		}
 	}
+	return next
+}
+
+// Releases the outstanding delivery of m.  If requeue is true and m has not reached the delivery
+// limit, m is returned to the front of the queue.  Otherwise, reports true; the caller should move
+// m to the dead-letter queue.  The caller must hold q.mu.
+func (q *SimpleQueue) release(m *message, requeue bool) bool {
+	m.delivery = nil
+	delete(q.unacked, m)
+	if !requeue || (q.opts.MaxDeliveries > 0 && m.deliveries >= q.opts.MaxDeliveries) {
+		return true
+	}
+	q.ready = append([]*message{m}, q.ready...)
+	q.notify()
+	return false
+}
+
+// Pushes m to the dead-letter queue, if there is one.  Must not be called while holding q.mu.
+func (q *SimpleQueue) deadLetter(ctx context.Context, m *message) error {
+	if q.opts.DeadLetter == nil {
+		return nil
+	}
+	pushed, err := q.opts.DeadLetter.PushWithOptions(ctx, m.item, backend.PushOptions{Headers: m.headers})
+	if err == nil && !pushed {
+		return fmt.Errorf("unable to push item to the dead-letter queue: %w", ctx.Err())
+	}
+	return err
+}
+
+// Pop implements backend.Queue.
+func (q *SimpleQueue) Pop(ctx context.Context, dst interface{}) (bool, error) {
+	d, received, err := q.Receive(ctx)
+	if !received || err != nil {
+		return received, err
+	}
+	if err := d.Ack(ctx); err != nil {
+		return true, err
+	}
+	return true, d.Decode(dst)
 }
 
 // Push implements backend.Queue.
 func (q *SimpleQueue) Push(ctx context.Context, item interface{}) (bool, error) {
-	select {
-	case q.q <- item:
-		return true, nil
-	default:
-		{
-			select {
-			case q.q <- item:
-				return true, nil
-			case <-ctx.Done():
-				return false, nil
+	return q.PushWithOptions(ctx, item, backend.PushOptions{})
+}
+
+// PushWithOptions implements backend.Queue.
+func (q *SimpleQueue) PushWithOptions(ctx context.Context, item interface{}, opts backend.PushOptions) (bool, error) {
+	m := &message{item: item, headers: opts.Headers}
+	if opts.Delay > 0 {
+		m.deliverAt = time.Now().Add(opts.Delay)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.ready)+len(q.delayed) >= q.opts.Capacity {
+		if !q.wait(ctx, time.Time{}) {
+			return false, nil
+		}
+	}
+
+	if m.deliverAt.IsZero() {
+		q.ready = append(q.ready, m)
+	} else {
+		i := sort.Search(len(q.delayed), func(i int) bool { return q.delayed[i].deliverAt.After(m.deliverAt) })
+		q.delayed = append(q.delayed, nil)
+		copy(q.delayed[i+1:], q.delayed[i:])
+		q.delayed[i] = m
+	}
+	q.notify()
+	return true, nil
+}
+
+// Receive implements backend.Queue.
+func (q *SimpleQueue) Receive(ctx context.Context) (backend.Delivery, bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for {
+		now := time.Now()
+		next := q.update(now)
+		if len(q.ready) > 0 {
+			m := q.ready[0]
+			q.ready = q.ready[1:]
+			m.deliveries++
+			m.delivery = &simpleDelivery{q: q, m: m, count: m.deliveries}
+			if q.opts.AckTimeout > 0 {
+				m.ackDeadline = now.Add(q.opts.AckTimeout)
 			}
+			q.unacked[m] = struct{}{}
+			q.notify()
+			return m.delivery, true, nil
+		}
+		if !q.wait(ctx, next) {
+			return nil, false, nil
+		}
+	}
+}
+
+// Implements the [backend.Delivery] interface for items received from a [SimpleQueue]
+type simpleDelivery struct {
+	q     *SimpleQueue
+	m     *message
+	count int
+}
+
+// Decode implements backend.Delivery.
+func (d *simpleDelivery) Decode(dst interface{}) error {
+	if raw, isRaw := d.m.item.(json.RawMessage); isRaw {
+		// Restored items are JSON
+		return json.Unmarshal(raw, dst)
+	}
+	return backend.CopyResult(d.m.item, dst)
+}
+
+// Headers implements backend.Delivery.
+func (d *simpleDelivery) Headers() map[string]string {
+	return d.m.headers
+}
+
+// DeliveryCount implements backend.Delivery.
+func (d *simpleDelivery) DeliveryCount() int {
+	return d.count
+}
+
+// Ack implements backend.Delivery.
+func (d *simpleDelivery) Ack(ctx context.Context) error {
+	d.q.mu.Lock()
+	defer d.q.mu.Unlock()
+	if d.m.delivery != d {
+		return backend.ErrDeliveryClosed
+	}
+	d.m.delivery = nil
+	delete(d.q.unacked, d.m)
+	return nil
+}
+
+// Nack implements backend.Delivery.
+func (d *simpleDelivery) Nack(ctx context.Context, requeue bool) error {
+	d.q.mu.Lock()
+	if d.m.delivery != d {
+		d.q.mu.Unlock()
+		return backend.ErrDeliveryClosed
+	}
+	deadLetter := d.q.release(d.m, requeue)
+	d.q.mu.Unlock()
+
+	if deadLetter {
+		return d.q.deadLetter(ctx, d.m)
+	}
+	return nil
+}
+
+// An item of a snapshot
+type snapshotItem struct {
+	Item      json.RawMessage   `json:"item"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	DeliverAt *time.Time        `json:"deliver_at,omitempty"`
+}
+
+// Writes the items of the queue to w as JSON, in delivery order.  Items that have been received
+// but not acknowledged are included first, since they would be redelivered.
+//
+// Implements the snapshot.Snapshotter interface.
+func (q *SimpleQueue) Snapshot(w io.Writer) error {
+	q.mu.Lock()
+	var unacked []*message
+	for m := range q.unacked {
+		unacked = append(unacked, m)
+	}
+	sort.SliceStable(unacked, func(i, j int) bool { return unacked[i].ackDeadline.Before(unacked[j].ackDeadline) })
+	messages := append(append(unacked, q.ready...), q.delayed...)
+	q.mu.Unlock()
+
+	snapshot := make([]snapshotItem, 0, len(messages))
+	for _, m := range messages {
+		item, err := json.Marshal(m.item)
+		if err != nil {
+			return fmt.Errorf("unable to snapshot queue item: %w", err)
+		}
+		s := snapshotItem{Item: item, Headers: m.headers}
+		if !m.deliverAt.IsZero() {
+			s.DeliverAt = &m.deliverAt
 		}
+		snapshot = append(snapshot, s)
+	}
+	return json.NewEncoder(w).Encode(snapshot)
+}
+
+// Replaces the contents of the queue with the JSON items read from r.  Outstanding deliveries
+// are closed.  Items are restored even if they exceed the queue's capacity, in which case Push
+// blocks until enough items have been received.
+//
+// Implements the snapshot.Snapshotter interface.
+func (q *SimpleQueue) Restore(r io.Reader) error {
+	var snapshot []snapshotItem
+	if err := json.NewDecoder(r).Decode(&snapshot); err != nil {
+		return err
+	}
+	var ready, delayed []*message
+	for _, s := range snapshot {
+		if s.Item == nil {
+			return fmt.Errorf("queue snapshot contains an item with no value")
+		}
+		m := &message{item: s.Item, headers: s.Headers}
+		if s.DeliverAt != nil {
+			m.deliverAt = *s.DeliverAt
+			delayed = append(delayed, m)
+		} else {
+			ready = append(ready, m)
+		}
+	}
+	sort.SliceStable(delayed, func(i, j int) bool { return delayed[i].deliverAt.Before(delayed[j].deliverAt) })
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for m := range q.unacked {
+		m.delivery = nil
 	}
+	q.ready = ready
+	q.delayed = delayed
+	q.unacked = make(map[*message]struct{})
+	q.notify()
+	return nil
 }
@@ -1,14 +1,26 @@
 package simplequeue
 
 import (
+	"bytes"
 	"context"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend/backendtest"
 	"github.com/stretchr/testify/require"
 )
 
+func TestConformance(t *testing.T) {
+	backendtest.RunQueueSuite(t, func(t *testing.T) backend.Queue {
+		q, err := NewSimpleQueue(context.Background())
+		require.NoError(t, err)
+		return q
+	})
+}
+
 func TestPushPop(t *testing.T) {
 	ctx := context.Background()
 
@@ -248,3 +260,234 @@ func TestTryTimeout(t *testing.T) {
 		require.Equal(t, second, rcv)
 	}
 }
+
+func receive(t *testing.T, q *SimpleQueue, timeout time.Duration) (backend.Delivery, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	d, received, err := q.Receive(ctx)
+	require.NoError(t, err)
+	return d, received
+}
+
+func TestAck(t *testing.T) {
+	ctx := context.Background()
+	q := newSimpleQueueWithCapacity(1)
+
+	success, err := q.Push(ctx, "hello")
+	require.NoError(t, err)
+	require.True(t, success)
+
+	d, received := receive(t, q, 0)
+	require.True(t, received)
+	require.Equal(t, 1, d.DeliveryCount())
+
+	var rcv string
+	require.NoError(t, d.Decode(&rcv))
+	require.Equal(t, "hello", rcv)
+
+	// An unacknowledged item does not count towards the capacity
+	success, err = q.Push(ctx, "world")
+	require.NoError(t, err)
+	require.True(t, success)
+
+	require.NoError(t, d.Ack(ctx))
+	require.ErrorIs(t, d.Ack(ctx), backend.ErrDeliveryClosed)
+	require.ErrorIs(t, d.Nack(ctx, true), backend.ErrDeliveryClosed)
+
+	d, received = receive(t, q, 0)
+	require.True(t, received)
+	require.NoError(t, d.Decode(&rcv))
+	require.Equal(t, "world", rcv)
+	require.NoError(t, d.Ack(ctx))
+
+	_, received = receive(t, q, 0)
+	require.False(t, received)
+}
+
+func TestNackRedelivers(t *testing.T) {
+	ctx := context.Background()
+	q := newSimpleQueueWithCapacity(10)
+
+	for _, item := range []string{"first", "second"} {
+		_, err := q.Push(ctx, item)
+		require.NoError(t, err)
+	}
+
+	d, _ := receive(t, q, 0)
+	require.NoError(t, d.Nack(ctx, true))
+
+	// The item is redelivered before subsequent items
+	for i := 2; i <= 4; i++ {
+		d, received := receive(t, q, 0)
+		require.True(t, received)
+		var rcv string
+		require.NoError(t, d.Decode(&rcv))
+		require.Equal(t, "first", rcv)
+		require.Equal(t, i, d.DeliveryCount())
+		if i < 4 {
+			require.NoError(t, d.Nack(ctx, true))
+		} else {
+			require.NoError(t, d.Ack(ctx))
+		}
+	}
+
+	var rcv string
+	success, err := q.Pop(ctx, &rcv)
+	require.NoError(t, err)
+	require.True(t, success)
+	require.Equal(t, "second", rcv)
+}
+
+func TestDeadLetter(t *testing.T) {
+	ctx := context.Background()
+	dlq := newSimpleQueueWithCapacity(10)
+	q, err := NewSimpleQueueWithOptions(Options{MaxDeliveries: 2, DeadLetter: dlq})
+	require.NoError(t, err)
+
+	headers := map[string]string{"trace": "abc"}
+	for _, item := range []string{"retried", "rejected"} {
+		success, err := q.PushWithOptions(ctx, item, backend.PushOptions{Headers: headers})
+		require.NoError(t, err)
+		require.True(t, success)
+	}
+
+	// Nacked items are dead-lettered once they reach the delivery limit
+	for i := 0; i < 2; i++ {
+		d, received := receive(t, q, 0)
+		require.True(t, received)
+		require.NoError(t, d.Nack(ctx, true))
+	}
+
+	// Rejected items are dead-lettered immediately
+	d, received := receive(t, q, 0)
+	require.True(t, received)
+	require.NoError(t, d.Nack(ctx, false))
+
+	_, received = receive(t, q, 0)
+	require.False(t, received)
+
+	for _, expect := range []string{"retried", "rejected"} {
+		d, received := receive(t, dlq, 0)
+		require.True(t, received)
+		var rcv string
+		require.NoError(t, d.Decode(&rcv))
+		require.Equal(t, expect, rcv)
+		require.Equal(t, headers, d.Headers())
+	}
+}
+
+func TestDelay(t *testing.T) {
+	ctx := context.Background()
+	q := newSimpleQueueWithCapacity(10)
+
+	_, err := q.PushWithOptions(ctx, "later", backend.PushOptions{Delay: 30 * time.Millisecond})
+	require.NoError(t, err)
+	_, err = q.PushWithOptions(ctx, "sooner", backend.PushOptions{Delay: 10 * time.Millisecond})
+	require.NoError(t, err)
+	_, err = q.Push(ctx, "now")
+	require.NoError(t, err)
+
+	start := time.Now()
+	for _, expect := range []string{"now", "sooner", "later"} {
+		var rcv string
+		success, err := q.Pop(ctx, &rcv)
+		require.NoError(t, err)
+		require.True(t, success)
+		require.Equal(t, expect, rcv)
+	}
+	require.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+}
+
+func TestAckTimeout(t *testing.T) {
+	ctx := context.Background()
+	q, err := NewSimpleQueueWithOptions(Options{AckTimeout: 10 * time.Millisecond})
+	require.NoError(t, err)
+
+	_, err = q.Push(ctx, "hello")
+	require.NoError(t, err)
+
+	// The consumer never acknowledges the first delivery
+	first, received := receive(t, q, 0)
+	require.True(t, received)
+
+	second, received := receive(t, q, 100*time.Millisecond)
+	require.True(t, received)
+	require.Equal(t, 2, second.DeliveryCount())
+
+	// The first delivery expired
+	require.ErrorIs(t, first.Ack(ctx), backend.ErrDeliveryClosed)
+	require.NoError(t, second.Ack(ctx))
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	ctx := context.Background()
+	q := newSimpleQueueWithCapacity(10)
+
+	type order struct {
+		ID    string
+		Total int
+	}
+	for i, id := range []string{"a", "b", "c"} {
+		_, err := q.PushWithOptions(ctx, order{id, i}, backend.PushOptions{Headers: map[string]string{"id": id}})
+		require.NoError(t, err)
+	}
+	_, err := q.PushWithOptions(ctx, order{"later", 0}, backend.PushOptions{Delay: time.Hour})
+	require.NoError(t, err)
+
+	// An unacknowledged item is included in the snapshot
+	d, received, err := q.Receive(ctx)
+	require.NoError(t, err)
+	require.True(t, received)
+
+	var buf bytes.Buffer
+	require.NoError(t, q.Snapshot(&buf))
+
+	restored := newSimpleQueueWithCapacity(10)
+	_, err = restored.Push(ctx, order{"stale", 0})
+	require.NoError(t, err)
+	require.NoError(t, restored.Restore(&buf))
+
+	for _, id := range []string{"a", "b", "c"} {
+		d, received, err := restored.Receive(ctx)
+		require.NoError(t, err)
+		require.True(t, received)
+		var o order
+		require.NoError(t, d.Decode(&o))
+		require.Equal(t, id, o.ID)
+		require.Equal(t, id, d.Headers()["id"])
+		require.NoError(t, d.Ack(ctx))
+	}
+
+	// The delayed item is not yet delivered
+	shortCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, received, err = restored.Receive(shortCtx)
+	require.NoError(t, err)
+	require.False(t, received)
+
+	// Restoring does not affect the original queue
+	require.NoError(t, d.Ack(ctx))
+}
+
+func TestRestoreFixture(t *testing.T) {
+	ctx := context.Background()
+	q := newSimpleQueueWithCapacity(1)
+
+	fixture := `[
+		{"item": "first"},
+		{"item": "second", "deliver_at": "2000-01-01T00:00:00Z"},
+		{"item": "third"}
+	]`
+	require.NoError(t, q.Restore(strings.NewReader(fixture)))
+
+	// The queue is restored beyond its capacity; the past delivery time is due immediately
+	for _, expected := range []string{"first", "third", "second"} {
+		var s string
+		popped, err := q.Pop(ctx, &s)
+		require.NoError(t, err)
+		require.True(t, popped)
+		require.Equal(t, expected, s)
+	}
+
+	require.Error(t, q.Restore(strings.NewReader(`[{"headers": {}}]`)))
+}
@@ -0,0 +1,205 @@
+// Package simplesearch implements an in-memory [backend.SearchIndex] using an inverted index.
+//
+// Text fields are split into terms with [backend.SearchTerms], and documents are scored with BM25.  As with
+// the default "best fields" queries of Elasticsearch, a document's score is the score of its best matching field.
+package simplesearch
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+)
+
+// BM25 parameters; these are the defaults used by Elasticsearch
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+// A simple [backend.SearchIndex] that stores an inverted index in memory
+type SimpleSearchIndex struct {
+	backend.SearchIndex
+
+	mu     sync.RWMutex
+	docs   map[string]*document
+	fields map[string]*field
+}
+
+type document struct {
+	doc    backend.SearchDocument
+	length map[string]int // The number of terms in each text field
+}
+
+// The inverted index of a text field
+type field struct {
+	postings    map[string]map[string]int // For each term, the number of times it occurs in each document
+	docs        int                       // The number of documents that have the field
+	totalLength int                       // The total number of terms in the field, across all documents
+}
+
+// Instantiates a [SimpleSearchIndex]
+func NewSimpleSearchIndex(ctx context.Context) (*SimpleSearchIndex, error) {
+	return &SimpleSearchIndex{docs: make(map[string]*document), fields: make(map[string]*field)}, nil
+}
+
+// Implements backend.SearchIndex
+func (index *SimpleSearchIndex) Index(ctx context.Context, id string, doc backend.SearchDocument) error {
+	if err := backend.ValidateSearchDocument(id, doc); err != nil {
+		return err
+	}
+	d := &document{doc: copyDocument(doc), length: make(map[string]int)}
+
+	index.mu.Lock()
+	defer index.mu.Unlock()
+	index.remove(id)
+	for name, text := range doc.Text {
+		f, exists := index.fields[name]
+		if !exists {
+			f = &field{postings: make(map[string]map[string]int)}
+			index.fields[name] = f
+		}
+		terms := backend.SearchTerms(text)
+		for _, term := range terms {
+			if f.postings[term] == nil {
+				f.postings[term] = make(map[string]int)
+			}
+			f.postings[term][id]++
+		}
+		d.length[name] = len(terms)
+		f.docs++
+		f.totalLength += len(terms)
+	}
+	index.docs[id] = d
+	return nil
+}
+
+// Removes the document id from the index, if it exists
+func (index *SimpleSearchIndex) remove(id string) {
+	d, exists := index.docs[id]
+	if !exists {
+		return
+	}
+	for name, text := range d.doc.Text {
+		f := index.fields[name]
+		for _, term := range backend.SearchTerms(text) {
+			delete(f.postings[term], id)
+			if len(f.postings[term]) == 0 {
+				delete(f.postings, term)
+			}
+		}
+		f.docs--
+		f.totalLength -= d.length[name]
+		if f.docs == 0 {
+			delete(index.fields, name)
+		}
+	}
+	delete(index.docs, id)
+}
+
+// Implements backend.SearchIndex
+func (index *SimpleSearchIndex) Delete(ctx context.Context, id string) error {
+	index.mu.Lock()
+	defer index.mu.Unlock()
+	index.remove(id)
+	return nil
+}
+
+// Implements backend.SearchIndex
+func (index *SimpleSearchIndex) Search(ctx context.Context, query backend.SearchQuery) ([]backend.SearchHit, error) {
+	index.mu.RLock()
+	defer index.mu.RUnlock()
+
+	var scores map[string]float64
+	if terms := backend.SearchTerms(query.Text); len(terms) == 0 {
+		scores = make(map[string]float64, len(index.docs))
+		for id := range index.docs {
+			scores[id] = 1
+		}
+	} else {
+		scores = index.score(terms, query.Fields)
+	}
+
+	var hits []backend.SearchHit
+	for id, score := range scores {
+		d := index.docs[id]
+		if matchesFilters(d.doc, query.Filters) {
+			hits = append(hits, backend.SearchHit{ID: id, Score: score, Document: copyDocument(d.doc)})
+		}
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID < hits[j].ID
+	})
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = backend.DefaultSearchLimit
+	}
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+// Returns the BM25 score of every document that contains any of terms in any of fields, or in any text
+// field if fields is empty.  A document's score is the score of its best matching field.
+func (index *SimpleSearchIndex) score(terms []string, fields []string) map[string]float64 {
+	if len(fields) == 0 {
+		for name := range index.fields {
+			fields = append(fields, name)
+		}
+	}
+	scores := make(map[string]float64)
+	for _, name := range fields {
+		f, exists := index.fields[name]
+		if !exists {
+			continue
+		}
+		avgLength := float64(f.totalLength) / float64(f.docs)
+		fieldScores := make(map[string]float64)
+		for _, term := range terms {
+			postings := f.postings[term]
+			idf := math.Log(1 + (float64(f.docs)-float64(len(postings))+0.5)/(float64(len(postings))+0.5))
+			for id, count := range postings {
+				tf := float64(count)
+				norm := 1 - b + b*float64(index.docs[id].length[name])/avgLength
+				fieldScores[id] += idf * tf * (k1 + 1) / (tf + k1*norm)
+			}
+		}
+		for id, score := range fieldScores {
+			if score > scores[id] {
+				scores[id] = score
+			}
+		}
+	}
+	return scores
+}
+
+func matchesFilters(doc backend.SearchDocument, filters map[string]string) bool {
+	for name, value := range filters {
+		if v, exists := doc.Keywords[name]; !exists || v != value {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns a copy of doc, so that callers cannot modify the indexed document
+func copyDocument(doc backend.SearchDocument) backend.SearchDocument {
+	copyFields := func(fields map[string]string) map[string]string {
+		if fields == nil {
+			return nil
+		}
+		copied := make(map[string]string, len(fields))
+		for k, v := range fields {
+			copied[k] = v
+		}
+		return copied
+	}
+	return backend.SearchDocument{Text: copyFields(doc.Text), Keywords: copyFields(doc.Keywords)}
+}
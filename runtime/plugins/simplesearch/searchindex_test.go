@@ -0,0 +1,40 @@
+package simplesearch
+
+import (
+	"context"
+	"testing"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend/backendtest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConformance(t *testing.T) {
+	backendtest.RunSearchIndexSuite(t, func(t *testing.T) backend.SearchIndex {
+		index, err := NewSimpleSearchIndex(context.Background())
+		require.NoError(t, err)
+		return index
+	})
+}
+
+func TestScoring(t *testing.T) {
+	ctx := context.Background()
+	index, err := NewSimpleSearchIndex(ctx)
+	require.NoError(t, err)
+
+	require.NoError(t, index.Index(ctx, "common", backend.SearchDocument{Text: map[string]string{"body": "the cat sat on the mat"}}))
+	require.NoError(t, index.Index(ctx, "rare", backend.SearchDocument{Text: map[string]string{"body": "the zebra"}}))
+	require.NoError(t, index.Index(ctx, "long", backend.SearchDocument{Text: map[string]string{"body": "the cat and the dog and the bird and the fish"}}))
+
+	// Rare terms score higher than common terms, and shorter fields score higher than longer fields
+	hits, err := index.Search(ctx, backend.SearchQuery{Text: "zebra cat"})
+	require.NoError(t, err)
+	require.Len(t, hits, 3)
+	require.Equal(t, []string{"rare", "common", "long"}, []string{hits[0].ID, hits[1].ID, hits[2].ID})
+
+	// Deleted documents no longer contribute to term statistics
+	require.NoError(t, index.Delete(ctx, "rare"))
+	require.NoError(t, index.Delete(ctx, "long"))
+	require.NoError(t, index.Delete(ctx, "common"))
+	require.Empty(t, index.fields)
+}
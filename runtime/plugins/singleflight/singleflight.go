@@ -0,0 +1,105 @@
+// Package singleflight implements the runtime components of Blueprint's singleflight plugin.
+//
+// Groups do not need to be used directly by application workflow specs.  Instead, this
+// code is included in a compiled application by applying the singleflight modifier to the wiring spec.
+//
+// A [Group] coalesces concurrent identical calls: while a call to a method with some arguments is in
+// progress, further calls to the same method with the same arguments wait for, and share, the result
+// of the in-progress call rather than executing again.
+package singleflight
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Returned to callers that were coalesced with a call that panicked.
+var ErrPanicked = errors.New("coalesced call panicked")
+
+// A Group coalesces concurrent identical calls to the methods of a single service.
+//
+// The number of coalesced calls is exported as the singleflight.coalesced OpenTelemetry counter
+// using [backend.MeterOrGlobal], with service and method attributes.
+type Group struct {
+	service   string
+	counter   metric.Int64Counter
+	coalesced int64
+
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+// An in-progress call
+type call struct {
+	done     chan struct{}
+	response any
+	err      error
+}
+
+// Instantiates a [Group] for service.
+func NewGroup(ctx context.Context, service string) (*Group, error) {
+	meter := backend.MeterOrGlobal(ctx, "singleflight")
+	counter, err := meter.Int64Counter("singleflight.coalesced", metric.WithDescription("Number of calls that shared the result of an identical in-progress call"))
+	if err != nil {
+		return nil, err
+	}
+	return &Group{service: service, counter: counter, calls: make(map[string]*call)}, nil
+}
+
+// Returns the total number of calls that shared the result of an identical in-progress call
+func (g *Group) Coalesced() int64 {
+	return atomic.LoadInt64(&g.coalesced)
+}
+
+// Invokes fn, unless an identical call to method with args is already in progress, in which case
+// Do waits for the in-progress call to complete and returns its result.
+//
+// The in-progress call executes with the context of the caller that started it, so coalesced callers
+// observe its cancellation.  Coalesced callers share the response, so responses must not be modified.
+// If args cannot be serialized, the call is not coalesced.
+func Do[T any](ctx context.Context, g *Group, method string, args []any, fn func(context.Context) (T, error)) (T, error) {
+	encoded, err := json.Marshal(args)
+	if err != nil {
+		return fn(ctx)
+	}
+	key := method + string(encoded)
+
+	g.mu.Lock()
+	if c, exists := g.calls[key]; exists {
+		g.mu.Unlock()
+		atomic.AddInt64(&g.coalesced, 1)
+		g.counter.Add(ctx, 1, metric.WithAttributes(attribute.String("service", g.service), attribute.String("method", method)))
+
+		var response T
+		select {
+		case <-c.done:
+		case <-ctx.Done():
+			return response, ctx.Err()
+		}
+		if c.err != nil {
+			return response, c.err
+		}
+		return c.response.(T), nil
+	}
+	c := &call{done: make(chan struct{}), err: ErrPanicked}
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(c.done)
+	}()
+
+	response, err := fn(ctx)
+	c.response, c.err = response, err
+	return response, err
+}
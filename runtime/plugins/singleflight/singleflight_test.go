@@ -0,0 +1,123 @@
+package singleflight_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/singleflight"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoalesce(t *testing.T) {
+	ctx := context.Background()
+	g, err := singleflight.NewGroup(ctx, "svc")
+	require.NoError(t, err)
+
+	var calls int64
+	release := make(chan struct{})
+	fn := func(ctx context.Context) (string, error) {
+		atomic.AddInt64(&calls, 1)
+		<-release
+		return "result", nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := singleflight.Do(ctx, g, "M", []any{1, "a"}, fn)
+			require.NoError(t, err)
+			require.Equal(t, "result", res)
+		}()
+	}
+
+	require.Eventually(t, func() bool { return g.Coalesced() == 9 }, time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+	require.Equal(t, int64(1), calls)
+}
+
+func TestDifferentArgsNotCoalesced(t *testing.T) {
+	ctx := context.Background()
+	g, err := singleflight.NewGroup(ctx, "svc")
+	require.NoError(t, err)
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 2)
+	fn := func(ctx context.Context) (int, error) {
+		started <- struct{}{}
+		<-release
+		return 0, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := singleflight.Do(ctx, g, "M", []any{i}, fn)
+			require.NoError(t, err)
+		}(i)
+	}
+	<-started
+	<-started
+	close(release)
+	wg.Wait()
+	require.Equal(t, int64(0), g.Coalesced())
+}
+
+func TestErrorShared(t *testing.T) {
+	ctx := context.Background()
+	g, err := singleflight.NewGroup(ctx, "svc")
+	require.NoError(t, err)
+
+	failed := errors.New("failed")
+	release := make(chan struct{})
+	fn := func(ctx context.Context) (int, error) {
+		<-release
+		return 0, failed
+	}
+
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := singleflight.Do(ctx, g, "M", nil, fn)
+			errs <- err
+		}()
+	}
+	require.Eventually(t, func() bool { return g.Coalesced() == 1 }, time.Second, time.Millisecond)
+
+	close(release)
+	require.ErrorIs(t, <-errs, failed)
+	require.ErrorIs(t, <-errs, failed)
+
+	// Subsequent calls execute again
+	res, err := singleflight.Do(ctx, g, "M", nil, func(ctx context.Context) (int, error) { return 5, nil })
+	require.NoError(t, err)
+	require.Equal(t, 5, res)
+}
+
+func TestWaiterContextCancelled(t *testing.T) {
+	g, err := singleflight.NewGroup(context.Background(), "svc")
+	require.NoError(t, err)
+
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+	go singleflight.Do(context.Background(), g, "M", nil, func(ctx context.Context) (int, error) {
+		close(started)
+		<-release
+		return 0, nil
+	})
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = singleflight.Do(ctx, g, "M", nil, func(ctx context.Context) (int, error) { return 0, nil })
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,126 @@
+// Package snapshot saves the contents of Blueprint's in-memory backends to disk, and restores them.
+//
+// The in-memory backends, such as [simplecache], [simplequeue], and [simplenosqldb], implement the
+// [Snapshotter] interface.  A snapshot is the same format as the backend's fixture files, so a saved
+// snapshot can also be used as a fixture.
+//
+// [simplecache]: https://github.com/Blueprint-uServices/blueprint/tree/main/runtime/plugins/simplecache
+// [simplequeue]: https://github.com/Blueprint-uServices/blueprint/tree/main/runtime/plugins/simplequeue
+// [simplenosqldb]: https://github.com/Blueprint-uServices/blueprint/tree/main/runtime/plugins/simplenosqldb
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/exp/slog"
+)
+
+// A backend whose contents can be saved to, and restored from, a snapshot
+type Snapshotter interface {
+	// Writes the contents of the backend to w
+	Snapshot(w io.Writer) error
+
+	// Replaces the contents of the backend with the snapshot read from r
+	Restore(r io.Reader) error
+}
+
+// Saves a snapshot of s to the file at path.
+//
+// The snapshot is first written to a temporary file in the same directory, which then replaces the
+// file at path, so that the file at path always contains a complete snapshot.
+func Save(s Snapshotter, path string) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if err := s.Snapshot(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// Restores s from the snapshot in the file at path.
+//
+// Reports false if the file does not exist, in which case s is unmodified.
+func Load(s Snapshotter, path string) (bool, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	if err := s.Restore(f); err != nil {
+		return false, fmt.Errorf("unable to restore snapshot %v: %w", path, err)
+	}
+	return true, nil
+}
+
+// Persists the contents of s in the file at path.
+//
+// If the file exists, s is first restored from it.  Snapshots are then saved by [Persister.Run], which
+// the caller must run in the background, e.g. by returning the Persister from a Blueprint build func
+// so that the namespace runs it.
+func Persist(s Snapshotter, path string, interval time.Duration) (*Persister, error) {
+	if interval < 0 {
+		return nil, fmt.Errorf("invalid snapshot interval %v", interval)
+	}
+	restored, err := Load(s, path)
+	if err != nil {
+		return nil, err
+	}
+	if restored {
+		slog.Info(fmt.Sprintf("restored snapshot %v", path))
+	}
+	return &Persister{s: s, path: path, interval: interval}, nil
+}
+
+// Saves snapshots of a [Snapshotter] to a file; returned by [Persist].
+//
+// Persister implements golang.Runnable, so a namespace that builds a Persister runs it, and awaits
+// the final snapshot when the namespace is shut down.
+type Persister struct {
+	s        Snapshotter
+	path     string
+	interval time.Duration
+}
+
+// Saves a snapshot every interval until ctx is done, then saves a final snapshot.  If interval is zero,
+// only the final snapshot is saved.
+//
+// Errors saving periodic snapshots are logged; returns the error saving the final snapshot, if any.
+func (p *Persister) Run(ctx context.Context) error {
+	var tick <-chan time.Time
+	if p.interval > 0 {
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-tick:
+			save(p.s, p.path)
+		case <-ctx.Done():
+			return Save(p.s, p.path)
+		}
+	}
+}
+
+// Saves a snapshot of s to path, logging any error
+func save(s Snapshotter, path string) {
+	if err := Save(s, path); err != nil {
+		slog.Error(fmt.Sprintf("unable to save snapshot %v: %v", path, err))
+	}
+}
@@ -0,0 +1,101 @@
+package snapshot_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/simplecache"
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/snapshot"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveLoad(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cache.json")
+
+	cache, _ := simplecache.NewSimpleCache(ctx)
+	restored, err := snapshot.Load(cache, path)
+	require.NoError(t, err)
+	require.False(t, restored)
+
+	require.NoError(t, cache.Put(ctx, "hello", "world"))
+	require.NoError(t, snapshot.Save(cache, path))
+
+	other, _ := simplecache.NewSimpleCache(ctx)
+	restored, err = snapshot.Load(other, path)
+	require.NoError(t, err)
+	require.True(t, restored)
+
+	var v string
+	exists, err := other.Get(ctx, "hello", &v)
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.Equal(t, "world", v)
+
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0644))
+	_, err = snapshot.Load(other, path)
+	require.Error(t, err)
+}
+
+func TestPersist(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	path := filepath.Join(t.TempDir(), "cache.json")
+
+	cache, _ := simplecache.NewSimpleCache(ctx)
+	persister, err := snapshot.Persist(cache, path, 10*time.Millisecond)
+	require.NoError(t, err)
+	done := make(chan error)
+	go func() { done <- persister.Run(ctx) }()
+	require.NoError(t, cache.Put(ctx, "hello", "world"))
+
+	// A snapshot is saved periodically
+	require.Eventually(t, func() bool {
+		other, _ := simplecache.NewSimpleCache(context.Background())
+		if _, err := snapshot.Load(other, path); err != nil {
+			return false
+		}
+		exists, _ := other.Get(ctx, "hello", new(string))
+		return exists
+	}, time.Second, 10*time.Millisecond)
+
+	// A final snapshot is saved when ctx is done
+	require.NoError(t, cache.Put(ctx, "goodbye", "world"))
+	cancel()
+	require.NoError(t, <-done)
+
+	// A new instance is restored from the snapshot
+	ctx = context.Background()
+	restored, _ := simplecache.NewSimpleCache(ctx)
+	_, err = snapshot.Persist(restored, path, 0)
+	require.NoError(t, err)
+	var v string
+	exists, err := restored.Get(ctx, "goodbye", &v)
+	require.NoError(t, err)
+	require.True(t, exists)
+	require.Equal(t, "world", v)
+
+	_, err = snapshot.Persist(restored, path, -time.Second)
+	require.Error(t, err)
+}
+
+func TestPersistOnlyOnShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	path := filepath.Join(t.TempDir(), "cache.json")
+
+	cache, _ := simplecache.NewSimpleCache(ctx)
+	persister, err := snapshot.Persist(cache, path, 0)
+	require.NoError(t, err)
+	done := make(chan error)
+	go func() { done <- persister.Run(ctx) }()
+	require.NoError(t, cache.Put(ctx, "hello", "world"))
+
+	cancel()
+	require.NoError(t, <-done)
+	restored, _ := simplecache.NewSimpleCache(context.Background())
+	found, err := snapshot.Load(restored, path)
+	require.NoError(t, err)
+	require.True(t, found)
+}
@@ -1,6 +1,10 @@
 // Package sqlitereldb implements a [backend.RelationalDB] using the in-memory Golang
 // SQLite package [github.com/mattn/go-sqlite3].
 //
+// By default a database is held in memory, and its contents are lost when the process exits.  Each
+// database is identified by a name; within a process, instances with the same name share a database.
+// Alternatively, a database can be backed by a file on disk, so that it persists across restarts.
+//
 // If you are directly running go code (e.g. not from a docker container), the go-sqlite3
 // package requires CGO_ENABLED=1 and you must have gcc installed.  See [https://github.com/mattn/go-sqlite3]
 // for more details about installation instructions.
@@ -9,47 +13,79 @@ package sqlitereldb
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"net/url"
+	"sync/atomic"
 
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/internal/sqlutil"
 	"github.com/jmoiron/sqlx"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
-// An in-memory relational DB that uses the go-sqlite3 package
+// A relational DB that uses the go-sqlite3 package
 type SqliteRelDB struct {
 	db *sqlx.DB
 }
 
-// Instantiates a new [SqliteRelDB] instance that stores query data in-memory
-func NewSqliteRelDB(ctx context.Context) (*SqliteRelDB, error) {
-	db, err := sqlx.Open("sqlite3", "file:foobar?mode=memory&cache=shared")
+// Used to name the in-memory databases of instances that have no name
+var anonymous atomic.Int64
+
+// Instantiates a new [SqliteRelDB] instance.
+//
+// If path is empty, the database is held in memory.  The in-memory database is shared by the
+// instances of the process with the same name; if name is empty, the instance gets a new database
+// of its own.
+//
+// If path is not empty, the database is stored in the file at path, which is created if it does not
+// already exist.  name is ignored.
+func NewSqliteRelDB(ctx context.Context, name string, path string) (*SqliteRelDB, error) {
+	var dsn string
+	if path != "" {
+		dsn = "file:" + path + "?_busy_timeout=5000"
+	} else {
+		if name == "" {
+			name = fmt.Sprintf("sqlitereldb_%d", anonymous.Add(1))
+		}
+		dsn = "file:" + url.PathEscape(name) + "?mode=memory&cache=shared"
+	}
+	db, err := sqlx.Open("sqlite3", dsn)
 	if err != nil {
 		return nil, err
 	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
 	return &SqliteRelDB{db: db}, nil
 }
 
 // Exec implements backend.RelationalDB.
 func (s *SqliteRelDB) Exec(ctx context.Context, query string, args ...any) (sql.Result, error) {
-	return s.db.ExecContext(ctx, query, args...)
+	return sqlutil.Conn(ctx, s.db).ExecContext(ctx, query, args...)
 }
 
 // Query implements backend.RelationalDB.
 func (s *SqliteRelDB) Query(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
-	return s.db.QueryContext(ctx, query, args...)
+	return sqlutil.Conn(ctx, s.db).QueryContext(ctx, query, args...)
 }
 
 // Get implements backend.RelationalDB.
 func (s *SqliteRelDB) Get(ctx context.Context, dst interface{}, query string, args ...any) error {
-	return s.db.GetContext(ctx, dst, query, args...)
+	return sqlutil.Conn(ctx, s.db).GetContext(ctx, dst, query, args...)
 }
 
 // Prepare implements backend.RelationalDB.
 func (s *SqliteRelDB) Prepare(ctx context.Context, query string) (*sql.Stmt, error) {
-	return s.db.Prepare(query)
+	return sqlutil.Conn(ctx, s.db).PrepareContext(ctx, query)
 }
 
 // Select implements backend.RelationalDB.
 func (s *SqliteRelDB) Select(ctx context.Context, dst interface{}, query string, args ...any) error {
-	return s.db.SelectContext(ctx, dst, query, args...)
+	return sqlutil.Conn(ctx, s.db).SelectContext(ctx, dst, query, args...)
+}
+
+// WithTransaction implements backend.RelationalDB
+func (s *SqliteRelDB) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return sqlutil.WithTransaction(ctx, s.db, fn)
 }
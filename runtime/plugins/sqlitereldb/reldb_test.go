@@ -2,16 +2,28 @@ package sqlitereldb_test
 
 import (
 	"context"
+	"errors"
+	"path/filepath"
 	"testing"
 
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend/backendtest"
 	"github.com/blueprint-uservices/blueprint/runtime/plugins/sqlitereldb"
 	"github.com/stretchr/testify/require"
 )
 
+func TestConformance(t *testing.T) {
+	backendtest.RunRelDBSuite(t, func(t *testing.T) backend.RelationalDB {
+		db, err := sqlitereldb.NewSqliteRelDB(context.Background(), "", "")
+		require.NoError(t, err)
+		return db
+	})
+}
+
 func TestRelDB(t *testing.T) {
 	ctx := context.Background()
 
-	db, err := sqlitereldb.NewSqliteRelDB(ctx)
+	db, err := sqlitereldb.NewSqliteRelDB(ctx, "", "")
 	require.NoError(t, err)
 
 	batch := []string{
@@ -114,3 +126,116 @@ type Address struct {
 	Street string `db:"street"`
 	Number int    `db:"street_number"`
 }
+
+func TestTransaction(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := sqlitereldb.NewSqliteRelDB(ctx, "", "")
+	require.NoError(t, err)
+
+	_, err = db.Exec(ctx, `CREATE TABLE IF NOT EXISTS account (name TEXT PRIMARY KEY, balance INT);`)
+	require.NoError(t, err)
+	_, err = db.Exec(ctx, `INSERT INTO account (name, balance) VALUES ('alice', 100), ('bob', 0);`)
+	require.NoError(t, err)
+
+	transfer := func(ctx context.Context, amount int) error {
+		if _, err := db.Exec(ctx, `UPDATE account SET balance = balance - ? WHERE name = 'alice';`, amount); err != nil {
+			return err
+		}
+		if _, err := db.Exec(ctx, `UPDATE account SET balance = balance + ? WHERE name = 'bob';`, amount); err != nil {
+			return err
+		}
+		var balance int
+		if err := db.Get(ctx, &balance, `SELECT balance FROM account WHERE name = 'alice';`); err != nil {
+			return err
+		}
+		if balance < 0 {
+			return errors.New("insufficient funds")
+		}
+		return nil
+	}
+
+	balances := func() []int {
+		var balances []int
+		require.NoError(t, db.Select(ctx, &balances, `SELECT balance FROM account ORDER BY name;`))
+		return balances
+	}
+
+	require.NoError(t, db.WithTransaction(ctx, func(ctx context.Context) error {
+		return transfer(ctx, 60)
+	}))
+	require.Equal(t, []int{40, 60}, balances())
+
+	err = db.WithTransaction(ctx, func(ctx context.Context) error {
+		return transfer(ctx, 60)
+	})
+	require.Error(t, err)
+	require.Equal(t, []int{40, 60}, balances())
+
+	// A nested transaction joins the enclosing transaction
+	err = db.WithTransaction(ctx, func(ctx context.Context) error {
+		require.NoError(t, db.WithTransaction(ctx, func(ctx context.Context) error {
+			return transfer(ctx, 10)
+		}))
+		return errors.New("abort")
+	})
+	require.Error(t, err)
+	require.Equal(t, []int{40, 60}, balances())
+}
+
+func TestIsolation(t *testing.T) {
+	ctx := context.Background()
+
+	db1, err := sqlitereldb.NewSqliteRelDB(ctx, "isolation_db1", "")
+	require.NoError(t, err)
+	db2, err := sqlitereldb.NewSqliteRelDB(ctx, "isolation_db2", "")
+	require.NoError(t, err)
+	anon, err := sqlitereldb.NewSqliteRelDB(ctx, "", "")
+	require.NoError(t, err)
+
+	_, err = db1.Exec(ctx, `CREATE TABLE item (id INT PRIMARY KEY);`)
+	require.NoError(t, err)
+
+	// Databases with different names do not share tables
+	_, err = db2.Exec(ctx, `CREATE TABLE item (id INT PRIMARY KEY);`)
+	require.NoError(t, err)
+	_, err = anon.Exec(ctx, `CREATE TABLE item (id INT PRIMARY KEY);`)
+	require.NoError(t, err)
+
+	// Instances with the same name share a database
+	shared, err := sqlitereldb.NewSqliteRelDB(ctx, "isolation_db1", "")
+	require.NoError(t, err)
+	_, err = db1.Exec(ctx, `INSERT INTO item (id) VALUES (1);`)
+	require.NoError(t, err)
+
+	var count int
+	require.NoError(t, shared.Get(ctx, &count, `SELECT COUNT(*) FROM item;`))
+	require.Equal(t, 1, count)
+	require.NoError(t, db2.Get(ctx, &count, `SELECT COUNT(*) FROM item;`))
+	require.Equal(t, 0, count)
+}
+
+func TestPersistence(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "persistent.db")
+
+	db, err := sqlitereldb.NewSqliteRelDB(ctx, "persistent", path)
+	require.NoError(t, err)
+	_, err = db.Exec(ctx, `CREATE TABLE item (id INT PRIMARY KEY);`)
+	require.NoError(t, err)
+	_, err = db.Exec(ctx, `INSERT INTO item (id) VALUES (1), (2);`)
+	require.NoError(t, err)
+
+	// A new instance for the same file sees the data
+	reopened, err := sqlitereldb.NewSqliteRelDB(ctx, "persistent", path)
+	require.NoError(t, err)
+	var count int
+	require.NoError(t, reopened.Get(ctx, &count, `SELECT COUNT(*) FROM item;`))
+	require.Equal(t, 2, count)
+
+	// The in-memory database of the same name is separate
+	inMemory, err := sqlitereldb.NewSqliteRelDB(ctx, "persistent", "")
+	require.NoError(t, err)
+	_, err = inMemory.Exec(ctx, `CREATE TABLE item (id INT PRIMARY KEY);`)
+	require.NoError(t, err)
+}
@@ -0,0 +1,172 @@
+// Package sqlmigrate applies versioned SQL migrations to a [backend.RelationalDB].
+//
+// Migrations are SQL files named <version>_<name>.sql, e.g. 0001_create_posts.sql, where the version is a
+// positive integer.  Migrations are applied in version order.  Seed data is added by migrations like any
+// other change, e.g. 0002_seed_posts.sql.
+//
+// The versions of the migrations that have been applied to a database are recorded in the database's
+// blueprint_migrations table.  Each migration is applied once, in a transaction that also records its
+// version.  Note that some databases, such as mysql, implicitly commit schema changes such as CREATE TABLE,
+// so a migration that fails part way through might be partially applied.
+package sqlmigrate
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/internal/sqlutil"
+)
+
+// The table in which the versions of applied migrations are recorded
+const VersionTable = "blueprint_migrations"
+
+// The statement that creates the [VersionTable]
+const createVersionTable = "CREATE TABLE IF NOT EXISTS " + VersionTable + " (version BIGINT PRIMARY KEY, name VARCHAR(255) NOT NULL)"
+
+// A versioned SQL migration
+type Migration struct {
+	Version  int64
+	Name     string
+	Filename string
+	SQL      string
+}
+
+var filenamePattern = regexp.MustCompile(`^([0-9]+)_([A-Za-z0-9_\-]+)\.sql$`)
+
+// Loads the migrations in the root directory of fsys, sorted by version.
+//
+// Returns an error if a .sql file is not named <version>_<name>.sql, or if two migrations have the
+// same version.  Files that do not have the .sql extension are ignored.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	var migrations []Migration
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+		match := filenamePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration filename %v; expected <version>_<name>.sql", entry.Name())
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration filename %v; the version must be a positive integer", entry.Name())
+		}
+		contents, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, Migration{Version: version, Name: match[2], Filename: entry.Name(), SQL: string(contents)})
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int { return cmp.Compare(a.Version, b.Version) })
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return nil, fmt.Errorf("migrations %v and %v have the same version", migrations[i-1].Filename, migrations[i].Filename)
+		}
+	}
+	return migrations, nil
+}
+
+// Applies the migrations in the root directory of fsys that have not yet been applied to db.
+//
+// Migrations are applied in version order, including migrations with a lower version than
+// migrations that have already been applied.
+func Apply(ctx context.Context, db backend.RelationalDB, fsys fs.FS) error {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return err
+	}
+	if _, err := db.Exec(ctx, createVersionTable); err != nil {
+		return err
+	}
+	var applied []int64
+	if err := db.Select(ctx, &applied, "SELECT version FROM "+VersionTable); err != nil {
+		return err
+	}
+	for _, m := range migrations {
+		if slices.Contains(applied, m.Version) {
+			continue
+		}
+		err := db.WithTransaction(ctx, func(ctx context.Context) error {
+			for _, stmt := range Statements(m.SQL) {
+				if _, err := db.Exec(ctx, stmt); err != nil {
+					return err
+				}
+			}
+			_, err := db.Exec(ctx, "INSERT INTO "+VersionTable+" (version, name) VALUES (?, ?)", m.Version, m.Name)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("unable to apply migration %v: %w", m.Filename, err)
+		}
+	}
+	return nil
+}
+
+// Returns a SQL script that applies migrations and records their versions in the [VersionTable].
+//
+// The script is intended for database containers that run init scripts when the database is first
+// created.  A client that later calls [Apply] with the same migrations does not apply them again.
+func Script(migrations []Migration) string {
+	var b strings.Builder
+	b.WriteString(createVersionTable + ";\n")
+	for _, m := range migrations {
+		fmt.Fprintf(&b, "\n-- %v\n", m.Filename)
+		for _, stmt := range Statements(m.SQL) {
+			b.WriteString(stmt + ";\n")
+		}
+		// Names are restricted to letters, digits, underscores and dashes, so they need no escaping
+		fmt.Fprintf(&b, "INSERT INTO %v (version, name) VALUES (%d, '%v');\n", VersionTable, m.Version, m.Name)
+	}
+	return b.String()
+}
+
+// Splits a SQL script into its statements, which are separated by semicolons.
+//
+// Semicolons within string literals, quoted identifiers, dollar-quoted strings, and comments do not
+// separate statements.  Quotes are escaped by doubling them, and backslashes escape characters in
+// postgres's escape strings, e.g. E'it\'s'.
+// Statements that contain semicolons outside of dollar-quoted strings, such as mysql trigger bodies,
+// are not supported.  Empty statements are omitted.
+func Statements(script string) []string {
+	var stmts []string
+	start := 0
+	add := func(end int) {
+		if stmt := strings.TrimSpace(script[start:end]); stmt != "" && !isComment(stmt) {
+			stmts = append(stmts, stmt)
+		}
+		start = end + 1
+	}
+	for i := 0; i < len(script); i++ {
+		if script[i] == ';' {
+			add(i)
+		} else if end := sqlutil.SkipQuoted(script, i); end > i {
+			i = end - 1
+		}
+	}
+	if start < len(script) {
+		add(len(script))
+	}
+	return stmts
+}
+
+// Reports whether stmt consists only of comments
+func isComment(stmt string) bool {
+	for stmt != "" {
+		if !strings.HasPrefix(stmt, "--") && !strings.HasPrefix(stmt, "/*") {
+			return false
+		}
+		stmt = strings.TrimSpace(stmt[sqlutil.SkipQuoted(stmt, 0):])
+	}
+	return true
+}
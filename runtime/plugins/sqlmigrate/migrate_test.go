@@ -0,0 +1,119 @@
+package sqlmigrate_test
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/sqlitereldb"
+	"github.com/blueprint-uservices/blueprint/runtime/plugins/sqlmigrate"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatements(t *testing.T) {
+	script := `-- the posts table
+CREATE TABLE posts (id INT PRIMARY KEY, title TEXT);
+INSERT INTO posts VALUES (1, 'hello; world'), (2, 'it''s');
+/* a comment; with a semicolon */
+INSERT INTO "odd;name" VALUES (1);
+CREATE FUNCTION f() RETURNS INT AS $body$ SELECT 1; $body$ LANGUAGE SQL;
+;
+-- trailing comment`
+
+	require.Equal(t, []string{
+		"-- the posts table\nCREATE TABLE posts (id INT PRIMARY KEY, title TEXT)",
+		"INSERT INTO posts VALUES (1, 'hello; world'), (2, 'it''s')",
+		"/* a comment; with a semicolon */\nINSERT INTO \"odd;name\" VALUES (1)",
+		"CREATE FUNCTION f() RETURNS INT AS $body$ SELECT 1; $body$ LANGUAGE SQL",
+	}, sqlmigrate.Statements(script))
+
+	require.Equal(t, []string{"SELECT 1"}, sqlmigrate.Statements("SELECT 1"))
+	require.Empty(t, sqlmigrate.Statements("  -- nothing\n"))
+
+	// Escape strings and identifiers that contain dollar signs
+	require.Equal(t, []string{"INSERT INTO t VALUES (E'it\\'s; ok')", "SELECT a$b$c FROM t", "SELECT 1"},
+		sqlmigrate.Statements("INSERT INTO t VALUES (E'it\\'s; ok'); SELECT a$b$c FROM t; SELECT 1"))
+}
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"10_seed_posts.sql":    {Data: []byte("INSERT INTO posts VALUES (1)")},
+		"2_create_posts.sql":   {Data: []byte("CREATE TABLE posts (id INT)")},
+		"README.md":            {Data: []byte("not a migration")},
+		"nested/3_ignored.sql": {Data: []byte("SELECT 1")},
+	}
+	migrations, err := sqlmigrate.Load(fsys)
+	require.NoError(t, err)
+	require.Len(t, migrations, 2)
+	require.Equal(t, int64(2), migrations[0].Version)
+	require.Equal(t, "create_posts", migrations[0].Name)
+	require.Equal(t, int64(10), migrations[1].Version)
+	require.Equal(t, "seed_posts", migrations[1].Name)
+
+	_, err = sqlmigrate.Load(fstest.MapFS{"create_posts.sql": {}})
+	require.Error(t, err)
+
+	_, err = sqlmigrate.Load(fstest.MapFS{"0_create_posts.sql": {}})
+	require.Error(t, err)
+
+	_, err = sqlmigrate.Load(fstest.MapFS{"1_a.sql": {}, "01_b.sql": {}})
+	require.Error(t, err)
+}
+
+func TestApply(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := sqlitereldb.NewSqliteRelDB(ctx, "", "")
+	require.NoError(t, err)
+
+	fsys := fstest.MapFS{
+		"0001_create_posts.sql": {Data: []byte("CREATE TABLE posts (id INT PRIMARY KEY, title TEXT);\nCREATE INDEX posts_title ON posts (title);")},
+		"0002_seed_posts.sql":   {Data: []byte("INSERT INTO posts VALUES (1, 'hello');")},
+	}
+	require.NoError(t, sqlmigrate.Apply(ctx, db, fsys))
+
+	// Applying the same migrations again does nothing
+	require.NoError(t, sqlmigrate.Apply(ctx, db, fsys))
+
+	var titles []string
+	require.NoError(t, db.Select(ctx, &titles, "SELECT title FROM posts"))
+	require.Equal(t, []string{"hello"}, titles)
+
+	// Only new migrations are applied
+	fsys["0003_more_posts.sql"] = &fstest.MapFile{Data: []byte("INSERT INTO posts VALUES (2, 'world');")}
+	require.NoError(t, sqlmigrate.Apply(ctx, db, fsys))
+
+	var versions []int64
+	require.NoError(t, db.Select(ctx, &versions, "SELECT version FROM "+sqlmigrate.VersionTable+" ORDER BY version"))
+	require.Equal(t, []int64{1, 2, 3}, versions)
+
+	// A failed migration is rolled back and not recorded
+	fsys["0004_broken.sql"] = &fstest.MapFile{Data: []byte("INSERT INTO posts VALUES (3, 'partial');\nINSERT INTO missing VALUES (1);")}
+	require.Error(t, sqlmigrate.Apply(ctx, db, fsys))
+
+	var count int
+	require.NoError(t, db.Get(ctx, &count, "SELECT COUNT(*) FROM posts"))
+	require.Equal(t, 2, count)
+	require.NoError(t, db.Get(ctx, &count, "SELECT COUNT(*) FROM "+sqlmigrate.VersionTable))
+	require.Equal(t, 3, count)
+}
+
+func TestScript(t *testing.T) {
+	migrations, err := sqlmigrate.Load(fstest.MapFS{
+		"1_create_posts.sql": {Data: []byte("CREATE TABLE posts (id INT PRIMARY KEY);")},
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	db, err := sqlitereldb.NewSqliteRelDB(ctx, "", "")
+	require.NoError(t, err)
+
+	// Running the script records the migrations, so they are not applied again
+	for _, stmt := range sqlmigrate.Statements(sqlmigrate.Script(migrations)) {
+		_, err := db.Exec(ctx, stmt)
+		require.NoError(t, err)
+	}
+	require.NoError(t, sqlmigrate.Apply(ctx, db, fstest.MapFS{
+		"1_create_posts.sql": {Data: []byte("CREATE TABLE posts (id INT PRIMARY KEY);")},
+	}))
+}
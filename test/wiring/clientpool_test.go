@@ -9,6 +9,8 @@ import (
 	"github.com/blueprint-uservices/blueprint/plugins/retries"
 	"github.com/blueprint-uservices/blueprint/plugins/workflow"
 	wf "github.com/blueprint-uservices/blueprint/test/workflow/workflow"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestBasicClientPool(t *testing.T) {
@@ -164,3 +166,32 @@ func TestInvalidModifierOrder(t *testing.T) {
 	assertBuildFailure(t, spec, leafproc, nonleafproc)
 
 }
+
+func TestInvalidClientPoolSize(t *testing.T) {
+	spec := newWiringSpec("TestInvalidClientPoolSize")
+
+	leaf := workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+	nonleaf := workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+
+	clientpool.Create(spec, leaf, 0)
+
+	nonleafproc := goproc.CreateProcess(spec, "nonleafproc", nonleaf)
+
+	err := assertBuildFailure(t, spec, nonleafproc)
+	assert.Contains(t, err.Error(), "invalid NumClients 0")
+}
+
+func TestInvalidMethodGroupSize(t *testing.T) {
+	spec := newWiringSpec("TestInvalidMethodGroupSize")
+
+	leaf := workflow.Service[*wf.TestLeafServiceImpl](spec, "leaf")
+	nonleaf := workflow.Service[wf.TestNonLeafService](spec, "nonleaf", leaf)
+
+	clientpool.CreateBulkhead(spec, leaf, clientpool.Config{NumClients: 7},
+		clientpool.MethodGroup{Name: "hello", Methods: []string{"HelloInt"}, Config: clientpool.Config{NumClients: -1}})
+
+	nonleafproc := goproc.CreateProcess(spec, "nonleafproc", nonleaf)
+
+	err := assertBuildFailure(t, spec, nonleafproc)
+	assert.Contains(t, err.Error(), "invalid NumClients -1 for method group hello")
+}
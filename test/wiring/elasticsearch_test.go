@@ -0,0 +1,36 @@
+package wiring
+
+import (
+	"testing"
+
+	"github.com/blueprint-uservices/blueprint/plugins/elasticsearch"
+	"github.com/blueprint-uservices/blueprint/plugins/goproc"
+	"github.com/blueprint-uservices/blueprint/plugins/workflow"
+	"github.com/blueprint-uservices/blueprint/test/workflow/search"
+)
+
+func TestElasticsearchIndex(t *testing.T) {
+	spec := newWiringSpec("TestElasticsearchIndex")
+
+	leaf_index := elasticsearch.Container(spec, "leaf_index")
+	leaf := workflow.Service[*search.TestLeafServiceImplWithSearchIndex](spec, "leaf", leaf_index)
+	leaf_proc := goproc.CreateProcess(spec, "leaf_proc", leaf)
+
+	app := assertBuildSuccess(t, spec, leaf_proc, leaf_index)
+
+	assertIR(t, app,
+		`TestElasticsearchIndex = BlueprintApplication() {
+			leaf.handler.visibility
+			leaf_index.addr
+			leaf_index.bind_addr = AddressConfig()
+			leaf_index.client = ElasticsearchClient(leaf_index.dial_addr)
+			leaf_index.ctr = ElasticsearchContainer(leaf_index.bind_addr)
+			leaf_index.dial_addr = AddressConfig()
+			leaf_proc = GolangProcessNode(leaf_index.dial_addr) {
+			  leaf = TestLeafService(leaf_index.client)
+			  leaf_index.client = ElasticsearchClient(leaf_index.dial_addr)
+			  leaf_proc.logger = SLogger()
+			  leaf_proc.stdoutmetriccollector = StdoutMetricCollector()
+			}
+		  }`)
+}
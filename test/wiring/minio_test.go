@@ -0,0 +1,37 @@
+package wiring
+
+import (
+	"testing"
+
+	"github.com/blueprint-uservices/blueprint/plugins/goproc"
+	"github.com/blueprint-uservices/blueprint/plugins/minio"
+	"github.com/blueprint-uservices/blueprint/plugins/workflow"
+	"github.com/blueprint-uservices/blueprint/test/workflow/blobstore"
+)
+
+func TestMinioBlobStore(t *testing.T) {
+	spec := newWiringSpec("TestMinioBlobStore")
+
+	leaf_store := minio.Container(spec, "leaf_store")
+	leaf := workflow.Service[*blobstore.TestLeafServiceImplWithBlobStore](spec, "leaf", leaf_store)
+	leaf_proc := goproc.CreateProcess(spec, "leaf_proc", leaf)
+
+	app := assertBuildSuccess(t, spec, leaf_proc, leaf_store)
+
+	assertIR(t, app,
+		`TestMinioBlobStore = BlueprintApplication() {
+			leaf.handler.visibility
+			leaf_proc = GolangProcessNode(leaf_store.dial_addr, leaf_store.password) {
+			  leaf = TestLeafService(leaf_store.client)
+			  leaf_proc.logger = SLogger()
+			  leaf_proc.stdoutmetriccollector = StdoutMetricCollector()
+			  leaf_store.client = MinioClient(leaf_store.dial_addr)
+			}
+			leaf_store.addr
+			leaf_store.bind_addr = AddressConfig()
+			leaf_store.client = MinioClient(leaf_store.dial_addr)
+			leaf_store.ctr = MinioContainer(leaf_store.bind_addr)
+			leaf_store.dial_addr = AddressConfig()
+			leaf_store.password = Secret()
+		  }`)
+}
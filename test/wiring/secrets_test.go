@@ -0,0 +1,74 @@
+package wiring
+
+import (
+	"testing"
+
+	"github.com/blueprint-uservices/blueprint/plugins/goproc"
+	"github.com/blueprint-uservices/blueprint/plugins/minio"
+	"github.com/blueprint-uservices/blueprint/plugins/mongodb"
+	"github.com/blueprint-uservices/blueprint/plugins/secrets"
+	"github.com/blueprint-uservices/blueprint/plugins/simple"
+	"github.com/blueprint-uservices/blueprint/plugins/workflow"
+	"github.com/blueprint-uservices/blueprint/test/workflow/blobstore"
+	"github.com/blueprint-uservices/blueprint/test/workflow/nosqldb"
+)
+
+func TestMongoDBSecret(t *testing.T) {
+	spec := newWiringSpec("TestMongoDBSecret")
+
+	leaf_cache := simple.Cache(spec, "leaf_cache")
+	leaf_db := mongodb.Container(spec, "leaf_db")
+	secrets.Use(spec, leaf_db, secrets.Define(spec, "leaf_db_password"))
+	leaf := workflow.Service[*nosqldb.TestLeafServiceImplWithDB](spec, "leaf", leaf_cache, leaf_db)
+	leaf_proc := goproc.CreateProcess(spec, "leaf_proc", leaf)
+
+	app := assertBuildSuccess(t, spec, leaf_proc, leaf_db)
+
+	assertIR(t, app,
+		`TestMongoDBSecret = BlueprintApplication() {
+			leaf.handler.visibility
+			leaf_cache.backend.visibility
+			leaf_db.addr
+			leaf_db.bind_addr = AddressConfig()
+			leaf_db.client = MongoClient(leaf_db.dial_addr)
+			leaf_db.ctr = MongoDBProcess(leaf_db.bind_addr)
+			leaf_db.dial_addr = AddressConfig()
+			leaf_db_password = Secret()
+			leaf_proc = GolangProcessNode(leaf_db.dial_addr, leaf_db_password) {
+			  leaf = TestLeafService(leaf_cache, leaf_db.client)
+			  leaf_cache = SimpleCache()
+			  leaf_db.client = MongoClient(leaf_db.dial_addr)
+			  leaf_proc.logger = SLogger()
+			  leaf_proc.stdoutmetriccollector = StdoutMetricCollector()
+			}
+		  }`)
+}
+
+func TestMinioSecret(t *testing.T) {
+	spec := newWiringSpec("TestMinioSecret")
+
+	store_password := secrets.Define(spec, "store_password")
+	leaf_store := minio.Container(spec, "leaf_store")
+	secrets.Use(spec, leaf_store, store_password)
+	leaf := workflow.Service[*blobstore.TestLeafServiceImplWithBlobStore](spec, "leaf", leaf_store)
+	leaf_proc := goproc.CreateProcess(spec, "leaf_proc", leaf)
+
+	app := assertBuildSuccess(t, spec, leaf_proc, leaf_store)
+
+	assertIR(t, app,
+		`TestMinioSecret = BlueprintApplication() {
+			leaf.handler.visibility
+			leaf_proc = GolangProcessNode(leaf_store.dial_addr, store_password) {
+			  leaf = TestLeafService(leaf_store.client)
+			  leaf_proc.logger = SLogger()
+			  leaf_proc.stdoutmetriccollector = StdoutMetricCollector()
+			  leaf_store.client = MinioClient(leaf_store.dial_addr)
+			}
+			leaf_store.addr
+			leaf_store.bind_addr = AddressConfig()
+			leaf_store.client = MinioClient(leaf_store.dial_addr)
+			leaf_store.ctr = MinioContainer(leaf_store.bind_addr)
+			leaf_store.dial_addr = AddressConfig()
+			store_password = Secret()
+		  }`)
+}
@@ -0,0 +1,61 @@
+package wiring
+
+import (
+	"testing"
+
+	"github.com/blueprint-uservices/blueprint/plugins/goproc"
+	"github.com/blueprint-uservices/blueprint/plugins/simple"
+	"github.com/blueprint-uservices/blueprint/plugins/workflow"
+	"github.com/blueprint-uservices/blueprint/test/workflow/blobstore"
+)
+
+func TestSimpleBlobStore(t *testing.T) {
+	spec := newWiringSpec("TestSimpleBlobStore")
+
+	leaf_store := simple.BlobStore(spec, "leaf_store")
+	leaf := workflow.Service[*blobstore.TestLeafServiceImplWithBlobStore](spec, "leaf", leaf_store)
+
+	app := assertBuildSuccess(t, spec, leaf, leaf_store)
+
+	assertIR(t, app,
+		`TestSimpleBlobStore = BlueprintApplication() {
+			leaf = TestLeafService(leaf_store)
+			leaf.client = leaf
+			leaf.handler.visibility
+			leaf_store = SimpleBlobStore()
+			leaf_store.backend.visibility
+		  }`)
+}
+
+func TestSimplePersistentBlobStore(t *testing.T) {
+	spec := newWiringSpec("TestSimplePersistentBlobStore")
+
+	leaf_store := simple.PersistentBlobStore(spec, "leaf_store")
+	leaf := workflow.Service[*blobstore.TestLeafServiceImplWithBlobStore](spec, "leaf", leaf_store)
+	leaf_proc := goproc.CreateProcess(spec, "leaf_proc", leaf, leaf_store)
+
+	app := assertBuildSuccess(t, spec, leaf_proc)
+
+	assertIR(t, app,
+		`TestSimplePersistentBlobStore = BlueprintApplication() {
+			leaf.handler.visibility
+			leaf_proc = GolangProcessNode(leaf_store.dir) {
+			  leaf = TestLeafService(leaf_store)
+			  leaf_proc.logger = SLogger()
+			  leaf_proc.stdoutmetriccollector = StdoutMetricCollector()
+			  leaf_store = SimpleBlobStore(leaf_store.dir)
+			}
+			leaf_store.backend.visibility
+			leaf_store.dir = FileConfig()
+		  }`)
+}
+
+func TestSimpleBlobStoreFixture(t *testing.T) {
+	spec := newWiringSpec("TestSimpleBlobStoreFixture")
+
+	leaf_store := simple.BlobStore(spec, "leaf_store")
+	simple.LoadFixture(spec, leaf_store, "simpleblobstore_test.go")
+	leaf := workflow.Service[*blobstore.TestLeafServiceImplWithBlobStore](spec, "leaf", leaf_store)
+
+	assertBuildFailure(t, spec, leaf)
+}
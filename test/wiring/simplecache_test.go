@@ -3,6 +3,7 @@ package wiring
 import (
 	"testing"
 
+	"github.com/blueprint-uservices/blueprint/plugins/goproc"
 	"github.com/blueprint-uservices/blueprint/plugins/simple"
 	"github.com/blueprint-uservices/blueprint/plugins/workflow"
 	"github.com/blueprint-uservices/blueprint/test/workflow/cache"
@@ -47,3 +48,28 @@ func TestSimpleCacheAndServices(t *testing.T) {
 			nonleaf.handler.visibility
           }`)
 }
+
+func TestSimpleTypedCache(t *testing.T) {
+	spec := newWiringSpec("TestSimpleTypedCache")
+
+	int_cache := simple.Cache(spec, "int_cache")
+	object_cache := simple.Cache(spec, "object_cache")
+	leaf := workflow.Service[*cache.TestLeafServiceImplWithTypedCache](spec, "leaf", int_cache, object_cache)
+	leaf_proc := goproc.CreateProcess(spec, "leaf_proc", leaf)
+
+	app := assertBuildSuccess(t, spec, leaf_proc)
+
+	assertIR(t, app,
+		`TestSimpleTypedCache = BlueprintApplication() {
+			int_cache.backend.visibility
+			leaf.handler.visibility
+			leaf_proc = GolangProcessNode() {
+			  int_cache = SimpleCache()
+			  leaf = TestLeafService(int_cache, object_cache)
+			  leaf_proc.logger = SLogger()
+			  leaf_proc.stdoutmetriccollector = StdoutMetricCollector()
+			  object_cache = SimpleCache()
+			}
+			object_cache.backend.visibility
+          }`)
+}
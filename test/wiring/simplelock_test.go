@@ -0,0 +1,55 @@
+package wiring
+
+import (
+	"testing"
+
+	"github.com/blueprint-uservices/blueprint/plugins/goproc"
+	"github.com/blueprint-uservices/blueprint/plugins/redis"
+	"github.com/blueprint-uservices/blueprint/plugins/simple"
+	"github.com/blueprint-uservices/blueprint/plugins/workflow"
+	"github.com/blueprint-uservices/blueprint/test/workflow/lock"
+)
+
+func TestSimpleLockService(t *testing.T) {
+	spec := newWiringSpec("TestSimpleLockService")
+
+	leaf_locks := simple.LockService(spec, "leaf_locks")
+	leaf := workflow.Service[*lock.TestLeafServiceImplWithLocks](spec, "leaf", leaf_locks)
+
+	app := assertBuildSuccess(t, spec, leaf, leaf_locks)
+
+	assertIR(t, app,
+		`TestSimpleLockService = BlueprintApplication() {
+			leaf = TestLeafService(leaf_locks)
+			leaf.client = leaf
+			leaf.handler.visibility
+			leaf_locks = SimpleLockService()
+			leaf_locks.backend.visibility
+		  }`)
+}
+
+func TestRedisLockService(t *testing.T) {
+	spec := newWiringSpec("TestRedisLockService")
+
+	leaf_locks := redis.LockService(spec, "leaf_locks")
+	leaf := workflow.Service[*lock.TestLeafServiceImplWithLocks](spec, "leaf", leaf_locks)
+	leaf_proc := goproc.CreateProcess(spec, "leaf_proc", leaf)
+
+	app := assertBuildSuccess(t, spec, leaf_proc, leaf_locks)
+
+	assertIR(t, app,
+		`TestRedisLockService = BlueprintApplication() {
+			leaf.handler.visibility
+			leaf_locks.addr
+			leaf_locks.bind_addr = AddressConfig()
+			leaf_locks.client = RedisLockClient(leaf_locks.dial_addr)
+			leaf_locks.ctr = RedisProcess(leaf_locks.bind_addr)
+			leaf_locks.dial_addr = AddressConfig()
+			leaf_proc = GolangProcessNode(leaf_locks.dial_addr) {
+			  leaf = TestLeafService(leaf_locks.client)
+			  leaf_locks.client = RedisLockClient(leaf_locks.dial_addr)
+			  leaf_proc.logger = SLogger()
+			  leaf_proc.stdoutmetriccollector = StdoutMetricCollector()
+			}
+		  }`)
+}
@@ -1,12 +1,17 @@
 package wiring
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/blueprint-uservices/blueprint/plugins/goproc"
 	"github.com/blueprint-uservices/blueprint/plugins/simple"
 	"github.com/blueprint-uservices/blueprint/plugins/workflow"
 	"github.com/blueprint-uservices/blueprint/test/workflow/nosqldb"
 	wf "github.com/blueprint-uservices/blueprint/test/workflow/workflow"
+	"github.com/stretchr/testify/require"
 )
 
 func TestSimpleNoSQLDB(t *testing.T) {
@@ -33,3 +38,51 @@ func TestSimpleNoSQLDB(t *testing.T) {
 			nonleaf.handler.visibility
 		  }`)
 }
+
+func TestSimpleNoSQLDBPersistence(t *testing.T) {
+	spec := newWiringSpec("TestSimpleNoSQLDBPersistence")
+
+	fixture := filepath.Join(t.TempDir(), "leaf_db.json")
+	require.NoError(t, os.WriteFile(fixture, []byte(`{"leafdb": {"leafcollection": []}}`), 0644))
+
+	leaf_cache := simple.Cache(spec, "leaf_cache")
+	leaf_db := simple.NoSQLDB(spec, "leaf_db")
+	simple.LoadFixture(spec, leaf_db, fixture)
+	simple.Persist(spec, leaf_db, time.Minute)
+	leaf := workflow.Service[*nosqldb.TestLeafServiceImplWithDB](spec, "leaf", leaf_cache, leaf_db)
+	leaf_proc := goproc.CreateProcess(spec, "leaf_proc", leaf, leaf_db)
+
+	app := assertBuildSuccess(t, spec, leaf_proc)
+
+	assertIR(t, app,
+		`TestSimpleNoSQLDBPersistence = BlueprintApplication() {
+			leaf.handler.visibility
+			leaf_cache.backend.visibility
+			leaf_db.backend.visibility
+			leaf_db.snapshot = FileConfig()
+			leaf_proc = GolangProcessNode(leaf_db.snapshot) {
+			  leaf = TestLeafService(leaf_cache, leaf_db)
+			  leaf_cache = SimpleCache()
+			  leaf_db = SimpleNoSQLDB(leaf_db.snapshot)
+			  leaf_proc.logger = SLogger()
+			  leaf_proc.stdoutmetriccollector = StdoutMetricCollector()
+			}
+		  }`)
+}
+
+func TestSimpleNoSQLDBFixtureErrors(t *testing.T) {
+	{
+		spec := newWiringSpec("TestSimpleNoSQLDBMissingFixture")
+		leaf_db := simple.NoSQLDB(spec, "leaf_db")
+		simple.LoadFixture(spec, leaf_db, filepath.Join(t.TempDir(), "missing.json"))
+		assertBuildFailure(t, spec, leaf_db)
+	}
+	{
+		spec := newWiringSpec("TestSimpleCacheBSONFixture")
+		fixture := filepath.Join(t.TempDir(), "leaf_cache.bson")
+		require.NoError(t, os.WriteFile(fixture, nil, 0644))
+		leaf_cache := simple.Cache(spec, "leaf_cache")
+		simple.LoadFixture(spec, leaf_cache, fixture)
+		assertBuildFailure(t, spec, leaf_cache)
+	}
+}
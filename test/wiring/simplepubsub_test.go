@@ -0,0 +1,31 @@
+package wiring
+
+import (
+	"testing"
+
+	"github.com/blueprint-uservices/blueprint/plugins/simple"
+	"github.com/blueprint-uservices/blueprint/plugins/workflow"
+	"github.com/blueprint-uservices/blueprint/test/workflow/pubsub"
+)
+
+func TestSimplePubSub(t *testing.T) {
+	spec := newWiringSpec("TestSimplePubSub")
+
+	leaf_pubsub := simple.PubSub(spec, "leaf_pubsub")
+	leaf := workflow.Service[*pubsub.TestLeafServiceImplWithPubSub](spec, "leaf", leaf_pubsub)
+	subscriber := workflow.Service[pubsub.TestSubscriberService](spec, "subscriber", leaf_pubsub)
+
+	app := assertBuildSuccess(t, spec, leaf, subscriber, leaf_pubsub)
+
+	assertIR(t, app,
+		`TestSimplePubSub = BlueprintApplication() {
+			leaf = TestLeafService(leaf_pubsub)
+			leaf.client = leaf
+			leaf.handler.visibility
+			leaf_pubsub = SimplePubSub()
+			leaf_pubsub.backend.visibility
+			subscriber = TestSubscriberService(leaf_pubsub)
+			subscriber.client = subscriber
+			subscriber.handler.visibility
+          }`)
+}
@@ -0,0 +1,51 @@
+package wiring
+
+import (
+	"testing"
+
+	"github.com/blueprint-uservices/blueprint/plugins/goproc"
+	"github.com/blueprint-uservices/blueprint/plugins/simple"
+	"github.com/blueprint-uservices/blueprint/plugins/workflow"
+	"github.com/blueprint-uservices/blueprint/test/workflow/reldb"
+)
+
+func TestSimpleRelationalDB(t *testing.T) {
+	spec := newWiringSpec("TestSimpleRelationalDB")
+
+	leaf_db := simple.RelationalDB(spec, "leaf_db")
+	leaf := workflow.Service[*reldb.TestLeafServiceImplWithRelDB](spec, "leaf", leaf_db)
+
+	app := assertBuildSuccess(t, spec, leaf, leaf_db)
+
+	assertIR(t, app,
+		`TestSimpleRelationalDB = BlueprintApplication() {
+			leaf = TestLeafService(leaf_db)
+			leaf.client = leaf
+			leaf.handler.visibility
+			leaf_db = SqliteRelDB()
+			leaf_db.backend.visibility
+		  }`)
+}
+
+func TestSimplePersistentRelationalDB(t *testing.T) {
+	spec := newWiringSpec("TestSimplePersistentRelationalDB")
+
+	leaf_db := simple.PersistentRelationalDB(spec, "leaf_db")
+	leaf := workflow.Service[*reldb.TestLeafServiceImplWithRelDB](spec, "leaf", leaf_db)
+	leaf_proc := goproc.CreateProcess(spec, "leaf_proc", leaf, leaf_db)
+
+	app := assertBuildSuccess(t, spec, leaf_proc)
+
+	assertIR(t, app,
+		`TestSimplePersistentRelationalDB = BlueprintApplication() {
+			leaf.handler.visibility
+			leaf_db.backend.visibility
+			leaf_db.file = FileConfig()
+			leaf_proc = GolangProcessNode(leaf_db.file) {
+			  leaf = TestLeafService(leaf_db)
+			  leaf_db = SqliteRelDB(leaf_db.file)
+			  leaf_proc.logger = SLogger()
+			  leaf_proc.stdoutmetriccollector = StdoutMetricCollector()
+			}
+		  }`)
+}
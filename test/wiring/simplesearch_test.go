@@ -0,0 +1,37 @@
+package wiring
+
+import (
+	"testing"
+
+	"github.com/blueprint-uservices/blueprint/plugins/simple"
+	"github.com/blueprint-uservices/blueprint/plugins/workflow"
+	"github.com/blueprint-uservices/blueprint/test/workflow/search"
+)
+
+func TestSimpleSearchIndex(t *testing.T) {
+	spec := newWiringSpec("TestSimpleSearchIndex")
+
+	leaf_index := simple.SearchIndex(spec, "leaf_index")
+	leaf := workflow.Service[*search.TestLeafServiceImplWithSearchIndex](spec, "leaf", leaf_index)
+
+	app := assertBuildSuccess(t, spec, leaf, leaf_index)
+
+	assertIR(t, app,
+		`TestSimpleSearchIndex = BlueprintApplication() {
+			leaf = TestLeafService(leaf_index)
+			leaf.client = leaf
+			leaf.handler.visibility
+			leaf_index = SimpleSearchIndex()
+			leaf_index.backend.visibility
+		  }`)
+}
+
+func TestSimpleSearchIndexFixture(t *testing.T) {
+	spec := newWiringSpec("TestSimpleSearchIndexFixture")
+
+	leaf_index := simple.SearchIndex(spec, "leaf_index")
+	simple.LoadFixture(spec, leaf_index, "simplesearch_test.go")
+	leaf := workflow.Service[*search.TestLeafServiceImplWithSearchIndex](spec, "leaf", leaf_index)
+
+	assertBuildFailure(t, spec, leaf)
+}
@@ -0,0 +1,60 @@
+package blobstore
+
+import (
+	"bytes"
+	ctxx "context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"github.com/blueprint-uservices/blueprint/test/workflow/workflow"
+)
+
+/*
+Implements the services from ../workflow using a blob store
+*/
+
+/*
+Service implementation structs
+*/
+type (
+	TestLeafServiceImplWithBlobStore struct {
+		workflow.TestLeafService
+		Store backend.BlobStore
+	}
+)
+
+/*
+Constructors
+*/
+
+func NewTestLeafServiceImplWithBlobStore(ctx ctxx.Context, store backend.BlobStore) (*TestLeafServiceImplWithBlobStore, error) {
+	return &TestLeafServiceImplWithBlobStore{Store: store}, nil
+}
+
+/*
+Interface method bodies
+*/
+
+func (l *TestLeafServiceImplWithBlobStore) HelloNothing(ctx ctxx.Context) error {
+	return nil
+}
+
+func (l *TestLeafServiceImplWithBlobStore) HelloInt(ctx ctxx.Context, a int16) (int32, error) {
+	return int32(a), nil
+}
+
+func (l *TestLeafServiceImplWithBlobStore) HelloObject(ctx ctxx.Context, obj workflow.TestLeafObject) (*workflow.TestLeafObject, error) {
+	objects, err := l.Store.List(ctx, "objects/")
+	if err != nil {
+		return nil, err
+	}
+	obj.Count = len(objects) + 1
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("objects/%v", obj.Count)
+	err = l.Store.Put(ctx, key, bytes.NewReader(data), backend.BlobOptions{ContentType: "application/json"})
+	return &obj, err
+}
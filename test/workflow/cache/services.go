@@ -19,6 +19,12 @@ type (
 		workflow.TestLeafService
 		Cache backend.Cache
 	}
+
+	TestLeafServiceImplWithTypedCache struct {
+		workflow.TestLeafService
+		Ints    backend.TypedCache[int32]
+		Objects backend.TypedCache[workflow.TestLeafObject]
+	}
 )
 
 /*
@@ -29,6 +35,10 @@ func NewTestLeafServiceImplWithCache(ctx ctxx.Context, cache backend.Cache) (*Te
 	return &TestLeafServiceImplWithCache{Cache: cache}, nil
 }
 
+func NewTestLeafServiceImplWithTypedCache(ctx ctxx.Context, ints backend.TypedCache[int32], objects backend.TypedCache[workflow.TestLeafObject]) (*TestLeafServiceImplWithTypedCache, error) {
+	return &TestLeafServiceImplWithTypedCache{Ints: ints, Objects: objects}, nil
+}
+
 /*
 Interface method bodies
 */
@@ -57,3 +67,25 @@ func (l *TestLeafServiceImplWithCache) HelloObject(ctx ctxx.Context, obj workflo
 	obj.Count = int(count)
 	return &obj, l.Cache.Put(ctx, "objectcount", count)
 }
+
+func (l *TestLeafServiceImplWithTypedCache) HelloNothing(ctx ctxx.Context) error {
+	return nil
+}
+
+func (l *TestLeafServiceImplWithTypedCache) HelloInt(ctx ctxx.Context, a int16) (int32, error) {
+	err := l.Ints.Put(ctx, "myint", int32(a))
+	if err != nil {
+		return 0, err
+	}
+	myint, _, err := l.Ints.Get(ctx, "myint")
+	return myint, err
+}
+
+func (l *TestLeafServiceImplWithTypedCache) HelloObject(ctx ctxx.Context, obj workflow.TestLeafObject) (*workflow.TestLeafObject, error) {
+	prev, _, err := l.Objects.Get(ctx, "lastobject")
+	if err != nil {
+		return nil, err
+	}
+	obj.Count = prev.Count + 10
+	return &obj, l.Objects.Put(ctx, "lastobject", obj)
+}
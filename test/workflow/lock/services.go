@@ -0,0 +1,59 @@
+package lock
+
+import (
+	ctxx "context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"github.com/blueprint-uservices/blueprint/test/workflow/workflow"
+)
+
+/*
+Implements the services from ../workflow using a lock service
+*/
+
+/*
+Service implementation structs
+*/
+type (
+	TestLeafServiceImplWithLocks struct {
+		workflow.TestLeafService
+		Locks backend.LockService
+	}
+)
+
+/*
+Constructors
+*/
+
+func NewTestLeafServiceImplWithLocks(ctx ctxx.Context, locks backend.LockService) (*TestLeafServiceImplWithLocks, error) {
+	return &TestLeafServiceImplWithLocks{Locks: locks}, nil
+}
+
+/*
+Interface method bodies
+*/
+
+func (l *TestLeafServiceImplWithLocks) HelloNothing(ctx ctxx.Context) error {
+	return nil
+}
+
+func (l *TestLeafServiceImplWithLocks) HelloInt(ctx ctxx.Context, a int16) (int32, error) {
+	return int32(a), nil
+}
+
+// Acquires a lease on the object's lock, which is held until it expires.  Sets the object's count
+// to 1 if the lease was acquired, or 0 if the lock was already held.
+func (l *TestLeafServiceImplWithLocks) HelloObject(ctx ctxx.Context, obj workflow.TestLeafObject) (*workflow.TestLeafObject, error) {
+	_, err := l.Locks.TryAcquire(ctx, "objects/"+strconv.FormatInt(obj.ID, 10), time.Minute)
+	if errors.Is(err, backend.ErrLockHeld) {
+		obj.Count = 0
+		return &obj, nil
+	} else if err != nil {
+		return nil, err
+	}
+	obj.Count = 1
+	return &obj, nil
+}
@@ -0,0 +1,95 @@
+package pubsub
+
+import (
+	ctxx "context"
+	"sync/atomic"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"github.com/blueprint-uservices/blueprint/test/workflow/workflow"
+)
+
+/*
+Implements the services from ../workflow using a pubsub, and a subscriber service that consumes
+the published items in the background
+*/
+
+/*
+Service interfaces
+*/
+type (
+	TestSubscriberService interface {
+		HelloCount(ctx ctxx.Context) (int64, error)
+	}
+)
+
+/*
+Service implementation structs
+*/
+type (
+	TestLeafServiceImplWithPubSub struct {
+		workflow.TestLeafService
+		PubSub backend.PubSub
+	}
+
+	TestSubscriberServiceImpl struct {
+		PubSub backend.PubSub
+		count  atomic.Int64
+	}
+)
+
+/*
+Constructors
+*/
+
+func NewTestLeafServiceImplWithPubSub(ctx ctxx.Context, events backend.PubSub) (*TestLeafServiceImplWithPubSub, error) {
+	return &TestLeafServiceImplWithPubSub{PubSub: events}, nil
+}
+
+func NewTestSubscriberServiceImpl(ctx ctxx.Context, events backend.PubSub) (TestSubscriberService, error) {
+	return &TestSubscriberServiceImpl{PubSub: events}, nil
+}
+
+/*
+Interface method bodies
+*/
+
+func (l *TestLeafServiceImplWithPubSub) HelloNothing(ctx ctxx.Context) error {
+	return nil
+}
+
+func (l *TestLeafServiceImplWithPubSub) HelloInt(ctx ctxx.Context, a int16) (int32, error) {
+	return int32(a), l.PubSub.Publish(ctx, "hello.int", a)
+}
+
+func (l *TestLeafServiceImplWithPubSub) HelloObject(ctx ctxx.Context, obj workflow.TestLeafObject) (*workflow.TestLeafObject, error) {
+	return &obj, l.PubSub.Publish(ctx, "hello.object", obj)
+}
+
+func (s *TestSubscriberServiceImpl) HelloCount(ctx ctxx.Context) (int64, error) {
+	return s.count.Load(), nil
+}
+
+/*
+Implements golang.Runnable, so the subscriber consumes items in the background once instantiated
+*/
+
+func (s *TestSubscriberServiceImpl) Run(ctx ctxx.Context) error {
+	sub, err := s.PubSub.Subscribe(ctx, "hello.*", "counter")
+	if err != nil {
+		return err
+	}
+	defer sub.Close(ctxx.Background())
+
+	for {
+		d, received, err := sub.Receive(ctx)
+		if err != nil {
+			return err
+		} else if !received {
+			return nil
+		}
+		s.count.Add(1)
+		if err := d.Ack(ctx); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package reldb
+
+import (
+	ctxx "context"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"github.com/blueprint-uservices/blueprint/test/workflow/workflow"
+)
+
+/*
+Implements the services from ../workflow using a relational database
+*/
+
+/*
+Service implementation structs
+*/
+type (
+	TestLeafServiceImplWithRelDB struct {
+		workflow.TestLeafService
+		Database backend.RelationalDB
+	}
+)
+
+/*
+Constructors
+*/
+
+func NewTestLeafServiceImplWithRelDB(ctx ctxx.Context, db backend.RelationalDB) (*TestLeafServiceImplWithRelDB, error) {
+	_, err := db.Exec(ctx, "CREATE TABLE IF NOT EXISTS objects (id INTEGER PRIMARY KEY, count INT)")
+	return &TestLeafServiceImplWithRelDB{Database: db}, err
+}
+
+/*
+Interface method bodies
+*/
+
+func (l *TestLeafServiceImplWithRelDB) HelloNothing(ctx ctxx.Context) error {
+	return nil
+}
+
+func (l *TestLeafServiceImplWithRelDB) HelloInt(ctx ctxx.Context, a int16) (int32, error) {
+	return int32(a), nil
+}
+
+func (l *TestLeafServiceImplWithRelDB) HelloObject(ctx ctxx.Context, obj workflow.TestLeafObject) (*workflow.TestLeafObject, error) {
+	if _, err := l.Database.Exec(ctx, "INSERT INTO objects (count) VALUES (?)", obj.Count); err != nil {
+		return nil, err
+	}
+	var count int
+	if err := l.Database.Get(ctx, &count, "SELECT COUNT(*) FROM objects"); err != nil {
+		return nil, err
+	}
+	obj.Count = count
+	return &obj, nil
+}
@@ -0,0 +1,56 @@
+package search
+
+import (
+	ctxx "context"
+	"strconv"
+
+	"github.com/blueprint-uservices/blueprint/runtime/core/backend"
+	"github.com/blueprint-uservices/blueprint/test/workflow/workflow"
+)
+
+/*
+Implements the services from ../workflow using a search index
+*/
+
+/*
+Service implementation structs
+*/
+type (
+	TestLeafServiceImplWithSearchIndex struct {
+		workflow.TestLeafService
+		Index backend.SearchIndex
+	}
+)
+
+/*
+Constructors
+*/
+
+func NewTestLeafServiceImplWithSearchIndex(ctx ctxx.Context, index backend.SearchIndex) (*TestLeafServiceImplWithSearchIndex, error) {
+	return &TestLeafServiceImplWithSearchIndex{Index: index}, nil
+}
+
+/*
+Interface method bodies
+*/
+
+func (l *TestLeafServiceImplWithSearchIndex) HelloNothing(ctx ctxx.Context) error {
+	return nil
+}
+
+func (l *TestLeafServiceImplWithSearchIndex) HelloInt(ctx ctxx.Context, a int16) (int32, error) {
+	return int32(a), nil
+}
+
+func (l *TestLeafServiceImplWithSearchIndex) HelloObject(ctx ctxx.Context, obj workflow.TestLeafObject) (*workflow.TestLeafObject, error) {
+	doc := backend.SearchDocument{Text: map[string]string{"name": obj.Name}}
+	if err := l.Index.Index(ctx, strconv.FormatInt(obj.ID, 10), doc); err != nil {
+		return nil, err
+	}
+	hits, err := l.Index.Search(ctx, backend.SearchQuery{Text: obj.Name, Limit: 100})
+	if err != nil {
+		return nil, err
+	}
+	obj.Count = len(hits)
+	return &obj, nil
+}